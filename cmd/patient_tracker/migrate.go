@@ -0,0 +1,72 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/patienttracker/internal/db"
+)
+
+const migrateusage = `usage: patient_tracker migrate up|down [steps]|status|force version
+  up       apply every pending migration
+  down     roll back the last migration,or the last steps migrations
+  status   show the current version and the pending migrations
+  force    set the version without running migrations,use after fixing a failed one`
+
+// runMigrate handles the migrate subcommand
+func runMigrate(conn *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateusage)
+	}
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		fmt.Printf("applied %d migrations\n", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errors.New(migrateusage)
+			}
+		}
+		rolledback, err := migrator.Down(steps)
+		fmt.Printf("rolled back %d migrations\n", rolledback)
+		return err
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d dirty: %t\n", status.Version, status.Dirty)
+		for _, m := range status.Pending() {
+			fmt.Printf("pending: %06d_%s\n", m.Version, m.Name)
+		}
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateusage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errors.New(migrateusage)
+		}
+		return migrator.Force(version)
+	}
+	return errors.New(migrateusage)
+}
+
+// autoMigrate applies the pending migrations before the server starts serving
+func autoMigrate(conn *sql.DB) (int, error) {
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		return 0, err
+	}
+	return migrator.Up()
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
+	"github.com/patienttracker/internal/auth"
 	"github.com/patienttracker/internal/models"
 	"github.com/patienttracker/internal/services"
 )
@@ -42,7 +43,7 @@ func (server *Server) AdminLogin(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "admin-login.html", msg)
 		return
 	}
-	user, err := server.Services.RbacService.UsersService.FindbyEmail(login.Email)
+	user, err := server.Services.RbacService.UsersService.FindbyEmail(r.Context(), login.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusBadRequest)
@@ -59,7 +60,7 @@ func (server *Server) AdminLogin(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "login.html", msg)
 		return
 	}
-	permission, err := server.Services.RbacService.PermissionsService.FindbyRoleId(user.Roleid)
+	permission, err := server.Services.RbacService.PermissionsService.FindbyRoleId(r.Context(), user.Roleid)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 		return
@@ -75,6 +76,10 @@ func (server *Server) AdminLogin(w http.ResponseWriter, r *http.Request) {
 	admin := UserResponse(user, perm)
 	gobRegister(admin)
 	session.Values["admin"] = admin
+	if err = server.startCookieSession(r, session, auth.AccountAdmin, user.Id, user.Email); err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
 	if err = session.Save(r, w); err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 		return
@@ -87,6 +92,9 @@ func (server *Server) AdminLogout(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
+	if err = server.endCookieSession(r.Context(), session); err != nil {
+		server.Log.Error(err)
+	}
 	session.Values["admin"] = UserResp{}
 	session.Options.MaxAge = -1
 	err = session.Save(r, w)
@@ -115,14 +123,14 @@ func (server *Server) Adminhome(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 		return
 	}
-	_, ametadata, err := server.Services.AppointmentService.FindAll(models.Filters{
+	_, ametadata, err := server.Services.AppointmentService.FindAll(r.Context(), models.Filters{
 		PageSize: 20,
 		Page:     1,
 	})
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	_, rmetadata, err := server.Services.PatientRecordService.FindAll(
+	_, rmetadata, err := server.Services.PatientRecordService.FindAll(r.Context(),
 		models.Filters{
 			PageSize: 20,
 			Page:     1,
@@ -199,7 +207,7 @@ func (server *Server) Adminrecord(w http.ResponseWriter, r *http.Request) {
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	records, metadata, err := server.Services.PatientRecordService.FindAll(
+	records, metadata, err := server.Services.PatientRecordService.FindAll(r.Context(),
 		models.Filters{
 			PageSize: PageCount,
 			Page:     idparam,
@@ -238,7 +246,7 @@ func (server *Server) Adminappointments(w http.ResponseWriter, r *http.Request)
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	appointment, metadata, err := server.Services.AppointmentService.FindAll(models.Filters{
+	appointment, metadata, err := server.Services.AppointmentService.FindAll(r.Context(), models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -270,7 +278,7 @@ func (server *Server) Adminuser(w http.ResponseWriter, r *http.Request) {
 	if !admin.Authenticated {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 	}
-	users, err := server.Services.RbacService.UsersService.FindAll()
+	users, err := server.Services.RbacService.UsersService.FindAll(r.Context())
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -323,7 +331,7 @@ func (server *Server) Admincreateuser(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "admin-edit-user.html", data)
 		return
 	}
-	role, err := server.Services.RbacService.RolesService.FindbyRole(register.Rolename)
+	role, err := server.Services.RbacService.RolesService.FindbyRole(r.Context(), register.Rolename)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			msg.Errors["NonExistence"] = "No such role"
@@ -338,7 +346,7 @@ func (server *Server) Admincreateuser(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	if _, err := server.Services.RbacService.UsersService.Create(models.Users{
+	if _, err := server.Services.RbacService.UsersService.Create(r.Context(), models.Users{
 		Email:    register.Email,
 		Password: password,
 		Roleid:   role.Roleid,
@@ -377,11 +385,11 @@ func (server *Server) Adminupdateuser(w http.ResponseWriter, r *http.Request) {
 		ConfirmPassword: r.PostFormValue("ConfirmPassword"),
 	}
 	msg = NewForm(r, &register)
-	user, err := server.Services.RbacService.UsersService.Find(idparam)
+	user, err := server.Services.RbacService.UsersService.Find(r.Context(), idparam)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	role, err := server.Services.RbacService.RolesService.Find(user.Roleid)
+	role, err := server.Services.RbacService.RolesService.Find(r.Context(), user.Roleid)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -410,7 +418,7 @@ func (server *Server) Adminupdateuser(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "admin-update-user.html", data)
 		return
 	}
-	role, err = server.Services.RbacService.RolesService.FindbyRole(register.Rolename)
+	role, err = server.Services.RbacService.RolesService.FindbyRole(r.Context(), register.Rolename)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			msg.Errors["NonExistence"] = "No such role"
@@ -425,7 +433,7 @@ func (server *Server) Adminupdateuser(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	user, err = server.Services.RbacService.UsersService.Update(models.Users{
+	user, err = server.Services.RbacService.UsersService.Update(r.Context(), models.Users{
 		Id:       user.Id,
 		Email:    register.Email,
 		Password: password,
@@ -460,7 +468,7 @@ func (server *Server) Admindeleteuser(w http.ResponseWriter, r *http.Request) {
 	if !admin.Authenticated {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 	}
-	if err := server.Services.RbacService.UsersService.Delete(idparam); err != nil {
+	if err := server.Services.RbacService.UsersService.Delete(r.Context(), idparam); err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
 	http.Redirect(w, r, r.URL.String(), http.StatusMovedPermanently)
@@ -483,7 +491,7 @@ func (server *Server) Admindeleterole(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 		return
 	}
-	if err := server.Services.RbacService.RolesService.Delete(idparam); err != nil {
+	if err := server.Services.RbacService.RolesService.Delete(r.Context(), idparam); err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
 	http.Redirect(w, r, r.URL.String(), http.StatusMovedPermanently)
@@ -503,7 +511,7 @@ func (server *Server) Admindeletenurse(w http.ResponseWriter, r *http.Request) {
 	if !admin.Authenticated {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 	}
-	if err := server.Services.NurseService.Delete(idparam); err != nil {
+	if err := server.Services.NurseService.Delete(r.Context(), idparam); err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
 }
@@ -516,7 +524,7 @@ func (server *Server) Adminroles(w http.ResponseWriter, r *http.Request) {
 	if !admin.Authenticated {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 	}
-	roles, err := server.Services.RbacService.RolesService.FindAll()
+	roles, err := server.Services.RbacService.RolesService.FindAll(r.Context())
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -595,7 +603,7 @@ func (server *Server) AdmincreateRoles(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "admin-edit-role.html", data)
 		return
 	}
-	role, err := server.Services.RbacService.RolesService.Create(models.Roles{
+	role, err := server.Services.RbacService.RolesService.Create(r.Context(), models.Roles{
 		Role: r.PostFormValue("Role"),
 	},
 	)
@@ -606,7 +614,7 @@ func (server *Server) AdmincreateRoles(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "admin-edit-role.html", data)
 		return
 	}
-	if _, err = server.Services.CreatePermission(models.Permissions{
+	if _, err = server.Services.CreatePermission(r.Context(), models.Permissions{
 		Permission: r.PostFormValue("permission"),
 		Roleid:     role.Roleid,
 	}, admin.Id); err != nil {
@@ -638,7 +646,7 @@ func (server *Server) Adminupdateroles(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/admin/login", http.StatusMovedPermanently)
 	}
 	var msg Form
-	assigned_permissions, err := server.Services.RbacService.PermissionsService.FindbyRoleId(idparam)
+	assigned_permissions, err := server.Services.RbacService.PermissionsService.FindbyRoleId(r.Context(), idparam)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -647,7 +655,7 @@ func (server *Server) Adminupdateroles(w http.ResponseWriter, r *http.Request) {
 		Permission: r.Form["permission"],
 	}
 	msg = NewForm(r, &register)
-	role, _ := server.Services.RbacService.RolesService.Find(idparam)
+	role, _ := server.Services.RbacService.RolesService.Find(r.Context(), idparam)
 	data := struct {
 		User                 UserResp
 		Errors               Errors
@@ -674,7 +682,7 @@ func (server *Server) Adminupdateroles(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "admin-update-role.html", data)
 		return
 	}
-	_, err = server.Services.RbacService.UsersService.Find(admin.Id)
+	_, err = server.Services.RbacService.UsersService.Find(r.Context(), admin.Id)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
@@ -683,7 +691,7 @@ func (server *Server) Adminupdateroles(w http.ResponseWriter, r *http.Request) {
 		return
 
 	}
-	role, err = server.Services.RbacService.RolesService.Update(
+	role, err = server.Services.RbacService.RolesService.Update(r.Context(),
 		models.Roles{
 			Role:   r.PostFormValue("Role"),
 			Roleid: role.Roleid,
@@ -695,7 +703,7 @@ func (server *Server) Adminupdateroles(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "admin-update-role.html", data)
 		return
 	}
-	if err := server.Services.UpdateRolePermissions(register.Permission, idparam); err != nil {
+	if err := server.Services.UpdateRolePermissions(r.Context(), register.Permission, idparam); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
 		data.Errors = msg.Errors
@@ -745,7 +753,7 @@ func (server *Server) Adminfilterphysician(w http.ResponseWriter, r *http.Reques
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	doctors, metadata, err := server.Services.DoctorService.Filter(name, dept, models.Filters{
+	doctors, metadata, err := server.Services.DoctorService.Filter(r.Context(), name, dept, models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -798,7 +806,7 @@ func (server *Server) Adminfilterpatient(w http.ResponseWriter, r *http.Request)
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	patient, metadata, err := server.Services.PatientService.Filter(name, models.Filters{
+	patient, metadata, err := server.Services.PatientService.Filter(r.Context(), name, models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -850,7 +858,7 @@ func (server *Server) Adminfilternurse(w http.ResponseWriter, r *http.Request) {
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	nurse, metadata, err := server.Services.NurseService.Filter(name, models.Filters{
+	nurse, metadata, err := server.Services.NurseService.Filter(r.Context(), name, models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -889,7 +897,7 @@ func (server *Server) Adminschedule(w http.ResponseWriter, r *http.Request) {
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	schedules, metadata, err := server.Services.ScheduleService.FindAll(models.Filters{
+	schedules, metadata, err := server.Services.ScheduleService.FindAll(r.Context(), models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -927,7 +935,7 @@ func (server *Server) Admindepartment(w http.ResponseWriter, r *http.Request) {
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	department, metadata, err := server.Services.DepartmentService.FindAll(models.Filters{
+	department, metadata, err := server.Services.DepartmentService.FindAll(r.Context(), models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -1024,7 +1032,7 @@ func (server *Server) Admincreatepatient(w http.ResponseWriter, r *http.Request)
 		Hashed_password: hashed_password,
 		Created_at:      time.Now(),
 	}
-	if _, err := server.Services.PatientService.Create(patient); err != nil {
+	if _, err := server.Services.PatientService.Create(r.Context(), patient); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
 		data.Errors = msg.Errors
@@ -1083,7 +1091,7 @@ func (server *Server) Admincreateschedule(w http.ResponseWriter, r *http.Request
 		Endtime:   register.Endtime,
 		Active:    actvie,
 	}
-	if _, err := server.Services.MakeSchedule(schedule); err != nil {
+	if _, err := server.Services.MakeSchedule(r.Context(), schedule); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
 		data.Errors = msg.Errors
@@ -1105,12 +1113,12 @@ func (server *Server) AdmincreateAppointment(w http.ResponseWriter, r *http.Requ
 		http.Redirect(w, r, "/admin/login", http.StatusMovedPermanently)
 	}
 	var msg Form
-	var approval bool
 	register := Appointment{
 		Doctorid:        r.PostFormValue("Doctorid"),
 		Patientid:       r.PostFormValue("Patientid"),
 		AppointmentDate: r.PostFormValue("Appointmentdate"),
 		Duration:        r.PostFormValue("Duration"),
+		location:        server.Services.Clinic(),
 	}
 	msg = NewForm(r, &register)
 
@@ -1138,19 +1146,23 @@ func (server *Server) AdmincreateAppointment(w http.ResponseWriter, r *http.Requ
 	}
 	doctorid, _ := strconv.Atoi(register.Doctorid)
 	patientid, _ := strconv.Atoi(r.PostFormValue("Patientid"))
-	date, err := time.Parse("2006-01-02T15:04", r.PostFormValue("Appointmentdate"))
+	date, err := parsedatetime(r.PostFormValue("Appointmentdate"), server.Services.Clinic())
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	approval = checkboxvalue(r.PostFormValue("Approval"))
+	// the admin may approve the appointment as it's booked
+	status := models.StatusRequested
+	if checkboxvalue(r.PostFormValue("Approval")) {
+		status = models.StatusApproved
+	}
 	apntmt := models.Appointment{
 		Doctorid:        doctorid,
 		Patientid:       patientid,
 		Appointmentdate: date,
-		Duration:        register.Duration,
-		Approval:        approval,
+		Duration:        parseduration(register.Duration),
+		Status:          status,
 	}
-	_, err = server.Services.DoctorBookAppointment(apntmt)
+	_, err = server.Services.DoctorBookAppointment(r.Context(), apntmt)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
@@ -1223,7 +1235,7 @@ func (server *Server) Admincreaterecords(w http.ResponseWriter, r *http.Request)
 		Additional:  r.PostFormValue("Additional"),
 		Date:        time.Now(),
 	}
-	if _, err := server.Services.PatientRecordService.Create(records); err != nil {
+	if _, err := server.Services.CreateRecord(r.Context(), records); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = "record already exist"
 		data.Errors = msg.Errors
@@ -1275,7 +1287,7 @@ func (server *Server) Admincreatedepartment(w http.ResponseWriter, r *http.Reque
 	dept := models.Department{
 		Departmentname: register.Departmentname,
 	}
-	if _, err := server.Services.DepartmentService.Create(dept); err != nil {
+	if _, err := server.Services.DepartmentService.Create(r.Context(), dept); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = "department already exists"
 		data.Errors = msg.Errors
@@ -1341,7 +1353,7 @@ func (server *Server) Admincreatedoctor(w http.ResponseWriter, r *http.Request)
 		Departmentname:  register.Departmentname,
 		Created_at:      time.Now(),
 	}
-	if _, err := server.Services.DoctorService.Create(doctor); err != nil {
+	if _, err := server.Services.DoctorService.Create(r.Context(), doctor); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = "doctor already exists"
 		data.Errors = msg.Errors
@@ -1399,7 +1411,7 @@ func (server *Server) Admincreatenurse(w http.ResponseWriter, r *http.Request) {
 		Hashed_password: hashed_password,
 		Created_at:      time.Now(),
 	}
-	if _, err := server.Services.NurseService.Create(nurse); err != nil {
+	if _, err := server.Services.NurseService.Create(r.Context(), nurse); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = "doctor already exists"
 		data.Errors = msg.Errors
@@ -1426,7 +1438,7 @@ func (server *Server) Admindeletedoctor(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	if err := server.Services.DoctorService.Delete(idparam); err != nil {
+	if err := server.Services.DoctorService.Delete(r.Context(), idparam); err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
 }
@@ -1448,7 +1460,7 @@ func (server *Server) Admindeletepatient(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := server.Services.PatientService.Delete(idparam); err != nil {
+	if err := server.Services.PatientService.Delete(r.Context(), idparam); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		server.Templates.Render(w, "admin-edit-patient.html", nil)
 		return
@@ -1471,7 +1483,7 @@ func (server *Server) Admindeletedepartment(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := server.Services.DepartmentService.Delete(idparam); err != nil {
+	if err := server.Services.DepartmentService.Delete(r.Context(), idparam); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		server.Templates.Render(w, "admin-edit-department.html", nil)
 		return
@@ -1493,7 +1505,7 @@ func (server *Server) Admindeleterecord(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	if err := server.Services.PatientRecordService.Delete(idparam); err != nil {
+	if err := server.Services.PatientRecordService.Delete(r.Context(), idparam); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		server.Templates.Render(w, "admin-edit-records.html", nil)
 		return
@@ -1515,7 +1527,12 @@ func (server *Server) Admindeleteappointment(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	if err := server.Services.AppointmentService.Delete(idparam); err != nil {
+	if _, err := server.Services.AppointmentService.Find(r.Context(), idparam); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		server.Templates.Render(w, "404.html", nil)
+		return
+	}
+	if err := server.Services.DeleteAppointment(r.Context(), idparam); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		server.Templates.Render(w, "admin-edit-apntmt.html", nil)
 		return
@@ -1538,7 +1555,7 @@ func (server *Server) Admindeleteschedule(w http.ResponseWriter, r *http.Request
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
 
-	if err := server.Services.ScheduleService.Delete(idparam); err != nil {
+	if err := server.Services.ScheduleService.Delete(r.Context(), idparam); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		server.Templates.Render(w, "admin-edit-schedule.html", nil)
 		return
@@ -1556,7 +1573,7 @@ func (server *Server) Adminupdatepatient(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	data, err := server.Services.PatientService.Find(idparam)
+	data, err := server.Services.PatientService.Find(r.Context(), idparam)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		server.Templates.Render(w, "404.html", nil)
@@ -1626,7 +1643,7 @@ func (server *Server) Adminupdatepatient(w http.ResponseWriter, r *http.Request)
 		server.Templates.Render(w, "admin-update-patient.html", pdata)
 		return
 	}
-	if _, err := server.Services.PatientService.Update(patient); err != nil {
+	if _, err := server.Services.PatientService.Update(r.Context(), patient); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -1649,7 +1666,7 @@ func (server *Server) Adminupdateschedule(w http.ResponseWriter, r *http.Request
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	data, err := server.Services.ScheduleService.Find(idparam)
+	data, err := server.Services.ScheduleService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
@@ -1699,8 +1716,12 @@ func (server *Server) Adminupdateschedule(w http.ResponseWriter, r *http.Request
 		Starttime:  register.Starttime,
 		Endtime:    register.Endtime,
 		Active:     actvie,
+		// the form only edits the daily hours
+		Validfrom:  data.Validfrom,
+		Validuntil: data.Validuntil,
+		Blocks:     data.Blocks,
 	}
-	if _, err := server.Services.UpdateSchedule(schedule); err != nil {
+	if _, err := server.Services.UpdateSchedule(r.Context(), schedule); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -1722,10 +1743,12 @@ func (server *Server) AdminupdateAppointment(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	data, err := server.Services.AppointmentService.Find(idparam)
+	data, err := server.Services.AppointmentService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
+	// the form shows & reads the wall clock of the clinic
+	data.Appointmentdate = data.Appointmentdate.In(server.Services.Clinic())
 	session, err := server.Store.Get(r, "admin")
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -1739,26 +1762,50 @@ func (server *Server) AdminupdateAppointment(w http.ResponseWriter, r *http.Requ
 		Patientid:       r.PostFormValue("Patientid"),
 		AppointmentDate: r.PostFormValue("Appointmentdate"),
 		Duration:        r.PostFormValue("Duration"),
+		location:        server.Services.Clinic(),
 	}
 	msg = NewForm(r, &register)
+	actor := models.Actor{AccountType: auth.AccountAdmin, AccountId: admin.Id}
 	pdata := struct {
 		User        UserResp
 		Errors      Errors
 		Csrf        map[string]interface{}
 		Appointment models.Appointment
+		History     []models.Transition
+		Statuses    []models.Status
 		Success     string
 	}{
 		Errors:      Errmap,
 		Appointment: data,
+		History:     server.appointmenthistory(r, data.Appointmentid),
+		Statuses:    nextstatuses(data, actor.AccountType),
 		User:        admin,
 		Csrf:        msg.Csrf,
 	}
-	var approval bool
 	if r.Method == "GET" {
 		w.WriteHeader(http.StatusOK)
 		server.Templates.Render(w, "admin-update-appointment.html", pdata)
 		return
 	}
+	// the status form only posts the status to move to & why
+	if to := r.PostFormValue("Status"); to != "" {
+		appointment, err := server.Services.TransitionAppointment(r.Context(), data.Appointmentid, models.Status(to), actor, r.PostFormValue("Reason"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			Errmap["Exists"] = err.Error()
+			pdata.Errors = Errmap
+			server.Templates.Render(w, "admin-update-appointment.html", pdata)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		appointment.Appointmentdate = appointment.Appointmentdate.In(server.Services.Clinic())
+		pdata.Appointment = appointment
+		pdata.History = server.appointmenthistory(r, appointment.Appointmentid)
+		pdata.Statuses = nextstatuses(appointment, actor.AccountType)
+		pdata.Success = "appointment " + string(appointment.Status)
+		server.Templates.Render(w, "admin-update-appointment.html", pdata)
+		return
+	}
 	if ok := msg.Validate(); !ok {
 		pdata.Errors = msg.Errors
 		w.WriteHeader(http.StatusBadRequest)
@@ -1767,22 +1814,21 @@ func (server *Server) AdminupdateAppointment(w http.ResponseWriter, r *http.Requ
 	}
 	doctorid, _ := strconv.Atoi(r.PostFormValue("Doctorid"))
 	patientid, _ := strconv.Atoi(r.PostFormValue("Patientid"))
-	date, err := time.Parse("2006-01-02T15:04", r.PostFormValue("Appointmentdate"))
+	date, err := parsedatetime(r.PostFormValue("Appointmentdate"), server.Services.Clinic())
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	approval = checkboxvalue(r.PostFormValue("Approval"))
 	outbound := checkboxvalue(r.PostFormValue("Outbound"))
 	apntmt := models.Appointment{
 		Appointmentid:   data.Appointmentid,
 		Doctorid:        doctorid,
 		Patientid:       patientid,
 		Appointmentdate: date,
-		Duration:        register.Duration,
-		Approval:        approval,
+		Duration:        parseduration(register.Duration),
 		Outbound:        outbound,
 	}
-	if _, err := server.Services.UpdateappointmentbyDoctor(apntmt); err != nil {
+	appointment, err := server.Services.UpdateappointmentbyDoctor(r.Context(), apntmt, actor)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -1790,7 +1836,10 @@ func (server *Server) AdminupdateAppointment(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	w.WriteHeader(http.StatusOK)
-	pdata.Appointment = apntmt
+	appointment.Appointmentdate = appointment.Appointmentdate.In(server.Services.Clinic())
+	pdata.Appointment = appointment
+	pdata.History = server.appointmenthistory(r, appointment.Appointmentid)
+	pdata.Statuses = nextstatuses(appointment, actor.AccountType)
 	pdata.Success = "appointment updated successfully"
 	server.Templates.Render(w, "admin-update-appointment.html", pdata)
 }
@@ -1805,7 +1854,7 @@ func (server *Server) Adminupdaterecords(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	data, err := server.Services.PatientRecordService.Find(idparam)
+	data, err := server.Services.PatientRecordService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
@@ -1842,7 +1891,7 @@ func (server *Server) Adminupdatenurse(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	data, err := server.Services.NurseService.Find(idparam)
+	data, err := server.Services.NurseService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
@@ -1894,7 +1943,7 @@ func (server *Server) Adminupdatenurse(w http.ResponseWriter, r *http.Request) {
 		Email:           register.Email,
 		Hashed_password: hashed_password,
 	}
-	if _, err := server.Services.NurseService.Update(nurse); err != nil {
+	if _, err := server.Services.NurseService.Update(r.Context(), nurse); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -1914,7 +1963,7 @@ func (server *Server) Adminupdatedoctor(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	data, err := server.Services.DoctorService.Find(idparam)
+	data, err := server.Services.DoctorService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
@@ -1969,7 +2018,7 @@ func (server *Server) Adminupdatedoctor(w http.ResponseWriter, r *http.Request)
 		Hashed_password: hashed_password,
 		Departmentname:  register.Departmentname,
 	}
-	if _, err := server.Services.DoctorService.Update(doctor); err != nil {
+	if _, err := server.Services.DoctorService.Update(r.Context(), doctor); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -1991,7 +2040,7 @@ func (server *Server) Adminupdatedepartment(w http.ResponseWriter, r *http.Reque
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	data, err := server.Services.DepartmentService.Find(idparam)
+	data, err := server.Services.DepartmentService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
@@ -2034,8 +2083,9 @@ func (server *Server) Adminupdatedepartment(w http.ResponseWriter, r *http.Reque
 	dept := models.Department{
 		Departmentid:   data.Departmentid,
 		Departmentname: register.Departmentname,
+		Durations:      data.Durations,
 	}
-	if _, err := server.Services.DepartmentService.Update(dept); err != nil {
+	if _, err := server.Services.DepartmentService.Update(r.Context(), dept); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -2062,7 +2112,7 @@ func (server *Server) admin_reset_password(w http.ResponseWriter, r *http.Reques
 		server.Templates.Render(w, "404.html", nil)
 		return
 	}
-	user, err := server.Services.RbacService.UsersService.FindbyEmail(value)
+	user, err := server.Services.RbacService.UsersService.FindbyEmail(r.Context(), value)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		server.Templates.Render(w, "404.html", nil)
@@ -2099,7 +2149,7 @@ func (server *Server) admin_reset_password(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	if _, err := server.Services.RbacService.UsersService.Update(user); err != nil {
+	if _, err := server.Services.RbacService.UsersService.Update(r.Context(), user); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		data.Errors = Errmap
@@ -2150,19 +2200,19 @@ func (server *Server) Reports(w http.ResponseWriter, r *http.Request) {
 	type appointment_report struct {
 		Appointment models.Appointment
 	}
-	appointments, metadata, err := server.Services.AppointmentService.FindAll(models.Filters{PageSize: 1000000000, Page: 1})
+	appointments, metadata, err := server.Services.AppointmentService.FindAll(r.Context(), models.Filters{PageSize: 1000000000, Page: 1})
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	records, report_meta, err := server.Services.PatientRecordService.FindAll(models.Filters{PageSize: 1000000000, Page: 1})
+	records, report_meta, err := server.Services.PatientRecordService.FindAll(r.Context(), models.Filters{PageSize: 1000000000, Page: 1})
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	doctor, docmeta, err := server.Services.DoctorService.FindAll(models.Filters{PageSize: size, Page: 1})
+	doctor, docmeta, err := server.Services.DoctorService.FindAll(r.Context(), models.Filters{PageSize: size, Page: 1})
 	average_aptmnt_per_doctor := float64(metadata.TotalRecords) / float64(docmeta.TotalRecords)
 
-	nurses, nursemeta, err := server.Services.NurseService.FindAll(models.Filters{PageSize: size, Page: 1})
-	patients, patientmeta, err := server.Services.PatientService.FindAll(models.Filters{PageSize: size, Page: 1})
+	nurses, nursemeta, err := server.Services.NurseService.FindAll(r.Context(), models.Filters{PageSize: size, Page: 1})
+	patients, patientmeta, err := server.Services.PatientService.FindAll(r.Context(), models.Filters{PageSize: size, Page: 1})
 
 	average_record_per_nurse := float64(report_meta.TotalRecords) / float64(nursemeta.TotalRecords)
 	data_general := General_report{
@@ -2174,24 +2224,18 @@ func (server *Server) Reports(w http.ResponseWriter, r *http.Request) {
 		Average_record_nurse:    float32(average_record_per_nurse),
 		Patient:                 patientmeta.TotalRecords,
 	}
-	var t = Ticket{}
-	var tickets []Ticket
-	iter := server.Redis.Scan(server.Context, 0, "*", 0).Iterator()
-	for iter.Next(server.Context) {
-		if strings.Contains(iter.Val(), "ticket") {
-			value, _ := server.Redis.Get(server.Context, iter.Val()).Result()
-			t.UnMarshalBinary([]byte(value))
-			tickets = append(tickets, t)
-		}
-	}
 	var data_amount = 20
+	tickets, _, err := server.Services.TicketService.FindAll(r.Context(), models.Filters{PageSize: data_amount, Page: 1})
+	if err != nil {
+		server.Log.Error(err)
+	}
 	logs := readlogfile("system.log")
 	data := struct {
 		General      General_report
 		Appointments []models.Appointment
 		Records      []models.Patientrecords
 		Doctors      []models.Physician
-		Tickets      []Ticket
+		Tickets      []models.Ticket
 		User         UserResp
 		Nurses       []models.Nurse
 		Patients     []models.Patient
@@ -2201,7 +2245,7 @@ func (server *Server) Reports(w http.ResponseWriter, r *http.Request) {
 		Appointments: appointments[:data_amount],
 		Records:      records[:data_amount],
 		Doctors:      doctor[:data_amount],
-		Tickets:      tickets[:data_amount],
+		Tickets:      tickets,
 		User:         admin,
 		Nurses:       nurses[:data_amount],
 		Patients:     patients[:data_amount],
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/services"
+)
+
+// appointmenthistory lists the moves of the appointment for the update pages,
+// the page still renders without it
+func (server *Server) appointmenthistory(r *http.Request, id int) []models.Transition {
+	history, err := server.Services.TransitionService.FindbyAppointment(r.Context(), id)
+	if err != nil {
+		server.Log.Error(err)
+	}
+	return history
+}
+
+// appointmentseries finds the series of an appointment & its occurrences in the clinic's zone for the update pages,
+// the series is nil when the appointment was booked on its own or it couldn't be read.
+func (server *Server) appointmentseries(r *http.Request, id int) (*models.Series, []models.Appointment) {
+	if id == 0 {
+		return nil, nil
+	}
+	series, err := server.Services.SeriesService.Find(r.Context(), id)
+	if err != nil {
+		server.Log.Error(err)
+		return nil, nil
+	}
+	occurrences, err := server.Services.AppointmentService.FindAllBySeries(r.Context(), id)
+	if err != nil {
+		server.Log.Error(err)
+	}
+	series.Start = series.Start.In(server.Services.Clinic())
+	for i := range occurrences {
+		occurrences[i].Appointmentdate = occurrences[i].Appointmentdate.In(server.Services.Clinic())
+	}
+	return &series, occurrences
+}
+
+// nextstatuses lists the statuses the update pages offer to move the appointment to,
+// rescheduling is done by changing its time and patients can only cancel
+func nextstatuses(appointment models.Appointment, accounttype string) []models.Status {
+	var next []models.Status
+	for _, status := range models.Statuses {
+		if status == models.StatusRescheduled || !appointment.Status.CanMoveTo(status) {
+			continue
+		}
+		if accounttype == auth.AccountPatient && status != models.StatusCancelled {
+			continue
+		}
+		next = append(next, status)
+	}
+	return next
+}
+
+// WaitlistOffer shows the slot offered with the token emailed to the patient and books it once they accept,
+// the token is all it takes like the link verifying an account.
+func (server *Server) WaitlistOffer(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	waitlist, err := server.Services.WaitlistService.FindbyOffer(r.Context(), token)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		server.Templates.Render(w, "404.html", nil)
+		return
+	}
+	doctor, err := server.Services.DoctorService.Find(r.Context(), waitlist.Doctorid)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		server.Templates.Render(w, "404.html", nil)
+		return
+	}
+	csrfmap := make(map[string]interface{})
+	csrfmap[csrf.TemplateTag] = csrf.TemplateField(r)
+	data := struct {
+		Doctor   models.Physician
+		Date     time.Time
+		Expires  time.Time
+		Duration time.Duration
+		Expired  bool
+		Errors   Errors
+		Csrf     map[string]interface{}
+		Success  string
+	}{
+		Doctor:   doctor,
+		Date:     waitlist.Offer.Starttime.In(server.Services.Clinic()),
+		Expires:  waitlist.Offer.Expires.In(server.Services.Clinic()),
+		Duration: waitlist.Duration,
+		Expired:  !waitlist.Offer.Pending(time.Now()),
+		Errors:   make(Errors),
+		Csrf:     csrfmap,
+	}
+	if r.Method == http.MethodGet {
+		w.WriteHeader(http.StatusOK)
+		server.Templates.Render(w, "waitlist-offer.html", data)
+		return
+	}
+	if _, err := server.Services.AcceptOffer(r.Context(), token); err != nil {
+		data.Expired = errors.Is(err, services.ErrOfferExpired)
+		data.Errors["Exists"] = err.Error()
+		w.WriteHeader(http.StatusConflict)
+		server.Templates.Render(w, "waitlist-offer.html", data)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	data.Success = "your appointment is booked"
+	server.Templates.Render(w, "waitlist-offer.html", data)
+}
+
+// seriesfailures lists why each occurrence of a series failed by its date in loc written with layout,
+// false is returned when err isn't a services.SeriesError.
+func seriesfailures(err error, loc *time.Location, layout string) (Errors, bool) {
+	var serieserr *services.SeriesError
+	if !errors.As(err, &serieserr) {
+		return nil, false
+	}
+	failures := make(Errors)
+	for _, failed := range serieserr.Failed {
+		failures[failed.Date.In(loc).Format(layout)] = failed.Err.Error()
+	}
+	return failures, true
+}
+
+// serieserrors is seriesfailures for the staff pages,they only list the values so the date goes along
+func (server *Server) serieserrors(err error) Errors {
+	failures, ok := seriesfailures(err, server.Services.Clinic(), "Mon 2006-01-02 15:04")
+	if !ok {
+		return Errors{"Exists": err.Error()}
+	}
+	errs := make(Errors)
+	for date, reason := range failures {
+		errs[date] = date + ": " + reason
+	}
+	return errs
+}
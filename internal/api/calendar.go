@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/ical"
+	"github.com/patienttracker/internal/mailer"
+	"github.com/patienttracker/internal/models"
+)
+
+// feedURL is the address calendar apps subscribe to,the token in it is the whole credential
+func (server *Server) feedURL(feed models.CalendarFeed) string {
+	return server.Config.BaseURL + "/calendar/" + feed.Token + ".ics"
+}
+
+type calendarJSON struct {
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// appointmentwith names whoever the appointment is with as accounttype sees it,it's empty when they can't be found
+func (server *Server) appointmentwith(ctx context.Context, appointment models.Appointment, accounttype string) string {
+	if accounttype == auth.AccountPhysician {
+		patient, err := server.Services.PatientService.Find(ctx, appointment.Patientid)
+		if err != nil {
+			server.Log.Error(err)
+			return ""
+		}
+		return patient.Username
+	}
+	doctor, err := server.Services.DoctorService.Find(ctx, appointment.Doctorid)
+	if err != nil {
+		server.Log.Error(err)
+		return ""
+	}
+	return "Dr. " + doctor.Username
+}
+
+// appointmentevent is the calendar event of the appointment as accounttype sees it,
+// names keeps the names already looked up when a whole calendar is written.
+func (server *Server) appointmentevent(ctx context.Context, appointment models.Appointment, accounttype string, names map[int]string) ical.Event {
+	with := appointment.Doctorid
+	if accounttype == auth.AccountPhysician {
+		with = appointment.Patientid
+	}
+	name, ok := names[with]
+	if !ok {
+		name = server.appointmentwith(ctx, appointment, accounttype)
+		names[with] = name
+	}
+	summary := "Appointment"
+	if name != "" {
+		summary += " with " + name
+	}
+	host := "patienttracker"
+	if u, err := url.Parse(server.Config.BaseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return ical.Event{
+		UID:         fmt.Sprintf("appointment-%d@%s", appointment.Appointmentid, host),
+		Summary:     summary,
+		Description: fmt.Sprintf("Appointment %d is %s", appointment.Appointmentid, appointment.Status),
+		Start:       appointment.Appointmentdate,
+		End:         appointment.End(),
+		Cancelled:   appointment.Status == models.StatusCancelled,
+	}
+}
+
+// appointmentscalendar is the calendar of the appointments as accounttype sees them
+func (server *Server) appointmentscalendar(ctx context.Context, name string, appointments []models.Appointment, accounttype string) ical.Calendar {
+	names := make(map[int]string)
+	calendar := ical.Calendar{Name: name}
+	for _, appointment := range appointments {
+		calendar.Events = append(calendar.Events, server.appointmentevent(ctx, appointment, accounttype, names))
+	}
+	return calendar
+}
+
+// appointmentics is the .ics file of a single appointment,its sequence counts the moves
+// of the appointment so calendar apps replace the copy they imported before.
+func (server *Server) appointmentics(ctx context.Context, appointment models.Appointment, accounttype string) mailer.Attachment {
+	event := server.appointmentevent(ctx, appointment, accounttype, make(map[int]string))
+	if history, err := server.Services.TransitionService.FindbyAppointment(ctx, appointment.Appointmentid); err == nil {
+		event.Sequence = len(history)
+	}
+	return mailer.Attachment{
+		Name:        fmt.Sprintf("appointment-%d.ics", appointment.Appointmentid),
+		ContentType: ical.ContentType,
+		Data:        ical.Calendar{Events: []ical.Event{event}}.Bytes(),
+	}
+}
+
+// writeics sends the file as a download
+func writeics(w http.ResponseWriter, file mailer.Attachment) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Data)
+}
+
+// CalendarFeed serves the appointments of the account the token belongs to,
+// calendar apps can't log in so the token in the address is all they send.
+func (server *Server) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	feed, appointments, err := server.Services.FeedAppointments(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			server.Log.Error(err)
+		}
+		http.NotFound(w, r)
+		return
+	}
+	calendar := server.appointmentscalendar(r.Context(), "Appointments", appointments, feed.AccountType)
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(http.StatusOK)
+	calendar.WriteTo(w)
+}
+
+// PatientAppointmentics downloads the .ics of one of the patient's appointments
+func (server *Server) PatientAppointmentics(w http.ResponseWriter, r *http.Request) {
+	session, err := server.Store.Get(r, "user-session")
+	if err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	user := getUser(session)
+	if !user.Authenticated {
+		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
+		return
+	}
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	appointment, err := server.Services.AppointmentService.Find(r.Context(), id)
+	if err != nil || appointment.Patientid != user.Id {
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
+	}
+	writeics(w, server.appointmentics(r.Context(), appointment, auth.AccountPatient))
+}
+
+// Staffappointmentics downloads the .ics of one of the doctor's appointments
+func (server *Server) Staffappointmentics(w http.ResponseWriter, r *http.Request) {
+	session, err := server.Store.Get(r, "staff")
+	if err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	user := getStaff(session)
+	if !user.Authenticated {
+		http.Redirect(w, r, "/staff/login", http.StatusMovedPermanently)
+		return
+	}
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	appointment, err := server.Services.AppointmentService.Find(r.Context(), id)
+	if err != nil || appointment.Doctorid != user.Id {
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
+	}
+	writeics(w, server.appointmentics(r.Context(), appointment, auth.AccountPhysician))
+}
+
+// calendarfeed is the address of the account's calendar feed for the appointment pages,
+// it's empty until the account asks for one. Posting Calendar gives the account a new feed.
+func (server *Server) calendarfeed(r *http.Request, actor models.Actor) (string, error) {
+	if r.Method == http.MethodPost && r.PostFormValue("Calendar") != "" {
+		feed, err := server.Services.ResetCalendarFeed(r.Context(), actor)
+		if err != nil {
+			return "", err
+		}
+		return server.feedURL(feed), nil
+	}
+	feed, err := server.Services.CalendarService.FindbyAccount(r.Context(), actor.AccountType, actor.AccountId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return server.feedURL(feed), nil
+}
+
+// showAccountCalendarJSON returns the calendar feed of the doctor or patient,it's created the first time
+func (server *Server) showAccountCalendarJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	feed, err := server.Services.CalendarFeed(r.Context(), account.actor())
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"calendar": calendarJSON{URL: server.feedURL(feed), CreatedAt: feed.CreatedAt.In(server.viewerzone(r))}})
+}
+
+// resetAccountCalendarJSON gives the doctor or patient a new calendar feed,the old address stops working
+func (server *Server) resetAccountCalendarJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	feed, err := server.Services.ResetCalendarFeed(r.Context(), account.actor())
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"calendar": calendarJSON{URL: server.feedURL(feed), CreatedAt: feed.CreatedAt.In(server.viewerzone(r))}})
+}
+
+// accountAppointmenticsJSON downloads the .ics of one of the doctor's or patient's own appointments
+func (server *Server) accountAppointmenticsJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	appointment, err := server.Services.AppointmentService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	owner := (account.AccountType == auth.AccountPatient && appointment.Patientid == account.Id) ||
+		(account.AccountType == auth.AccountPhysician && appointment.Doctorid == account.Id)
+	if !owner {
+		server.notFoundJSON(w, r)
+		return
+	}
+	writeics(w, server.appointmentics(r.Context(), appointment, account.AccountType))
+}
+
+// appointmenticsJSON downloads the .ics of any appointment,it's written as the doctor sees it
+func (server *Server) appointmenticsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	appointment, err := server.Services.AppointmentService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	writeics(w, server.appointmentics(r.Context(), appointment, auth.AccountPhysician))
+}
@@ -1,23 +1,37 @@
 package api
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/mail"
 	"reflect"
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/csrf"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/services"
 )
 
-var durationregex = `^\d+h(\d+m)?(\d+s)?$`
+// durations are written the way time.Duration prints them e.g 1h,1h30m or 30m0s
+var durationregex = `^(\d+h)?(\d+m)?(\d+s)?$`
 var contactregex = `^[\+]?[(]?[0-9]{3}[)]?[-\s\.]?[0-9]{3}[-\s\.]?[0-9]{4,6}$`
 var weightregex = `^\d+kgs|lbs$`
 var keyvaluepairregex = `\s*(\w+)\s*:\s*(\w+)\s*,?`
 var bpregex = `^\d+/\d+$`
 
+// datetimeLayout is the format of the datetime-local inputs,they hold a wall clock time of the clinic
+const datetimeLayout = "2006-01-02T15:04"
+
+// parsedatetime reads the value of a datetime-local input in loc,UTC when it's nil
+func parsedatetime(value string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.ParseInLocation(datetimeLayout, value, loc)
+}
+
 type validation interface {
 	validate() (Errors, bool)
 }
@@ -117,7 +131,7 @@ func IsEmpty(data any, collect map[string]string) map[string]string {
 	values := reflect.ValueOf(data)
 	typesOf := values.Type()
 	for i := 0; i < values.NumField(); i++ {
-		if values.Field(i).Len() == 0 && typesOf.Field(i).Name != "Errors" {
+		if values.Field(i).Kind() == reflect.String && values.Field(i).Len() == 0 {
 			collect[typesOf.Field(i).Name] = fmt.Sprintf("%s required", typesOf.Field(i).Name)
 		}
 	}
@@ -187,29 +201,82 @@ type Appointment struct {
 	Duration        string
 	// Approval        string
 	Errors
+	// the zone AppointmentDate is read in
+	location *time.Location
 }
 
 func (a *Appointment) validate() (Errors, bool) {
 	a.Errors = make(map[string]string)
 	a.Errors = IsEmpty(*a, a.Errors)
-	today := time.Now().Format("2006-01-02T15:04")
-	td, _ := time.Parse("2006-01-02T15:04", today)
-	appointmentday, _ := time.Parse("2006-01-02T15:04", a.AppointmentDate)
-	if appointmentday.Before(td) {
+	appointmentday, _ := parsedatetime(a.AppointmentDate, a.location)
+	if appointmentday.Before(time.Now().Truncate(time.Minute)) {
 		a.Errors["AppointmentDate Input"] = "You can't travel back to the past,unless you have a time travel machine"
 	}
 	// duration format
-	if !checkinputregexformat(a.Duration, durationregex) {
+	if !checkinputregexformat(a.Duration, durationregex) || parseduration(a.Duration) <= 0 {
 		a.Errors["duration format"] = "Check your duration format"
 	}
 	return a.Errors, len(a.Errors) == 0
 }
 
+// AppointmentSeries is the recurrence form a doctor books follow ups with,
+// the series ends either after Count occurrences or on the day of Until.
+type AppointmentSeries struct {
+	Patientid       string
+	AppointmentDate string
+	Duration        string
+	Interval        string
+	Unit            string
+	Count           string
+	Until           string
+	Errors
+	// the zone AppointmentDate is read in
+	location *time.Location
+}
+
+func (a *AppointmentSeries) validate() (Errors, bool) {
+	a.Errors = make(map[string]string)
+	a.Errors = IsEmpty(struct{ Patientid, AppointmentDate, Duration, Interval, Unit string }{
+		a.Patientid, a.AppointmentDate, a.Duration, a.Interval, a.Unit,
+	}, a.Errors)
+	appointmentday, _ := parsedatetime(a.AppointmentDate, a.location)
+	if appointmentday.Before(time.Now().Truncate(time.Minute)) {
+		a.Errors["AppointmentDate Input"] = "You can't travel back to the past,unless you have a time travel machine"
+	}
+	if !checkinputregexformat(a.Duration, durationregex) || parseduration(a.Duration) <= 0 {
+		a.Errors["duration format"] = "Check your duration format"
+	}
+	if (a.Count == "") == (a.Until == "") {
+		a.Errors["Ends"] = "The series should end either after a number of appointments or on a day"
+	} else if _, err := a.recurrence(); err != nil {
+		a.Errors["Recurrence"] = err.Error()
+	}
+	return a.Errors, len(a.Errors) == 0
+}
+
+// recurrence reads the recurrence of the form,Until is a date
+func (a *AppointmentSeries) recurrence() (models.Recurrence, error) {
+	interval, _ := strconv.Atoi(a.Interval)
+	count, _ := strconv.Atoi(a.Count)
+	until, err := parsedate(a.Until)
+	if err != nil {
+		return models.Recurrence{}, err
+	}
+	recurrence := models.Recurrence{Interval: interval, Unit: models.Unit(a.Unit), Count: count, Until: until}
+	start, _ := parsedatetime(a.AppointmentDate, a.location)
+	if !recurrence.ValidFrom(start) {
+		return recurrence, services.ErrInvalidRecurrence
+	}
+	return recurrence, nil
+}
+
 type PatientAppointment struct {
 	PatientEmail    string
 	AppointmentDate string
 	Duration        string
 	Errors
+	// the zone AppointmentDate is read in
+	location *time.Location
 }
 
 func (a *PatientAppointment) validate() (Errors, bool) {
@@ -219,14 +286,12 @@ func (a *PatientAppointment) validate() (Errors, bool) {
 	if err != nil {
 		a.Errors["Email"] = "Please enter a valid email address"
 	}
-	today := time.Now().Format("2006-01-02T15:04")
-	td, _ := time.Parse("2006-01-02T15:04", today)
-	appointmentday, _ := time.Parse("2006-01-02T15:04", a.AppointmentDate)
-	if appointmentday.Before(td) {
+	appointmentday, _ := parsedatetime(a.AppointmentDate, a.location)
+	if appointmentday.Before(time.Now().Truncate(time.Minute)) {
 		a.Errors["AppointmentDate Input"] = "You can't travel back to the past,unless you have a time travel machine"
 	}
 	// duration format
-	if !checkinputregexformat(a.Duration, durationregex) {
+	if !checkinputregexformat(a.Duration, durationregex) || parseduration(a.Duration) <= 0 {
 		a.Errors["duration format"] = "Check your duration format"
 	}
 	return a.Errors, len(a.Errors) == 0
@@ -394,6 +459,13 @@ func checkboxvalue(value string) bool {
 }
 
 // regex check for input
+// parseduration parses a duration that matched durationregex,anything else is 0
+// which the services reject as an invalid duration.
+func parseduration(value string) time.Duration {
+	duration, _ := time.ParseDuration(value)
+	return duration
+}
+
 func checkinputregexformat(value, regexformat string) bool {
 	var format = regexp.MustCompile(regexformat)
 	return format.MatchString(value)
@@ -403,18 +475,3 @@ func matchsubstring(value, regexformat string) [][]string {
 	var format = regexp.MustCompile(regexformat)
 	return format.FindAllStringSubmatch(value, -1)
 }
-
-type Ticket struct {
-	Ticketid     string
-	Patientemail string
-	Doctorid     int
-	Nurseid      int
-	Attendedto   bool
-}
-
-func (t Ticket) MarshalBinary() ([]byte, error) {
-	return json.Marshal(t)
-}
-func (t *Ticket) UnMarshalBinary(data []byte) error {
-	return json.Unmarshal(data, t)
-}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/patienttracker/internal/events"
+)
+
+const (
+	// streamfor is how long an event stream stays open,it ends before the write timeout of the server
+	// and the browser reconnects with the id of the last event it got so nothing is missed in between.
+	streamfor = 25 * time.Second
+	// heartbeat keeps the proxies from closing a quiet stream
+	heartbeat = 10 * time.Second
+)
+
+type eventJSON struct {
+	Ticketid      int       `json:"ticket_id,omitempty"`
+	Appointmentid int       `json:"appointment_id,omitempty"`
+	Patientid     int       `json:"patient_id,omitempty"`
+	Nurseid       int       `json:"nurse_id,omitempty"`
+	Doctorid      int       `json:"doctor_id,omitempty"`
+	At            time.Time `json:"at"`
+}
+
+// lastevent is where a stream picks up,the Last-Event-ID a reconnecting browser sends
+// wins over the after parameter the page subscribed with. Without either it's the latest event.
+func (server *Server) lastevent(r *http.Request) uint64 {
+	id := r.Header.Get("Last-Event-ID")
+	if id == "" {
+		id = r.URL.Query().Get("after")
+	}
+	after, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return server.Services.Events.LastID()
+	}
+	return after
+}
+
+// stream writes the events match accepts as server-sent events until the client leaves or streamfor is up
+func (server *Server) stream(w http.ResponseWriter, r *http.Request, match func(events.Event) bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	ch, cancel := server.Services.Events.Subscribe(server.lastevent(r), match)
+	defer cancel()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 1000\n\n")
+	flusher.Flush()
+	done := time.NewTimer(streamfor)
+	defer done.Stop()
+	ping := time.NewTicker(heartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-done.C:
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(eventJSON{
+				Ticketid:      e.Ticketid,
+				Appointmentid: e.Appointmentid,
+				Patientid:     e.Patientid,
+				Nurseid:       e.Nurseid,
+				Doctorid:      e.Doctorid,
+				At:            e.At,
+			})
+			if err != nil {
+				server.Log.Error(err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// NurseEvents streams the changes to the tickets in the queue of the nurse
+func (server *Server) NurseEvents(w http.ResponseWriter, r *http.Request) {
+	session, err := server.Store.Get(r, "nurse")
+	if err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	user := getNurse(session)
+	if !user.Authenticated {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	server.stream(w, r, func(e events.Event) bool {
+		return e.Ticketid != 0 && e.Nurseid == user.Id
+	})
+}
+
+// StaffEvents streams the changes to the appointments of the doctor & the tickets referred to them
+func (server *Server) StaffEvents(w http.ResponseWriter, r *http.Request) {
+	session, err := server.Store.Get(r, "staff")
+	if err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	user := getStaff(session)
+	if !user.Authenticated {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	server.stream(w, r, func(e events.Event) bool {
+		return e.Doctorid == user.Id
+	})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStream(t *testing.T) {
+	bus := testserver.Services.Events
+	after := bus.LastID()
+	bus.Publish(events.Event{Kind: events.TicketOpened, Ticketid: 1, Nurseid: 7})
+	bus.Publish(events.Event{Kind: events.TicketOpened, Ticketid: 2, Nurseid: 8})
+	bus.Publish(events.Event{Kind: events.TicketMoved, Ticketid: 1, Nurseid: 7})
+	mine := func(e events.Event) bool { return e.Nurseid == 7 }
+	stream := func(r *http.Request) string {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		w := httptest.NewRecorder()
+		testserver.stream(w, r.WithContext(ctx), mine)
+		require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		return w.Body.String()
+	}
+
+	// the page subscribes after the events it shows
+	body := stream(httptest.NewRequest(http.MethodGet, "/nurse/events?after="+strconv.FormatUint(after, 10), nil))
+	require.Contains(t, body, fmt.Sprintf("id: %d\nevent: ticket.opened\ndata: {\"ticket_id\":1,", after+1))
+	require.Contains(t, body, fmt.Sprintf("id: %d\nevent: ticket.moved\n", after+3))
+	require.NotContains(t, body, `"ticket_id":2`)
+
+	// a reconnecting browser resumes after the last event it got
+	r := httptest.NewRequest(http.MethodGet, "/nurse/events?after="+strconv.FormatUint(after, 10), nil)
+	r.Header.Set("Last-Event-ID", strconv.FormatUint(after+1, 10))
+	body = stream(r)
+	require.NotContains(t, body, "event: ticket.opened")
+	require.Contains(t, body, "event: ticket.moved")
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/patienttracker/internal/worker"
+)
+
+type jobsJSON struct {
+	Queued      int              `json:"queued"`
+	Retrying    int              `json:"retrying"`
+	Running     int              `json:"running"`
+	Workers     int              `json:"workers"`
+	Succeeded   int              `json:"succeeded"`
+	Failed      int              `json:"failed"`
+	Dead        int              `json:"dead"`
+	DeadLetters []deadLetterJSON `json:"dead_letters"`
+}
+
+type deadLetterJSON struct {
+	ID       int       `json:"id"`
+	Task     string    `json:"task"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+func newJobsJSON(stats worker.Stats, letters []worker.DeadLetter) jobsJSON {
+	jobs := jobsJSON{
+		Queued:      stats.Queued,
+		Retrying:    stats.Retrying,
+		Running:     stats.Running,
+		Workers:     stats.Workers,
+		Succeeded:   stats.Succeeded,
+		Failed:      stats.Failed,
+		Dead:        stats.Dead,
+		DeadLetters: []deadLetterJSON{},
+	}
+	for _, letter := range letters {
+		jobs.DeadLetters = append(jobs.DeadLetters, deadLetterJSON{
+			ID:       letter.ID,
+			Task:     letter.Task,
+			Attempts: letter.Attempts,
+			Error:    letter.Err,
+			FailedAt: letter.FailedAt,
+		})
+	}
+	return jobs
+}
+
+// Adminjobs shows how busy the background workers are & the tasks that failed every attempt
+func (server *Server) Adminjobs(w http.ResponseWriter, r *http.Request) {
+	session, err := server.Store.Get(r, "admin")
+	if err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	data := struct {
+		User        UserResp
+		Stats       worker.Stats
+		DeadLetters []worker.DeadLetter
+		Csrf        map[string]interface{}
+	}{
+		User:        getAdmin(session),
+		Stats:       server.Worker.Stats(),
+		DeadLetters: server.Worker.DeadLetters(),
+		Csrf:        NewForm(r, &Login{}).Csrf,
+	}
+	w.WriteHeader(http.StatusOK)
+	server.Templates.Render(w, "admin-jobs.html", data)
+}
+
+// Adminrequeuejob queues the task of a dead letter again
+func (server *Server) Adminrequeuejob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
+	}
+	if err := server.Worker.Requeue(id); err != nil {
+		if errors.Is(err, worker.ErrNoSuchTask) {
+			http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+			return
+		}
+		server.Log.Error(err)
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	http.Redirect(w, r, "/admin/jobs", http.StatusSeeOther)
+}
+
+func (server *Server) showJobsJSON(w http.ResponseWriter, r *http.Request) {
+	server.writeJSON(w, r, http.StatusOK, envelope{"jobs": newJobsJSON(server.Worker.Stats(), server.Worker.DeadLetters())})
+}
+
+func (server *Server) requeueJobJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if err := server.Worker.Requeue(id); err != nil {
+		if errors.Is(err, worker.ErrNoSuchTask) {
+			server.notFoundJSON(w, r)
+			return
+		}
+		server.messageJSON(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	server.writeJSON(w, r, http.StatusAccepted, envelope{"jobs": newJobsJSON(server.Worker.Stats(), server.Worker.DeadLetters())})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/patienttracker/internal/events"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// undeliverable is an email that fails its only attempt
+type undeliverable struct{}
+
+func (undeliverable) Background() error { return errors.New("no such mailbox") }
+func (undeliverable) MaxAttempts() int  { return 1 }
+func (undeliverable) String() string    { return "email to nobody" }
+
+func TestJobsJSON(t *testing.T) {
+	dead := testserver.Worker.Stats().Dead
+	require.NoError(t, testserver.Worker.Submit(undeliverable{}))
+	require.Eventually(t, func() bool { return testserver.Worker.Stats().Dead == dead+1 }, time.Second, time.Millisecond)
+
+	var body struct {
+		Jobs jobsJSON `json:"jobs"`
+	}
+	w := httptest.NewRecorder()
+	testserver.showJobsJSON(w, httptest.NewRequest(http.MethodGet, "/v1/jobs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, testserver.Config.Worker.Workers, body.Jobs.Workers)
+	require.NotEmpty(t, body.Jobs.DeadLetters)
+	letter := body.Jobs.DeadLetters[0]
+	require.Equal(t, "email to nobody", letter.Task)
+	require.Equal(t, 1, letter.Attempts)
+	require.Equal(t, "no such mailbox", letter.Error)
+
+	requeue := func(id int) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/v1/jobs/dead/"+strconv.Itoa(id)+"/requeue", nil)
+		w := httptest.NewRecorder()
+		testserver.requeueJobJSON(w, mux.SetURLVars(r, map[string]string{"id": strconv.Itoa(id)}))
+		return w
+	}
+	require.Equal(t, http.StatusAccepted, requeue(letter.ID).Code)
+	require.Equal(t, http.StatusNotFound, requeue(letter.ID).Code)
+	// it fails its attempt again & is back in the dead letters
+	require.Eventually(t, func() bool { return testserver.Worker.Stats().Dead == dead+2 }, time.Second, time.Millisecond)
+}
+
+func TestMailQueued(t *testing.T) {
+	ctx := context.Background()
+	services := testserver.Services
+	dept, err := services.DepartmentService.Create(ctx, models.Department{Departmentname: utils.RandString(6)})
+	require.NoError(t, err)
+	doctor, err := services.DoctorService.Create(ctx, models.Physician{Username: utils.RandUsername(6), Email: utils.RandEmail(5), Contact: utils.RandContact(10), Departmentname: dept.Departmentname})
+	require.NoError(t, err)
+	patient, err := services.PatientService.Create(ctx, models.Patient{Username: utils.RandUsername(6), Email: utils.RandEmail(5), Contact: utils.RandContact(10)})
+	require.NoError(t, err)
+	at := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	waitlist, err := services.WaitlistService.Create(ctx, models.Waitlist{Patientid: patient.Patientid, Doctorid: doctor.Physicianid, Day: models.Day(at, time.UTC), Duration: 30 * time.Minute})
+	require.NoError(t, err)
+	waitlist.Offer = models.Offer{Token: utils.RandString(20), Starttime: at, Expires: time.Now().Add(time.Hour)}
+	waitlist, err = services.WaitlistService.Update(ctx, waitlist)
+	require.NoError(t, err)
+
+	// the email of the event goes through the worker pool,there's no mail server so it fails its attempts there
+	stats := testserver.Worker.Stats()
+	require.NoError(t, testserver.mail(ctx, events.Event{Kind: events.SlotOffered, Waitlistid: waitlist.Waitlistid}))
+	require.Eventually(t, func() bool { return testserver.Worker.Stats().Failed > stats.Failed }, 5*time.Second, time.Millisecond)
+}
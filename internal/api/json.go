@@ -1,4 +1,221 @@
 package api
 
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/services"
+)
+
 type Errorjson map[string]string
 type UrlResponse map[string]string
+
+// envelope wraps every json response body so that clients always get an object
+// keyed by the resource name i.e {"patient": {...}} or {"error": {...}}
+type envelope map[string]any
+
+// maximum size of a json request body
+const maxjsonbody = 1_048_576
+
+// maximum page size a client is allowed to request
+const maxpagesize = 100
+
+func (server *Server) writeJSON(w http.ResponseWriter, r *http.Request, status int, data envelope) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		server.Log.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// api clients authenticated through the admin session need the token to make unsafe requests
+	w.Header().Set("X-CSRF-Token", csrf.Token(r))
+	w.WriteHeader(status)
+	w.Write(append(js, '\n'))
+}
+
+// readJSON decodes a single json object from the request body into dst
+// and turns the decoder errors into messages that can be sent back to the client.
+func (server *Server) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxjsonbody)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return fmt.Errorf("body contains unknown key %s", fieldName)
+		case err.Error() == "http: request body too large":
+			return fmt.Errorf("body must not be larger than %d bytes", maxjsonbody)
+		default:
+			return err
+		}
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errors.New("body must only contain a single JSON value")
+	}
+	return nil
+}
+
+// readIDParam reads the {id} route variable
+func readIDParam(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid id parameter")
+	}
+	return id, nil
+}
+
+// readFilters reads the page & page_size query strings,falling back to the first page of PageCount records
+func readFilters(r *http.Request) (models.Filters, Errors) {
+	errs := make(Errors)
+	filters := models.Filters{Page: 1, PageSize: PageCount}
+	qs := r.URL.Query()
+	if page := qs.Get("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			errs["page"] = "must be a positive integer"
+		}
+		filters.Page = value
+	}
+	if size := qs.Get("page_size"); size != "" {
+		value, err := strconv.Atoi(size)
+		if err != nil || value < 1 || value > maxpagesize {
+			errs["page_size"] = fmt.Sprintf("must be between 1 and %d", maxpagesize)
+		}
+		filters.PageSize = value
+	}
+	return filters, errs
+}
+
+// timezone reads the zone the times of the json responses are written in from the tz
+// query parameter or the Time-Zone header,e.g Africa/Nairobi. It's the clinic's when neither is set.
+func (server *Server) timezone(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("tz")
+		if name == "" {
+			name = r.Header.Get("Time-Zone")
+		}
+		loc := server.Services.Clinic()
+		if name != "" {
+			var err error
+			if loc, err = time.LoadLocation(name); err != nil {
+				server.failedValidationJSON(w, r, Errors{"tz": "must be a time zone such as Africa/Nairobi"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), timezone_key, loc)))
+	})
+}
+
+// viewerzone is the zone the timezone middleware picked for the request
+func (server *Server) viewerzone(r *http.Request) *time.Location {
+	if loc, ok := r.Context().Value(timezone_key).(*time.Location); ok {
+		return loc
+	}
+	return server.Services.Clinic()
+}
+
+// errorJSON is the single place json error bodies are written from, every error body
+// has the shape {"error": {"message": "..."}} or {"error": {"<field>": "..."}} for validation errors
+func (server *Server) errorJSON(w http.ResponseWriter, r *http.Request, status int, body Errorjson) {
+	server.writeJSON(w, r, status, envelope{"error": body})
+}
+
+func (server *Server) messageJSON(w http.ResponseWriter, r *http.Request, status int, message string) {
+	server.errorJSON(w, r, status, Errorjson{"message": message})
+}
+
+func (server *Server) serverErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	server.Log.Error(err, fmt.Sprintf("method=%s", r.Method), fmt.Sprintf("path=%s", r.URL))
+	server.messageJSON(w, r, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+}
+
+func (server *Server) notFoundJSON(w http.ResponseWriter, r *http.Request) {
+	server.messageJSON(w, r, http.StatusNotFound, "the requested resource could not be found")
+}
+
+func (server *Server) methodNotAllowedJSON(w http.ResponseWriter, r *http.Request) {
+	server.messageJSON(w, r, http.StatusMethodNotAllowed, fmt.Sprintf("the %s method is not supported for this resource", r.Method))
+}
+
+func (server *Server) badRequestJSON(w http.ResponseWriter, r *http.Request, err error) {
+	server.messageJSON(w, r, http.StatusBadRequest, err.Error())
+}
+
+func (server *Server) failedValidationJSON(w http.ResponseWriter, r *http.Request, errs Errors) {
+	server.errorJSON(w, r, http.StatusUnprocessableEntity, Errorjson(errs))
+}
+
+func (server *Server) unauthorizedJSON(w http.ResponseWriter, r *http.Request) {
+	server.messageJSON(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
+}
+
+func (server *Server) forbiddenJSON(w http.ResponseWriter, r *http.Request) {
+	server.messageJSON(w, r, http.StatusForbidden, "you don't have the required permissions to access this resource")
+}
+
+func (server *Server) rateLimitExceededJSON(w http.ResponseWriter, r *http.Request) {
+	server.messageJSON(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+}
+
+// lookupErrorJSON writes the response for a failed repository lookup
+func (server *Server) lookupErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		server.notFoundJSON(w, r)
+		return
+	}
+	server.serverErrorJSON(w, r, err)
+}
+
+// serviceErrorJSON maps the business rule errors returned by services.Service to a status code
+func (server *Server) serviceErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	// a series reports each date that failed by itself
+	if failures, ok := seriesfailures(err, server.viewerzone(r), time.RFC3339); ok {
+		server.errorJSON(w, r, http.StatusConflict, Errorjson(failures))
+		return
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		server.notFoundJSON(w, r)
+	case errors.Is(err, services.ErrTimeSlotAllocated), errors.Is(err, services.ErrScheduleActive),
+		errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrNotStarted),
+		errors.Is(err, services.ErrOfferExpired), errors.Is(err, services.ErrInvalidTicketMove):
+		server.messageJSON(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidSchedule), errors.Is(err, services.ErrNotWithinSchedule),
+		errors.Is(err, services.ErrInvalidDuration), errors.Is(err, services.ErrDurationNotAllowed),
+		errors.Is(err, services.ErrDoctorAway), errors.Is(err, services.ErrInvalidHours),
+		errors.Is(err, services.ErrInvalidRange), errors.Is(err, services.ErrReasonRequired),
+		errors.Is(err, services.ErrPastDay), errors.Is(err, services.ErrInvalidRecurrence),
+		errors.Is(err, services.ErrInvalidAcuity):
+		server.messageJSON(w, r, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, services.ErrNotAuthorized), errors.Is(err, services.ErrForbidden):
+		server.forbiddenJSON(w, r)
+	default:
+		server.badRequestJSON(w, r, err)
+	}
+}
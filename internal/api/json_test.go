@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFilters(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/patients", nil)
+	filters, errs := readFilters(r)
+	require.Empty(t, errs)
+	require.Equal(t, 1, filters.Page)
+	require.Equal(t, PageCount, filters.PageSize)
+
+	r = httptest.NewRequest("GET", "/v1/patients?page=3&page_size=50", nil)
+	filters, errs = readFilters(r)
+	require.Empty(t, errs)
+	require.Equal(t, 3, filters.Page)
+	require.Equal(t, 50, filters.PageSize)
+
+	r = httptest.NewRequest("GET", "/v1/patients?page=-1&page_size=1000", nil)
+	_, errs = readFilters(r)
+	require.Contains(t, errs, "page")
+	require.Contains(t, errs, "page_size")
+}
+
+func TestReadSlotSearch(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/physicians/1/slots", nil)
+	from, to, duration, errs := readSlotSearch(r, time.UTC)
+	require.Empty(t, errs)
+	require.Equal(t, slotsearch, to.Sub(from))
+	require.Zero(t, duration)
+
+	r = httptest.NewRequest("GET", "/v1/physicians/1/slots?from=2023-12-12&to=2023-12-13T12:00:00%2B03:00&duration=1h30m", nil)
+	from, to, duration, errs = readSlotSearch(r, time.UTC)
+	require.Empty(t, errs)
+	require.Equal(t, time.Date(2023, 12, 12, 0, 0, 0, 0, time.UTC), from)
+	require.True(t, to.Equal(time.Date(2023, 12, 13, 9, 0, 0, 0, time.UTC)))
+	require.Equal(t, 90*time.Minute, duration)
+
+	nairobi, err := time.LoadLocation("Africa/Nairobi")
+	require.NoError(t, err)
+	r = httptest.NewRequest("GET", "/v1/physicians/1/slots?from=2023-12-12", nil)
+	from, _, _, errs = readSlotSearch(r, nairobi)
+	require.Empty(t, errs)
+	require.True(t, from.Equal(time.Date(2023, 12, 11, 21, 0, 0, 0, time.UTC)))
+
+	r = httptest.NewRequest("GET", "/v1/physicians/1/slots?from=tomorrow&to=12/12/2023&duration=soon", nil)
+	_, _, _, errs = readSlotSearch(r, time.UTC)
+	require.Contains(t, errs, "from")
+	require.Contains(t, errs, "to")
+	require.Contains(t, errs, "duration")
+}
+
+func TestReadJSON(t *testing.T) {
+	tc := []struct {
+		name string
+		body string
+		ok   bool
+	}{
+		{"valid", `{"departmentname":"surgery"}`, true},
+		{"empty", ``, false},
+		{"malformed", `{"departmentname":`, false},
+		{"unknown field", `{"name":"surgery"}`, false},
+		{"wrong type", `{"departmentname":1}`, false},
+		{"two values", `{"departmentname":"a"}{"departmentname":"b"}`, false},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var input departmentInput
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/v1/departments", strings.NewReader(c.body))
+			err := testserver.readJSON(w, r, &input)
+			if c.ok {
+				require.NoError(t, err)
+				require.Equal(t, "surgery", input.Departmentname)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	var got *time.Location
+	handler := testserver.timezone(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = testserver.viewerzone(r)
+	}))
+	testcases := []struct {
+		name   string
+		query  string
+		header string
+		zone   string
+	}{
+		{"the clinic's", "", "", "UTC"},
+		{"query parameter", "?tz=Africa/Nairobi", "", "Africa/Nairobi"},
+		{"header", "", "America/New_York", "America/New_York"},
+		{"query parameter over header", "?tz=Asia/Tokyo", "America/New_York", "Asia/Tokyo"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/appointments"+tc.query, nil)
+			if tc.header != "" {
+				r.Header.Set("Time-Zone", tc.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tc.zone, got.String())
+		})
+	}
+	r := httptest.NewRequest("GET", "/v1/appointments?tz=Mars/Olympus_Mons", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Contains(t, w.Body.String(), `"tz"`)
+}
@@ -5,22 +5,13 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
-	"github.com/patienttracker/internal/auth"
-	"github.com/patienttracker/internal/inmem"
+	"github.com/patienttracker/internal/config"
 	"github.com/patienttracker/internal/services"
 	"github.com/stretchr/testify/require"
 )
 
 func mockservices() services.Service {
-	mockstore := inmem.NewMockStore()
-	return services.Service{
-		DoctorService:        mockstore.DoctorMemStore,
-		AppointmentService:   mockstore.AppointmentMemStore,
-		ScheduleService:      mockstore.ScheduleMemStore,
-		PatientService:       mockstore.PatientMemStore,
-		DepartmentService:    mockstore.DepartmentMemStore,
-		PatientRecordService: mockstore.RecordMemStore,
-	}
+	return services.NewMemService()
 }
 
 var testserver *Server
@@ -72,8 +63,8 @@ func TestWeightRegex(t *testing.T) {
 }
 
 func TestMain(m *testing.M) {
-	token, _ := auth.PasetoMaker("YELLOW SUBMARINE, BLACK WIZARDRY")
-	testserver = NewServer(mockservices(), mux.NewRouter())
-	testserver.Auth = token
+	c := config.Defaults()
+	c.Token.SymmetricKey = "YELLOW SUBMARINE, BLACK WIZARDRY"
+	testserver = NewServer(c, mockservices(), mux.NewRouter())
 	os.Exit(m.Run())
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +23,8 @@ const (
 	admin_session  key = "admin"
 	nurse_sesssion key = "nurse"
 	staff_session  key = "staff"
+	account_key    key = "account"
+	timezone_key   key = "timezone"
 )
 
 // responseWriter is a minimal wrapper for http.ResponseWriter that allows the
@@ -45,6 +48,13 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.wroteHeader = true
 }
 
+// Flush lets the event streams flush through the wrapper
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // LoggingMiddleware logs the incoming HTTP request & its duration.
 func (server *Server) LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -57,10 +67,15 @@ func (server *Server) LoggingMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 		wrapped := wrapResponseWriter(w)
 		next.ServeHTTP(wrapped, r)
+		path := r.URL.String()
+		if strings.HasPrefix(r.URL.Path, "/calendar/") {
+			// the token of a calendar feed is a long lived credential,it's kept out of the logs
+			path = "/calendar/[token].ics"
+		}
 		server.Log.Info(
 			fmt.Sprintf("status=%d", wrapped.status),
 			fmt.Sprintf("method=%s", r.Method),
-			fmt.Sprintf("path=%s", r.URL),
+			fmt.Sprintf("path=%s", path),
 			fmt.Sprintf("duration=%s", time.Since(start)),
 		)
 	})
@@ -73,8 +88,9 @@ func (server *Server) sessionmiddleware(next http.Handler) http.Handler {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
 		}
 		user := getUser(session)
-		if !user.Authenticated {
+		if !user.Authenticated || !server.activeSession(r.Context(), session) {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+			return
 		}
 		ctx := context.WithValue(r.Context(), session, session)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -88,8 +104,9 @@ func (server *Server) sessionadminmiddleware(next http.Handler) http.Handler {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
 		}
 		user := getAdmin(session)
-		if !user.Authenticated {
+		if !user.Authenticated || !server.activeSession(r.Context(), session) {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+			return
 		}
 		ctx := context.WithValue(r.Context(), admin_session, session)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -103,8 +120,9 @@ func (server *Server) sessionstaffmiddleware(next http.Handler) http.Handler {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
 		}
 		user := getStaff(session)
-		if !user.Authenticated {
+		if !user.Authenticated || !server.activeSession(r.Context(), session) {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+			return
 		}
 		ctx := context.WithValue(r.Context(), staff_session, session)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -117,8 +135,9 @@ func (server *Server) sessionnursemiddleware(next http.Handler) http.Handler {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
 		}
 		user := getNurse(session)
-		if !user.Authenticated {
+		if !user.Authenticated || !server.activeSession(r.Context(), session) {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+			return
 		}
 		ctx := context.WithValue(r.Context(), nurse_sesssion, session)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -135,8 +154,9 @@ func (server *Server) CheckPermissions(next http.HandlerFunc, c services.Checker
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
 		}
 		user := getAdmin(session)
-		if !user.Authenticated {
+		if !user.Authenticated || !server.activeSession(r.Context(), session) {
 			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+			return
 		}
 		if ok := c.IsSatisfied(user.Permission); !ok {
 			w.WriteHeader(http.StatusForbidden)
@@ -185,7 +205,12 @@ func (server *Server) Ipratelimiter(next http.Handler) http.Handler {
 		clients[ip].lastSeen = time.Now()
 		if !clients[ip].limiter.Allow() {
 			mu.Unlock()
+			if strings.HasPrefix(r.URL.Path, "/v1/") {
+				server.rateLimitExceededJSON(w, r)
+				return
+			}
 			http.Redirect(w, r, "/429", http.StatusMovedPermanently)
+			return
 		}
 		mu.Unlock()
 		next.ServeHTTP(w, r)
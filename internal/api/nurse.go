@@ -2,17 +2,19 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
+	"github.com/patienttracker/internal/auth"
 	"github.com/patienttracker/internal/models"
 	"github.com/patienttracker/internal/services"
-	"github.com/patienttracker/internal/utils"
 )
 
 type NurseResp struct {
@@ -56,7 +58,7 @@ func (server *Server) NurseLogin(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "nurse-login.html", msg)
 		return
 	}
-	nurse, err := server.Services.NurseService.FindbyEmail(login.Email)
+	nurse, err := server.Services.NurseService.FindbyEmail(r.Context(), login.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusBadRequest)
@@ -75,6 +77,10 @@ func (server *Server) NurseLogin(w http.ResponseWriter, r *http.Request) {
 	user := NurseResponse(nurse)
 	gobRegister(user)
 	session.Values["nurse"] = user
+	if err = server.startCookieSession(r, session, auth.AccountNurse, nurse.Id, nurse.Username); err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
 	if err = session.Save(r, w); err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -85,6 +91,9 @@ func (server *Server) NurseLogout(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
+	if err = server.endCookieSession(r.Context(), session); err != nil {
+		server.Log.Error(err)
+	}
 	session.Values["nurse"] = NurseResp{}
 	session.Options.MaxAge = -1
 	err = session.Save(r, w)
@@ -104,7 +113,7 @@ func (server *Server) Nurserecord(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 
-	records, err := server.Services.PatientRecordService.FindAllByNurse(user.Id)
+	records, err := server.Services.PatientRecordService.FindAllByNurse(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -125,7 +134,7 @@ func (server *Server) NurseViewRecord(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	data, err := server.Services.PatientRecordService.Find(idparam)
+	data, err := server.Services.PatientRecordService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
@@ -178,45 +187,15 @@ func (server *Server) resetpassword(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "reset.html", dt)
 		return
 	}
-	key, account_type := key_reset_pass(r.URL.String())
-	value := reset.Email
-	if err := server.Redis.Set(server.Context, key, value, 0).Err(); err != nil {
+	if err := server.Services.RequestPasswordReset(r.Context(), resetaccount(r.URL.String()), reset.Email); err != nil {
 		server.Log.Error(err)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
-	}
-	data := struct {
-		URL   string
-		Email string
-	}{
-		URL:   fmt.Sprintf(`http://localhost:9000/%s/passwordreset?id=%s`, account_type, key),
-		Email: reset.Email,
-	}
-	mailer := server.Mailer.setdata(data, "Reset Password!!", "reset_password.account.html", data.Email)
-	server.WaitGroup.Add(server.Worker.Nworker)
-	go func() {
-		server.Worker.Task <- &mailer
-	}()
-	server.WaitGroup.Add(server.Worker.Nworker)
-	for i := 0; i < server.Worker.Nworker; i++ {
-		go func() {
-			defer server.Done()
-			server.Worker.Workqueue()
-		}()
+		return
 	}
 	w.WriteHeader(http.StatusCreated)
 	dt.Success = "email sent successfuly to reset your account"
 	server.Templates.Render(w, "reset.html", dt)
 }
-func key_reset_pass(url string) (string, string) {
-	if strings.Contains(url, "nurse") {
-		return "nurse" + utils.RandString(40), "nurse"
-	} else if strings.Contains(url, "doctor") {
-		return "doctor" + utils.RandString(40), "doctor"
-	} else if strings.Contains(url, "admin") {
-		return "admin" + utils.RandString(40), "admin"
-	}
-	return "patient" + utils.RandString(40), "patient"
-}
 
 func (server *Server) nurse_reset_password(w http.ResponseWriter, r *http.Request) {
 	Errmap := make(map[string]string)
@@ -232,7 +211,7 @@ func (server *Server) nurse_reset_password(w http.ResponseWriter, r *http.Reques
 		server.Templates.Render(w, "404.html", nil)
 		return
 	}
-	nurse, err := server.Services.NurseService.FindbyEmail(value)
+	nurse, err := server.Services.NurseService.FindbyEmail(r.Context(), value)
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
@@ -270,7 +249,7 @@ func (server *Server) nurse_reset_password(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	if _, err := server.Services.NurseService.Update(nurse); err != nil {
+	if _, err := server.Services.NurseService.Update(r.Context(), nurse); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		data.Errors = Errmap
@@ -292,96 +271,135 @@ func (server *Server) Nursetickets(w http.ResponseWriter, r *http.Request) {
 	if !user.Authenticated {
 		http.Redirect(w, r, "/nurse/login", http.StatusMovedPermanently)
 	}
-	var tickets = server.getnursetickets(user.Id)
+	w.WriteHeader(http.StatusOK)
+	server.nursetickets(w, r, user, nil)
+}
+
+// nursetickets renders the triage queue of the nurse,the most urgent tickets first
+func (server *Server) nursetickets(w http.ResponseWriter, r *http.Request, user NurseResp, errs Errors) {
+	// the page subscribes after the events it already shows
+	lastevent := server.Services.Events.LastID()
+	tickets, err := server.Services.TicketService.FindbyNurse(r.Context(), user.Id)
+	if err != nil {
+		server.Log.Error(err)
+	}
+	now := time.Now()
+	queue := make([]queuedticket, 0, len(tickets))
+	for _, ticket := range tickets {
+		queue = append(queue, queuedticket{
+			Ticket:  ticket,
+			Waited:  waited(now.Sub(ticket.CreatedAt)),
+			Target:  waited(server.Services.WaitTarget(ticket.Acuity)),
+			Overdue: server.Services.Overdue(ticket, now),
+		})
+	}
+	csrfmap := make(map[string]interface{})
+	csrfmap[csrf.TemplateTag] = csrf.TemplateField(r)
 	data := struct {
-		User    NurseResp
-		Tickets []Ticket
+		User      NurseResp
+		Tickets   []queuedticket
+		Errors    Errors
+		Csrf      map[string]interface{}
+		LastEvent uint64
 	}{
-		User:    user,
-		Tickets: tickets,
+		User:      user,
+		Tickets:   queue,
+		Errors:    errs,
+		Csrf:      csrfmap,
+		LastEvent: lastevent,
 	}
 	server.Templates.Render(w, "nurse-tickets.html", data)
 }
 
-func (server *Server) getnursetickets(nurseid int) []Ticket {
-	var ticketids []string
-	var t = Ticket{}
-	var tickets []Ticket
-	iter := server.Redis.Scan(server.Context, 0, "*", 0).Iterator()
-	for iter.Next(server.Context) {
-		if strings.Contains(iter.Val(), "ticket") {
-			ticketids = append(ticketids, iter.Val())
-		}
+// queuedticket is a ticket of the triage queue with how long the patient has waited,
+// it's overdue once the patient waited longer than the target of the acuity.
+type queuedticket struct {
+	models.Ticket
+	Waited  string
+	Target  string
+	Overdue bool
+}
+
+// waited writes a wait in hours & minutes
+func waited(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
 	}
-	for _, ids := range ticketids {
-		value, err := server.Redis.Get(server.Context, ids).Result()
-		if err != nil {
-			return tickets
-		}
-		t.UnMarshalBinary([]byte(value))
-		if t.Nurseid == nurseid {
-			tickets = append(tickets, t)
-		}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// acuities lists the triage scale from the most urgent
+func acuities() []models.Acuity {
+	var scale []models.Acuity
+	for acuity := models.MostUrgent; acuity <= models.LeastUrgent; acuity++ {
+		scale = append(scale, acuity)
 	}
-	return tickets
+	return scale
 }
-func (server *Server) getpatienttickets(email string) []Ticket {
-	var ticketids []string
-	var t = Ticket{}
-	var tickets []Ticket
-	iter := server.Redis.Scan(server.Context, 0, "*", 0).Iterator()
-	for iter.Next(server.Context) {
-		if strings.Contains(iter.Val(), "ticket") {
-			ticketids = append(ticketids, iter.Val())
-		}
+
+// NurseTicketStatus takes a ticket of the queue up,puts it back or closes it
+func (server *Server) NurseTicketStatus(w http.ResponseWriter, r *http.Request) {
+	session, err := server.Store.Get(r, "nurse")
+	if err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
 	}
-	for _, ids := range ticketids {
-		value, err := server.Redis.Get(server.Context, ids).Result()
-		if err != nil {
-			return tickets
-		}
-		t.UnMarshalBinary([]byte(value))
-		if t.Patientemail == email {
-			tickets = append(tickets, t)
-		}
+	user := getNurse(session)
+	if !user.Authenticated {
+		http.Redirect(w, r, "/nurse/login", http.StatusMovedPermanently)
+		return
+	}
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	actor := models.Actor{AccountType: auth.AccountNurse, AccountId: user.Id}
+	if _, err := server.Services.MoveTicket(r.Context(), id, models.TicketStatus(r.PostFormValue("Status")), actor); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		server.nursetickets(w, r, user, Errors{"Ticket": err.Error()})
+		return
 	}
-	return tickets
+	http.Redirect(w, r, "/nurse/home", http.StatusMovedPermanently)
 }
 
 func (server *Server) NurseCreateRecord(w http.ResponseWriter, r *http.Request) {
-	var t Ticket
 	session, err := server.Store.Get(r, "nurse")
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
 	}
 	nurse := getNurse(session)
 	if !nurse.Authenticated {
 		http.Redirect(w, r, "/staff/login", http.StatusMovedPermanently)
+		return
 	}
 	var msg Form
 	height, _ := strconv.Atoi(r.PostFormValue("Height"))
 	temp, _ := strconv.Atoi(r.PostFormValue("Temperature"))
 	heartrate, _ := strconv.Atoi(r.PostFormValue("HeartRate"))
-	ticketid := mux.Vars(r)
-	ticket, err := server.Redis.Get(server.Context, ticketid["ticket"]).Result()
-	if err != nil {
-		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+	ticketid, _ := strconv.Atoi(mux.Vars(r)["ticket"])
+	t, err := server.Services.TicketService.Find(r.Context(), ticketid)
+	if err != nil || t.Nurseid != nurse.Id {
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
 	}
-	t.UnMarshalBinary([]byte(ticket))
-	patient, err := server.Services.PatientService.FindbyEmail(t.Patientemail)
+	patient, err := server.Services.PatientService.Find(r.Context(), t.Patientid)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			if err != nil {
-				http.Redirect(w, r, "/404", http.StatusMovedPermanently)
-			}
+			http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+			return
 		}
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
 	}
-	doctors, _, _ := server.Services.DoctorService.FindAll(models.Filters{PageSize: size, Page: 1})
+	doctors, _, _ := server.Services.DoctorService.FindAll(r.Context(), models.Filters{PageSize: size, Page: 1})
 	var emails []string
 	for _, doctor := range doctors {
 		emails = append(emails, doctor.Email)
 	}
+	departments, _, _ := server.Services.DepartmentService.FindAll(r.Context(), models.Filters{PageSize: size, Page: 1})
+	var names []string
+	for _, department := range departments {
+		names = append(names, department.Departmentname)
+	}
 	register := Records{
 		Height:      r.PostFormValue("Height"),
 		Bp:          r.PostFormValue("Bp"),
@@ -393,18 +411,31 @@ func (server *Server) NurseCreateRecord(w http.ResponseWriter, r *http.Request)
 	}
 	msg = NewForm(r, &register)
 	data := struct {
-		User    NurseResp
-		Errors  Errors
-		Csrf    map[string]interface{}
-		Success string
-		Doctors []string
+		User        NurseResp
+		Errors      Errors
+		Csrf        map[string]interface{}
+		Success     string
+		Doctors     []string
+		Departments []string
+		Acuities    []models.Acuity
+		Ticket      models.Ticket
 	}{
-		User:    nurse,
-		Errors:  msg.Errors,
-		Csrf:    msg.Csrf,
-		Doctors: emails,
-	}
+		User:        nurse,
+		Errors:      msg.Errors,
+		Csrf:        msg.Csrf,
+		Doctors:     emails,
+		Departments: names,
+		Acuities:    acuities(),
+		Ticket:      t,
+	}
+	actor := models.Actor{AccountType: auth.AccountNurse, AccountId: nurse.Id}
 	if r.Method == "GET" {
+		// opening the form takes the ticket up so it's not picked twice
+		if t.Status == models.TicketOpen {
+			if _, err := server.Services.MoveTicket(r.Context(), t.Ticketid, models.TicketInProgress, actor); err != nil {
+				server.Log.Error(err)
+			}
+		}
 		w.WriteHeader(http.StatusOK)
 		server.Templates.Render(w, "nurse-edit-record.html", data)
 		return
@@ -415,28 +446,27 @@ func (server *Server) NurseCreateRecord(w http.ResponseWriter, r *http.Request)
 		server.Templates.Render(w, "nurse-edit-record.html", data)
 		return
 	}
-	doc, err := server.Services.DoctorService.FindbyEmail(r.PostFormValue("Email"))
+	// without a doctor picked the patient goes to the least busy doctor of the department
+	var doc models.Physician
+	if email := r.PostFormValue("Email"); email != "" {
+		doc, err = server.Services.DoctorService.FindbyEmail(r.Context(), email)
+	} else {
+		doc, err = server.Services.AssignDoctor(r.Context(), r.PostFormValue("Department"))
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["No Doc"] = "no such doctor"
+		if errors.Is(err, services.ErrNoDoctorAvailable) {
+			msg.Errors["No Doc"] = err.Error()
+		}
 		data.Errors = msg.Errors
 		server.Templates.Render(w, "nurse-edit-record.html", data)
 		return
 	}
-	if err = server.Redis.Set(server.Context, ticketid["ticket"], Ticket{
-		Ticketid:     t.Ticketid,
-		Patientemail: t.Patientemail,
-		Doctorid:     doc.Physicianid,
-		Nurseid:      t.Nurseid,
-		Attendedto:   true,
-	}, 0).Err(); err != nil {
-		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
-	}
-
+	// the acuity is left to the vitals unless the nurse gives one
+	acuity, _ := strconv.Atoi(r.PostFormValue("Acuity"))
 	records := models.Patientrecords{
 		Doctorid:    doc.Physicianid,
-		Patienid:    patient.Patientid,
-		Nurseid:     nurse.Id,
 		Height:      height,
 		Bp:          r.PostFormValue("Bp"),
 		Temperature: temp,
@@ -445,9 +475,9 @@ func (server *Server) NurseCreateRecord(w http.ResponseWriter, r *http.Request)
 		Additional:  r.PostFormValue("Additional"),
 		Date:        time.Now(),
 	}
-	if _, err := server.Services.PatientRecordService.Create(records); err != nil {
+	if _, err := server.Services.AttendTicket(r.Context(), t.Ticketid, records, models.Acuity(acuity), actor); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		msg.Errors["Exists"] = "record already exist"
+		msg.Errors["Exists"] = err.Error()
 		data.Errors = msg.Errors
 		server.Templates.Render(w, "nurse-edit-record.html", data)
 		return
@@ -467,7 +497,7 @@ func (server *Server) Nurseprofile(w http.ResponseWriter, r *http.Request) {
 	if !user.Authenticated {
 		http.Redirect(w, r, "/nurse/login", http.StatusMovedPermanently)
 	}
-	nusrse, err := server.Services.NurseService.Find(user.Id)
+	nusrse, err := server.Services.NurseService.Find(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -512,7 +542,7 @@ func (server *Server) Nurseprofile(w http.ResponseWriter, r *http.Request) {
 		Hashed_password:     hashed_password,
 		Password_changed_at: time.Now(),
 	}
-	if _, err := server.Services.NurseService.Update(nurse); err != nil {
+	if _, err := server.Services.NurseService.Update(r.Context(), nurse); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		data.Errors = Errmap
@@ -567,7 +597,7 @@ func (server *Server) Filterdoctor(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	doctors, metadata, err := server.Services.DoctorService.Filter(name, dept, models.Filters{
+	doctors, metadata, err := server.Services.DoctorService.Filter(r.Context(), name, dept, models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -2,16 +2,18 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
+	"github.com/patienttracker/internal/auth"
 	"github.com/patienttracker/internal/models"
 	"github.com/patienttracker/internal/services"
-	"github.com/patienttracker/internal/utils"
 )
 
 type PatientResp struct {
@@ -52,7 +54,7 @@ func (server *Server) PatientLogin(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "login.html", msg)
 		return
 	}
-	patient, err := server.Services.PatientService.FindbyEmail(login.Email)
+	patient, err := server.Services.PatientService.FindbyEmail(r.Context(), login.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusBadRequest)
@@ -71,6 +73,10 @@ func (server *Server) PatientLogin(w http.ResponseWriter, r *http.Request) {
 	user := PatientResponse(patient)
 	gobRegister(user)
 	session.Values["user"] = user
+	if err = server.startCookieSession(r, session, auth.AccountPatient, patient.Patientid, patient.Username); err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
 	if err = session.Save(r, w); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -90,12 +96,12 @@ func (server *Server) home(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 		return
 	}
-	appointment, err := server.Services.AppointmentService.FindAllByPatient(user.Id)
+	appointment, err := server.Services.AppointmentService.FindAllByPatient(r.Context(), user.Id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	records, err := server.Services.PatientRecordService.FindAllByPatient(user.Id)
+	records, err := server.Services.PatientRecordService.FindAllByPatient(r.Context(), user.Id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -141,7 +147,7 @@ func (server *Server) profile(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 		return
 	}
-	pat, err := server.Services.PatientService.Find(user.Id)
+	pat, err := server.Services.PatientService.Find(r.Context(), user.Id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -219,7 +225,7 @@ func (server *Server) profile(w http.ResponseWriter, r *http.Request) {
 		About:              r.PostFormValue("About"),
 		Password_change_at: time.Now(),
 	}
-	if _, err := server.Services.PatientService.Update(patient); err != nil {
+	if _, err := server.Services.PatientService.Update(r.Context(), patient); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		data.Errors = Errmap
@@ -238,6 +244,9 @@ func (server *Server) PatientLogout(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
+	if err = server.endCookieSession(r.Context(), session); err != nil {
+		server.Log.Error(err)
+	}
 	session.Values["user"] = PatientResp{}
 	session.Options.MaxAge = -1
 	err = session.Save(r, w)
@@ -262,7 +271,7 @@ func (server *Server) record(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 
-	records, err := server.Services.PatientRecordService.FindAllByPatient(user.Id)
+	records, err := server.Services.PatientRecordService.FindAllByPatient(r.Context(), user.Id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -289,17 +298,28 @@ func (server *Server) appointments(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 		return
 	}
-	appointment, err := server.Services.AppointmentService.FindAllByPatient(user.Id)
+	appointment, err := server.Services.AppointmentService.FindAllByPatient(r.Context(), user.Id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
+	// the page posts only to get a new calendar feed
+	feed, err := server.calendarfeed(r, models.Actor{AccountType: auth.AccountPatient, AccountId: user.Id})
+	if err != nil {
+		server.Log.Error(err)
+	}
+	csrfmap := make(map[string]interface{})
+	csrfmap[csrf.TemplateTag] = csrf.TemplateField(r)
 	data := struct {
-		User   PatientResp
-		Apntmt []models.Appointment
+		User     PatientResp
+		Apntmt   []models.Appointment
+		Calendar string
+		Csrf     map[string]interface{}
 	}{
-		User:   user,
-		Apntmt: appointment,
+		User:     user,
+		Apntmt:   appointment,
+		Calendar: feed,
+		Csrf:     csrfmap,
 	}
 	w.WriteHeader(http.StatusOK)
 	server.Templates.Render(w, "appointments.html", data)
@@ -368,38 +388,14 @@ func (server *Server) createpatient(w http.ResponseWriter, r *http.Request) {
 		Ischild:         child,
 		Created_at:      time.Now(),
 	}
-	if _, err := server.Services.PatientService.Create(patient); err != nil {
+	// the verification email goes out from the outbox once the patient is saved
+	if _, err := server.Services.RegisterPatient(r.Context(), patient); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = "User already Exists"
 		dataform.Errors = msg.Errors
 		server.Templates.Render(w, "register.html", dataform)
 		return
 	}
-	key := utils.RandString(20)
-	value := patient.Email
-	err := server.Redis.Set(server.Context, key, value, 0).Err()
-	if err != nil {
-		server.Log.Error(err)
-	}
-	data := struct {
-		URL   string
-		Name  string
-		Email string
-	}{
-		URL:   `http://localhost:9000/verify/` + key,
-		Name:  patient.Username,
-		Email: patient.Email,
-	}
-	mailer := server.Mailer.setdata(data, "Welcome to Our System!!", "verify.account.html", data.Email)
-	go func() {
-		server.Worker.Task <- &mailer
-	}()
-	for i := 0; i < server.Worker.Nworker; i++ {
-		go func() {
-			defer server.Done()
-			server.Worker.Workqueue()
-		}()
-	}
 	http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 }
 func (server *Server) VerifyAccount(w http.ResponseWriter, r *http.Request) {
@@ -410,13 +406,13 @@ func (server *Server) VerifyAccount(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		server.Templates.Render(w, "404.html", nil)
 	}
-	data, err := server.Services.PatientService.FindbyEmail(value)
+	data, err := server.Services.PatientService.FindbyEmail(r.Context(), value)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		server.Templates.Render(w, "404.html", nil)
 	}
 	data.Verified = true
-	server.Services.PatientService.Update(data)
+	server.Services.PatientService.Update(r.Context(), data)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -436,14 +432,16 @@ func (server *Server) Patienteditappointment(w http.ResponseWriter, r *http.Requ
 		w.WriteHeader(http.StatusUnauthorized)
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 	}
-	appointment, err := server.Services.AppointmentService.FindAllByPatient(user.Id)
+	appointment, err := server.Services.AppointmentService.FindAllByPatient(r.Context(), user.Id)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
 	data := struct {
-		User   PatientResp
-		Apntmt []models.Appointment
+		User     PatientResp
+		Apntmt   []models.Appointment
+		Calendar string
+		Csrf     map[string]interface{}
 	}{
 		User:   user,
 		Apntmt: appointment,
@@ -484,7 +482,7 @@ func (server *Server) Patientfilternurse(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	nurses, metadata, err := server.Services.NurseService.Filter(name, models.Filters{
+	nurses, metadata, err := server.Services.NurseService.Filter(r.Context(), name, models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -525,6 +523,7 @@ func (server *Server) PatienBookAppointment(w http.ResponseWriter, r *http.Reque
 		PatientEmail:    r.PostFormValue("Email"),
 		AppointmentDate: r.PostFormValue("Appointmentdate"),
 		Duration:        r.PostFormValue("Duration"),
+		location:        server.Services.Clinic(),
 	}
 
 	params := mux.Vars(r)
@@ -537,13 +536,18 @@ func (server *Server) PatienBookAppointment(w http.ResponseWriter, r *http.Reque
 		Csrf     map[string]interface{}
 		Success  string
 		Schedule models.Schedule
+		// Slots are the free slots of the coming week,they're booked for Duration
+		Slots    []models.Slot
+		Duration string
+		// Waitlist is the booking that found its slot taken,the patient can wait for the day instead
+		Waitlist *PatientAppointment
 	}{
 		User:   user,
 		Errors: msg.Errors,
 		Csrf:   msg.Csrf,
 	}
 	var schedule models.Schedule
-	schedules, err := server.Services.ScheduleService.FindbyDoctor(doctorid)
+	schedules, err := server.Services.ScheduleService.FindbyDoctor(r.Context(), doctorid)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			data.Errors["doctor"] = "No such Doctor"
@@ -563,6 +567,14 @@ func (server *Server) PatienBookAppointment(w http.ResponseWriter, r *http.Reque
 			schedule = sched
 		}
 	}
+	from := time.Now().UTC().Truncate(time.Minute)
+	slots, err := server.Services.FreeSlots(r.Context(), doctorid, from, from.Add(slotsearch), parseduration(r.URL.Query().Get("duration")))
+	if err != nil {
+		data.Errors["slots"] = err.Error()
+	} else if len(slots) > 0 {
+		data.Slots = slots
+		data.Duration = slots[0].Endtime.Sub(slots[0].Starttime).String()
+	}
 	if r.Method == "GET" {
 		w.WriteHeader(http.StatusOK)
 		data.Schedule = schedule
@@ -579,12 +591,12 @@ func (server *Server) PatienBookAppointment(w http.ResponseWriter, r *http.Reque
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	date, err := time.Parse("2006-01-02T15:04", r.PostFormValue("Appointmentdate"))
+	date, err := parsedatetime(r.PostFormValue("Appointmentdate"), server.Services.Clinic())
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	patient, err := server.Services.PatientService.FindbyEmail(register.PatientEmail)
+	patient, err := server.Services.PatientService.FindbyEmail(r.Context(), register.PatientEmail)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
@@ -592,18 +604,41 @@ func (server *Server) PatienBookAppointment(w http.ResponseWriter, r *http.Reque
 		server.Templates.Render(w, "book-appointment.html", data)
 		return
 	}
+	// the waitlist form posts the booking that found its slot taken back
+	if r.PostFormValue("Waitlist") != "" {
+		_, err := server.Services.JoinWaitlist(r.Context(), models.Waitlist{
+			Patientid: patient.Patientid,
+			Doctorid:  doctorid,
+			Day:       date,
+			Duration:  parseduration(register.Duration),
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			msg.Errors["Exists"] = err.Error()
+			data.Errors = msg.Errors
+			server.Templates.Render(w, "book-appointment.html", data)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		data.Success = "patient added to the waitlist,they'll be emailed when a slot that day frees up"
+		server.Templates.Render(w, "book-appointment.html", data)
+		return
+	}
 	apntmt := models.Appointment{
 		Doctorid:        doctorid,
 		Patientid:       patient.Patientid,
 		Appointmentdate: date,
-		Duration:        register.Duration,
-		Approval:        true,
+		Duration:        parseduration(register.Duration),
+		Status:          models.StatusApproved,
 	}
-	_, err = server.Services.PatientBookAppointment(apntmt)
+	_, err = server.Services.PatientBookAppointment(r.Context(), apntmt)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
 		data.Errors = msg.Errors
+		if errors.Is(err, services.ErrTimeSlotAllocated) {
+			data.Waitlist = &register
+		}
 		server.Templates.Render(w, "book-appointment.html", data)
 		return
 	}
@@ -621,7 +656,7 @@ func (server *Server) PatientViewRecord(w http.ResponseWriter, r *http.Request)
 		server.Templates.Render(w, "404.html", nil)
 		return
 	}
-	data, err := server.Services.PatientRecordService.Find(idparam)
+	data, err := server.Services.PatientRecordService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
@@ -662,10 +697,12 @@ func (server *Server) PatientUpdateAppointment(w http.ResponseWriter, r *http.Re
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	data, err := server.Services.AppointmentService.Find(idparam)
+	data, err := server.Services.AppointmentService.Find(r.Context(), idparam)
 	if err != nil {
 		server.Templates.Render(w, "404.html", nil)
 	}
+	// the form shows & reads the wall clock of the clinic
+	data.Appointmentdate = data.Appointmentdate.In(server.Services.Clinic())
 	session, err := server.Store.Get(r, "user-session")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -686,17 +723,23 @@ func (server *Server) PatientUpdateAppointment(w http.ResponseWriter, r *http.Re
 		Patientid:       r.PostFormValue("Patientid"),
 		AppointmentDate: r.PostFormValue("Appointmentdate"),
 		Duration:        r.PostFormValue("Duration"),
+		location:        server.Services.Clinic(),
 	}
 	msg = NewForm(r, &register)
+	actor := models.Actor{AccountType: auth.AccountPatient, AccountId: user.Id}
 	pdata := struct {
 		User        PatientResp
 		Errors      Errors
 		Csrf        map[string]interface{}
 		Appointment models.Appointment
+		History     []models.Transition
+		Statuses    []models.Status
 		Success     string
 	}{
 		Errors:      Errmap,
 		Appointment: data,
+		History:     server.appointmenthistory(r, data.Appointmentid),
+		Statuses:    nextstatuses(data, actor.AccountType),
 		User:        user,
 		Csrf:        msg.Csrf,
 	}
@@ -705,29 +748,47 @@ func (server *Server) PatientUpdateAppointment(w http.ResponseWriter, r *http.Re
 		server.Templates.Render(w, "update-appointment.html", pdata)
 		return
 	}
+	// the status form only posts the status to move to & why,a patient can only cancel
+	if to := r.PostFormValue("Status"); to != "" {
+		appointment, err := server.Services.TransitionAppointment(r.Context(), data.Appointmentid, models.Status(to), actor, r.PostFormValue("Reason"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			Errmap["Exists"] = err.Error()
+			pdata.Errors = Errmap
+			server.Templates.Render(w, "update-appointment.html", pdata)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		appointment.Appointmentdate = appointment.Appointmentdate.In(server.Services.Clinic())
+		pdata.Appointment = appointment
+		pdata.History = server.appointmenthistory(r, appointment.Appointmentid)
+		pdata.Statuses = nextstatuses(appointment, actor.AccountType)
+		pdata.Success = "appointment " + string(appointment.Status)
+		server.Templates.Render(w, "update-appointment.html", pdata)
+		return
+	}
 	if ok := msg.Validate(); !ok {
 		pdata.Errors = msg.Errors
 		w.WriteHeader(http.StatusBadRequest)
 		server.Templates.Render(w, "update-appointment.html", pdata)
 		return
 	}
-	doctorid, _ := strconv.Atoi(r.PostFormValue("Doctorid"))
-	patientid, _ := strconv.Atoi(r.PostFormValue("Patientid"))
-	date, err := time.Parse("2006-01-02T15:04", r.PostFormValue("Appointmentdate"))
+	date, err := parsedatetime(r.PostFormValue("Appointmentdate"), server.Services.Clinic())
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
+	// the patient only moves the appointment,it stays with the doctor & the patient it was booked for
 	apntmt := models.Appointment{
 		Appointmentid:   data.Appointmentid,
-		Doctorid:        doctorid,
-		Patientid:       patientid,
+		Doctorid:        data.Doctorid,
+		Patientid:       data.Patientid,
 		Appointmentdate: date,
-		Duration:        register.Duration,
-		Approval:        false,
+		Duration:        parseduration(register.Duration),
 	}
 
-	if _, err := server.Services.UpdateappointmentbyPatient(apntmt); err != nil {
+	appointment, err := server.Services.UpdateappointmentbyPatient(r.Context(), apntmt, actor)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -735,7 +796,10 @@ func (server *Server) PatientUpdateAppointment(w http.ResponseWriter, r *http.Re
 		return
 	}
 	w.WriteHeader(http.StatusOK)
-	pdata.Appointment = apntmt
+	appointment.Appointmentdate = appointment.Appointmentdate.In(server.Services.Clinic())
+	pdata.Appointment = appointment
+	pdata.History = server.appointmenthistory(r, appointment.Appointmentid)
+	pdata.Statuses = nextstatuses(appointment, actor.AccountType)
 	pdata.Success = "appointment updated successfully"
 	server.Templates.Render(w, "update-appointment.html", pdata)
 }
@@ -754,7 +818,7 @@ func (server *Server) patient_reset_password(w http.ResponseWriter, r *http.Requ
 		server.Templates.Render(w, "404.html", nil)
 		return
 	}
-	pat, err := server.Services.PatientService.FindbyEmail(value)
+	pat, err := server.Services.PatientService.FindbyEmail(r.Context(), value)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		server.Templates.Render(w, "404.html", nil)
@@ -795,7 +859,7 @@ func (server *Server) patient_reset_password(w http.ResponseWriter, r *http.Requ
 		w.WriteHeader(http.StatusInternalServerError)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	if _, err := server.Services.PatientService.Update(pat); err != nil {
+	if _, err := server.Services.PatientService.Update(r.Context(), pat); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		data.Errors = Errmap
@@ -822,17 +886,11 @@ func (server *Server) PatientTriage(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	var keyticket = "ticket" + utils.RandString(20)
-	doctors, _, _ := server.Services.DoctorService.FindAll(models.Filters{Page: 1, PageSize: 20})
-	patient, _ := server.Services.PatientService.Find(user.Id)
-	if err = server.Redis.Set(server.Context, keyticket, Ticket{
-		Ticketid:     keyticket,
-		Patientemail: patient.Email,
-		Doctorid:     doctors[0].Physicianid,
-		Nurseid:      idparam,
-		Attendedto:   false,
-	}, 0).Err(); err != nil {
-		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+	// the nurse refers the patient to a doctor when attending to the ticket
+	if _, err = server.Services.OpenTicket(r.Context(), models.Ticket{Patientid: user.Id, Nurseid: idparam}); err != nil {
+		server.Log.Error(err)
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
 	}
 	http.Redirect(w, r, "/triages", http.StatusMovedPermanently)
 }
@@ -846,11 +904,13 @@ func (server *Server) PatientListTriage(w http.ResponseWriter, r *http.Request)
 	if !user.Authenticated {
 		http.Redirect(w, r, "/nurse/login", http.StatusMovedPermanently)
 	}
-	patient, _ := server.Services.PatientService.Find(user.Id)
-	var tickets = server.getpatienttickets(patient.Email)
+	tickets, err := server.Services.TicketService.FindbyPatient(r.Context(), user.Id)
+	if err != nil {
+		server.Log.Error(err)
+	}
 	data := struct {
 		User    PatientResp
-		Tickets []Ticket
+		Tickets []models.Ticket
 	}{
 		User:    user,
 		Tickets: tickets,
@@ -14,9 +14,9 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
+	"github.com/patienttracker/internal/auth"
 	"github.com/patienttracker/internal/models"
 	"github.com/patienttracker/internal/services"
-	"github.com/patienttracker/internal/utils"
 )
 
 type DoctorResp struct {
@@ -63,7 +63,7 @@ func (server *Server) StaffLogin(w http.ResponseWriter, r *http.Request) {
 		server.Templates.Render(w, "staff-login.html", msg)
 		return
 	}
-	user, err := server.Services.DoctorService.FindbyEmail(login.Email)
+	user, err := server.Services.DoctorService.FindbyEmail(r.Context(), login.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusBadRequest)
@@ -82,6 +82,10 @@ func (server *Server) StaffLogin(w http.ResponseWriter, r *http.Request) {
 	staff := DoctorResponse(user)
 	gobRegister(staff)
 	session.Values["staff"] = staff
+	if err = server.startCookieSession(r, session, auth.AccountPhysician, user.Physicianid, user.Username); err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
 	if err = session.Save(r, w); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -132,7 +136,7 @@ func (server *Server) Staffcreateschedule(w http.ResponseWriter, r *http.Request
 		Endtime:   register.Endtime,
 		Active:    actvie,
 	}
-	if _, err := server.Services.MakeSchedule(schedule); err != nil {
+	if _, err := server.Services.MakeSchedule(r.Context(), schedule); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		msg.Errors["Exists"] = err.Error()
 		data.Errors = msg.Errors
@@ -148,6 +152,9 @@ func (server *Server) StaffLogout(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
+	if err = server.endCookieSession(r.Context(), session); err != nil {
+		server.Log.Error(err)
+	}
 	session.Values["staff"] = DoctorResp{}
 	session.Options.MaxAge = -1
 	err = session.Save(r, w)
@@ -165,7 +172,7 @@ func (server *Server) Staffviewrecord(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	data, err := server.Services.PatientRecordService.Find(idparam)
+	data, err := server.Services.PatientRecordService.Find(r.Context(), idparam)
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
@@ -202,7 +209,7 @@ func (server *Server) Staffupdateschedule(w http.ResponseWriter, r *http.Request
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	data, err := server.Services.ScheduleService.Find(idparam)
+	data, err := server.Services.ScheduleService.Find(r.Context(), idparam)
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
@@ -252,8 +259,12 @@ func (server *Server) Staffupdateschedule(w http.ResponseWriter, r *http.Request
 		Starttime:  register.Starttime,
 		Endtime:    register.Endtime,
 		Active:     active,
+		// the form only edits the daily hours
+		Validfrom:  data.Validfrom,
+		Validuntil: data.Validuntil,
+		Blocks:     data.Blocks,
 	}
-	if _, err := server.Services.UpdateSchedule(schedule); err != nil {
+	if _, err := server.Services.UpdateSchedule(r.Context(), schedule); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		pdata.Errors = Errmap
@@ -275,7 +286,7 @@ func (server *Server) Staffschedule(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
 	}
 
-	schedules, err := server.Services.ScheduleService.FindbyDoctor(user.Id)
+	schedules, err := server.Services.ScheduleService.FindbyDoctor(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -305,7 +316,7 @@ func (server *Server) Staffdeleteschedule(w http.ResponseWriter, r *http.Request
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	if err := server.Services.ScheduleService.Delete(idparam); err != nil {
+	if err := server.Services.ScheduleService.Delete(r.Context(), idparam); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		server.Templates.Render(w, "staff-schedule.html", nil)
 		return
@@ -322,7 +333,7 @@ func (server *Server) Staffprofile(w http.ResponseWriter, r *http.Request) {
 	if !user.Authenticated {
 		http.Redirect(w, r, "/staff/login", http.StatusMovedPermanently)
 	}
-	doc, err := server.Services.DoctorService.Find(user.Id)
+	doc, err := server.Services.DoctorService.Find(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -392,7 +403,7 @@ func (server *Server) Staffprofile(w http.ResponseWriter, r *http.Request) {
 		Departmentname:      register.Departmentname,
 		Password_changed_at: time.Now(),
 	}
-	if _, err := server.Services.DoctorService.Update(doctor); err != nil {
+	if _, err := server.Services.DoctorService.Update(r.Context(), doctor); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		data.Errors = Errmap
@@ -413,22 +424,26 @@ func (server *Server) Staffhome(w http.ResponseWriter, r *http.Request) {
 	if !user.Authenticated {
 		http.Redirect(w, r, "/staff/login", http.StatusMovedPermanently)
 	}
-	appointment, err := server.Services.AppointmentService.FindAllByDoctor(user.Id)
+	// the page subscribes after the events it already shows
+	lastevent := server.Services.Events.LastID()
+	appointment, err := server.Services.AppointmentService.FindAllByDoctor(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	records, err := server.Services.PatientRecordService.FindAllByDoctor(user.Id)
+	records, err := server.Services.PatientRecordService.FindAllByDoctor(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
 	data := struct {
-		User    DoctorResp
-		Apntmt  []models.Appointment
-		Records []models.Patientrecords
+		User      DoctorResp
+		Apntmt    []models.Appointment
+		Records   []models.Patientrecords
+		LastEvent uint64
 	}{
-		User:    user,
-		Apntmt:  appointment,
-		Records: records,
+		User:      user,
+		Apntmt:    appointment,
+		Records:   records,
+		LastEvent: lastevent,
 	}
 	w.WriteHeader(http.StatusOK)
 	server.Templates.Render(w, "staff-home.html", data)
@@ -445,19 +460,75 @@ func (server *Server) Staffappointments(w http.ResponseWriter, r *http.Request)
 		http.Redirect(w, r, "/staff/login", http.StatusSeeOther)
 		return
 	}
-	appointment, err := server.Services.AppointmentService.FindAllByDoctor(user.Id)
+	appointment, err := server.Services.AppointmentService.FindAllByDoctor(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
+	register := AppointmentSeries{
+		Patientid:       r.PostFormValue("Patientid"),
+		AppointmentDate: r.PostFormValue("Appointmentdate"),
+		Duration:        r.PostFormValue("Duration"),
+		Interval:        r.PostFormValue("Interval"),
+		Unit:            r.PostFormValue("Unit"),
+		Count:           r.PostFormValue("Count"),
+		Until:           r.PostFormValue("Until"),
+		location:        server.Services.Clinic(),
+	}
+	msg := NewForm(r, &register)
+	feed, err := server.calendarfeed(r, models.Actor{AccountType: auth.AccountPhysician, AccountId: user.Id})
+	if err != nil {
+		server.Log.Error(err)
+	}
 	data := struct {
-		User   DoctorResp
-		Apntmt []models.Appointment
+		User     DoctorResp
+		Apntmt   []models.Appointment
+		Units    []models.Unit
+		Calendar string
+		Errors   Errors
+		Csrf     map[string]interface{}
+		Success  string
 	}{
-		User:   user,
-		Apntmt: appointment,
+		User:     user,
+		Apntmt:   appointment,
+		Units:    []models.Unit{models.UnitWeek, models.UnitDay},
+		Calendar: feed,
+		Csrf:     msg.Csrf,
+	}
+	// the calendar form only asks for a new feed
+	if r.Method == http.MethodGet || r.PostFormValue("Calendar") != "" {
+		w.WriteHeader(http.StatusOK)
+		server.Templates.Render(w, "staff-appointments.html", data)
+		return
+	}
+	// the series form books follow ups recurring from the first appointment
+	if ok := msg.Validate(); !ok {
+		data.Errors = msg.Errors
+		w.WriteHeader(http.StatusBadRequest)
+		server.Templates.Render(w, "staff-appointments.html", data)
+		return
+	}
+	patientid, _ := strconv.Atoi(register.Patientid)
+	date, _ := parsedatetime(register.AppointmentDate, server.Services.Clinic())
+	recurrence, _ := register.recurrence()
+	series, booked, err := server.Services.BookSeries(r.Context(), models.Series{
+		Doctorid:   user.Id,
+		Patientid:  patientid,
+		Start:      date,
+		Duration:   parseduration(register.Duration),
+		Recurrence: recurrence,
+	})
+	if err != nil {
+		data.Errors = server.serieserrors(err)
+		w.WriteHeader(http.StatusBadRequest)
+		server.Templates.Render(w, "staff-appointments.html", data)
+		return
+	}
+	data.Apntmt, err = server.Services.AppointmentService.FindAllByDoctor(r.Context(), user.Id)
+	if err != nil {
+		server.Log.Error(err)
 	}
-
 	w.WriteHeader(http.StatusOK)
+	data.Success = fmt.Sprintf("%d appointments booked in series %d", len(booked), series.Seriesid)
 	server.Templates.Render(w, "staff-appointments.html", data)
 }
 func (server *Server) Staffrecord(w http.ResponseWriter, r *http.Request) {
@@ -470,7 +541,7 @@ func (server *Server) Staffrecord(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/staff/login", http.StatusMovedPermanently)
 	}
 
-	records, err := server.Services.PatientRecordService.FindAllByDoctor(user.Id)
+	records, err := server.Services.PatientRecordService.FindAllByDoctor(r.Context(), user.Id)
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
@@ -494,10 +565,12 @@ func (server *Server) StaffUpdateAppointment(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	data, err := server.Services.AppointmentService.Find(idparam)
+	data, err := server.Services.AppointmentService.Find(r.Context(), idparam)
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
+	// the form shows & reads the wall clock of the clinic
+	data.Appointmentdate = data.Appointmentdate.In(server.Services.Clinic())
 	session, err := server.Store.Get(r, "staff")
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
@@ -516,26 +589,100 @@ func (server *Server) StaffUpdateAppointment(w http.ResponseWriter, r *http.Requ
 		Patientid:       r.PostFormValue("Patientid"),
 		AppointmentDate: r.PostFormValue("Appointmentdate"),
 		Duration:        r.PostFormValue("Duration"),
+		location:        server.Services.Clinic(),
 	}
 	msg = NewForm(r, &register)
+	actor := models.Actor{AccountType: auth.AccountPhysician, AccountId: user.Id}
 	pdata := struct {
 		User        DoctorResp
 		Errors      Errors
 		Appointment models.Appointment
+		History     []models.Transition
+		Statuses    []models.Status
+		Series      *models.Series
+		Occurrences []models.Appointment
 		Csrf        map[string]interface{}
 		Success     string
 	}{
 		Errors:      Errmap,
 		Appointment: data,
+		History:     server.appointmenthistory(r, data.Appointmentid),
+		Statuses:    nextstatuses(data, actor.AccountType),
 		User:        user,
 		Csrf:        msg.Csrf,
 	}
-	var approval bool
+	pdata.Series, pdata.Occurrences = server.appointmentseries(r, data.Seriesid)
 	if r.Method == http.MethodGet {
 		w.WriteHeader(http.StatusOK)
 		server.Templates.Render(w, "staff-update-appointment.html", pdata)
 		return
 	}
+	// the series forms move or cancel the upcoming occurrences all at once
+	switch scope := r.PostFormValue("Series"); {
+	case scope != "" && pdata.Series == nil:
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
+	case scope == "cancel":
+		cancelled, err := server.Services.CancelSeries(r.Context(), pdata.Series.Seriesid, actor, r.PostFormValue("Reason"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			Errmap["Exists"] = err.Error()
+			pdata.Errors = Errmap
+			server.Templates.Render(w, "staff-update-appointment.html", pdata)
+			return
+		}
+		pdata.Success = fmt.Sprintf("%d appointments of the series cancelled", len(cancelled))
+	case scope == "update":
+		start, err := parsedatetime(r.PostFormValue("Seriesdate"), server.Services.Clinic())
+		if err != nil || !checkinputregexformat(r.PostFormValue("Seriesduration"), durationregex) {
+			w.WriteHeader(http.StatusBadRequest)
+			Errmap["Series"] = "Check the start and the duration of the series"
+			pdata.Errors = Errmap
+			server.Templates.Render(w, "staff-update-appointment.html", pdata)
+			return
+		}
+		series := *pdata.Series
+		series.Start, series.Duration = start, parseduration(r.PostFormValue("Seriesduration"))
+		_, moved, err := server.Services.UpdateSeries(r.Context(), series, actor)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			pdata.Errors = server.serieserrors(err)
+			server.Templates.Render(w, "staff-update-appointment.html", pdata)
+			return
+		}
+		pdata.Success = fmt.Sprintf("%d appointments of the series moved", len(moved))
+	}
+	if pdata.Success != "" {
+		if appointment, err := server.Services.AppointmentService.Find(r.Context(), data.Appointmentid); err == nil {
+			appointment.Appointmentdate = appointment.Appointmentdate.In(server.Services.Clinic())
+			pdata.Appointment = appointment
+			pdata.History = server.appointmenthistory(r, appointment.Appointmentid)
+			pdata.Statuses = nextstatuses(appointment, actor.AccountType)
+		}
+		pdata.Series, pdata.Occurrences = server.appointmentseries(r, data.Seriesid)
+		w.WriteHeader(http.StatusOK)
+		server.Templates.Render(w, "staff-update-appointment.html", pdata)
+		return
+	}
+	// the status form only posts the status to move to & why
+	if to := r.PostFormValue("Status"); to != "" {
+		appointment, err := server.Services.TransitionAppointment(r.Context(), data.Appointmentid, models.Status(to), actor, r.PostFormValue("Reason"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			Errmap["Exists"] = err.Error()
+			pdata.Errors = Errmap
+			server.Templates.Render(w, "staff-update-appointment.html", pdata)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		appointment.Appointmentdate = appointment.Appointmentdate.In(server.Services.Clinic())
+		pdata.Appointment = appointment
+		pdata.History = server.appointmenthistory(r, appointment.Appointmentid)
+		pdata.Statuses = nextstatuses(appointment, actor.AccountType)
+		pdata.Success = "appointment " + string(appointment.Status)
+		server.Templates.Render(w, "staff-update-appointment.html", pdata)
+		return
+	}
 	if ok := msg.Validate(); !ok {
 		pdata.Errors = msg.Errors
 		w.WriteHeader(http.StatusBadRequest)
@@ -544,23 +691,21 @@ func (server *Server) StaffUpdateAppointment(w http.ResponseWriter, r *http.Requ
 	}
 	doctorid, _ := strconv.Atoi(r.PostFormValue("Doctorid"))
 	patientid, _ := strconv.Atoi(r.PostFormValue("Patientid"))
-	date, err := time.Parse("2006-01-02T15:04", r.PostFormValue("Appointmentdate"))
+	date, err := parsedatetime(r.PostFormValue("Appointmentdate"), server.Services.Clinic())
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
 	var outbound bool
-	approval = checkboxvalue(r.PostFormValue("Approval"))
 	outbound = checkboxvalue(r.PostFormValue("Outbound"))
 	apntmt := models.Appointment{
 		Appointmentid:   data.Appointmentid,
 		Doctorid:        doctorid,
 		Patientid:       patientid,
 		Appointmentdate: date,
-		Duration:        register.Duration,
+		Duration:        parseduration(register.Duration),
 		Outbound:        outbound,
-		Approval:        approval,
 	}
-	appointment, err := server.Services.UpdateappointmentbyDoctor(apntmt)
+	appointment, err := server.Services.UpdateappointmentbyDoctor(r.Context(), apntmt, actor)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
@@ -568,87 +713,24 @@ func (server *Server) StaffUpdateAppointment(w http.ResponseWriter, r *http.Requ
 		server.Templates.Render(w, "staff-update-appointment.html", pdata)
 		return
 	}
-	if appointment.Approval {
-		if err := server.Redis.Set(server.Context, strconv.Itoa(appointment.Appointmentid), appointment.Appointmentdate, 0).Err(); err != nil {
-			server.Log.Error(err)
-		}
-	}
 	w.WriteHeader(http.StatusOK)
-	pdata.Appointment = apntmt
+	appointment.Appointmentdate = appointment.Appointmentdate.In(server.Services.Clinic())
+	pdata.Appointment = appointment
+	pdata.History = server.appointmenthistory(r, appointment.Appointmentid)
+	pdata.Statuses = nextstatuses(appointment, actor.AccountType)
 	pdata.Success = "appointment updated successfully"
 	server.Templates.Render(w, "staff-update-appointment.html", pdata)
 }
 
-// AppointmentsSubscriber will be used to send upcoming appointments to our users via email
+// reminderbatch is how many due reminders are claimed at a time
+const reminderbatch = 100
+
+// AppointmentsEmailSender claims the reminders that are due,each is handed to the mailer through the outbox
+// in the transaction claiming it so it's emailed to the patient & the doctor even when sending fails at first.
 func (server *Server) AppointmentsEmailSender() {
-	var ids []int
-	var upcoming_appointment []models.Appointment
-	iter := server.Redis.Scan(server.Context, 0, "*", 0).Iterator()
-	for iter.Next(server.Context) {
-		if int, err := strconv.Atoi(iter.Val()); err == nil {
-			ids = append(ids, int)
-		}
-	}
-	for _, v := range ids {
-		appointment, err := server.Services.AppointmentService.Find(v)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				// delete appointments aren't in our database from redis
-				server.Redis.Del(server.Context, strconv.Itoa(v))
-			}
-		}
-		if int(time.Until(appointment.Appointmentdate).Hours()) <= 24 {
-			upcoming_appointment = append(upcoming_appointment, appointment)
-		}
-	}
-	var data []SendEmails
-	for _, appointment := range upcoming_appointment {
-		doctor, err := server.Services.DoctorService.Find(appointment.Doctorid)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				server.Redis.Del(server.Context, strconv.Itoa(appointment.Appointmentid))
-			}
-		}
-		patient, err := server.Services.PatientService.Find(appointment.Patientid)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				server.Redis.Del(server.Context, strconv.Itoa(appointment.Appointmentid))
-			}
-		}
-		type emaildata struct {
-			Email          string
-			LinkedUsername string
-			Date           time.Time
-			Username       string
-		}
-		subject := "Upcoming Appointments!!"
-		patientemaildata := server.Mailer.setdata(emaildata{
-			Email:          patient.Email,
-			Date:           appointment.Appointmentdate,
-			LinkedUsername: doctor.Username,
-			Username:       patient.Username,
-		}, subject, "reminder.template.html", patient.Email)
-		doctoremaildata := server.Mailer.setdata(emaildata{
-			Email:          doctor.Email,
-			LinkedUsername: patient.Username,
-			Date:           appointment.Appointmentdate,
-			Username:       doctor.Username,
-		}, subject, "reminder.template.html", doctor.Email)
-		data = append(data, patientemaildata, doctoremaildata)
-		server.Redis.Del(server.Context, strconv.Itoa(appointment.Appointmentid))
-	}
-	go func() {
-		for _, notifications := range data {
-			notify := notifications
-			server.Worker.Task <- &notify
-		}
-	}()
-	server.WaitGroup.Add(server.Worker.Nworker)
-	for i := 0; i < server.Worker.Nworker; i++ {
-		go func() {
-			defer server.Done()
-			server.Worker.Workqueue()
-		}()
+	if _, err := server.Services.ClaimReminders(server.Context, time.Now(), reminderbatch); err != nil {
+		// the reminders claimed before the error are still sent
+		server.Log.Error(err)
 	}
 }
 
@@ -681,7 +763,7 @@ func (server *Server) doctor_reset_password(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	doctor, err := server.Services.DoctorService.FindbyEmail(value)
+	doctor, err := server.Services.DoctorService.FindbyEmail(r.Context(), value)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Redirect(w, r, "/404", http.StatusMovedPermanently)
@@ -722,7 +804,7 @@ func (server *Server) doctor_reset_password(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
 	}
-	if _, err := server.Services.DoctorService.Update(doctor); err != nil {
+	if _, err := server.Services.DoctorService.Update(r.Context(), doctor); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		Errmap["Exists"] = err.Error()
 		data.Errors = Errmap
@@ -764,7 +846,7 @@ func (server *Server) Stafffilternurse(w http.ResponseWriter, r *http.Request) {
 	if err != nil || idparam <= 0 {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	nurse, metadata, err := server.Services.NurseService.Filter(name, models.Filters{
+	nurse, metadata, err := server.Services.NurseService.Filter(r.Context(), name, models.Filters{
 		PageSize: PageCount,
 		Page:     idparam,
 	})
@@ -808,11 +890,18 @@ func (server *Server) Staffticketrecord(w http.ResponseWriter, r *http.Request)
 		Email: r.FormValue("Email"),
 	}
 	msg := NewForm(r, &form)
+	// the tickets referred to the doctor,the ones the doctor opened among them
+	tickets, err := server.Services.TicketService.FindbyDoctor(r.Context(), staff.Id)
+	if err != nil {
+		server.Log.Error(err)
+	}
 	ticketdata := struct {
 		PatientEmail string
 		User         DoctorResp
 		Csrf         map[string]interface{}
 		Nurseid      int
+		Acuities     []models.Acuity
+		Tickets      []models.Ticket
 		Success      string
 		Errors       Errors
 	}{
@@ -820,6 +909,8 @@ func (server *Server) Staffticketrecord(w http.ResponseWriter, r *http.Request)
 		User:         staff,
 		Csrf:         msg.Csrf,
 		Nurseid:      idparam,
+		Acuities:     acuities(),
+		Tickets:      tickets,
 		Errors:       msg.Errors,
 	}
 	if r.Method == "GET" {
@@ -833,17 +924,28 @@ func (server *Server) Staffticketrecord(w http.ResponseWriter, r *http.Request)
 		server.Templates.Render(w, "ticket.html", ticketdata)
 		return
 	}
-	var keyticket = "ticket" + utils.RandString(20)
-	if err = server.Redis.Set(server.Context, keyticket, Ticket{
-		Ticketid:     keyticket,
-		Patientemail: ticketdata.PatientEmail,
-		Doctorid:     staff.Id,
-		Nurseid:      idparam,
-	}, 0).Err(); err != nil {
-		fmt.Println(err)
-		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+	patient, err := server.Services.PatientService.FindbyEmail(r.Context(), ticketdata.PatientEmail)
+	if err != nil {
+		ticketdata.Errors["Patient"] = "no such patient"
+		w.WriteHeader(http.StatusBadRequest)
+		server.Templates.Render(w, "ticket.html", ticketdata)
+		return
+	}
+	acuity, _ := strconv.Atoi(r.PostFormValue("Acuity"))
+	ticket, err := server.Services.OpenTicket(r.Context(), models.Ticket{
+		Patientid: patient.Patientid,
+		Doctorid:  staff.Id,
+		Nurseid:   idparam,
+		Acuity:    models.Acuity(acuity),
+	})
+	if err != nil {
+		ticketdata.Errors["Ticket"] = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		server.Templates.Render(w, "ticket.html", ticketdata)
+		return
 	}
 	w.WriteHeader(http.StatusCreated)
+	ticketdata.Tickets = append(ticketdata.Tickets, ticket)
 	ticketdata.Success = "ticket sent to nurse"
 	server.Templates.Render(w, "ticket.html", ticketdata)
 }
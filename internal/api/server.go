@@ -8,12 +8,14 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"strconv"
-	"sync"
+	"time"
 
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/config"
 	"github.com/patienttracker/internal/services"
 	"github.com/patienttracker/internal/worker"
 	"github.com/patienttracker/pkg/logger"
@@ -24,36 +26,60 @@ import (
 const version = "1.0.0"
 
 type Server struct {
+	Config    config.Config
 	Router    *mux.Router
 	Services  *services.Service
 	Log       *logger.Logger
 	Templates tmp.Template
-	Store     *sessions.CookieStore
+	Store     sessions.Store
 	Mailer    *SendEmails
 	Redis     *redis.Client
-	Worker    worker.Worker
+	Worker    *worker.Pool
 	Context   context.Context
-	sync.WaitGroup
+	Auth      auth.Token
 }
 
-func NewServer(services services.Service, router *mux.Router) *Server {
+func NewServer(c config.Config, services services.Service, router *mux.Router) *Server {
 	logger := logger.New()
 	temp := tmp.New()
-	authKey := securecookie.GenerateRandomKey(64)
-	encryptionKey := securecookie.GenerateRandomKey(32)
-	store := sessions.NewCookieStore(
-		authKey,
-		encryptionKey,
-	)
 	redis := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379", // TODO: keep this value in env file
-		Password: "",               // no password set
-		DB:       0,                // use default DB
+		Addr:     c.Redis.Addr,
+		Password: c.Redis.Password,
+		DB:       c.Redis.DB,
 	})
-	mailworker := NewSenderMail()
-	workerchan := make(chan chan worker.Task, 100)
-	woker := worker.Newworker(10, workerchan)
+	if len(c.Session.AuthKey) == 0 {
+		logger.Info("SESSION_AUTH_KEY is not set, sessions won't survive a restart")
+	}
+	store := NewSessionStore(c.Session, redis)
+	// the session values are gob encoded,their types have to be known before
+	// the first request decodes a session saved by a previous run
+	gobRegister(PatientResp{})
+	gobRegister(DoctorResp{})
+	gobRegister(NurseResp{})
+	gobRegister(UserResp{})
+	// tokens signed with a random key don't survive restarts,set TOKEN_SYMMETRIC_KEY to keep them valid
+	symmetrickey := c.Token.SymmetricKey
+	if symmetrickey == "" {
+		symmetrickey = string(securecookie.GenerateRandomKey(32))
+	}
+	token, err := auth.PasetoMaker(symmetrickey)
+	if err != nil {
+		logger.Fatal(err, "TOKEN_SYMMETRIC_KEY")
+	}
+	if c.Csrf.Key == "" {
+		c.Csrf.Key = string(securecookie.GenerateRandomKey(32))
+	}
+	mailworker := NewSenderMail(c.Smtp)
+	pool := worker.NewPool(worker.Options{
+		Workers:     c.Worker.Workers,
+		QueueSize:   c.Worker.QueueSize,
+		MaxAttempts: c.Worker.MaxAttempts,
+		Backoff:     time.Second,
+		MaxBackoff:  5 * time.Minute,
+	})
+	pool.Start()
 	server := Server{
+		Config:    c,
 		Router:    router,
 		Log:       logger,
 		Services:  &services,
@@ -61,9 +87,11 @@ func NewServer(services services.Service, router *mux.Router) *Server {
 		Store:     store,
 		Redis:     redis,
 		Mailer:    &mailworker,
-		Worker:    woker,
+		Worker:    pool,
 		Context:   context.Background(),
+		Auth:      token,
 	}
+	server.subscribe()
 	server.Routes()
 	return &server
 }
@@ -76,12 +104,15 @@ func (server *Server) Routes() {
 	server.Router.PathPrefix("/upload/").Handler(http.StripPrefix("/upload/", upload))
 	server.Router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", fs))
 	server.Router.Use(server.LoggingMiddleware)
-	server.Router.Use(csrf.Protect([]byte("MgONCCTehPKsRZyfBsBdjdL83X7ABRkt"), csrf.SameSite(csrf.SameSiteStrictMode))) // TODO: keep this value in env file
+	server.Router.Use(skipcsrf)
+	server.Router.Use(csrf.Protect([]byte(server.Config.Csrf.Key), csrf.Secure(server.Config.Csrf.Secure), csrf.SameSite(csrf.SameSiteStrictMode)))
 	server.Router.Use(server.Ipratelimiter)
 	server.Router.HandleFunc("/", server.Homepage)
 	server.Router.HandleFunc("/generate/{id:[0-9]+}", server.generatepdfs)
 	server.Router.HandleFunc("/v1/healthcheck", server.Healthcheck)
 	server.Router.HandleFunc("/verify/{id}", server.VerifyAccount)
+	server.Router.HandleFunc("/waitlist/offer/{token}", server.WaitlistOffer).Methods(http.MethodGet, http.MethodPost)
+	server.Router.HandleFunc("/calendar/{token:[0-9a-f]+}.ics", server.CalendarFeed).Methods(http.MethodGet)
 	server.Router.HandleFunc("/register", server.createpatient)
 	server.Router.HandleFunc("/login", server.PatientLogin)
 	server.Router.HandleFunc("/admin/login", server.AdminLogin)
@@ -99,15 +130,18 @@ func (server *Server) Routes() {
 	server.Router.HandleFunc("/500", server.InternalServeError)
 	server.Router.HandleFunc("/404", server.NotFound)
 	server.Router.HandleFunc("/429", server.Toomanyrequest)
+	server.v1routes()
 
 	staff := server.Router.PathPrefix("/staff").Subrouter()
 	staff.Use(server.sessionstaffmiddleware)
 	staff.HandleFunc("/home", server.Staffhome)
+	staff.HandleFunc("/events", server.StaffEvents).Methods(http.MethodGet)
 	staff.HandleFunc("/logout", server.StaffLogout)
 	staff.HandleFunc("/records", server.Staffrecord)
 	staff.HandleFunc("/appointments", server.Staffappointments)
 	staff.HandleFunc("/schedules", server.Staffschedule)
 	staff.HandleFunc("/update/appointment/{id:[0-9]+}", server.StaffUpdateAppointment)
+	staff.HandleFunc("/appointment/{id:[0-9]+}/ics", server.Staffappointmentics)
 	staff.HandleFunc("/view/record/{id:[0-9]+}", server.Staffviewrecord)
 	staff.HandleFunc("/register/schedule", server.Staffcreateschedule)
 	staff.HandleFunc("/update/schedule/{id:[0-9]+}", server.Staffupdateschedule)
@@ -155,6 +189,11 @@ func (server *Server) Routes() {
 	admin.HandleFunc("/update/schedule/{id:[0-9]+}", server.CheckPermissions(server.Adminupdateschedule, services.Or{Permissions: []string{"admin", "editor", "schedule:admin", "schedule:editor"}}))
 	admin.HandleFunc("/update/department/{id:[0-9]+}", server.CheckPermissions(server.Adminupdatedepartment, services.Or{Permissions: []string{"admin", "editor", "department:admin", "department:editor"}}))
 	admin.HandleFunc("/update/nurse/{id:[0-9]+}", server.CheckPermissions(server.Adminupdatenurse, services.Or{Permissions: []string{"admin", "editor", "nurse:admin", "nurse:editor"}}))
+	admin.HandleFunc("/sessions/{pageid:[0-9]+}", server.CheckPermissions(server.Adminsessions, services.Or{Permissions: []string{"admin"}}))
+	admin.HandleFunc("/sessions/revoke/{id}", server.CheckPermissions(server.Adminrevokesession, services.Or{Permissions: []string{"admin"}})).Methods(http.MethodPost)
+	admin.HandleFunc("/sessions/revokeall", server.CheckPermissions(server.Adminrevokeaccountsessions, services.Or{Permissions: []string{"admin"}})).Methods(http.MethodPost)
+	admin.HandleFunc("/jobs", server.CheckPermissions(server.Adminjobs, services.Or{Permissions: []string{"admin"}}))
+	admin.HandleFunc("/jobs/requeue/{id:[0-9]+}", server.CheckPermissions(server.Adminrequeuejob, services.Or{Permissions: []string{"admin"}})).Methods(http.MethodPost)
 	admin.HandleFunc("/reports", server.Reports)
 
 	nurse := server.Router.PathPrefix("/nurse").Subrouter()
@@ -162,8 +201,10 @@ func (server *Server) Routes() {
 	nurse.HandleFunc("/logout", server.NurseLogout)
 	nurse.HandleFunc("/records", server.Nurserecord)
 	nurse.HandleFunc("/home", server.Nursetickets)
+	nurse.HandleFunc("/events", server.NurseEvents).Methods(http.MethodGet)
 	nurse.HandleFunc("/view/record/{id:[0-9]+}", server.NurseViewRecord)
-	nurse.HandleFunc("/create/record/{ticket}", server.NurseCreateRecord)
+	nurse.HandleFunc("/create/record/{ticket:[0-9]+}", server.NurseCreateRecord)
+	nurse.HandleFunc("/ticket/{id:[0-9]+}/status", server.NurseTicketStatus).Methods(http.MethodPost)
 	nurse.HandleFunc("/profile", server.Nurseprofile)
 	nurse.HandleFunc("/appointment/doctor/{id:[0-9]+}", server.PatienBookAppointment)
 	nurse.HandleFunc("/doctors", server.Filterdoctor)
@@ -174,6 +215,8 @@ func (server *Server) Routes() {
 	session.HandleFunc("/logout", server.PatientLogout)
 	session.HandleFunc("/records", server.record)
 	session.HandleFunc("/appointments", server.appointments)
+	session.HandleFunc("/update/appointment/{id:[0-9]+}", server.PatientUpdateAppointment)
+	session.HandleFunc("/appointment/{id:[0-9]+}/ics", server.PatientAppointmentics)
 	session.HandleFunc("/nurse", server.Patientfilternurse)
 	session.HandleFunc("/triage/{id:[0-9]+}", server.PatientTriage)
 	session.HandleFunc("/triages", server.PatientListTriage)
@@ -210,7 +253,7 @@ func (server *Server) generatepdfs(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
 	}
-	server.Services.Generate(idparam, w)
+	server.Services.Generate(r.Context(), idparam, w)
 }
 func gobRegister(data any) {
 	gob.Register(data)
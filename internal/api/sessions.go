@@ -0,0 +1,324 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/sessions"
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/models"
+)
+
+func clientip(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// startSession records a new server side session for the account
+func (server *Server) startSession(r *http.Request, accounttype string, id int, username, kind string) (models.Session, error) {
+	session := models.Session{
+		Id:          uuid.New(),
+		AccountType: accounttype,
+		AccountId:   id,
+		Username:    username,
+		Kind:        kind,
+		UserAgent:   r.UserAgent(),
+		ClientIp:    clientip(r),
+		ExpiresAt:   time.Now().Add(server.Config.Session.Duration),
+	}
+	if kind == models.SessionToken {
+		session.RefreshTokenId = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		session.ExpiresAt = time.Now().Add(server.Config.Token.RefreshDuration)
+	}
+	return server.Services.SessionService.Create(r.Context(), session)
+}
+
+// startCookieSession records a browser login and keeps the session id in the cookie session,
+// the session middlewares reject cookies whose session has been revoked.
+func (server *Server) startCookieSession(r *http.Request, s *sessions.Session, accounttype string, id int, username string) error {
+	session, err := server.startSession(r, accounttype, id, username, models.SessionCookie)
+	if err != nil {
+		return err
+	}
+	s.Values["sid"] = session.Id.String()
+	return nil
+}
+
+// endCookieSession revokes the server side session of a browser logout
+func (server *Server) endCookieSession(ctx context.Context, s *sessions.Session) error {
+	sid, ok := s.Values["sid"].(string)
+	if !ok {
+		return nil
+	}
+	delete(s.Values, "sid")
+	id, err := uuid.Parse(sid)
+	if err != nil {
+		return nil
+	}
+	return server.Services.SessionService.Revoke(ctx, id)
+}
+
+// activeSession reports whether the cookie session still has a live server side session
+func (server *Server) activeSession(ctx context.Context, s *sessions.Session) bool {
+	sid, ok := s.Values["sid"].(string)
+	if !ok {
+		return false
+	}
+	id, err := uuid.Parse(sid)
+	if err != nil {
+		return false
+	}
+	session, err := server.Services.SessionService.Find(ctx, id)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			server.Log.Error(err)
+		}
+		return false
+	}
+	return session.Active()
+}
+
+// issueTokens writes a fresh access & refresh token pair for the session
+func (server *Server) issueTokens(w http.ResponseWriter, r *http.Request, status int, session models.Session, account *Account) {
+	access, accesspayload, err := server.Auth.CreateAccountToken(auth.Claims{
+		Username:    account.Username,
+		AccountType: account.AccountType,
+		AccountID:   account.Id,
+		Kind:        auth.AccessToken,
+		SessionID:   session.Id,
+	}, server.Config.Token.AccessDuration)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	refresh, refreshpayload, err := server.Auth.CreateAccountToken(auth.Claims{
+		ID:          session.RefreshTokenId.UUID,
+		Username:    account.Username,
+		AccountType: account.AccountType,
+		AccountID:   account.Id,
+		Kind:        auth.RefreshToken,
+		SessionID:   session.Id,
+	}, time.Until(session.ExpiresAt))
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, status, envelope{
+		"authentication_token": envelope{"token": access, "expires_at": accesspayload.ExpiredAt},
+		"refresh_token":        envelope{"token": refresh, "expires_at": refreshpayload.ExpiredAt},
+		"account":              account,
+	})
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (i *refreshInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	required(errs, "refresh_token", i.RefreshToken)
+	return errs, len(errs) == 0
+}
+
+// refreshTokenJSON exchanges a refresh token for a new token pair and rotates the refresh token,
+// presenting a refresh token that was already rotated revokes the whole session since it has leaked.
+func (server *Server) refreshTokenJSON(w http.ResponseWriter, r *http.Request) {
+	var input refreshInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	payload, err := server.Auth.VerifyToken(input.RefreshToken)
+	if err != nil || payload.Kind != auth.RefreshToken {
+		server.invalidTokenJSON(w, r)
+		return
+	}
+	session, err := server.Services.SessionService.FindbyRefreshToken(r.Context(), payload.ID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		if err := server.Services.SessionService.Revoke(r.Context(), payload.SessionID); err != nil {
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		server.invalidTokenJSON(w, r)
+		return
+	}
+	if !session.Active() {
+		server.invalidTokenJSON(w, r)
+		return
+	}
+	account, err := server.loadAccount(r.Context(), payload)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			server.invalidTokenJSON(w, r)
+			return
+		}
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	rotated, err := server.Services.SessionService.Rotate(r.Context(), session.Id, payload.ID, uuid.New(), time.Now().Add(server.Config.Token.RefreshDuration))
+	if err != nil {
+		// the token was rotated by another request since it was found,it's been presented twice
+		if errors.Is(err, sql.ErrNoRows) {
+			if err := server.Services.SessionService.Revoke(r.Context(), session.Id); err != nil {
+				server.serverErrorJSON(w, r, err)
+				return
+			}
+			server.invalidTokenJSON(w, r)
+			return
+		}
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.issueTokens(w, r, http.StatusCreated, rotated, account)
+}
+
+// deleteTokenJSON logs out the session of the bearer token
+func (server *Server) deleteTokenJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	if err := server.Services.SessionService.Revoke(r.Context(), account.SessionId); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "logged out successfully"})
+}
+
+type sessionJSON struct {
+	Id        uuid.UUID `json:"id"`
+	Kind      string    `json:"kind"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	Current   bool      `json:"current"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (server *Server) listAccountSessionsJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	list, err := server.Services.SessionService.FindAllByAccount(r.Context(), account.AccountType, account.Id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]sessionJSON, 0, len(list))
+	for _, s := range list {
+		resp = append(resp, sessionJSON{
+			Id:        s.Id,
+			Kind:      s.Kind,
+			UserAgent: s.UserAgent,
+			ClientIp:  s.ClientIp,
+			Current:   s.Id == account.SessionId,
+			ExpiresAt: s.ExpiresAt,
+			CreatedAt: s.CreatedAt,
+		})
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"sessions": resp})
+}
+
+// deleteAccountSessionsJSON logs the account out everywhere,browsers included
+func (server *Server) deleteAccountSessionsJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	if err := server.Services.SessionService.RevokeAllByAccount(r.Context(), account.AccountType, account.Id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "all sessions revoked"})
+}
+
+// Adminsessions lists the active sessions,?account_type=patient&account_id=1 narrows it down to one user
+func (server *Server) Adminsessions(w http.ResponseWriter, r *http.Request) {
+	session, err := server.Store.Get(r, "admin")
+	if err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	admin := getAdmin(session)
+	params := mux.Vars(r)
+	idparam, err := strconv.Atoi(params["pageid"])
+	if err != nil || idparam <= 0 {
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
+	}
+	var list []models.Session
+	paging := Pagination{Page: 1, FirstPage: 1, LastPage: 1}
+	accounttype := r.URL.Query().Get("account_type")
+	accountid, _ := strconv.Atoi(r.URL.Query().Get("account_id"))
+	if accounttype != "" && accountid > 0 {
+		list, err = server.Services.SessionService.FindAllByAccount(r.Context(), accounttype, accountid)
+		if err != nil {
+			http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+			return
+		}
+	} else {
+		var metadata *models.Metadata
+		list, metadata, err = server.Services.SessionService.FindAll(r.Context(), models.Filters{
+			PageSize: PageCount,
+			Page:     idparam,
+		})
+		if err != nil {
+			http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+			return
+		}
+		paging = Newpagination(*metadata)
+		paging.nextpage(idparam)
+		paging.previouspage(idparam)
+	}
+	data := struct {
+		User        UserResp
+		Sessions    []models.Session
+		Pagination  Pagination
+		AccountType string
+		AccountId   int
+		Csrf        map[string]interface{}
+	}{
+		User:        admin,
+		Sessions:    list,
+		Pagination:  paging,
+		AccountType: accounttype,
+		AccountId:   accountid,
+		Csrf:        NewForm(r, &Login{}).Csrf,
+	}
+	w.WriteHeader(http.StatusOK)
+	server.Templates.Render(w, "admin-sessions.html", data)
+}
+
+// Adminrevokesession revokes a single session
+func (server *Server) Adminrevokesession(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
+	}
+	if err := server.Services.SessionService.Revoke(r.Context(), id); err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	http.Redirect(w, r, "/admin/sessions/1", http.StatusSeeOther)
+}
+
+// Adminrevokeaccountsessions logs a user out everywhere
+func (server *Server) Adminrevokeaccountsessions(w http.ResponseWriter, r *http.Request) {
+	accounttype := r.PostFormValue("account_type")
+	accountid, err := strconv.Atoi(r.PostFormValue("account_id"))
+	if err != nil || accounttype == "" {
+		http.Redirect(w, r, "/404", http.StatusMovedPermanently)
+		return
+	}
+	if err := server.Services.SessionService.RevokeAllByAccount(r.Context(), accounttype, accountid); err != nil {
+		http.Redirect(w, r, "/500", http.StatusMovedPermanently)
+		return
+	}
+	http.Redirect(w, r, "/admin/sessions/1", http.StatusSeeOther)
+}
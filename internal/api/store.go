@@ -0,0 +1,38 @@
+package api
+
+import (
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/patienttracker/internal/config"
+	"github.com/patienttracker/pkg/redistore"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPairs lists the current key pair first since it's the one new cookies are encoded with
+func keyPairs(c config.Session) [][]byte {
+	pairs := [][]byte{c.AuthKey, c.EncryptionKey}
+	if len(c.PreviousAuthKey) > 0 {
+		pairs = append(pairs, c.PreviousAuthKey, c.PreviousEncryptionKey)
+	}
+	return pairs
+}
+
+// NewSessionStore creates the session store described by the config,without keys
+// it falls back to random ones which means every restart logs everybody out.
+// The cookies live as long as the sessions recorded at login.
+func NewSessionStore(c config.Session, client *redis.Client) sessions.Store {
+	if len(c.AuthKey) == 0 {
+		c.AuthKey = securecookie.GenerateRandomKey(64)
+		c.EncryptionKey = securecookie.GenerateRandomKey(32)
+	}
+	if c.Store == config.RedisSessionStore {
+		store := redistore.New(client, keyPairs(c)...)
+		store.MaxAge(int(c.Duration.Seconds()))
+		store.Options.HttpOnly = true
+		return store
+	}
+	store := sessions.NewCookieStore(keyPairs(c)...)
+	store.MaxAge(int(c.Duration.Seconds()))
+	store.Options.HttpOnly = true
+	return store
+}
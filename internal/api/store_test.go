@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/patienttracker/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionKeyRotation(t *testing.T) {
+	old := config.Session{
+		AuthKey:       securecookie.GenerateRandomKey(64),
+		EncryptionKey: securecookie.GenerateRandomKey(32),
+	}
+	store := NewSessionStore(old, nil)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	session, err := store.New(r, "admin")
+	require.NoError(t, err)
+	session.Values["admin"] = UserResp{Id: 1, Authenticated: true}
+	w := httptest.NewRecorder()
+	require.NoError(t, store.Save(r, w, session))
+	cookie := w.Result().Cookies()[0]
+
+	rotated := NewSessionStore(config.Session{
+		AuthKey:               securecookie.GenerateRandomKey(64),
+		EncryptionKey:         securecookie.GenerateRandomKey(32),
+		PreviousAuthKey:       old.AuthKey,
+		PreviousEncryptionKey: old.EncryptionKey,
+	}, nil)
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	session, err = rotated.New(r, "admin")
+	require.NoError(t, err)
+	require.True(t, getAdmin(session).Authenticated)
+}
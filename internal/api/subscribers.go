@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/events"
+	"github.com/patienttracker/internal/utils"
+)
+
+// subscribe hands the events the service publishes to the side effects of the server
+func (server *Server) subscribe() {
+	outbox := server.Services.Outbox
+	outbox.Subscribe("mailer", server.mail, events.PatientRegistered, events.PasswordResetRequested, events.SlotOffered, events.ReminderDue)
+	outbox.Subscribe("audit", server.audit)
+}
+
+// mail queues the emails the events call for on the worker pool,which retries them and keeps the ones
+// failing every attempt in its dead letters. The event is only handed over again when the email couldn't
+// be queued,it's queued again then so the links of the previous one keep working as well.
+func (server *Server) mail(ctx context.Context, e events.Event) error {
+	switch e.Kind {
+	case events.PatientRegistered:
+		patient, err := server.Services.PatientService.Find(ctx, e.Patientid)
+		if err != nil {
+			return err
+		}
+		key := utils.RandString(20)
+		if err := server.Redis.Set(ctx, key, patient.Email, 0).Err(); err != nil {
+			return err
+		}
+		data := struct {
+			URL   string
+			Name  string
+			Email string
+		}{
+			URL:   server.Config.BaseURL + `/verify/` + key,
+			Name:  patient.Username,
+			Email: patient.Email,
+		}
+		mailer := server.Mailer.setdata(data, "Welcome to Our System!!", "verify.account.html", data.Email)
+		return server.Worker.SubmitWait(ctx, &mailer)
+	case events.PasswordResetRequested:
+		path := resetpath(e.AccountType)
+		key := path + utils.RandString(40)
+		if err := server.Redis.Set(ctx, key, e.Email, 0).Err(); err != nil {
+			return err
+		}
+		data := struct {
+			URL   string
+			Email string
+		}{
+			URL:   fmt.Sprintf(`%s/%s/passwordreset?id=%s`, server.Config.BaseURL, path, key),
+			Email: e.Email,
+		}
+		mailer := server.Mailer.setdata(data, "Reset Password!!", "reset_password.account.html", data.Email)
+		return server.Worker.SubmitWait(ctx, &mailer)
+	case events.SlotOffered:
+		return server.mailoffer(ctx, e.Waitlistid)
+	case events.ReminderDue:
+		return server.mailreminder(ctx, e.Appointmentid, e.AccountType)
+	}
+	return nil
+}
+
+// mailreminder emails the reminder of the appointment to the recipient,its patient or its doctor,
+// nothing is sent once the appointment no longer holds its slot or started.
+func (server *Server) mailreminder(ctx context.Context, appointmentid int, recipient string) error {
+	if recipient != auth.AccountPatient && recipient != auth.AccountPhysician {
+		return fmt.Errorf("no reminder for %q", recipient)
+	}
+	appointment, err := server.Services.AppointmentService.Find(ctx, appointmentid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !appointment.Approved() || !appointment.Appointmentdate.After(time.Now()) {
+		return nil
+	}
+	doctor, err := server.Services.DoctorService.Find(ctx, appointment.Doctorid)
+	if err != nil {
+		return err
+	}
+	patient, err := server.Services.PatientService.Find(ctx, appointment.Patientid)
+	if err != nil {
+		return err
+	}
+	type emaildata struct {
+		Email          string
+		LinkedUsername string
+		Date           time.Time
+		Username       string
+	}
+	// emails can't tell the zone of the reader,they're sent the clinic's
+	data := emaildata{
+		Email:          patient.Email,
+		Date:           appointment.Appointmentdate.In(server.Services.Clinic()),
+		LinkedUsername: doctor.Username,
+		Username:       patient.Username,
+	}
+	if recipient == auth.AccountPhysician {
+		data.Email, data.LinkedUsername, data.Username = doctor.Email, patient.Username, doctor.Username
+	}
+	mailer := server.Mailer.setdata(data, "Upcoming Appointments!!", "reminder.template.html", data.Email).attach(server.appointmentics(ctx, appointment, recipient))
+	return server.Worker.SubmitWait(ctx, &mailer)
+}
+
+// mailoffer emails the patient the slot offered to them,nothing is sent once the offer was taken up or expired
+func (server *Server) mailoffer(ctx context.Context, waitlistid int) error {
+	waitlist, err := server.Services.WaitlistService.Find(ctx, waitlistid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !waitlist.Offer.Pending(time.Now()) {
+		return nil
+	}
+	patient, err := server.Services.PatientService.Find(ctx, waitlist.Patientid)
+	if err != nil {
+		return err
+	}
+	doctor, err := server.Services.DoctorService.Find(ctx, waitlist.Doctorid)
+	if err != nil {
+		return err
+	}
+	// emails can't tell the zone of the reader,they're sent the clinic's
+	data := struct {
+		URL            string
+		Username       string
+		LinkedUsername string
+		Date           time.Time
+		Expires        time.Time
+	}{
+		URL:            server.Config.BaseURL + "/waitlist/offer/" + waitlist.Offer.Token,
+		Username:       patient.Username,
+		LinkedUsername: doctor.Username,
+		Date:           waitlist.Offer.Starttime.In(server.Services.Clinic()),
+		Expires:        waitlist.Offer.Expires.In(server.Services.Clinic()),
+	}
+	mailer := server.Mailer.setdata(data, "A Slot Has Freed Up!!", "waitlist.offer.html", patient.Email)
+	return server.Worker.SubmitWait(ctx, &mailer)
+}
+
+// audit logs every event published
+func (server *Server) audit(ctx context.Context, e events.Event) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "event %s", e.Kind)
+	for _, field := range []struct {
+		name string
+		id   int
+	}{
+		{"patient", e.Patientid},
+		{"nurse", e.Nurseid},
+		{"doctor", e.Doctorid},
+		{"appointment", e.Appointmentid},
+		{"ticket", e.Ticketid},
+		{"record", e.Recordid},
+		{"waitlist", e.Waitlistid},
+		{"reminder", e.Reminderid},
+	} {
+		if field.id != 0 {
+			fmt.Fprintf(&b, " %s=%d", field.name, field.id)
+		}
+	}
+	if e.AccountType != "" {
+		fmt.Fprintf(&b, " account=%s", e.AccountType)
+	}
+	server.Log.Info(b.String())
+	return nil
+}
+
+// resetaccount returns the account type the forgot password page of the url is for
+func resetaccount(url string) string {
+	if strings.Contains(url, "nurse") {
+		return auth.AccountNurse
+	} else if strings.Contains(url, "doctor") {
+		return auth.AccountPhysician
+	} else if strings.Contains(url, "admin") {
+		return auth.AccountAdmin
+	}
+	return auth.AccountPatient
+}
+
+// resetpath is where the reset pages of the account type are,the reset keys start with it too
+func resetpath(accounttype string) string {
+	if accounttype == auth.AccountPhysician {
+		return "doctor"
+	}
+	return accounttype
+}
+
+// DispatchOutbox hands the events waiting in the outbox to their subscribers until none are due
+func (server *Server) DispatchOutbox() {
+	now := time.Now()
+	for {
+		claimed, err := server.Services.DispatchOutbox(server.Context, now)
+		if err != nil {
+			server.Log.Error(err)
+			return
+		}
+		if claimed == 0 {
+			return
+		}
+	}
+}
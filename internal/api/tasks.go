@@ -1,19 +1,23 @@
 package api
 
 import (
+	"fmt"
+
+	"github.com/patienttracker/internal/config"
 	"github.com/patienttracker/internal/mailer"
 )
 
 type SendEmails struct {
-	data     any
-	mailer   mailer.Mailer
-	email    string
-	template string
-	subject  string
+	data        any
+	mailer      mailer.Mailer
+	email       string
+	template    string
+	subject     string
+	attachments []mailer.Attachment
 }
 
-func NewSenderMail() SendEmails {
-	mail := mailer.NewMailer(25, "", "", "", "") //TODO:Don't send this to upstream with credentials
+func NewSenderMail(c config.Smtp) SendEmails {
+	mail := mailer.NewMailer(c.Port, c.Sender, c.Host, c.Username, c.Password)
 	return SendEmails{
 		mailer: mail,
 	}
@@ -27,8 +31,20 @@ func (s *SendEmails) setdata(data any, subject, template, receiveremail string)
 		subject:  subject,
 	}
 }
+
+// attach returns the email with the files added to it
+func (s SendEmails) attach(attachments ...mailer.Attachment) SendEmails {
+	s.attachments = append(s.attachments, attachments...)
+	return s
+}
+
+// String describes the email in the dead letters of the worker pool
+func (s *SendEmails) String() string {
+	return fmt.Sprintf("email %q to %s", s.subject, s.email)
+}
+
 func (s *SendEmails) Background() error {
-	err := s.mailer.Send(s.email, s.subject, s.template, s.data)
+	err := s.mailer.Send(s.email, s.subject, s.template, s.data, s.attachments...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,418 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/services"
+)
+
+// Account is the principal behind a verified bearer token
+type Account struct {
+	Id          int      `json:"id"`
+	Username    string   `json:"username"`
+	Email       string   `json:"email"`
+	AccountType string   `json:"account_type"`
+	Permission  []string `json:"permissions"`
+	// server side session the token belongs to
+	SessionId uuid.UUID `json:"-"`
+}
+
+// actor is the account as the one moving an appointment along
+func (a *Account) actor() models.Actor {
+	return models.Actor{AccountType: a.AccountType, AccountId: a.Id}
+}
+
+func contextSetAccount(r *http.Request, account *Account) *http.Request {
+	ctx := context.WithValue(r.Context(), account_key, account)
+	return r.WithContext(ctx)
+}
+
+func contextGetAccount(r *http.Request) (*Account, bool) {
+	account, ok := r.Context().Value(account_key).(*Account)
+	return account, ok
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (c *credentials) validate() (Errors, bool) {
+	errs := make(Errors)
+	if err := validateEmail(c.Email); err != nil {
+		errs["email"] = "must be a valid email address"
+	}
+	required(errs, "password", c.Password)
+	return errs, len(errs) == 0
+}
+
+var errInvalidCredentials = errors.New("invalid authentication credentials")
+
+// findAccount looks up an account of the given type by email and checks the password against it
+func (server *Server) findAccount(ctx context.Context, accounttype string, c credentials) (*Account, error) {
+	var account Account
+	var hashed_password string
+	switch accounttype {
+	case auth.AccountPatient:
+		patient, err := server.Services.PatientService.FindbyEmail(ctx, c.Email)
+		if err != nil {
+			return nil, err
+		}
+		hashed_password = patient.Hashed_password
+		account = Account{Id: patient.Patientid, Username: patient.Username, Email: patient.Email}
+	case auth.AccountPhysician:
+		doctor, err := server.Services.DoctorService.FindbyEmail(ctx, c.Email)
+		if err != nil {
+			return nil, err
+		}
+		hashed_password = doctor.Hashed_password
+		account = Account{Id: doctor.Physicianid, Username: doctor.Username, Email: doctor.Email}
+	case auth.AccountNurse:
+		nurse, err := server.Services.NurseService.FindbyEmail(ctx, c.Email)
+		if err != nil {
+			return nil, err
+		}
+		hashed_password = nurse.Hashed_password
+		account = Account{Id: nurse.Id, Username: nurse.Username, Email: nurse.Email}
+	case auth.AccountAdmin:
+		user, err := server.Services.RbacService.UsersService.FindbyEmail(ctx, c.Email)
+		if err != nil {
+			return nil, err
+		}
+		hashed_password = user.Password
+		account = Account{Id: user.Id, Username: user.Email, Email: user.Email}
+	}
+	if err := services.CheckPassword(hashed_password, c.Password); err != nil {
+		return nil, errInvalidCredentials
+	}
+	account.AccountType = accounttype
+	return &account, nil
+}
+
+// loadAccount rebuilds the principal of a verified token,it makes sure the account still
+// exists and reads the current RBAC permissions so revoked permissions take effect immediately.
+func (server *Server) loadAccount(ctx context.Context, payload *auth.TokenPayload) (*Account, error) {
+	account := Account{Id: payload.AccountID, Username: payload.Username, AccountType: payload.AccountType}
+	switch payload.AccountType {
+	case auth.AccountPatient:
+		patient, err := server.Services.PatientService.Find(ctx, payload.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		account.Email = patient.Email
+	case auth.AccountPhysician:
+		doctor, err := server.Services.DoctorService.Find(ctx, payload.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		account.Email = doctor.Email
+	case auth.AccountNurse:
+		nurse, err := server.Services.NurseService.Find(ctx, payload.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		account.Email = nurse.Email
+	case auth.AccountAdmin:
+		user, err := server.Services.RbacService.UsersService.Find(ctx, payload.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		permissions, err := server.Services.RbacService.PermissionsService.FindbyRoleId(ctx, user.Roleid)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range permissions {
+			account.Permission = append(account.Permission, v.Permission)
+		}
+		account.Email = user.Email
+	default:
+		return nil, auth.ErrInvalidToken
+	}
+	return &account, nil
+}
+
+// createTokenJSON issues an access token for the given account type
+// i.e POST /v1/tokens/patient {"email": "...", "password": "..."}
+func (server *Server) createTokenJSON(accounttype string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input credentials
+		if ok := server.decodeAndValidate(w, r, &input); !ok {
+			return
+		}
+		account, err := server.findAccount(r.Context(), accounttype, input)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) || errors.Is(err, errInvalidCredentials) {
+				server.messageJSON(w, r, http.StatusUnauthorized, errInvalidCredentials.Error())
+				return
+			}
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		session, err := server.startSession(r, accounttype, account.Id, account.Username, models.SessionToken)
+		if err != nil {
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		server.issueTokens(w, r, http.StatusCreated, session, account)
+	}
+}
+
+func (server *Server) invalidTokenJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	server.messageJSON(w, r, http.StatusUnauthorized, "invalid or missing authentication token")
+}
+
+// authenticate verifies the bearer token of api requests and puts its account in the request
+// context,requests without an Authorization header carry on to the session based checks.
+func (server *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		parts := strings.Split(header, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			server.invalidTokenJSON(w, r)
+			return
+		}
+		payload, err := server.Auth.VerifyToken(parts[1])
+		if err != nil || payload.Kind != auth.AccessToken {
+			server.invalidTokenJSON(w, r)
+			return
+		}
+		session, err := server.Services.SessionService.Find(r.Context(), payload.SessionID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		if err != nil || !session.Active() {
+			server.invalidTokenJSON(w, r)
+			return
+		}
+		account, err := server.loadAccount(r.Context(), payload)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) || errors.Is(err, auth.ErrInvalidToken) {
+				server.invalidTokenJSON(w, r)
+				return
+			}
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		account.SessionId = session.Id
+		next.ServeHTTP(w, contextSetAccount(r, account))
+	})
+}
+
+// skipcsrf lets bearer authenticated,token login and token refresh requests through csrf.Protect,
+// they don't rely on cookies so there is no ambient credential to forge.
+// It has to be registered before csrf.Protect.
+func skipcsrf(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") || strings.HasPrefix(r.URL.Path, "/v1/tokens/") {
+			r = csrf.UnsafeSkipCheck(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAccount only lets through requests authenticated with a bearer token
+func (server *Server) requireAccount(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := contextGetAccount(r); !ok {
+			server.invalidTokenJSON(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+func (server *Server) showAccountJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	server.writeJSON(w, r, http.StatusOK, envelope{"account": account})
+}
+
+func (server *Server) listAccountAppointmentsJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	var err error
+	var resp []appointmentJSON
+	switch account.AccountType {
+	case auth.AccountPatient:
+		appointments, e := server.Services.AppointmentService.FindAllByPatient(r.Context(), account.Id)
+		resp, err = appointmentsJSON(appointments, server.viewerzone(r)), e
+	case auth.AccountPhysician:
+		appointments, e := server.Services.AppointmentService.FindAllByDoctor(r.Context(), account.Id)
+		resp, err = appointmentsJSON(appointments, server.viewerzone(r)), e
+	default:
+		server.forbiddenJSON(w, r)
+		return
+	}
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointments": resp})
+}
+
+// cancelAccountAppointmentJSON lets a patient cancel one of their own appointments,
+// the staff move appointments along through the appointments endpoints instead
+func (server *Server) cancelAccountAppointmentJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	if account.AccountType != auth.AccountPatient {
+		server.forbiddenJSON(w, r)
+		return
+	}
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := server.readJSON(w, r, &input); err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	appointment, err := server.Services.TransitionAppointment(r.Context(), id, models.StatusCancelled, account.actor(), input.Reason)
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointment": newAppointmentJSON(appointment, server.viewerzone(r))})
+}
+
+// listAccountWaitlistJSON lists the days the patient is waiting for a slot on
+func (server *Server) listAccountWaitlistJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	if account.AccountType != auth.AccountPatient {
+		server.forbiddenJSON(w, r)
+		return
+	}
+	waiting, err := server.Services.WaitlistService.FindbyPatient(r.Context(), account.Id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]waitlistJSON, 0, len(waiting))
+	for _, waitlist := range waiting {
+		resp = append(resp, newWaitlistJSON(waitlist, server.viewerzone(r)))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"waitlist": resp})
+}
+
+// joinAccountWaitlistJSON puts the patient on the waitlist of a doctor for a day,
+// they're emailed when a slot that day frees up
+func (server *Server) joinAccountWaitlistJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	if account.AccountType != auth.AccountPatient {
+		server.forbiddenJSON(w, r)
+		return
+	}
+	var input waitlistInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	day, _ := time.ParseInLocation(dateLayout, input.Day, server.Services.Clinic())
+	waitlist, err := server.Services.JoinWaitlist(r.Context(), models.Waitlist{
+		Patientid: account.Id,
+		Doctorid:  input.Doctorid,
+		Day:       day,
+		Duration:  parseduration(input.Duration),
+	})
+	if errors.Is(err, models.ErrDuplicate) {
+		server.messageJSON(w, r, http.StatusConflict, "you are already on the waitlist for this day")
+		return
+	}
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"waitlist": newWaitlistJSON(waitlist, server.viewerzone(r))})
+}
+
+func (server *Server) leaveAccountWaitlistJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	if account.AccountType != auth.AccountPatient {
+		server.forbiddenJSON(w, r)
+		return
+	}
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if err := server.Services.LeaveWaitlist(r.Context(), id, account.actor()); err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "left the waitlist successfully"})
+}
+
+// acceptAccountOfferJSON books the slot offered to the patient like the link emailed to them does
+func (server *Server) acceptAccountOfferJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	if account.AccountType != auth.AccountPatient {
+		server.forbiddenJSON(w, r)
+		return
+	}
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	waitlist, err := server.Services.WaitlistService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if waitlist.Patientid != account.Id {
+		server.forbiddenJSON(w, r)
+		return
+	}
+	if waitlist.Offer.Token == "" {
+		server.messageJSON(w, r, http.StatusConflict, "no slot has been offered yet")
+		return
+	}
+	appointment, err := server.Services.AcceptOffer(r.Context(), waitlist.Offer.Token)
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"appointment": newAppointmentJSON(appointment, server.viewerzone(r))})
+}
+
+func (server *Server) listAccountRecordsJSON(w http.ResponseWriter, r *http.Request) {
+	account, _ := contextGetAccount(r)
+	var err error
+	var resp []recordJSON
+	switch account.AccountType {
+	case auth.AccountPatient:
+		records, e := server.Services.PatientRecordService.FindAllByPatient(r.Context(), account.Id)
+		resp, err = recordsJSON(records, server.viewerzone(r)), e
+	case auth.AccountPhysician:
+		records, e := server.Services.PatientRecordService.FindAllByDoctor(r.Context(), account.Id)
+		resp, err = recordsJSON(records, server.viewerzone(r)), e
+	case auth.AccountNurse:
+		records, e := server.Services.PatientRecordService.FindAllByNurse(r.Context(), account.Id)
+		resp, err = recordsJSON(records, server.viewerzone(r)), e
+	default:
+		server.forbiddenJSON(w, r)
+		return
+	}
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"records": resp})
+}
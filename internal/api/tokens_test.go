@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/services"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+type tokenResponse struct {
+	Token struct {
+		Token string `json:"token"`
+	} `json:"authentication_token"`
+	Refresh struct {
+		Token string `json:"token"`
+	} `json:"refresh_token"`
+}
+
+func createPatientAccount(t *testing.T) (models.Patient, string) {
+	password := utils.RandString(8)
+	hashed_password, err := services.HashPassword(password)
+	require.NoError(t, err)
+	patient, err := testserver.Services.PatientService.Create(context.Background(), models.Patient{
+		Username:        utils.RandUsername(8),
+		Email:           utils.RandEmail(8),
+		Contact:         utils.RandContact(10),
+		Hashed_password: hashed_password,
+		Created_at:      time.Now(),
+	})
+	require.NoError(t, err)
+	return patient, password
+}
+
+func postJSON(path, body, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	testserver.Router.ServeHTTP(w, r)
+	return w
+}
+
+func getJSON(path, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	testserver.Router.ServeHTTP(w, r)
+	return w
+}
+
+func loginPatient(t *testing.T, patient models.Patient, password string) tokenResponse {
+	w := postJSON("/v1/tokens/patient", `{"email":"`+patient.Email+`","password":"`+password+`"}`, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var resp tokenResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.NotEmpty(t, resp.Token.Token)
+	require.NotEmpty(t, resp.Refresh.Token)
+	return resp
+}
+
+func TestPatientBearerToken(t *testing.T) {
+	patient, password := createPatientAccount(t)
+
+	w := postJSON("/v1/tokens/patient", `{"email":"`+patient.Email+`","password":"wrongpassword"}`, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	resp := loginPatient(t, patient, password)
+
+	w = getJSON("/v1/me", resp.Token.Token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var me struct {
+		Account Account `json:"account"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&me))
+	require.Equal(t, patient.Patientid, me.Account.Id)
+	require.Equal(t, auth.AccountPatient, me.Account.AccountType)
+
+	// a patient token carries no RBAC permissions
+	w = getJSON("/v1/patients", resp.Token.Token)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// refresh tokens are not accepted as bearer tokens
+	w = getJSON("/v1/me", resp.Refresh.Token)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	patient, password := createPatientAccount(t)
+	first := loginPatient(t, patient, password)
+
+	w := postJSON("/v1/tokens/refresh", `{"refresh_token":"`+first.Refresh.Token+`"}`, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var second tokenResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&second))
+	require.NotEqual(t, first.Refresh.Token, second.Refresh.Token)
+	require.Equal(t, http.StatusOK, getJSON("/v1/me", second.Token.Token).Code)
+
+	// replaying the rotated refresh token revokes the session
+	w = postJSON("/v1/tokens/refresh", `{"refresh_token":"`+first.Refresh.Token+`"}`, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, http.StatusUnauthorized, getJSON("/v1/me", second.Token.Token).Code)
+	w = postJSON("/v1/tokens/refresh", `{"refresh_token":"`+second.Refresh.Token+`"}`, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// racingsessions rotates the refresh token found before handing the session back,
+// as if another request presenting the same token got to rotate it first
+type racingsessions struct {
+	models.Sessionrepository
+}
+
+func (r racingsessions) FindbyRefreshToken(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	session, err := r.Sessionrepository.FindbyRefreshToken(ctx, id)
+	if err != nil {
+		return session, err
+	}
+	_, err = r.Sessionrepository.Rotate(ctx, session.Id, id, uuid.New(), session.ExpiresAt)
+	return session, err
+}
+
+func TestRefreshTokenRace(t *testing.T) {
+	patient, password := createPatientAccount(t)
+	login := loginPatient(t, patient, password)
+	sessions := testserver.Services.SessionService
+	testserver.Services.SessionService = racingsessions{sessions}
+	w := postJSON("/v1/tokens/refresh", `{"refresh_token":"`+login.Refresh.Token+`"}`, "")
+	testserver.Services.SessionService = sessions
+	// the request losing the race revokes the session the token was presented twice for
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, http.StatusUnauthorized, getJSON("/v1/me", login.Token.Token).Code)
+	payload, err := testserver.Auth.VerifyToken(login.Refresh.Token)
+	require.NoError(t, err)
+	session, err := sessions.Find(context.Background(), payload.SessionID)
+	require.NoError(t, err)
+	require.True(t, session.Revoked)
+}
+
+func TestLogoutEverywhere(t *testing.T) {
+	patient, password := createPatientAccount(t)
+	laptop := loginPatient(t, patient, password)
+	phone := loginPatient(t, patient, password)
+
+	w := getJSON("/v1/me/sessions", laptop.Token.Token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Sessions []sessionJSON `json:"sessions"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	require.Len(t, list.Sessions, 2)
+
+	r := httptest.NewRequest(http.MethodDelete, "/v1/me/sessions", nil)
+	r.Header.Set("Authorization", "Bearer "+laptop.Token.Token)
+	w = httptest.NewRecorder()
+	testserver.Router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Equal(t, http.StatusUnauthorized, getJSON("/v1/me", laptop.Token.Token).Code)
+	require.Equal(t, http.StatusUnauthorized, getJSON("/v1/me", phone.Token.Token).Code)
+	w = postJSON("/v1/tokens/refresh", `{"refresh_token":"`+phone.Refresh.Token+`"}`, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestInvalidBearerToken(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+	r.Header.Set("Authorization", "Bearer "+utils.RandString(20))
+	w := httptest.NewRecorder()
+	testserver.Router.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+}
+
+func TestPatientCancelAppointment(t *testing.T) {
+	patient, password := createPatientAccount(t)
+	other, _ := createPatientAccount(t)
+	book := func(patientid int) models.Appointment {
+		appointment, err := testserver.Services.AppointmentService.Create(context.Background(), models.Appointment{
+			Doctorid:        1,
+			Patientid:       patientid,
+			Appointmentdate: time.Now().Add(48 * time.Hour),
+			Duration:        time.Hour,
+			Status:          models.StatusRequested,
+		})
+		require.NoError(t, err)
+		return appointment
+	}
+	mine, theirs := book(patient.Patientid), book(other.Patientid)
+	resp := loginPatient(t, patient, password)
+	cancel := func(id int) string { return "/v1/me/appointments/" + strconv.Itoa(id) + "/cancel" }
+
+	w := postJSON(cancel(mine.Appointmentid), `{}`, resp.Token.Token)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = postJSON(cancel(theirs.Appointmentid), `{"reason":"not mine"}`, resp.Token.Token)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = postJSON(cancel(mine.Appointmentid), `{"reason":"feeling better"}`, resp.Token.Token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Appointment appointmentJSON `json:"appointment"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	require.Equal(t, string(models.StatusCancelled), body.Appointment.Status)
+	require.False(t, body.Appointment.Approval)
+
+	// a cancelled appointment stays cancelled
+	w = postJSON(cancel(mine.Appointmentid), `{"reason":"again"}`, resp.Token.Token)
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestPatientWaitlist(t *testing.T) {
+	ctx := context.Background()
+	dept, err := testserver.Services.DepartmentService.Create(ctx, models.Department{Departmentname: utils.RandString(6)})
+	require.NoError(t, err)
+	doctor, err := testserver.Services.DoctorService.Create(ctx, models.Physician{Username: utils.RandUsername(6), Email: utils.RandEmail(5), Departmentname: dept.Departmentname})
+	require.NoError(t, err)
+	patient, password := createPatientAccount(t)
+	resp := loginPatient(t, patient, password)
+	day := time.Now().In(testserver.Services.Clinic()).AddDate(0, 0, 2).Format(dateLayout)
+	join := `{"doctor_id":` + strconv.Itoa(doctor.Physicianid) + `,"day":"` + day + `"}`
+
+	w := postJSON("/v1/me/waitlist", `{"doctor_id":`+strconv.Itoa(doctor.Physicianid)+`,"day":"tomorrow"}`, resp.Token.Token)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = postJSON("/v1/me/waitlist", join, resp.Token.Token)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var body struct {
+		Waitlist waitlistJSON `json:"waitlist"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	require.Equal(t, day, body.Waitlist.Day)
+	require.Nil(t, body.Waitlist.Offer)
+	w = postJSON("/v1/me/waitlist", join, resp.Token.Token)
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	w = getJSON("/v1/me/waitlist", resp.Token.Token)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Waitlist []waitlistJSON `json:"waitlist"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	require.Len(t, list.Waitlist, 1)
+
+	// nothing has been offered yet
+	entry := "/v1/me/waitlist/" + strconv.Itoa(body.Waitlist.Id)
+	w = postJSON(entry+"/accept", `{}`, resp.Token.Token)
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	leave := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodDelete, entry, nil)
+		r.Header.Set("Authorization", "Bearer "+resp.Token.Token)
+		w := httptest.NewRecorder()
+		testserver.Router.ServeHTTP(w, r)
+		return w
+	}
+	require.Equal(t, http.StatusOK, leave().Code)
+	require.Equal(t, http.StatusNotFound, leave().Code)
+}
@@ -0,0 +1,1978 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/services"
+)
+
+// v1routes registers the versioned json api, it's the machine readable twin of
+// the server rendered admin pages and goes through the same services and repositories.
+func (server *Server) v1routes() {
+	v1 := server.Router.PathPrefix("/v1").Subrouter()
+	v1.NotFoundHandler = http.HandlerFunc(server.notFoundJSON)
+	v1.MethodNotAllowedHandler = http.HandlerFunc(server.methodNotAllowedJSON)
+	v1.Use(server.authenticate)
+	v1.Use(server.timezone)
+
+	v1.HandleFunc("/tokens/patient", server.createTokenJSON(auth.AccountPatient)).Methods(http.MethodPost)
+	v1.HandleFunc("/tokens/physician", server.createTokenJSON(auth.AccountPhysician)).Methods(http.MethodPost)
+	v1.HandleFunc("/tokens/nurse", server.createTokenJSON(auth.AccountNurse)).Methods(http.MethodPost)
+	v1.HandleFunc("/tokens/admin", server.createTokenJSON(auth.AccountAdmin)).Methods(http.MethodPost)
+	v1.HandleFunc("/tokens/refresh", server.refreshTokenJSON).Methods(http.MethodPost)
+	v1.HandleFunc("/tokens", server.requireAccount(server.deleteTokenJSON)).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/me", server.requireAccount(server.showAccountJSON)).Methods(http.MethodGet)
+	v1.HandleFunc("/me/appointments", server.requireAccount(server.listAccountAppointmentsJSON)).Methods(http.MethodGet)
+	v1.HandleFunc("/me/appointments/{id:[0-9]+}/cancel", server.requireAccount(server.cancelAccountAppointmentJSON)).Methods(http.MethodPost)
+	v1.HandleFunc("/me/appointments/{id:[0-9]+}/ics", server.requireAccount(server.accountAppointmenticsJSON)).Methods(http.MethodGet)
+	v1.HandleFunc("/me/calendar", server.requireAccount(server.showAccountCalendarJSON)).Methods(http.MethodGet)
+	v1.HandleFunc("/me/calendar/reset", server.requireAccount(server.resetAccountCalendarJSON)).Methods(http.MethodPost)
+	v1.HandleFunc("/me/records", server.requireAccount(server.listAccountRecordsJSON)).Methods(http.MethodGet)
+	v1.HandleFunc("/me/waitlist", server.requireAccount(server.listAccountWaitlistJSON)).Methods(http.MethodGet)
+	v1.HandleFunc("/me/waitlist", server.requireAccount(server.joinAccountWaitlistJSON)).Methods(http.MethodPost)
+	v1.HandleFunc("/me/waitlist/{id:[0-9]+}", server.requireAccount(server.leaveAccountWaitlistJSON)).Methods(http.MethodDelete)
+	v1.HandleFunc("/me/waitlist/{id:[0-9]+}/accept", server.requireAccount(server.acceptAccountOfferJSON)).Methods(http.MethodPost)
+	v1.HandleFunc("/me/sessions", server.requireAccount(server.listAccountSessionsJSON)).Methods(http.MethodGet)
+	v1.HandleFunc("/me/sessions", server.requireAccount(server.deleteAccountSessionsJSON)).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/patients", server.requirePermission(server.listPatientsJSON, readperms("patient"))).Methods(http.MethodGet)
+	v1.HandleFunc("/patients", server.requirePermission(server.createPatientJSON, writeperms("patient"))).Methods(http.MethodPost)
+	v1.HandleFunc("/patients/{id:[0-9]+}", server.requirePermission(server.showPatientJSON, readperms("patient"))).Methods(http.MethodGet)
+	v1.HandleFunc("/patients/{id:[0-9]+}", server.requirePermission(server.updatePatientJSON, writeperms("patient"))).Methods(http.MethodPut)
+	v1.HandleFunc("/patients/{id:[0-9]+}", server.requirePermission(server.deletePatientJSON, writeperms("patient"))).Methods(http.MethodDelete)
+	v1.HandleFunc("/patients/{id:[0-9]+}/appointments", server.requirePermission(server.listPatientAppointmentsJSON, readperms("appointment"))).Methods(http.MethodGet)
+	v1.HandleFunc("/patients/{id:[0-9]+}/records", server.requirePermission(server.listPatientRecordsJSON, readperms("record"))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/physicians", server.requirePermission(server.listPhysiciansJSON, readperms("physician"))).Methods(http.MethodGet)
+	v1.HandleFunc("/physicians", server.requirePermission(server.createPhysicianJSON, writeperms("physician"))).Methods(http.MethodPost)
+	v1.HandleFunc("/physicians/{id:[0-9]+}", server.requirePermission(server.showPhysicianJSON, readperms("physician"))).Methods(http.MethodGet)
+	v1.HandleFunc("/physicians/{id:[0-9]+}", server.requirePermission(server.updatePhysicianJSON, writeperms("physician"))).Methods(http.MethodPut)
+	v1.HandleFunc("/physicians/{id:[0-9]+}", server.requirePermission(server.deletePhysicianJSON, writeperms("physician"))).Methods(http.MethodDelete)
+	v1.HandleFunc("/physicians/{id:[0-9]+}/appointments", server.requirePermission(server.listPhysicianAppointmentsJSON, readperms("appointment"))).Methods(http.MethodGet)
+	v1.HandleFunc("/physicians/{id:[0-9]+}/schedules", server.requirePermission(server.listPhysicianSchedulesJSON, readperms("schedule"))).Methods(http.MethodGet)
+	v1.HandleFunc("/physicians/{id:[0-9]+}/exceptions", server.requirePermission(server.listPhysicianExceptionsJSON, readperms("schedule"))).Methods(http.MethodGet)
+	v1.HandleFunc("/physicians/{id:[0-9]+}/slots", server.requirePermission(server.listPhysicianSlotsJSON, readperms("appointment"))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/nurses", server.requirePermission(server.listNursesJSON, readperms("nurse"))).Methods(http.MethodGet)
+	v1.HandleFunc("/nurses", server.requirePermission(server.createNurseJSON, writeperms("nurse"))).Methods(http.MethodPost)
+	v1.HandleFunc("/nurses/{id:[0-9]+}", server.requirePermission(server.showNurseJSON, readperms("nurse"))).Methods(http.MethodGet)
+	v1.HandleFunc("/nurses/{id:[0-9]+}", server.requirePermission(server.updateNurseJSON, writeperms("nurse"))).Methods(http.MethodPut)
+	v1.HandleFunc("/nurses/{id:[0-9]+}", server.requirePermission(server.deleteNurseJSON, writeperms("nurse"))).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/departments", server.requirePermission(server.listDepartmentsJSON, readperms("department"))).Methods(http.MethodGet)
+	v1.HandleFunc("/departments", server.requirePermission(server.createDepartmentJSON, writeperms("department"))).Methods(http.MethodPost)
+	v1.HandleFunc("/departments/{id:[0-9]+}", server.requirePermission(server.showDepartmentJSON, readperms("department"))).Methods(http.MethodGet)
+	v1.HandleFunc("/departments/{id:[0-9]+}", server.requirePermission(server.updateDepartmentJSON, writeperms("department"))).Methods(http.MethodPut)
+	v1.HandleFunc("/departments/{id:[0-9]+}", server.requirePermission(server.deleteDepartmentJSON, writeperms("department"))).Methods(http.MethodDelete)
+	v1.HandleFunc("/departments/{id:[0-9]+}/physicians", server.requirePermission(server.listDepartmentPhysiciansJSON, readperms("physician"))).Methods(http.MethodGet)
+	v1.HandleFunc("/departments/{id:[0-9]+}/slots", server.requirePermission(server.listDepartmentSlotsJSON, readperms("appointment"))).Methods(http.MethodGet)
+
+	v1.HandleFunc("/schedules", server.requirePermission(server.listSchedulesJSON, readperms("schedule"))).Methods(http.MethodGet)
+	v1.HandleFunc("/schedules", server.requirePermission(server.createScheduleJSON, writeperms("schedule"))).Methods(http.MethodPost)
+	v1.HandleFunc("/schedules/{id:[0-9]+}", server.requirePermission(server.showScheduleJSON, readperms("schedule"))).Methods(http.MethodGet)
+	v1.HandleFunc("/schedules/{id:[0-9]+}", server.requirePermission(server.updateScheduleJSON, writeperms("schedule"))).Methods(http.MethodPut)
+	v1.HandleFunc("/schedules/{id:[0-9]+}", server.requirePermission(server.deleteScheduleJSON, writeperms("schedule"))).Methods(http.MethodDelete)
+	v1.HandleFunc("/exceptions", server.requirePermission(server.createExceptionJSON, writeperms("schedule"))).Methods(http.MethodPost)
+	v1.HandleFunc("/exceptions/{id:[0-9]+}", server.requirePermission(server.showExceptionJSON, readperms("schedule"))).Methods(http.MethodGet)
+	v1.HandleFunc("/exceptions/{id:[0-9]+}", server.requirePermission(server.deleteExceptionJSON, writeperms("schedule"))).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/appointments", server.requirePermission(server.listAppointmentsJSON, readperms("appointment"))).Methods(http.MethodGet)
+	v1.HandleFunc("/appointments", server.requirePermission(server.createAppointmentJSON, writeperms("appointment"))).Methods(http.MethodPost)
+	v1.HandleFunc("/appointments/{id:[0-9]+}", server.requirePermission(server.showAppointmentJSON, readperms("appointment"))).Methods(http.MethodGet)
+	v1.HandleFunc("/appointments/{id:[0-9]+}", server.requirePermission(server.updateAppointmentJSON, writeperms("appointment"))).Methods(http.MethodPut)
+	v1.HandleFunc("/appointments/{id:[0-9]+}", server.requirePermission(server.deleteAppointmentJSON, writeperms("appointment"))).Methods(http.MethodDelete)
+	v1.HandleFunc("/appointments/{id:[0-9]+}/status", server.requirePermission(server.transitionAppointmentJSON, writeperms("appointment"))).Methods(http.MethodPost)
+	v1.HandleFunc("/appointments/{id:[0-9]+}/transitions", server.requirePermission(server.listAppointmentTransitionsJSON, readperms("appointment"))).Methods(http.MethodGet)
+	v1.HandleFunc("/appointments/{id:[0-9]+}/ics", server.requirePermission(server.appointmenticsJSON, readperms("appointment"))).Methods(http.MethodGet)
+	v1.HandleFunc("/series", server.requirePermission(server.createSeriesJSON, writeperms("appointment"))).Methods(http.MethodPost)
+	v1.HandleFunc("/series/{id:[0-9]+}", server.requirePermission(server.showSeriesJSON, readperms("appointment"))).Methods(http.MethodGet)
+	v1.HandleFunc("/series/{id:[0-9]+}", server.requirePermission(server.updateSeriesJSON, writeperms("appointment"))).Methods(http.MethodPut)
+	v1.HandleFunc("/series/{id:[0-9]+}/cancel", server.requirePermission(server.cancelSeriesJSON, writeperms("appointment"))).Methods(http.MethodPost)
+
+	v1.HandleFunc("/records", server.requirePermission(server.listRecordsJSON, readperms("record"))).Methods(http.MethodGet)
+	v1.HandleFunc("/records", server.requirePermission(server.createRecordJSON, writeperms("record"))).Methods(http.MethodPost)
+	v1.HandleFunc("/records/{id:[0-9]+}", server.requirePermission(server.showRecordJSON, readperms("record"))).Methods(http.MethodGet)
+	v1.HandleFunc("/records/{id:[0-9]+}", server.requirePermission(server.updateRecordJSON, writeperms("record"))).Methods(http.MethodPut)
+	v1.HandleFunc("/records/{id:[0-9]+}", server.requirePermission(server.deleteRecordJSON, writeperms("record"))).Methods(http.MethodDelete)
+
+	v1.HandleFunc("/jobs", server.requirePermission(server.showJobsJSON, services.Or{Permissions: []string{"admin"}})).Methods(http.MethodGet)
+	v1.HandleFunc("/jobs/dead/{id:[0-9]+}/requeue", server.requirePermission(server.requeueJobJSON, services.Or{Permissions: []string{"admin"}})).Methods(http.MethodPost)
+}
+
+// readperms are the permissions that can view a resource domain e.g record:viewer
+func readperms(domain string) services.Or {
+	return services.Or{Permissions: []string{"admin", "viewer", "editor", domain + ":admin", domain + ":editor", domain + ":viewer"}}
+}
+
+// writeperms are the permissions that can create,update & delete a resource domain e.g record:editor
+func writeperms(domain string) services.Or {
+	return services.Or{Permissions: []string{"admin", "editor", domain + ":admin", domain + ":editor"}}
+}
+
+// requirePermission is the json counterpart of CheckPermissions,instead of redirecting
+// it answers with a 401 or 403 error body. Bearer token accounts are checked against
+// the permissions loaded by authenticate,everyone else against the admin session.
+func (server *Server) requirePermission(next http.HandlerFunc, c services.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if account, ok := contextGetAccount(r); ok {
+			if ok := c.IsSatisfied(account.Permission); !ok {
+				server.forbiddenJSON(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		session, err := server.Store.Get(r, "admin")
+		if err != nil {
+			server.unauthorizedJSON(w, r)
+			return
+		}
+		user := getAdmin(session)
+		if !user.Authenticated || !server.activeSession(r.Context(), session) {
+			server.unauthorizedJSON(w, r)
+			return
+		}
+		if ok := c.IsSatisfied(user.Permission); !ok {
+			server.forbiddenJSON(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+type patientJSON struct {
+	Id         int       `json:"id"`
+	Username   string    `json:"username"`
+	Full_name  string    `json:"full_name"`
+	Email      string    `json:"email"`
+	Dob        time.Time `json:"dob"`
+	Contact    string    `json:"contact"`
+	Bloodgroup string    `json:"bloodgroup"`
+	Avatar     string    `json:"avatar,omitempty"`
+	About      string    `json:"about,omitempty"`
+	Verified   bool      `json:"verified"`
+	Ischild    bool      `json:"ischild"`
+	Created_at time.Time `json:"created_at"`
+}
+
+func newPatientJSON(p models.Patient) patientJSON {
+	return patientJSON{
+		Id:         p.Patientid,
+		Username:   p.Username,
+		Full_name:  p.Full_name,
+		Email:      p.Email,
+		Dob:        p.Dob,
+		Contact:    p.Contact,
+		Bloodgroup: p.Bloodgroup,
+		Avatar:     p.Avatar,
+		About:      p.About,
+		Verified:   p.Verified,
+		Ischild:    p.Ischild,
+		Created_at: p.Created_at,
+	}
+}
+
+type physicianJSON struct {
+	Id             int       `json:"id"`
+	Username       string    `json:"username"`
+	Full_name      string    `json:"full_name"`
+	Email          string    `json:"email"`
+	Contact        string    `json:"contact"`
+	Departmentname string    `json:"departmentname"`
+	Avatar         string    `json:"avatar,omitempty"`
+	About          string    `json:"about,omitempty"`
+	Verified       bool      `json:"verified"`
+	Created_at     time.Time `json:"created_at"`
+}
+
+func newPhysicianJSON(p models.Physician) physicianJSON {
+	return physicianJSON{
+		Id:             p.Physicianid,
+		Username:       p.Username,
+		Full_name:      p.Full_name,
+		Email:          p.Email,
+		Contact:        p.Contact,
+		Departmentname: p.Departmentname,
+		Avatar:         p.Avatar,
+		About:          p.About,
+		Verified:       p.Verified,
+		Created_at:     p.Created_at,
+	}
+}
+
+type nurseJSON struct {
+	Id         int       `json:"id"`
+	Username   string    `json:"username"`
+	Full_name  string    `json:"full_name"`
+	Email      string    `json:"email"`
+	Created_at time.Time `json:"created_at"`
+}
+
+func newNurseJSON(n models.Nurse) nurseJSON {
+	return nurseJSON{
+		Id:         n.Id,
+		Username:   n.Username,
+		Full_name:  n.Full_name,
+		Email:      n.Email,
+		Created_at: n.Created_at,
+	}
+}
+
+type departmentJSON struct {
+	Id             int      `json:"id"`
+	Departmentname string   `json:"departmentname"`
+	Durations      []string `json:"durations"`
+}
+
+func newDepartmentJSON(d models.Department) departmentJSON {
+	durations := make([]string, 0, len(d.Durations))
+	for _, duration := range d.Durations {
+		durations = append(durations, duration.String())
+	}
+	return departmentJSON{Id: d.Departmentid, Departmentname: d.Departmentname, Durations: durations}
+}
+
+// dateLayout is the format of the days bounding the validity of a schedule
+const dateLayout = "2006-01-02"
+
+// slotsearch is how far ahead the free slots are searched when the search has no end
+const slotsearch = 7 * 24 * time.Hour
+
+type blockJSON struct {
+	Weekday   string `json:"weekday"`
+	Starttime string `json:"starttime"`
+	Endtime   string `json:"endtime"`
+}
+
+type scheduleJSON struct {
+	Id         int         `json:"id"`
+	Doctorid   int         `json:"doctor_id"`
+	Starttime  string      `json:"starttime"`
+	Endtime    string      `json:"endtime"`
+	Active     bool        `json:"active"`
+	Validfrom  string      `json:"valid_from,omitempty"`
+	Validuntil string      `json:"valid_until,omitempty"`
+	Blocks     []blockJSON `json:"blocks"`
+}
+
+func newScheduleJSON(s models.Schedule) scheduleJSON {
+	blocks := make([]blockJSON, 0, len(s.Blocks))
+	for _, block := range s.Blocks {
+		blocks = append(blocks, blockJSON{
+			Weekday:   strings.ToLower(block.Weekday.String()),
+			Starttime: block.Starttime,
+			Endtime:   block.Endtime,
+		})
+	}
+	return scheduleJSON{
+		Id:         s.Scheduleid,
+		Doctorid:   s.Doctorid,
+		Starttime:  s.Starttime,
+		Endtime:    s.Endtime,
+		Active:     s.Active,
+		Validfrom:  formatdate(s.Validfrom),
+		Validuntil: formatdate(s.Validuntil),
+		Blocks:     blocks,
+	}
+}
+
+// formatdate formats the day of t,nothing for the zero time
+func formatdate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+type slotJSON struct {
+	Doctorid  int       `json:"doctor_id"`
+	Starttime time.Time `json:"starttime"`
+	Endtime   time.Time `json:"endtime"`
+}
+
+func slotsJSON(slots []models.Slot, loc *time.Location) []slotJSON {
+	resp := make([]slotJSON, 0, len(slots))
+	for _, slot := range slots {
+		resp = append(resp, slotJSON{Doctorid: slot.Doctorid, Starttime: slot.Starttime.In(loc), Endtime: slot.Endtime.In(loc)})
+	}
+	return resp
+}
+
+type exceptionJSON struct {
+	Id        int       `json:"id"`
+	Doctorid  int       `json:"doctor_id"`
+	Starttime time.Time `json:"starttime"`
+	Endtime   time.Time `json:"endtime"`
+	Reason    string    `json:"reason"`
+}
+
+func newExceptionJSON(e models.ScheduleException, loc *time.Location) exceptionJSON {
+	return exceptionJSON{
+		Id:        e.Exceptionid,
+		Doctorid:  e.Doctorid,
+		Starttime: e.Starttime.In(loc),
+		Endtime:   e.Endtime.In(loc),
+		Reason:    e.Reason,
+	}
+}
+
+type appointmentJSON struct {
+	Id              int       `json:"id"`
+	Doctorid        int       `json:"doctor_id"`
+	Patientid       int       `json:"patient_id"`
+	Appointmentdate time.Time `json:"appointment_date"`
+	Duration        string    `json:"duration"`
+	Status          string    `json:"status"`
+	// Approval is whether the appointment holds its slot,kept for the clients reading it before there were statuses
+	Approval bool `json:"approval"`
+	Outbound bool `json:"outbound"`
+	Seriesid int  `json:"series_id,omitempty"`
+}
+
+// newAppointmentJSON writes the appointment in loc,the zone of whoever reads it
+func newAppointmentJSON(a models.Appointment, loc *time.Location) appointmentJSON {
+	return appointmentJSON{
+		Id:              a.Appointmentid,
+		Doctorid:        a.Doctorid,
+		Patientid:       a.Patientid,
+		Appointmentdate: a.Appointmentdate.In(loc),
+		Duration:        a.Duration.String(),
+		Status:          string(a.Status),
+		Approval:        a.Approved(),
+		Outbound:        a.Outbound,
+		Seriesid:        a.Seriesid,
+	}
+}
+
+type seriesJSON struct {
+	Id        int       `json:"id"`
+	Doctorid  int       `json:"doctor_id"`
+	Patientid int       `json:"patient_id"`
+	Start     time.Time `json:"start"`
+	Duration  string    `json:"duration"`
+	Interval  int       `json:"interval"`
+	Unit      string    `json:"unit"`
+	Count     int       `json:"count,omitempty"`
+	Until     string    `json:"until,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newSeriesJSON writes the series in loc,until is a day of the clinic
+func newSeriesJSON(s models.Series, loc *time.Location) seriesJSON {
+	resp := seriesJSON{
+		Id:        s.Seriesid,
+		Doctorid:  s.Doctorid,
+		Patientid: s.Patientid,
+		Start:     s.Start.In(loc),
+		Duration:  s.Duration.String(),
+		Interval:  s.Interval,
+		Unit:      string(s.Unit),
+		Count:     s.Count,
+		CreatedAt: s.CreatedAt.In(loc),
+	}
+	if !s.Until.IsZero() {
+		resp.Until = s.Until.Format(dateLayout)
+	}
+	return resp
+}
+
+type transitionJSON struct {
+	Id          int       `json:"id"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	AccountType string    `json:"account_type"`
+	AccountId   int       `json:"account_id"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newTransitionJSON(t models.Transition, loc *time.Location) transitionJSON {
+	return transitionJSON{
+		Id:          t.Transitionid,
+		From:        string(t.From),
+		To:          string(t.To),
+		AccountType: t.AccountType,
+		AccountId:   t.AccountId,
+		Reason:      t.Reason,
+		CreatedAt:   t.CreatedAt.In(loc),
+	}
+}
+
+type waitlistJSON struct {
+	Id        int       `json:"id"`
+	Doctorid  int       `json:"doctor_id"`
+	Patientid int       `json:"patient_id"`
+	Day       string    `json:"day"`
+	Duration  string    `json:"duration"`
+	CreatedAt time.Time `json:"created_at"`
+	// Offer is the freed slot held for the patient,it's left out unless one is pending
+	Offer *offerJSON `json:"offer,omitempty"`
+}
+
+type offerJSON struct {
+	Starttime time.Time `json:"start_time"`
+	Expires   time.Time `json:"expires"`
+}
+
+func newWaitlistJSON(w models.Waitlist, loc *time.Location) waitlistJSON {
+	resp := waitlistJSON{
+		Id:        w.Waitlistid,
+		Doctorid:  w.Doctorid,
+		Patientid: w.Patientid,
+		Day:       w.Day.Format(dateLayout),
+		Duration:  w.Duration.String(),
+		CreatedAt: w.CreatedAt.In(loc),
+	}
+	if w.Offer.Pending(time.Now()) {
+		resp.Offer = &offerJSON{Starttime: w.Offer.Starttime.In(loc), Expires: w.Offer.Expires.In(loc)}
+	}
+	return resp
+}
+
+type recordJSON struct {
+	Id          int       `json:"id"`
+	Patientid   int       `json:"patient_id"`
+	Doctorid    int       `json:"doctor_id"`
+	Nurseid     int       `json:"nurse_id"`
+	Date        time.Time `json:"date"`
+	Height      int       `json:"height"`
+	Bp          string    `json:"bp"`
+	HeartRate   int       `json:"heart_rate"`
+	Temperature int       `json:"temperature"`
+	Weight      string    `json:"weight"`
+	Additional  string    `json:"additional,omitempty"`
+}
+
+func newRecordJSON(r models.Patientrecords, loc *time.Location) recordJSON {
+	return recordJSON{
+		Id:          r.Recordid,
+		Patientid:   r.Patienid,
+		Doctorid:    r.Doctorid,
+		Nurseid:     r.Nurseid,
+		Date:        r.Date.In(loc),
+		Height:      r.Height,
+		Bp:          r.Bp,
+		HeartRate:   r.HeartRate,
+		Temperature: r.Temperature,
+		Weight:      r.Weight,
+		Additional:  r.Additional,
+	}
+}
+
+// patientInput is the request body used to create or replace a patient,
+// the password is optional on updates and keeps the current one when left out.
+type patientInput struct {
+	Username   string `json:"username"`
+	Full_name  string `json:"full_name"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	Dob        string `json:"dob"`
+	Contact    string `json:"contact"`
+	Bloodgroup string `json:"bloodgroup"`
+	About      string `json:"about"`
+	Ischild    bool   `json:"ischild"`
+	update     bool
+}
+
+func (p *patientInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	required(errs, "username", p.Username)
+	required(errs, "full_name", p.Full_name)
+	required(errs, "bloodgroup", p.Bloodgroup)
+	if err := validateEmail(p.Email); err != nil {
+		errs["email"] = "must be a valid email address"
+	}
+	if !checkinputregexformat(p.Contact, contactregex) {
+		errs["contact"] = "must be a valid phone number"
+	}
+	dob, err := time.Parse("2006-01-02", p.Dob)
+	if err != nil {
+		errs["dob"] = "must be a date in the format 2006-01-02"
+	} else if dob.After(time.Now()) {
+		errs["dob"] = "must not be in the future"
+	}
+	passwordlength(errs, p.Password, p.update)
+	return errs, len(errs) == 0
+}
+
+type physicianInput struct {
+	Username       string `json:"username"`
+	Full_name      string `json:"full_name"`
+	Email          string `json:"email"`
+	Password       string `json:"password"`
+	Contact        string `json:"contact"`
+	Departmentname string `json:"departmentname"`
+	About          string `json:"about"`
+	update         bool
+}
+
+func (p *physicianInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	required(errs, "username", p.Username)
+	required(errs, "full_name", p.Full_name)
+	required(errs, "departmentname", p.Departmentname)
+	if err := validateEmail(p.Email); err != nil {
+		errs["email"] = "must be a valid email address"
+	}
+	if !checkinputregexformat(p.Contact, contactregex) {
+		errs["contact"] = "must be a valid phone number"
+	}
+	passwordlength(errs, p.Password, p.update)
+	return errs, len(errs) == 0
+}
+
+type nurseInput struct {
+	Username  string `json:"username"`
+	Full_name string `json:"full_name"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	update    bool
+}
+
+func (n *nurseInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	required(errs, "username", n.Username)
+	required(errs, "full_name", n.Full_name)
+	if err := validateEmail(n.Email); err != nil {
+		errs["email"] = "must be a valid email address"
+	}
+	passwordlength(errs, n.Password, n.update)
+	return errs, len(errs) == 0
+}
+
+type departmentInput struct {
+	Departmentname string `json:"departmentname"`
+	// the allowed appointment durations,an update leaves them as they are when omitted
+	Durations []string `json:"durations"`
+}
+
+func (d *departmentInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	required(errs, "departmentname", d.Departmentname)
+	for _, duration := range d.Durations {
+		if !checkinputregexformat(duration, durationregex) || parseduration(duration) <= 0 || parseduration(duration)%time.Minute != 0 {
+			errs["durations"] = "must be whole minute durations such as 30m or 1h"
+		}
+	}
+	return errs, len(errs) == 0
+}
+
+func (d *departmentInput) durations() []time.Duration {
+	if d.Durations == nil {
+		return nil
+	}
+	durations := make([]time.Duration, 0, len(d.Durations))
+	for _, duration := range d.Durations {
+		durations = append(durations, parseduration(duration))
+	}
+	return durations
+}
+
+// the weekdays by their lowercase names
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+type scheduleInput struct {
+	Doctorid  int    `json:"doctor_id"`
+	Starttime string `json:"starttime"`
+	Endtime   string `json:"endtime"`
+	Active    bool   `json:"active"`
+	// the daily hours may be left out when there are blocks
+	Blocks     []blockJSON `json:"blocks"`
+	Validfrom  string      `json:"valid_from"`
+	Validuntil string      `json:"valid_until"`
+}
+
+func (s *scheduleInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if s.Doctorid < 1 {
+		errs["doctor_id"] = "must be provided"
+	}
+	if len(s.Blocks) == 0 || s.Starttime != "" || s.Endtime != "" {
+		start, err := time.Parse(models.ClockLayout, s.Starttime)
+		if err != nil {
+			errs["starttime"] = "must be a time in the format 15:04"
+		}
+		end, err := time.Parse(models.ClockLayout, s.Endtime)
+		if err != nil {
+			errs["endtime"] = "must be a time in the format 15:04"
+		}
+		if len(errs) == 0 && !end.After(start) {
+			errs["endtime"] = "must be after the starttime"
+		}
+	}
+	for _, block := range s.Blocks {
+		if _, ok := weekdays[block.Weekday]; !ok {
+			errs["blocks"] = "weekday must be the lowercase name of a day such as monday"
+		}
+	}
+	if _, err := parsedate(s.Validfrom); err != nil {
+		errs["valid_from"] = "must be a date in the format 2006-01-02"
+	}
+	if _, err := parsedate(s.Validuntil); err != nil {
+		errs["valid_until"] = "must be a date in the format 2006-01-02"
+	}
+	return errs, len(errs) == 0
+}
+
+// schedule converts the validated input,the service checks the hours of the blocks
+func (s *scheduleInput) schedule(id int) models.Schedule {
+	schedule := models.Schedule{
+		Scheduleid: id,
+		Doctorid:   s.Doctorid,
+		Starttime:  s.Starttime,
+		Endtime:    s.Endtime,
+		Active:     s.Active,
+	}
+	schedule.Validfrom, _ = parsedate(s.Validfrom)
+	schedule.Validuntil, _ = parsedate(s.Validuntil)
+	for _, block := range s.Blocks {
+		schedule.Blocks = append(schedule.Blocks, models.Block{
+			Weekday:   weekdays[block.Weekday],
+			Starttime: block.Starttime,
+			Endtime:   block.Endtime,
+		})
+	}
+	return schedule
+}
+
+// parsedate parses an optional day,the zero time when it's empty
+func parsedate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dateLayout, value)
+}
+
+// readSlotSearch reads the from, to & duration query parameters of a slot search,
+// from defaults to now & to a week after from. A bare date is the midnight of loc.
+func readSlotSearch(r *http.Request, loc *time.Location) (time.Time, time.Time, time.Duration, Errors) {
+	errs := make(Errors)
+	qs := r.URL.Query()
+	from := time.Now().UTC().Truncate(time.Minute)
+	if value := qs.Get("from"); value != "" {
+		t, err := parsetimeordate(value, loc)
+		if err != nil {
+			errs["from"] = "must be a date in the format 2006-01-02 or an RFC3339 time"
+		}
+		from = t
+	}
+	to := from.Add(slotsearch)
+	if value := qs.Get("to"); value != "" {
+		t, err := parsetimeordate(value, loc)
+		if err != nil {
+			errs["to"] = "must be a date in the format 2006-01-02 or an RFC3339 time"
+		}
+		to = t
+	}
+	var duration time.Duration
+	if value := qs.Get("duration"); value != "" {
+		duration = parseduration(value)
+		if duration <= 0 {
+			errs["duration"] = "must be a duration such as 30m or 1h"
+		}
+	}
+	return from, to, duration, errs
+}
+
+func parsetimeordate(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(dateLayout, value, loc); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+type exceptionInput struct {
+	Doctorid  int       `json:"doctor_id"`
+	Starttime time.Time `json:"starttime"`
+	Endtime   time.Time `json:"endtime"`
+	Reason    string    `json:"reason"`
+}
+
+func (e *exceptionInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if e.Doctorid < 1 {
+		errs["doctor_id"] = "must be provided"
+	}
+	if e.Starttime.IsZero() {
+		errs["starttime"] = "must be provided"
+	}
+	if !e.Endtime.After(e.Starttime) {
+		errs["endtime"] = "must be after the starttime"
+	}
+	return errs, len(errs) == 0
+}
+
+type transitionInput struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+func (t *transitionInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if !models.Status(t.Status).Valid() {
+		errs["status"] = "must be one of requested, approved, rescheduled, cancelled, completed or no_show"
+	}
+	return errs, len(errs) == 0
+}
+
+// waitlistInput is the day to wait for in the clinic's zone,
+// the duration defaults to the shortest the doctor's department offers
+type waitlistInput struct {
+	Doctorid int    `json:"doctor_id"`
+	Day      string `json:"day"`
+	Duration string `json:"duration"`
+}
+
+func (i *waitlistInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if i.Doctorid < 1 {
+		errs["doctor_id"] = "must be provided"
+	}
+	if _, err := time.Parse(dateLayout, i.Day); err != nil {
+		errs["day"] = "must be a date in the format 2006-01-02"
+	}
+	if i.Duration != "" && (!checkinputregexformat(i.Duration, durationregex) || parseduration(i.Duration) <= 0) {
+		errs["duration"] = "must be a duration such as 30m or 1h"
+	}
+	return errs, len(errs) == 0
+}
+
+type appointmentInput struct {
+	Doctorid        int       `json:"doctor_id"`
+	Patientid       int       `json:"patient_id"`
+	Appointmentdate time.Time `json:"appointment_date"`
+	Duration        string    `json:"duration"`
+	// Approval books the appointment approved,an update can't change the status
+	Approval bool `json:"approval"`
+	Outbound bool `json:"outbound"`
+}
+
+func (a *appointmentInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if a.Doctorid < 1 {
+		errs["doctor_id"] = "must be provided"
+	}
+	if a.Patientid < 1 {
+		errs["patient_id"] = "must be provided"
+	}
+	if a.Appointmentdate.IsZero() {
+		errs["appointment_date"] = "must be provided"
+	} else if a.Appointmentdate.Before(time.Now()) {
+		errs["appointment_date"] = "must not be in the past"
+	}
+	if !checkinputregexformat(a.Duration, durationregex) || parseduration(a.Duration) <= 0 {
+		errs["duration"] = "must be a duration such as 1h or 1h30m"
+	}
+	return errs, len(errs) == 0
+}
+
+// seriesInput repeats the appointment starting at start every interval days or weeks,
+// either count times or until the day of until in the format 2006-01-02
+type seriesInput struct {
+	Doctorid  int       `json:"doctor_id"`
+	Patientid int       `json:"patient_id"`
+	Start     time.Time `json:"start"`
+	Duration  string    `json:"duration"`
+	Interval  int       `json:"interval"`
+	Unit      string    `json:"unit"`
+	Count     int       `json:"count"`
+	Until     string    `json:"until"`
+}
+
+func (s *seriesInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if s.Doctorid < 1 {
+		errs["doctor_id"] = "must be provided"
+	}
+	if s.Patientid < 1 {
+		errs["patient_id"] = "must be provided"
+	}
+	if s.Start.IsZero() {
+		errs["start"] = "must be provided"
+	} else if s.Start.Before(time.Now()) {
+		errs["start"] = "must not be in the past"
+	}
+	if !checkinputregexformat(s.Duration, durationregex) || parseduration(s.Duration) <= 0 {
+		errs["duration"] = "must be a duration such as 1h or 1h30m"
+	}
+	if s.Interval < 1 {
+		errs["interval"] = "must be a positive integer"
+	}
+	if s.Unit != string(models.UnitDay) && s.Unit != string(models.UnitWeek) {
+		errs["unit"] = "must be either day or week"
+	}
+	if until, err := parsedate(s.Until); err != nil {
+		errs["until"] = "must be a date in the format 2006-01-02"
+	} else if s.Until != "" && until.Before(time.Date(s.Start.Year(), s.Start.Month(), s.Start.Day(), 0, 0, 0, 0, time.UTC)) {
+		errs["until"] = "must not be before the day of start"
+	} else if (s.Count == 0) == (s.Until == "") {
+		errs["count"] = "either count or until must be provided"
+	} else if s.Count < 0 || s.Count > models.MaxOccurrences {
+		errs["count"] = fmt.Sprintf("must be between 1 and %d", models.MaxOccurrences)
+	}
+	return errs, len(errs) == 0
+}
+
+// seriesUpdateInput moves the upcoming occurrences of a series,the recurrence can't change
+type seriesUpdateInput struct {
+	Start    time.Time `json:"start"`
+	Duration string    `json:"duration"`
+}
+
+func (s *seriesUpdateInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if s.Start.IsZero() {
+		errs["start"] = "must be provided"
+	}
+	if !checkinputregexformat(s.Duration, durationregex) || parseduration(s.Duration) <= 0 {
+		errs["duration"] = "must be a duration such as 1h or 1h30m"
+	}
+	return errs, len(errs) == 0
+}
+
+type recordInput struct {
+	Patientid   int    `json:"patient_id"`
+	Doctorid    int    `json:"doctor_id"`
+	Nurseid     int    `json:"nurse_id"`
+	Height      int    `json:"height"`
+	Bp          string `json:"bp"`
+	HeartRate   int    `json:"heart_rate"`
+	Temperature int    `json:"temperature"`
+	Weight      string `json:"weight"`
+	Additional  string `json:"additional"`
+}
+
+func (d *recordInput) validate() (Errors, bool) {
+	errs := make(Errors)
+	if d.Patientid < 1 {
+		errs["patient_id"] = "must be provided"
+	}
+	if d.Doctorid < 1 {
+		errs["doctor_id"] = "must be provided"
+	}
+	if d.Nurseid < 1 {
+		errs["nurse_id"] = "must be provided"
+	}
+	if d.Height <= 0 {
+		errs["height"] = "must be greater than zero"
+	}
+	if d.HeartRate <= 0 {
+		errs["heart_rate"] = "must be greater than zero"
+	}
+	if d.Temperature <= 0 {
+		errs["temperature"] = "must be greater than zero"
+	}
+	if !checkinputregexformat(d.Weight, weightregex) {
+		errs["weight"] = "must be in kgs or lbs e.g 60kgs"
+	}
+	if !checkinputregexformat(d.Bp, bpregex) {
+		errs["bp"] = "must be in the format 120/80"
+	}
+	return errs, len(errs) == 0
+}
+
+func (d recordInput) model() models.Patientrecords {
+	return models.Patientrecords{
+		Patienid:    d.Patientid,
+		Doctorid:    d.Doctorid,
+		Nurseid:     d.Nurseid,
+		Height:      d.Height,
+		Bp:          d.Bp,
+		HeartRate:   d.HeartRate,
+		Temperature: d.Temperature,
+		Weight:      d.Weight,
+		Additional:  d.Additional,
+	}
+}
+
+func required(errs Errors, field, value string) {
+	if value == "" {
+		errs[field] = "must be provided"
+	}
+}
+
+func passwordlength(errs Errors, password string, update bool) {
+	if update && password == "" {
+		return
+	}
+	if len([]rune(password)) < 6 {
+		errs["password"] = "must be at least six characters long"
+	}
+}
+
+// decodeAndValidate reads the request body into input and validates it,
+// it writes the error response itself and reports whether the handler can carry on.
+func (server *Server) decodeAndValidate(w http.ResponseWriter, r *http.Request, input validation) bool {
+	if err := server.readJSON(w, r, input); err != nil {
+		server.badRequestJSON(w, r, err)
+		return false
+	}
+	if errs, ok := validateType(input); !ok {
+		server.failedValidationJSON(w, r, errs)
+		return false
+	}
+	return true
+}
+
+func (server *Server) listPatientsJSON(w http.ResponseWriter, r *http.Request) {
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	patients, metadata, err := server.Services.PatientService.Filter(r.Context(), r.URL.Query().Get("name"), filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]patientJSON, 0, len(patients))
+	for _, patient := range patients {
+		resp = append(resp, newPatientJSON(*patient))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"patients": resp, "metadata": metadata})
+}
+
+func (server *Server) showPatientJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	patient, err := server.Services.PatientService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"patient": newPatientJSON(patient)})
+}
+
+func (server *Server) createPatientJSON(w http.ResponseWriter, r *http.Request) {
+	var input patientInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	dob, _ := time.Parse("2006-01-02", input.Dob)
+	hashed_password, err := services.HashPassword(input.Password)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	patient, err := server.Services.PatientService.Create(r.Context(), models.Patient{
+		Username:        input.Username,
+		Full_name:       input.Full_name,
+		Email:           input.Email,
+		Dob:             dob,
+		Contact:         input.Contact,
+		Bloodgroup:      input.Bloodgroup,
+		About:           input.About,
+		Ischild:         input.Ischild,
+		Hashed_password: hashed_password,
+		Created_at:      time.Now(),
+	})
+	if err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"patient": newPatientJSON(patient)})
+}
+
+func (server *Server) updatePatientJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	patient, err := server.Services.PatientService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	input := patientInput{update: true}
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	if input.Password != "" {
+		if patient.Hashed_password, err = services.HashPassword(input.Password); err != nil {
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		patient.Password_change_at = time.Now()
+	}
+	patient.Dob, _ = time.Parse("2006-01-02", input.Dob)
+	patient.Username = input.Username
+	patient.Full_name = input.Full_name
+	patient.Email = input.Email
+	patient.Contact = input.Contact
+	patient.Bloodgroup = input.Bloodgroup
+	patient.About = input.About
+	patient.Ischild = input.Ischild
+	if _, err := server.Services.PatientService.Update(r.Context(), patient); err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"patient": newPatientJSON(patient)})
+}
+
+func (server *Server) deletePatientJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.PatientService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if err := server.Services.PatientService.Delete(r.Context(), id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "patient deleted successfully"})
+}
+
+func (server *Server) listPatientAppointmentsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.PatientService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	appointments, err := server.Services.AppointmentService.FindAllByPatient(r.Context(), id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointments": appointmentsJSON(appointments, server.viewerzone(r))})
+}
+
+func (server *Server) listPatientRecordsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.PatientService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	records, err := server.Services.PatientRecordService.FindAllByPatient(r.Context(), id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"records": recordsJSON(records, server.viewerzone(r))})
+}
+
+func (server *Server) listPhysiciansJSON(w http.ResponseWriter, r *http.Request) {
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	qs := r.URL.Query()
+	doctors, metadata, err := server.Services.DoctorService.Filter(r.Context(), qs.Get("name"), qs.Get("dept"), filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]physicianJSON, 0, len(doctors))
+	for _, doctor := range doctors {
+		resp = append(resp, newPhysicianJSON(*doctor))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"physicians": resp, "metadata": metadata})
+}
+
+func (server *Server) showPhysicianJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	doctor, err := server.Services.DoctorService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"physician": newPhysicianJSON(doctor)})
+}
+
+func (server *Server) createPhysicianJSON(w http.ResponseWriter, r *http.Request) {
+	var input physicianInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	hashed_password, err := services.HashPassword(input.Password)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	doctor, err := server.Services.DoctorService.Create(r.Context(), models.Physician{
+		Username:        input.Username,
+		Full_name:       input.Full_name,
+		Email:           input.Email,
+		Contact:         input.Contact,
+		Departmentname:  input.Departmentname,
+		About:           input.About,
+		Hashed_password: hashed_password,
+		Created_at:      time.Now(),
+	})
+	if err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"physician": newPhysicianJSON(doctor)})
+}
+
+func (server *Server) updatePhysicianJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	doctor, err := server.Services.DoctorService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	input := physicianInput{update: true}
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	if input.Password != "" {
+		if doctor.Hashed_password, err = services.HashPassword(input.Password); err != nil {
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		doctor.Password_changed_at = time.Now()
+	}
+	doctor.Username = input.Username
+	doctor.Full_name = input.Full_name
+	doctor.Email = input.Email
+	doctor.Contact = input.Contact
+	doctor.Departmentname = input.Departmentname
+	doctor.About = input.About
+	if _, err := server.Services.DoctorService.Update(r.Context(), doctor); err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"physician": newPhysicianJSON(doctor)})
+}
+
+func (server *Server) deletePhysicianJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.DoctorService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if err := server.Services.DoctorService.Delete(r.Context(), id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "physician deleted successfully"})
+}
+
+func (server *Server) listPhysicianAppointmentsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.DoctorService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	appointments, err := server.Services.AppointmentService.FindAllByDoctor(r.Context(), id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointments": appointmentsJSON(appointments, server.viewerzone(r))})
+}
+
+func (server *Server) listPhysicianSchedulesJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.DoctorService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	schedules, err := server.Services.ScheduleService.FindbyDoctor(r.Context(), id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]scheduleJSON, 0, len(schedules))
+	for _, schedule := range schedules {
+		resp = append(resp, newScheduleJSON(schedule))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"schedules": resp})
+}
+
+func (server *Server) listNursesJSON(w http.ResponseWriter, r *http.Request) {
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	nurses, metadata, err := server.Services.NurseService.Filter(r.Context(), r.URL.Query().Get("name"), filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]nurseJSON, 0, len(nurses))
+	for _, nurse := range nurses {
+		resp = append(resp, newNurseJSON(*nurse))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"nurses": resp, "metadata": metadata})
+}
+
+func (server *Server) showNurseJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	nurse, err := server.Services.NurseService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"nurse": newNurseJSON(nurse)})
+}
+
+func (server *Server) createNurseJSON(w http.ResponseWriter, r *http.Request) {
+	var input nurseInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	hashed_password, err := services.HashPassword(input.Password)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	nurse, err := server.Services.NurseService.Create(r.Context(), models.Nurse{
+		Username:        input.Username,
+		Full_name:       input.Full_name,
+		Email:           input.Email,
+		Hashed_password: hashed_password,
+		Created_at:      time.Now(),
+	})
+	if err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"nurse": newNurseJSON(nurse)})
+}
+
+func (server *Server) updateNurseJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	nurse, err := server.Services.NurseService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	input := nurseInput{update: true}
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	if input.Password != "" {
+		if nurse.Hashed_password, err = services.HashPassword(input.Password); err != nil {
+			server.serverErrorJSON(w, r, err)
+			return
+		}
+		nurse.Password_changed_at = time.Now()
+	}
+	nurse.Username = input.Username
+	nurse.Full_name = input.Full_name
+	nurse.Email = input.Email
+	if _, err := server.Services.NurseService.Update(r.Context(), nurse); err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"nurse": newNurseJSON(nurse)})
+}
+
+func (server *Server) deleteNurseJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.NurseService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if err := server.Services.NurseService.Delete(r.Context(), id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "nurse deleted successfully"})
+}
+
+func (server *Server) listDepartmentsJSON(w http.ResponseWriter, r *http.Request) {
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	departments, metadata, err := server.Services.DepartmentService.FindAll(r.Context(), filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]departmentJSON, 0, len(departments))
+	for _, department := range departments {
+		resp = append(resp, newDepartmentJSON(department))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"departments": resp, "metadata": metadata})
+}
+
+func (server *Server) showDepartmentJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	department, err := server.Services.DepartmentService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"department": newDepartmentJSON(department)})
+}
+
+func (server *Server) createDepartmentJSON(w http.ResponseWriter, r *http.Request) {
+	var input departmentInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	department, err := server.Services.DepartmentService.Create(r.Context(), models.Department{Departmentname: input.Departmentname, Durations: input.durations()})
+	if err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"department": newDepartmentJSON(department)})
+}
+
+func (server *Server) updateDepartmentJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	department, err := server.Services.DepartmentService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	var input departmentInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	department.Departmentname = input.Departmentname
+	if input.Durations != nil {
+		department.Durations = input.durations()
+	}
+	department, err = server.Services.DepartmentService.Update(r.Context(), department)
+	if err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"department": newDepartmentJSON(department)})
+}
+
+func (server *Server) deleteDepartmentJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.DepartmentService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if err := server.Services.DepartmentService.Delete(r.Context(), id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "department deleted successfully"})
+}
+
+func (server *Server) listDepartmentPhysiciansJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	department, err := server.Services.DepartmentService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	doctors, metadata, err := server.Services.DoctorService.FindDoctorsbyDept(r.Context(), department.Departmentname, filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]physicianJSON, 0, len(doctors))
+	for _, doctor := range doctors {
+		resp = append(resp, newPhysicianJSON(doctor))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"physicians": resp, "metadata": metadata})
+}
+
+func (server *Server) listSchedulesJSON(w http.ResponseWriter, r *http.Request) {
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	schedules, metadata, err := server.Services.ScheduleService.FindAll(r.Context(), filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]scheduleJSON, 0, len(schedules))
+	for _, schedule := range schedules {
+		resp = append(resp, newScheduleJSON(schedule))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"schedules": resp, "metadata": metadata})
+}
+
+func (server *Server) showScheduleJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	schedule, err := server.Services.ScheduleService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"schedule": newScheduleJSON(schedule)})
+}
+
+func (server *Server) createScheduleJSON(w http.ResponseWriter, r *http.Request) {
+	var input scheduleInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	schedule, err := server.Services.MakeSchedule(r.Context(), input.schedule(0))
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"schedule": newScheduleJSON(schedule)})
+}
+
+func (server *Server) updateScheduleJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.ScheduleService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	var input scheduleInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	schedule, err := server.Services.UpdateSchedule(r.Context(), input.schedule(id))
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"schedule": newScheduleJSON(schedule)})
+}
+
+func (server *Server) deleteScheduleJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.ScheduleService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if err := server.Services.ScheduleService.Delete(r.Context(), id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "schedule deleted successfully"})
+}
+
+func (server *Server) listPhysicianExceptionsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.DoctorService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	exceptions, err := server.Services.ExceptionService.FindbyDoctor(r.Context(), id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]exceptionJSON, 0, len(exceptions))
+	for _, exception := range exceptions {
+		resp = append(resp, newExceptionJSON(exception, server.viewerzone(r)))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"exceptions": resp})
+}
+
+func (server *Server) listPhysicianSlotsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	from, to, duration, errs := readSlotSearch(r, server.viewerzone(r))
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	slots, err := server.Services.FreeSlots(r.Context(), id, from, to, duration)
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"slots": slotsJSON(slots, server.viewerzone(r))})
+}
+
+func (server *Server) listDepartmentSlotsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	from, to, duration, errs := readSlotSearch(r, server.viewerzone(r))
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	slots, err := server.Services.DepartmentFreeSlots(r.Context(), id, from, to, duration)
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"slots": slotsJSON(slots, server.viewerzone(r))})
+}
+
+func (server *Server) showExceptionJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	exception, err := server.Services.ExceptionService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"exception": newExceptionJSON(exception, server.viewerzone(r))})
+}
+
+func (server *Server) createExceptionJSON(w http.ResponseWriter, r *http.Request) {
+	var input exceptionInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	exception, err := server.Services.MakeScheduleException(r.Context(), models.ScheduleException{
+		Doctorid:  input.Doctorid,
+		Starttime: input.Starttime,
+		Endtime:   input.Endtime,
+		Reason:    input.Reason,
+	})
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"exception": newExceptionJSON(exception, server.viewerzone(r))})
+}
+
+func (server *Server) deleteExceptionJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.ExceptionService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if err := server.Services.ExceptionService.Delete(r.Context(), id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "exception deleted successfully"})
+}
+
+func appointmentsJSON(appointments []models.Appointment, loc *time.Location) []appointmentJSON {
+	resp := make([]appointmentJSON, 0, len(appointments))
+	for _, appointment := range appointments {
+		resp = append(resp, newAppointmentJSON(appointment, loc))
+	}
+	return resp
+}
+
+func (server *Server) listAppointmentsJSON(w http.ResponseWriter, r *http.Request) {
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	appointments, metadata, err := server.Services.AppointmentService.FindAll(r.Context(), filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointments": appointmentsJSON(appointments, server.viewerzone(r)), "metadata": metadata})
+}
+
+func (server *Server) showAppointmentJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	appointment, err := server.Services.AppointmentService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointment": newAppointmentJSON(appointment, server.viewerzone(r))})
+}
+
+func (server *Server) createAppointmentJSON(w http.ResponseWriter, r *http.Request) {
+	var input appointmentInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	status := models.StatusRequested
+	if input.Approval {
+		status = models.StatusApproved
+	}
+	appointment, err := server.Services.DoctorBookAppointment(r.Context(), models.Appointment{
+		Doctorid:        input.Doctorid,
+		Patientid:       input.Patientid,
+		Appointmentdate: input.Appointmentdate,
+		Duration:        parseduration(input.Duration),
+		Status:          status,
+		Outbound:        input.Outbound,
+	})
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"appointment": newAppointmentJSON(appointment, server.viewerzone(r))})
+}
+
+func (server *Server) updateAppointmentJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.AppointmentService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	var input appointmentInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	account, _ := contextGetAccount(r)
+	appointment, err := server.Services.UpdateappointmentbyDoctor(r.Context(), models.Appointment{
+		Appointmentid:   id,
+		Doctorid:        input.Doctorid,
+		Patientid:       input.Patientid,
+		Appointmentdate: input.Appointmentdate,
+		Duration:        parseduration(input.Duration),
+		Outbound:        input.Outbound,
+	}, account.actor())
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointment": newAppointmentJSON(appointment, server.viewerzone(r))})
+}
+
+func (server *Server) deleteAppointmentJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if err := server.Services.DeleteAppointment(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "appointment deleted successfully"})
+}
+
+// transitionAppointmentJSON moves the appointment along its lifecycle on behalf of the account
+func (server *Server) transitionAppointmentJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	var input transitionInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	account, _ := contextGetAccount(r)
+	appointment, err := server.Services.TransitionAppointment(r.Context(), id, models.Status(input.Status), account.actor(), input.Reason)
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointment": newAppointmentJSON(appointment, server.viewerzone(r))})
+}
+
+func (server *Server) listAppointmentTransitionsJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.AppointmentService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	transitions, err := server.Services.TransitionService.FindbyAppointment(r.Context(), id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	resp := make([]transitionJSON, 0, len(transitions))
+	for _, transition := range transitions {
+		resp = append(resp, newTransitionJSON(transition, server.viewerzone(r)))
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"transitions": resp})
+}
+
+func (server *Server) createSeriesJSON(w http.ResponseWriter, r *http.Request) {
+	var input seriesInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	until, _ := parsedate(input.Until)
+	series, appointments, err := server.Services.BookSeries(r.Context(), models.Series{
+		Doctorid:   input.Doctorid,
+		Patientid:  input.Patientid,
+		Start:      input.Start,
+		Duration:   parseduration(input.Duration),
+		Recurrence: models.Recurrence{Interval: input.Interval, Unit: models.Unit(input.Unit), Count: input.Count, Until: until},
+	})
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{
+		"series":       newSeriesJSON(series, server.viewerzone(r)),
+		"appointments": appointmentsJSON(appointments, server.viewerzone(r)),
+	})
+}
+
+// showSeriesJSON writes the series with all its occurrences,past & cancelled ones included
+func (server *Server) showSeriesJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	series, err := server.Services.SeriesService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	appointments, err := server.Services.AppointmentService.FindAllBySeries(r.Context(), id)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{
+		"series":       newSeriesJSON(series, server.viewerzone(r)),
+		"appointments": appointmentsJSON(appointments, server.viewerzone(r)),
+	})
+}
+
+// updateSeriesJSON moves the upcoming occurrences of the series,the moved ones are written back
+func (server *Server) updateSeriesJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	var input seriesUpdateInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	account, _ := contextGetAccount(r)
+	series, moved, err := server.Services.UpdateSeries(r.Context(), models.Series{
+		Seriesid: id,
+		Start:    input.Start,
+		Duration: parseduration(input.Duration),
+	}, account.actor())
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{
+		"series":       newSeriesJSON(series, server.viewerzone(r)),
+		"appointments": appointmentsJSON(moved, server.viewerzone(r)),
+	})
+}
+
+// cancelSeriesJSON cancels the upcoming occurrences of the series,the cancelled ones are written back
+func (server *Server) cancelSeriesJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := server.readJSON(w, r, &input); err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	account, _ := contextGetAccount(r)
+	cancelled, err := server.Services.CancelSeries(r.Context(), id, account.actor(), input.Reason)
+	if err != nil {
+		server.serviceErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"appointments": appointmentsJSON(cancelled, server.viewerzone(r))})
+}
+
+func recordsJSON(records []models.Patientrecords, loc *time.Location) []recordJSON {
+	resp := make([]recordJSON, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, newRecordJSON(record, loc))
+	}
+	return resp
+}
+
+func (server *Server) listRecordsJSON(w http.ResponseWriter, r *http.Request) {
+	filters, errs := readFilters(r)
+	if len(errs) > 0 {
+		server.failedValidationJSON(w, r, errs)
+		return
+	}
+	records, metadata, err := server.Services.PatientRecordService.FindAll(r.Context(), filters)
+	if err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"records": recordsJSON(records, server.viewerzone(r)), "metadata": metadata})
+}
+
+func (server *Server) showRecordJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	record, err := server.Services.PatientRecordService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"record": newRecordJSON(record, server.viewerzone(r))})
+}
+
+func (server *Server) createRecordJSON(w http.ResponseWriter, r *http.Request) {
+	var input recordInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	record := input.model()
+	record.Date = time.Now()
+	record, err := server.Services.CreateRecord(r.Context(), record)
+	if err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusCreated, envelope{"record": newRecordJSON(record, server.viewerzone(r))})
+}
+
+func (server *Server) updateRecordJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	existing, err := server.Services.PatientRecordService.Find(r.Context(), id)
+	if err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	var input recordInput
+	if ok := server.decodeAndValidate(w, r, &input); !ok {
+		return
+	}
+	record := input.model()
+	record.Recordid = id
+	record.Date = existing.Date
+	record, err = server.Services.PatientRecordService.Update(r.Context(), record)
+	if err != nil {
+		server.badRequestJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"record": newRecordJSON(record, server.viewerzone(r))})
+}
+
+func (server *Server) deleteRecordJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		server.notFoundJSON(w, r)
+		return
+	}
+	if _, err := server.Services.PatientRecordService.Find(r.Context(), id); err != nil {
+		server.lookupErrorJSON(w, r, err)
+		return
+	}
+	if err := server.Services.PatientRecordService.Delete(r.Context(), id); err != nil {
+		server.serverErrorJSON(w, r, err)
+		return
+	}
+	server.writeJSON(w, r, http.StatusOK, envelope{"message": "record deleted successfully"})
+}
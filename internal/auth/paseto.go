@@ -39,6 +39,18 @@ func (p *Paseto) CreateToken(username string, duration time.Duration) (string, e
 	return p.paseto.Encrypt(p.symmetrickey, payload, nil)
 }
 
+func (p *Paseto) CreateAccountToken(claims Claims, duration time.Duration) (string, *TokenPayload, error) {
+	payload, err := AccountPayload(claims, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	token, err := p.paseto.Encrypt(p.symmetrickey, payload, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
+}
+
 func (p *Paseto) VerifyToken(token string) (*TokenPayload, error) {
 	payload := &TokenPayload{}
 	err := p.paseto.Decrypt(token, p.symmetrickey, payload, nil)
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/patienttracker/internal/utils"
 	"github.com/stretchr/testify/require"
 	//"golang.org/x/tools/godoc/util"
@@ -37,3 +38,30 @@ func TestVerifyPasetoToken(t *testing.T) {
 	require.Empty(t, payload)
 
 }
+
+func TestCreateAccountToken(t *testing.T) {
+	token, err := PasetoMaker(utils.RandString(32))
+	require.NoError(t, err)
+	username := utils.RandString(6)
+	sessionid := uuid.New()
+	accesstoken, payload, err := token.CreateAccountToken(Claims{
+		Username:    username,
+		AccountType: AccountNurse,
+		AccountID:   7,
+		Kind:        AccessToken,
+		SessionID:   sessionid,
+	}, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, accesstoken)
+	require.NotEqual(t, uuid.Nil, payload.ID)
+	require.Equal(t, AccountNurse, payload.AccountType)
+
+	verified, err := token.VerifyToken(accesstoken)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, username, verified.Username)
+	require.Equal(t, AccountNurse, verified.AccountType)
+	require.Equal(t, 7, verified.AccountID)
+	require.Equal(t, AccessToken, verified.Kind)
+	require.Equal(t, sessionid, verified.SessionID)
+}
@@ -8,11 +8,41 @@ import (
 	"github.com/google/uuid"
 )
 
+// the kinds of accounts a token can be issued to
+const (
+	AccountPatient   = "patient"
+	AccountPhysician = "physician"
+	AccountNurse     = "nurse"
+	AccountAdmin     = "admin"
+)
+
+// the kinds of account tokens,only access tokens are accepted as bearer tokens
+// while refresh tokens can only be exchanged for a new pair of tokens.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
 type TokenPayload struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiredAt time.Time `json:"expired_at"`
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	AccountType string    `json:"account_type,omitempty"`
+	AccountID   int       `json:"account_id,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	SessionID   uuid.UUID `json:"session_id,omitempty"`
+	IssuedAt    time.Time `json:"issued_at"`
+	ExpiredAt   time.Time `json:"expired_at"`
+}
+
+// Claims describe the account and server side session a token is issued for,
+// ID is generated when left empty.
+type Claims struct {
+	ID          uuid.UUID
+	Username    string
+	AccountType string
+	AccountID   int
+	Kind        string
+	SessionID   uuid.UUID
 }
 
 func Payload(username string, duration time.Duration) (*TokenPayload, error) {
@@ -26,6 +56,22 @@ func Payload(username string, duration time.Duration) (*TokenPayload, error) {
 	return payload, nil
 }
 
+// AccountPayload is a payload that identifies the account the token was issued to
+func AccountPayload(claims Claims, duration time.Duration) (*TokenPayload, error) {
+	payload, err := Payload(claims.Username, duration)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID != uuid.Nil {
+		payload.ID = claims.ID
+	}
+	payload.AccountType = claims.AccountType
+	payload.AccountID = claims.AccountID
+	payload.Kind = claims.Kind
+	payload.SessionID = claims.SessionID
+	return payload, nil
+}
+
 func (payload *TokenPayload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrExpiredToken
@@ -5,5 +5,7 @@ import "time"
 type Token interface {
 	CreateToken(username string, duration time.Duration) (string, error)
 
+	CreateAccountToken(claims Claims, duration time.Duration) (string, *TokenPayload, error)
+
 	VerifyToken(token string) (*TokenPayload, error)
 }
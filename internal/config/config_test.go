@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writefile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestDefaults(t *testing.T) {
+	c := Defaults()
+	require.NoError(t, c.Validate())
+	require.Equal(t, Development, c.Env)
+	require.Equal(t, "localhost:9000", c.Addr)
+	require.Equal(t, 15*time.Minute, c.Token.AccessDuration)
+	require.Equal(t, 25, c.Smtp.Port)
+	require.Equal(t, 5*time.Second, c.Database.QueryTimeout)
+	require.Equal(t, time.UTC, c.Clinic.Location)
+	require.Equal(t, 2*time.Hour, c.Clinic.OfferDuration)
+	require.Equal(t, []time.Duration{24 * time.Hour, time.Hour}, c.Reminder.Offsets)
+	require.Equal(t, 20*time.Second, c.Reminder.Interval)
+	require.Equal(t, []time.Duration{0, 10 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour}, c.Triage.Targets)
+	require.Equal(t, 5*time.Second, c.Outbox.Interval)
+	require.Equal(t, 10, c.Outbox.MaxAttempts)
+	require.Equal(t, 10, c.Worker.Workers)
+	require.Equal(t, 100, c.Worker.QueueSize)
+	require.Equal(t, 5, c.Worker.MaxAttempts)
+}
+
+func TestLoad(t *testing.T) {
+	authkey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("a"), 64))
+	path := writefile(t, `
+# comment
+POSTGRES_URI="postgresql://file"
+HTTP_ADDR=localhost:8000
+export SMTP_PORT=587
+CLINIC_TIMEZONE=Africa/Nairobi
+REMINDER_OFFSETS=48h, 2h,30m
+SESSION_AUTH_KEY='`+authkey+`'
+`)
+	t.Setenv("HTTP_ADDR", "0.0.0.0:9000")
+	c, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "postgresql://file", c.Database.URI)
+	// the environment wins over the file
+	require.Equal(t, "0.0.0.0:9000", c.Addr)
+	require.Equal(t, 587, c.Smtp.Port)
+	require.Len(t, c.Session.AuthKey, 64)
+	require.Equal(t, "Africa/Nairobi", c.Clinic.Location.String())
+	require.Equal(t, []time.Duration{48 * time.Hour, 2 * time.Hour, 30 * time.Minute}, c.Reminder.Offsets)
+
+	// a missing file is not an error
+	_, err = Load(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	testcases := []struct {
+		name    string
+		content string
+	}{
+		{"malformed line", "POSTGRES_URI"},
+		{"integer", "SMTP_PORT=abc"},
+		{"duration", "ACCESS_TOKEN_DURATION=15"},
+		{"query timeout", "POSTGRES_QUERY_TIMEOUT=-1s"},
+		{"base64", "SESSION_AUTH_KEY=not base64!"},
+		{"key size", "SESSION_AUTH_KEY=" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		{"session store", "SESSION_STORE=memcached"},
+		{"base url", "BASE_URL=localhost:9000"},
+		{"token durations", "ACCESS_TOKEN_DURATION=24h\nREFRESH_TOKEN_DURATION=1h"},
+		{"production secrets", "APP_ENV=production"},
+		{"time zone", "CLINIC_TIMEZONE=Mars/Olympus_Mons"},
+		{"empty time zone", "CLINIC_TIMEZONE="},
+		{"offer duration", "WAITLIST_OFFER_DURATION=0s"},
+		{"reminder offsets", "REMINDER_OFFSETS=24h,1"},
+		{"negative reminder offset", "REMINDER_OFFSETS=-1h"},
+		{"repeated reminder offset", "REMINDER_OFFSETS=1h,60m"},
+		{"reminder interval", "REMINDER_INTERVAL=0s"},
+		{"triage targets", "TRIAGE_TARGETS=0s,10m,30m"},
+		{"shrinking triage targets", "TRIAGE_TARGETS=0s,30m,10m,1h,2h"},
+		{"outbox interval", "OUTBOX_INTERVAL=0s"},
+		{"outbox attempts", "OUTBOX_MAX_ATTEMPTS=0"},
+		{"workers", "WORKER_COUNT=0"},
+		{"worker queue", "WORKER_QUEUE_SIZE=0"},
+		{"worker attempts", "WORKER_MAX_ATTEMPTS=-1"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writefile(t, tc.content))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	c := Defaults()
+	c.Smtp.Password = "hunter22"
+	c.Smtp.Host = "smtp.example.com"
+	var buf bytes.Buffer
+	require.NoError(t, c.Print(&buf))
+	require.NotContains(t, buf.String(), "hunter22")
+	require.NotContains(t, buf.String(), "secret@localhost")
+	require.Contains(t, buf.String(), "SMTP_PASSWORD=[REDACTED]")
+	require.Contains(t, buf.String(), "SMTP_HOST=smtp.example.com")
+	// unset secrets print empty so it's clear they fall back to random keys
+	require.Contains(t, buf.String(), "CSRF_KEY=\n")
+}
@@ -3,78 +3,93 @@ package controllers
 import (
 	"context"
 	"database/sql"
+
 	_ "github.com/lib/pq"
 	"github.com/patienttracker/internal/models"
 	"time"
 )
 
 type Appointment struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (a *Appointment) Create(appointment models.Appointment) (models.Appointment, error) {
-	sqlStatement := `
-  INSERT INTO appointment (appointmentdate,doctorid,patientid,duration,approval,outbound) 
-  VALUES ($1,$2,$3,$4,$5,$6)
-  RETURNING *
-  `
-	err := a.db.QueryRow(sqlStatement, appointment.Appointmentdate, appointment.Doctorid, appointment.Patientid, appointment.Duration, appointment.Approval, appointment.Outbound).Scan(
+// scanappointment reads the columns of the appointment queries,the range is read back into a duration
+func scanappointment(row scanner) (models.Appointment, error) {
+	var appointment models.Appointment
+	var end time.Time
+	var series sql.NullInt64
+	err := row.Scan(
 		&appointment.Appointmentid,
 		&appointment.Doctorid,
 		&appointment.Patientid,
 		&appointment.Appointmentdate,
-		&appointment.Duration,
-		&appointment.Approval,
-		&appointment.Outbound)
+		&end,
+		&appointment.Status,
+		&appointment.Outbound,
+		&series)
+	appointment.Duration = end.Sub(appointment.Appointmentdate)
+	appointment.Seriesid = int(series.Int64)
 	return appointment, err
+}
+
+func (a *Appointment) Create(ctx context.Context, appointment models.Appointment) (models.Appointment, error) {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO appointment (appointmentdate,endtime,doctorid,patientid,status,outbound,seriesid) 
+  VALUES ($1,$2,$3,$4,$5,$6,$7)
+  RETURNING appointmentid,doctorid,patientid,appointmentdate,endtime,status,outbound,seriesid
+  `
+	series := sql.NullInt64{Int64: int64(appointment.Seriesid), Valid: appointment.Seriesid != 0}
+	return scanappointment(a.db.QueryRowContext(ctx, sqlStatement, appointment.Appointmentdate, appointment.End(), appointment.Doctorid, appointment.Patientid, appointment.Status, appointment.Outbound, series))
 
 }
-func (a *Appointment) Find(id int) (models.Appointment, error) {
+func (a *Appointment) Find(ctx context.Context, id int) (models.Appointment, error) {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
 	sqlStatement := `
-  SELECT * FROM appointment
+  SELECT appointmentid,doctorid,patientid,appointmentdate,endtime,status,outbound,seriesid FROM appointment
   WHERE appointment.appointmentid = $1 LIMIT 1
   `
-
-	var appointment models.Appointment
-	err := a.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
-		&appointment.Appointmentid,
-		&appointment.Doctorid,
-		&appointment.Patientid,
-		&appointment.Appointmentdate,
-		&appointment.Duration,
-		&appointment.Approval,
-		&appointment.Outbound)
-	return appointment, err
+	return scanappointment(a.db.QueryRowContext(ctx, sqlStatement, id))
 }
 
-func (a *Appointment) FindAll(args models.Filters) ([]models.Appointment, *models.Metadata, error) {
+func (a *Appointment) FindAll(ctx context.Context, args models.Filters) ([]models.Appointment, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
 	var items []models.Appointment
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
-	SELECT count(*) OVER(),* FROM appointment 
+	SELECT count(*) OVER(),appointmentid,doctorid,patientid,appointmentdate,endtime,status,outbound,seriesid FROM appointment 
 	ORDER BY appointmentid
 	LIMIT $1
 	OFFSET $2
   `
-	rows, err := a.db.QueryContext(context.Background(), sqlStatement, args.Limit(), args.Offset())
+	rows, err := a.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
 	if err != nil {
 		return items, &metadata, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var i models.Appointment
+		var end time.Time
+		var series sql.NullInt64
 		if err := rows.Scan(
 			&count,
 			&i.Appointmentid,
 			&i.Doctorid,
 			&i.Patientid,
 			&i.Appointmentdate,
-			&i.Duration,
-			&i.Approval,
-			&i.Outbound); err != nil {
+			&end,
+			&i.Status,
+			&i.Outbound,
+			&series); err != nil {
 			return nil, &metadata, err
 		}
+		i.Duration = end.Sub(i.Appointmentdate)
+		i.Seriesid = int(series.Int64)
 		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
@@ -87,14 +102,14 @@ func (a *Appointment) FindAll(args models.Filters) ([]models.Appointment, *model
 	return items, &metadata, nil
 }
 
-func (a *Appointment) FindAllByDoctor(id int) ([]models.Appointment, error) {
+func (a *Appointment) FindAllByDoctor(ctx context.Context, id int) ([]models.Appointment, error) {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
 	sqlStatement := `
-	SELECT * FROM appointment 
+	SELECT appointmentid,doctorid,patientid,appointmentdate,endtime,status,outbound,seriesid FROM appointment 
 	WHERE appointment.doctorid = $1
 	ORDER BY appointmentid
   `
-	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelfunc()
 	stmt, err := a.db.PrepareContext(ctx, sqlStatement)
 	if err != nil {
 		return nil, err
@@ -107,15 +122,8 @@ func (a *Appointment) FindAllByDoctor(id int) ([]models.Appointment, error) {
 	defer rows.Close()
 	var items []models.Appointment
 	for rows.Next() {
-		var i models.Appointment
-		if err := rows.Scan(
-			&i.Appointmentid,
-			&i.Doctorid,
-			&i.Patientid,
-			&i.Appointmentdate,
-			&i.Duration,
-			&i.Approval,
-			&i.Outbound); err != nil {
+		i, err := scanappointment(rows)
+		if err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -129,14 +137,14 @@ func (a *Appointment) FindAllByDoctor(id int) ([]models.Appointment, error) {
 	return items, nil
 }
 
-func (a *Appointment) FindAllByPatient(id int) ([]models.Appointment, error) {
+func (a *Appointment) FindAllByPatient(ctx context.Context, id int) ([]models.Appointment, error) {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
 	sqlStatement := `
-	SELECT * FROM appointment 
+	SELECT appointmentid,doctorid,patientid,appointmentdate,endtime,status,outbound,seriesid FROM appointment 
 	WHERE appointment.patientid = $1
 	ORDER BY appointmentid
   `
-	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelfunc()
 	stmt, err := a.db.PrepareContext(ctx, sqlStatement)
 	if err != nil {
 		return nil, err
@@ -149,19 +157,47 @@ func (a *Appointment) FindAllByPatient(id int) ([]models.Appointment, error) {
 	defer rows.Close()
 	var items []models.Appointment
 	for rows.Next() {
-		var i models.Appointment
-		if err := rows.Scan(
-			&i.Appointmentid,
-			&i.Doctorid,
-			&i.Patientid,
-			&i.Appointmentdate,
-			&i.Duration,
-			&i.Approval,
-			&i.Outbound); err != nil {
+		i, err := scanappointment(rows)
+		if err != nil {
 			return nil, err
 		}
 		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
 
+// FindAllBySeries lists the occurrences of the series by their date
+func (a *Appointment) FindAllBySeries(ctx context.Context, id int) ([]models.Appointment, error) {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
+	sqlStatement := `
+	SELECT appointmentid,doctorid,patientid,appointmentdate,endtime,status,outbound,seriesid FROM appointment 
+	WHERE appointment.seriesid = $1
+	ORDER BY appointmentdate,appointmentid
+  `
+	stmt, err := a.db.PrepareContext(ctx, sqlStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.Appointment
+	for rows.Next() {
+		i, err := scanappointment(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -172,31 +208,43 @@ func (a *Appointment) FindAllByPatient(id int) ([]models.Appointment, error) {
 	return items, nil
 }
 
-func (a *Appointment) Delete(id int) error {
+func (a *Appointment) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM appointment
   WHERE appointment.appointmentid = $1
   `
-	_, err := a.db.Exec(sqlStatement, id)
+	_, err := a.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (p *Appointment) Update(update models.Appointment) (models.Appointment, error) {
+func (p *Appointment) Update(ctx context.Context, update models.Appointment) (models.Appointment, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE appointment
-SET appointmentdate = $2,duration = $3,approval = $4,outbound = $5
+SET appointmentdate = $2,endtime = $3,status = $4,outbound = $5
 WHERE appointmentid = $1
-RETURNING *;
+RETURNING appointmentid,doctorid,patientid,appointmentdate,endtime,status,outbound,seriesid;
   `
-	var appointment models.Appointment
-	err := p.db.QueryRow(sqlStatement, update.Appointmentid, update.Appointmentdate, update.Duration, update.Approval, update.Outbound).Scan(
-		&appointment.Appointmentid,
-		&appointment.Doctorid,
-		&appointment.Patientid,
-		&appointment.Appointmentdate,
-		&appointment.Duration,
-		&appointment.Approval,
-		&appointment.Outbound)
-	if err != nil {
-		return appointment, err
+	return scanappointment(p.db.QueryRowContext(ctx, sqlStatement, update.Appointmentid, update.Appointmentdate, update.End(), update.Status, update.Outbound))
+}
+
+// the advisory lock namespaces of the booking locks,
+// they're two int keys so they never clash with the bigint key of the migrations
+const (
+	doctorlock = iota + 1
+	patientlock
+)
+
+// Lock takes transaction level advisory locks which are released on commit or rollback,
+// outside a transaction they would be released straight away. The doctor is always locked
+// before the patient so two bookings can't deadlock.
+func (a *Appointment) Lock(ctx context.Context, doctorid, patientid int) error {
+	ctx, cancel := querycontext(ctx, a.timeout)
+	defer cancel()
+	if _, err := a.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1,$2)`, doctorlock, doctorid); err != nil {
+		return err
 	}
-	return appointment, nil
+	_, err := a.db.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1,$2)`, patientlock, patientid)
+	return err
 }
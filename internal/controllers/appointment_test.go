@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/patienttracker/internal/models"
 	"github.com/patienttracker/internal/utils"
@@ -9,31 +11,33 @@ import (
 )
 
 func CreateAppointment() models.Appointment {
-	time := utils.Randate()
+	date := utils.Randate()
 	patient := RandPatient()
-	patient1, _ := controllers.Patient.Create(patient)
+	patient1, _ := controllers.Patient.Create(context.Background(), patient)
 	physcian := RandDoctor()
-	doc, _ := controllers.Doctors.Create(physcian)
-	appointment, _ := controllers.Appointment.Create(models.Appointment{
+	doc, _ := controllers.Doctors.Create(context.Background(), physcian)
+	appointment, _ := controllers.Appointment.Create(context.Background(), models.Appointment{
 		Patientid:       patient1.Patientid,
 		Doctorid:        doc.Physicianid,
-		Appointmentdate: time,
-		Duration:        "1h",
-		Approval:        false,
+		Appointmentdate: date,
+		Duration:        time.Hour,
+		Status:          models.StatusRequested,
 	})
 	return appointment
 }
 
 func TestCreateNewAppointment(t *testing.T) {
-	time := utils.Randate()
+	date := utils.Randate()
 	patient := RandPatient()
-	patient1, _ := controllers.Patient.Create(patient)
+	patient1, _ := controllers.Patient.Create(context.Background(), patient)
 	physcian := RandDoctor()
-	doc, _ := controllers.Doctors.Create(physcian)
-	appointment, err := controllers.Appointment.Create(models.Appointment{
+	doc, _ := controllers.Doctors.Create(context.Background(), physcian)
+	appointment, err := controllers.Appointment.Create(context.Background(), models.Appointment{
 		Patientid:       patient1.Patientid,
 		Doctorid:        doc.Physicianid,
-		Appointmentdate: time,
+		Appointmentdate: date,
+		Duration:        time.Hour,
+		Status:          models.StatusRequested,
 	})
 	require.NoError(t, err)
 	require.Equal(t, appointment.Patientid, patient1.Patientid)
@@ -41,7 +45,7 @@ func TestCreateNewAppointment(t *testing.T) {
 
 func TestFindAppointment(t *testing.T) {
 	appointment := CreateAppointment()
-	schedule, err := controllers.Appointment.Find(appointment.Appointmentid)
+	schedule, err := controllers.Appointment.Find(context.Background(), appointment.Appointmentid)
 	require.NoError(t, err)
 	require.NotEmpty(t, appointment)
 	require.Equal(t, appointment.Appointmentdate, schedule.Appointmentdate)
@@ -56,7 +60,7 @@ func TestListAppointments(t *testing.T) {
 		PageSize: 5,
 		Page:     1,
 	}
-	appointment, _, err := controllers.Appointment.FindAll(args)
+	appointment, _, err := controllers.Appointment.FindAll(context.Background(), args)
 	require.NoError(t, err)
 	for _, v := range appointment {
 		require.NotNil(t, v)
@@ -68,7 +72,7 @@ func TestListAppointments(t *testing.T) {
 
 func TestListAppointmentsByDoctor(t *testing.T) {
 	appointment := CreateAppointment()
-	appointments, err := controllers.Appointment.FindAllByDoctor(appointment.Doctorid)
+	appointments, err := controllers.Appointment.FindAllByDoctor(context.Background(), appointment.Doctorid)
 	require.NoError(t, err)
 	require.NotEmpty(t, appointments)
 	for _, v := range appointments {
@@ -83,9 +87,9 @@ func TestListAppointmentsByPatient(t *testing.T) {
 	var appointment models.Appointment
 	time := utils.Randate()
 	patient := RandPatient()
-	patient1, _ := controllers.Patient.Create(patient)
+	patient1, _ := controllers.Patient.Create(context.Background(), patient)
 	physcian := RandDoctor()
-	doc, _ := controllers.Doctors.Create(physcian)
+	doc, _ := controllers.Doctors.Create(context.Background(), physcian)
 	model := models.Appointment{
 		Patientid:       patient1.Patientid,
 		Doctorid:        doc.Physicianid,
@@ -93,9 +97,9 @@ func TestListAppointmentsByPatient(t *testing.T) {
 	}
 	//var appointment models.Appointment
 	for i := 0; i < 5; i++ {
-		appointment, _ = controllers.Appointment.Create(model)
+		appointment, _ = controllers.Appointment.Create(context.Background(), model)
 	}
-	appointments, err := controllers.Appointment.FindAllByPatient(appointment.Patientid)
+	appointments, err := controllers.Appointment.FindAllByPatient(context.Background(), appointment.Patientid)
 	require.NoError(t, err)
 	require.NotEmpty(t, appointments)
 	for _, v := range appointments {
@@ -108,9 +112,9 @@ func TestListAppointmentsByPatient(t *testing.T) {
 
 func TestDeleteAppointments(t *testing.T) {
 	appointment := CreateAppointment()
-	err := controllers.Appointment.Delete(appointment.Appointmentid)
+	err := controllers.Appointment.Delete(context.Background(), appointment.Appointmentid)
 	require.NoError(t, err)
-	schedule, err := controllers.Appointment.Find(appointment.Appointmentid)
+	schedule, err := controllers.Appointment.Find(context.Background(), appointment.Appointmentid)
 	require.Error(t, err)
 	require.Empty(t, schedule)
 }
@@ -120,10 +124,10 @@ func TestUpdateAppointment(t *testing.T) {
 	updt := models.Appointment{
 		Appointmentid:   appointment.Appointmentid,
 		Appointmentdate: utils.Randate(),
-		Duration:        "2h",
-		Approval:        true,
+		Duration:        2 * time.Hour,
+		Status:          models.StatusApproved,
 	}
-	updatedtime, err := controllers.Appointment.Update(updt)
+	updatedtime, err := controllers.Appointment.Update(context.Background(), updt)
 	require.NoError(t, err)
 	require.NotEqual(t, appointment.Appointmentdate, updatedtime)
 }
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type CalendarFeed struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func scanfeed(row scanner) (models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := row.Scan(
+		&feed.Token,
+		&feed.AccountType,
+		&feed.AccountId,
+		&feed.CreatedAt,
+	)
+	return feed, err
+}
+
+func (c *CalendarFeed) Create(ctx context.Context, feed models.CalendarFeed) (models.CalendarFeed, error) {
+	ctx, cancel := querycontext(ctx, c.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO calendar_feeds (token,account_type,account_id)
+  VALUES($1,$2,$3)
+  RETURNING *
+  `
+	feed, err := scanfeed(c.db.QueryRowContext(ctx, sqlStatement, feed.Token, feed.AccountType, feed.AccountId))
+	return feed, dberror(err)
+}
+
+func (c *CalendarFeed) FindbyToken(ctx context.Context, token string) (models.CalendarFeed, error) {
+	ctx, cancel := querycontext(ctx, c.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM calendar_feeds
+  WHERE token = $1
+  `
+	return scanfeed(c.db.QueryRowContext(ctx, sqlStatement, token))
+}
+
+func (c *CalendarFeed) FindbyAccount(ctx context.Context, accounttype string, id int) (models.CalendarFeed, error) {
+	ctx, cancel := querycontext(ctx, c.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM calendar_feeds
+  WHERE account_type = $1 AND account_id = $2
+  `
+	return scanfeed(c.db.QueryRowContext(ctx, sqlStatement, accounttype, id))
+}
+
+func (c *CalendarFeed) DeleteByAccount(ctx context.Context, accounttype string, id int) error {
+	ctx, cancel := querycontext(ctx, c.timeout)
+	defer cancel()
+	sqlStatement := `
+  DELETE FROM calendar_feeds
+  WHERE account_type = $1 AND account_id = $2
+  `
+	_, err := c.db.ExecContext(ctx, sqlStatement, accounttype, id)
+	return err
+}
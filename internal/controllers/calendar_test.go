@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCalendarFeed(t *testing.T) {
+	doctor, err := controllers.Doctors.Create(context.Background(), RandDoctor())
+	require.NoError(t, err)
+	feed, err := controllers.Calendars.Create(context.Background(), models.CalendarFeed{Token: utils.RandString(32), AccountType: "physician", AccountId: doctor.Physicianid})
+	require.NoError(t, err)
+	found, err := controllers.Calendars.FindbyToken(context.Background(), feed.Token)
+	require.NoError(t, err)
+	require.Equal(t, doctor.Physicianid, found.AccountId)
+	_, err = controllers.Calendars.Create(context.Background(), models.CalendarFeed{Token: utils.RandString(32), AccountType: "physician", AccountId: doctor.Physicianid})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	require.NoError(t, controllers.Calendars.DeleteByAccount(context.Background(), "physician", doctor.Physicianid))
+}
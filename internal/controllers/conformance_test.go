@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/patienttracker/internal/repotest"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, controllers.Repositories())
+}
+
+func TestUnitOfWorkContract(t *testing.T) {
+	repotest.UnitOfWork(t, controllers.UnitOfWork, controllers.Repositories())
+}
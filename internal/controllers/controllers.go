@@ -1,53 +1,183 @@
 package controllers
 
 import (
+	"context"
 	"database/sql"
+	"time"
+
+	"github.com/patienttracker/internal/models"
 )
 
+// dbtx is what the controllers need to run queries,it's satisfied by both
+// the connection pool and a transaction so the same controllers work in either.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type Controllers struct {
 	Records     PatientRecords
 	Doctors     Physician
 	Patient     Patient
 	Nurse       Nurse
 	Appointment Appointment
+	Series      Series
 	Schedule    Schedule
+	Exceptions  ScheduleException
+	Transitions Transition
+	Reminders   Reminder
+	Waitlists   Waitlist
+	Tickets     Ticket
 	Department  Department
 	Roles       Roles
 	Users       Users
 	Permissions Permissions
+	Session     Session
+	Calendars   CalendarFeed
+	Outbox      Outbox
+	UnitOfWork  UnitOfWork
 }
 
-func New(conn *sql.DB) Controllers {
+// New returns the controllers sharing conn,every query is cancelled once
+// timeout has passed or the context of the caller is done.
+func New(conn *sql.DB, timeout time.Duration) Controllers {
+	c := bind(conn, timeout)
+	c.UnitOfWork = UnitOfWork{db: conn, timeout: timeout}
+	return c
+}
+
+func bind(conn dbtx, timeout time.Duration) Controllers {
 	return Controllers{
 		Records: PatientRecords{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
 		},
 		Doctors: Physician{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
 		},
 		Patient: Patient{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
 		},
 		Appointment: Appointment{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
+		},
+		Series: Series{
+			db:      conn,
+			timeout: timeout,
 		},
 		Schedule: Schedule{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
+		},
+		Exceptions: ScheduleException{
+			db:      conn,
+			timeout: timeout,
+		},
+		Transitions: Transition{
+			db:      conn,
+			timeout: timeout,
+		},
+		Reminders: Reminder{
+			db:      conn,
+			timeout: timeout,
+		},
+		Waitlists: Waitlist{
+			db:      conn,
+			timeout: timeout,
 		},
 		Nurse: Nurse{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
+		},
+		Tickets: Ticket{
+			db:      conn,
+			timeout: timeout,
 		},
 		Department: Department{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
 		},
 		Roles: Roles{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
 		},
 		Users: Users{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
 		},
 		Permissions: Permissions{
-			db: conn,
+			db:      conn,
+			timeout: timeout,
+		},
+		Session: Session{
+			db:      conn,
+			timeout: timeout,
+		},
+		Calendars: CalendarFeed{
+			db:      conn,
+			timeout: timeout,
 		},
+		Outbox: Outbox{
+			db:      conn,
+			timeout: timeout,
+		},
+	}
+}
+
+// querycontext bounds a query by the timeout of the controller,a zero timeout leaves only the deadline of ctx
+func querycontext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Repositories returns the controllers as models repositories
+func (c *Controllers) Repositories() models.Repositories {
+	return models.Repositories{
+		Patients:     c.Patient,
+		Doctors:      c.Doctors,
+		Nurses:       c.Nurse,
+		Departments:  c.Department,
+		Appointments: &c.Appointment,
+		Series:       &c.Series,
+		Schedules:    c.Schedule,
+		Exceptions:   &c.Exceptions,
+		Transitions:  &c.Transitions,
+		Reminders:    &c.Reminders,
+		Waitlists:    &c.Waitlists,
+		Records:      c.Records,
+		Tickets:      &c.Tickets,
+		Roles:        &c.Roles,
+		Users:        &c.Users,
+		Permissions:  &c.Permissions,
+		Sessions:     &c.Session,
+		Calendars:    &c.Calendars,
+		Outbox:       &c.Outbox,
+	}
+}
+
+// UnitOfWork runs its units of work in postgres transactions
+type UnitOfWork struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func (u UnitOfWork) Do(ctx context.Context, fn func(models.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// a no-op once the transaction is committed
+	defer tx.Rollback()
+	c := bind(tx, u.timeout)
+	if err := fn(c.Repositories()); err != nil {
+		return err
 	}
+	return tx.Commit()
 }
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryContext(t *testing.T) {
+	ctx, cancel := querycontext(context.Background(), 0)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	require.False(t, ok)
+
+	ctx, cancel = querycontext(context.Background(), time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
+
+func TestCancelledQuery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := controllers.Patient.Find(ctx, 1)
+	require.ErrorIs(t, err, context.Canceled)
+	_, _, err = controllers.Patient.FindAll(ctx, models.Filters{Page: 1, PageSize: 10})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -2,58 +2,76 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
-	"log"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/patienttracker/internal/models"
 )
 
 type Department struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (d Department) Create(dept models.Department) (models.Department, error) {
+func (d Department) Create(ctx context.Context, dept models.Department) (models.Department, error) {
+	ctx, cancel := querycontext(ctx, d.timeout)
+	defer cancel()
 	sqlStatement := `
-  INSERT INTO department (departmentname) 
-  VALUES($1)
+  INSERT INTO department (departmentname,durations) 
+  VALUES($1,$2)
   RETURNING *
   `
 	var department models.Department
-	err := d.db.QueryRow(sqlStatement, dept.Departmentname).Scan(
+	var minutes []int64
+	err := d.db.QueryRowContext(ctx, sqlStatement, dept.Departmentname, pq.Array(tominutes(dept.Durations))).Scan(
 		&department.Departmentid,
 		&department.Departmentname,
+		pq.Array(&minutes),
 	)
-	return department, err
+	department.Durations = fromminutes(minutes)
+	return department, dberror(err)
 
 }
 
-func (d Department) Find(id int) (models.Department, error) {
+func (d Department) Find(ctx context.Context, id int) (models.Department, error) {
+	ctx, cancel := querycontext(ctx, d.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM department
   WHERE department.departmentid = $1
   `
 	var department models.Department
-	err := d.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	var minutes []int64
+	err := d.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&department.Departmentid,
 		&department.Departmentname,
+		pq.Array(&minutes),
 	)
-	return department, err
+	department.Durations = fromminutes(minutes)
+	return department, dberror(err)
 }
 
-func (d Department) FindbyName(name string) (models.Department, error) {
+func (d Department) FindbyName(ctx context.Context, name string) (models.Department, error) {
+	ctx, cancel := querycontext(ctx, d.timeout)
+	defer cancel()
 	sqlStatement := `
 	SELECT * FROM department
 	WHERE department.departmentname = $1
   `
 	var department models.Department
-	err := d.db.QueryRowContext(context.Background(), sqlStatement, name).Scan(
+	var minutes []int64
+	err := d.db.QueryRowContext(ctx, sqlStatement, name).Scan(
 		&department.Departmentid,
 		&department.Departmentname,
+		pq.Array(&minutes),
 	)
-	return department, err
+	department.Durations = fromminutes(minutes)
+	return department, dberror(err)
 }
 
-func (d Department) FindAll(args models.Filters) ([]models.Department, *models.Metadata, error) {
+func (d Department) FindAll(ctx context.Context, args models.Filters) ([]models.Department, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, d.timeout)
+	defer cancel()
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
@@ -62,21 +80,24 @@ func (d Department) FindAll(args models.Filters) ([]models.Department, *models.M
  LIMIT $1
  OFFSET $2
   `
-	rows, err := d.db.QueryContext(context.Background(), sqlStatement, args.Limit(), args.Offset())
+	rows, err := d.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
 	if err != nil {
-		log.Fatal(err)
+		return nil, &metadata, err
 	}
 	defer rows.Close()
 	var items []models.Department
 	for rows.Next() {
 		var i models.Department
+		var minutes []int64
 		if err := rows.Scan(
 			&count,
 			&i.Departmentid,
 			&i.Departmentname,
+			pq.Array(&minutes),
 		); err != nil {
 			return nil, &metadata, err
 		}
+		i.Durations = fromminutes(minutes)
 		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
@@ -89,24 +110,51 @@ func (d Department) FindAll(args models.Filters) ([]models.Department, *models.M
 	return items, &metadata, nil
 }
 
-func (d Department) Delete(id int) error {
+func (d Department) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, d.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM department
   WHERE departmentid  = $1
   `
-	_, err := d.db.Exec(sqlStatement, id)
+	_, err := d.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (d Department) Update(update models.Department) (models.Department, error) {
+func (d Department) Update(ctx context.Context, update models.Department) (models.Department, error) {
+	ctx, cancel := querycontext(ctx, d.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE department
-SET departmentname = $2
+SET departmentname = $2,durations = $3
 WHERE departmentid = $1
 RETURNING *;
   `
 	var department models.Department
-	err := d.db.QueryRow(sqlStatement, update.Departmentid, update.Departmentname).Scan(
+	var minutes []int64
+	err := d.db.QueryRowContext(ctx, sqlStatement, update.Departmentid, update.Departmentname, pq.Array(tominutes(update.Durations))).Scan(
 		&department.Departmentid,
 		&department.Departmentname,
+		pq.Array(&minutes),
 	)
-	return department, err
+	department.Durations = fromminutes(minutes)
+	return department, dberror(err)
+}
+
+// the allowed durations are stored in whole minutes
+func tominutes(durations []time.Duration) []int64 {
+	minutes := make([]int64, 0, len(durations))
+	for _, d := range durations {
+		minutes = append(minutes, int64(d/time.Minute))
+	}
+	return minutes
+}
+
+func fromminutes(minutes []int64) []time.Duration {
+	if len(minutes) == 0 {
+		return nil
+	}
+	durations := make([]time.Duration, 0, len(minutes))
+	for _, m := range minutes {
+		durations = append(durations, time.Duration(m)*time.Minute)
+	}
+	return durations
 }
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	//	"time"
@@ -11,27 +12,27 @@ import (
 )
 
 func TestCreateDeptName(t *testing.T) {
-	dept, err := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	dept, err := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	require.NoError(t, err)
 	require.NotEmpty(t, dept)
 
 }
 
 func TestFindDepartmentbyId(t *testing.T) {
-	dept, err := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	dept, err := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	require.NoError(t, err)
 	require.NotEmpty(t, dept)
-	dept1, err := controllers.Department.Find(dept.Departmentid)
+	dept1, err := controllers.Department.Find(context.Background(), dept.Departmentid)
 	require.NoError(t, err)
 	require.NotEmpty(t, dept1)
 	require.Equal(t, dept, dept1)
 }
 
 func TestFindDepartmentbyName(t *testing.T) {
-	dept, err := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	dept, err := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	require.NoError(t, err)
 	require.NotEmpty(t, dept)
-	dept1, err := controllers.Department.FindbyName(dept.Departmentname)
+	dept1, err := controllers.Department.FindbyName(context.Background(), dept.Departmentname)
 	require.NoError(t, err)
 	require.NotEmpty(t, dept1)
 	require.Equal(t, dept, dept1)
@@ -40,14 +41,14 @@ func TestFindDepartmentbyName(t *testing.T) {
 func TestListDepartments(t *testing.T) {
 	var dept models.Department
 	for i := 0; i < 5; i++ {
-		dept, _ = controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+		dept, _ = controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 		require.NotEmpty(t, dept)
 	}
 	data := models.Filters{
 		PageSize: 5,
 		Page:     1,
 	}
-	depts, _, err := controllers.Department.FindAll(data)
+	depts, _, err := controllers.Department.FindAll(context.Background(), data)
 	require.NoError(t, err)
 	require.NotEmpty(t, depts)
 	require.Equal(t, len(depts), 5)
@@ -55,25 +56,25 @@ func TestListDepartments(t *testing.T) {
 }
 
 func TestDeleteDepartment(t *testing.T) {
-	dept, err := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	dept, err := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	require.NoError(t, err)
 	require.NotEmpty(t, dept)
-	err = controllers.Department.Delete(dept.Departmentid)
+	err = controllers.Department.Delete(context.Background(), dept.Departmentid)
 	require.NoError(t, err)
-	dept1, err := controllers.Department.Find(dept.Departmentid)
+	dept1, err := controllers.Department.Find(context.Background(), dept.Departmentid)
 	require.Error(t, err)
 	require.Empty(t, dept1)
 }
 
 func TestUpdateDepartment(t *testing.T) {
-	dept, err := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	dept, err := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	require.NoError(t, err)
 	require.NotEmpty(t, dept)
 	data := models.Department{
 		Departmentid:   dept.Departmentid,
 		Departmentname: utils.RandString(6),
 	}
-	dept1, err := controllers.Department.Update(data)
+	dept1, err := controllers.Department.Update(context.Background(), data)
 	require.NoError(t, err)
 	require.NotEmpty(t, dept1)
 	require.NotEqual(t, dept1.Departmentname, dept.Departmentname)
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/patienttracker/internal/models"
+)
+
+// unique_violation,see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const uniqueviolation = "23505"
+
+// dberror translates the postgres errors callers care about into the models errors
+func dberror(err error) error {
+	var pqerr *pq.Error
+	if errors.As(err, &pqerr) && pqerr.Code == uniqueviolation {
+		return fmt.Errorf("%w: %s", models.ErrDuplicate, pqerr.Constraint)
+	}
+	return err
+}
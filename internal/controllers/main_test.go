@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 )
 
 var controllers Controllers
@@ -16,6 +17,6 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		log.Fatal(err)
 	}
-	controllers = New(conn)
+	controllers = New(conn, 5*time.Second)
 	os.Exit(m.Run())
 }
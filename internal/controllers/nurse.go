@@ -2,24 +2,25 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
-	"log"
 	"time"
 
 	"github.com/patienttracker/internal/models"
 )
 
 type Nurse struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (n Nurse) Create(nurse models.Nurse) (models.Nurse, error) {
+func (n Nurse) Create(ctx context.Context, nurse models.Nurse) (models.Nurse, error) {
+	ctx, cancel := querycontext(ctx, n.timeout)
+	defer cancel()
 	sqlStatement := `
   INSERT INTO nurse (username,full_name,email,hashed_password) 
   VALUES($1,$2,$3,$4)
   RETURNING *
   `
-	err := n.db.QueryRow(sqlStatement, nurse.Username, nurse.Full_name,
+	err := n.db.QueryRowContext(ctx, sqlStatement, nurse.Username, nurse.Full_name,
 		nurse.Email, nurse.Hashed_password).Scan(
 		&nurse.Id,
 		&nurse.Username,
@@ -29,20 +30,19 @@ func (n Nurse) Create(nurse models.Nurse) (models.Nurse, error) {
 		&nurse.Password_changed_at,
 		&nurse.Created_at,
 	)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return nurse, nil
+	return nurse, dberror(err)
 
 }
 
-func (n Nurse) Find(id int) (models.Nurse, error) {
+func (n Nurse) Find(ctx context.Context, id int) (models.Nurse, error) {
+	ctx, cancel := querycontext(ctx, n.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM nurse
   WHERE nurse.id = $1
   `
 	var nurse models.Nurse
-	err := n.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	err := n.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&nurse.Id,
 		&nurse.Username,
 		&nurse.Full_name,
@@ -53,13 +53,15 @@ func (n Nurse) Find(id int) (models.Nurse, error) {
 	return nurse, err
 }
 
-func (n Nurse) FindbyEmail(email string) (models.Nurse, error) {
+func (n Nurse) FindbyEmail(ctx context.Context, email string) (models.Nurse, error) {
+	ctx, cancel := querycontext(ctx, n.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM nurse
   WHERE nurse.email = $1
   `
 	var nurse models.Nurse
-	err := n.db.QueryRowContext(context.Background(), sqlStatement, email).Scan(
+	err := n.db.QueryRowContext(ctx, sqlStatement, email).Scan(
 		&nurse.Id,
 		&nurse.Username,
 		&nurse.Full_name,
@@ -70,7 +72,9 @@ func (n Nurse) FindbyEmail(email string) (models.Nurse, error) {
 	return nurse, err
 }
 
-func (p Nurse) FindAll(args models.Filters) ([]models.Nurse, *models.Metadata, error) {
+func (p Nurse) FindAll(ctx context.Context, args models.Filters) ([]models.Nurse, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
@@ -79,9 +83,9 @@ func (p Nurse) FindAll(args models.Filters) ([]models.Nurse, *models.Metadata, e
  LIMIT $1
  OFFSET $2
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, args.Limit(), args.Offset())
+	rows, err := p.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
 	if err != nil {
-		log.Fatal(err)
+		return nil, &metadata, err
 	}
 	defer rows.Close()
 	var items []models.Nurse
@@ -108,34 +112,40 @@ func (p Nurse) FindAll(args models.Filters) ([]models.Nurse, *models.Metadata, e
 	return items, &metadata, nil
 }
 
-func (n Nurse) Delete(id int) error {
+func (n Nurse) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, n.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM nurse
   WHERE id  = $1
   `
-	_, err := n.db.Exec(sqlStatement, id)
+	_, err := n.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (p Nurse) Update(nurse models.Nurse) (models.Nurse, error) {
+func (p Nurse) Update(ctx context.Context, nurse models.Nurse) (models.Nurse, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE nurse
 SET username = $2, full_name = $3, email = $4,hashed_password=$5,password_changed_at=$6
 WHERE id = $1
 RETURNING id,full_name,username,email;
   `
 	var nur models.Nurse
-	err := p.db.QueryRow(sqlStatement, nurse.Id, nurse.Username, nurse.Full_name, nurse.Email, nurse.Hashed_password, nurse.Password_changed_at).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, nurse.Id, nurse.Username, nurse.Full_name, nurse.Email, nurse.Hashed_password, nurse.Password_changed_at).Scan(
 		&nur.Id,
 		&nur.Full_name,
 		&nur.Username,
 		&nur.Email,
 	)
 	if err != nil {
-		return nur, err
+		return nur, dberror(err)
 	}
 	return nur, nil
 }
 
-func (p Nurse) Filter(username string, filters models.Filters) ([]*models.Nurse, *models.Metadata, error) {
+func (p Nurse) Filter(ctx context.Context, username string, filters models.Filters) ([]*models.Nurse, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var metadata models.Metadata
 	counter := 0
 	query := `
@@ -143,8 +153,6 @@ SELECT count(*) OVER(),id, username,full_name,email
 FROM nurse
 WHERE (username ILIKE '%' || $1 || '%' OR $1 = '')
 ORDER BY id ASC LIMIT $2 OFFSET $3`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
 	rows, err := p.db.QueryContext(ctx, query, username, filters.Limit(), filters.Offset())
 	if err != nil {
 		return nil, &metadata, err
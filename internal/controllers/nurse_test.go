@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
@@ -49,7 +50,7 @@ func TestCreateNurse(t *testing.T) {
 		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
-			user, err := controllers.Nurse.Create(scenario.input)
+			user, err := controllers.Nurse.Create(context.Background(), scenario.input)
 			require.NoError(t, err)
 			require.Equal(t, nurse.Username, user.Username)
 		})
@@ -63,7 +64,7 @@ func TestFindNurse(t *testing.T) {
 		test        func(*testing.T, models.Nurse, error)
 		data        models.Nurse
 	}
-	user, _ := controllers.Nurse.Create(nurse)
+	user, _ := controllers.Nurse.Create(context.Background(), nurse)
 	for _, scenario := range []NurseTest{
 		{
 			description: "Account existent",
@@ -83,7 +84,7 @@ func TestFindNurse(t *testing.T) {
 		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
-			user, err := controllers.Nurse.Find(scenario.data.Id)
+			user, err := controllers.Nurse.Find(context.Background(), scenario.data.Id)
 			scenario.test(t, user, err)
 		})
 	}
@@ -96,7 +97,7 @@ func TestFindNursebyEmail(t *testing.T) {
 		test        func(models.Nurse, error)
 		data        models.Nurse
 	}
-	user, _ := controllers.Nurse.Create(nurse)
+	user, _ := controllers.Nurse.Create(context.Background(), nurse)
 	for _, scenario := range []NurseTest{
 		{
 			description: "Account existent",
@@ -116,7 +117,7 @@ func TestFindNursebyEmail(t *testing.T) {
 		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
-			data, err := controllers.Nurse.FindbyEmail(scenario.data.Email)
+			data, err := controllers.Nurse.FindbyEmail(context.Background(), scenario.data.Email)
 			scenario.test(data, err)
 		})
 	}
@@ -125,14 +126,14 @@ func TestFindNursebyEmail(t *testing.T) {
 func TestListNurses(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		nurse := RandNurse()
-		_, err := controllers.Nurse.Create(nurse)
+		_, err := controllers.Nurse.Create(context.Background(), nurse)
 		require.NoError(t, err)
 	}
 	args := models.Filters{
 		PageSize: 5,
 		Page:     1,
 	}
-	patients, _, err := controllers.Nurse.FindAll(args)
+	patients, _, err := controllers.Nurse.FindAll(context.Background(), args)
 	require.NoError(t, err)
 	for _, v := range patients {
 		require.NotNil(t, v)
@@ -148,7 +149,7 @@ func TestDeleteNurse(t *testing.T) {
 		test        func(error)
 		data        models.Nurse
 	}
-	user, _ := controllers.Nurse.Create(nurse)
+	user, _ := controllers.Nurse.Create(context.Background(), nurse)
 	for _, scenario := range []NurseTest{
 		{
 			description: "Delete Account",
@@ -166,7 +167,7 @@ func TestDeleteNurse(t *testing.T) {
 		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
-			err := controllers.Nurse.Delete(scenario.data.Id)
+			err := controllers.Nurse.Delete(context.Background(), scenario.data.Id)
 			scenario.test(err)
 		})
 	}
@@ -174,7 +175,7 @@ func TestDeleteNurse(t *testing.T) {
 
 func TestUpdateNurse(t *testing.T) {
 	nurse := RandNurse()
-	user, err := controllers.Nurse.Create(nurse)
+	user, err := controllers.Nurse.Create(context.Background(), nurse)
 	require.NoError(t, err)
 	type NurseTest struct {
 		description string
@@ -201,7 +202,7 @@ func TestUpdateNurse(t *testing.T) {
 		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
-			upd, err := controllers.Nurse.Update(RandUpdNurse(scenario.data.Email, scenario.data.Id))
+			upd, err := controllers.Nurse.Update(context.Background(), RandUpdNurse(scenario.data.Email, scenario.data.Id))
 			scenario.test(upd, err)
 		})
 	}
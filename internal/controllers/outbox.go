@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Outbox struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func scanoutbox(row scanner) (models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	var nextattempt, dispatched sql.NullTime
+	err := row.Scan(
+		&event.Eventid,
+		&event.Subscriber,
+		&event.Kind,
+		&event.Payload,
+		&event.CreatedAt,
+		&event.Attempts,
+		&nextattempt,
+		&dispatched,
+		&event.LastError,
+	)
+	event.NextAttempt = nextattempt.Time
+	event.Dispatched = dispatched.Time
+	return event, err
+}
+
+func (o *Outbox) Create(ctx context.Context, event models.OutboxEvent) (models.OutboxEvent, error) {
+	ctx, cancel := querycontext(ctx, o.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO outbox (subscriber,kind,payload,nextattempt)
+  VALUES($1,$2,$3,COALESCE($4,now()))
+  RETURNING *
+  `
+	created, err := scanoutbox(o.db.QueryRowContext(ctx, sqlStatement, event.Subscriber, event.Kind, event.Payload, nulldate(event.NextAttempt)))
+	return created, dberror(err)
+}
+
+func (o *Outbox) Find(ctx context.Context, id int) (models.OutboxEvent, error) {
+	ctx, cancel := querycontext(ctx, o.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM outbox
+  WHERE eventid = $1 LIMIT 1
+  `
+	return scanoutbox(o.db.QueryRowContext(ctx, sqlStatement, id))
+}
+
+// Claim skips the rows another server is claiming so the same event isn't claimed twice at once
+func (o *Outbox) Claim(ctx context.Context, now, until time.Time, limit int) ([]models.OutboxEvent, error) {
+	ctx, cancel := querycontext(ctx, o.timeout)
+	defer cancel()
+	sqlStatement := `
+ WITH claimed AS (
+   UPDATE outbox
+   SET attempts = attempts + 1,nextattempt = $2
+   WHERE eventid IN (
+     SELECT eventid FROM outbox
+     WHERE dispatched IS NULL AND nextattempt <= $1
+     ORDER BY eventid
+     LIMIT $3
+     FOR UPDATE SKIP LOCKED
+   )
+   RETURNING *
+ )
+ SELECT * FROM claimed
+ ORDER BY eventid
+  `
+	rows, err := o.db.QueryContext(ctx, sqlStatement, now, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.OutboxEvent
+	for rows.Next() {
+		i, err := scanoutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (o *Outbox) MarkDispatched(ctx context.Context, id int, at time.Time) error {
+	ctx, cancel := querycontext(ctx, o.timeout)
+	defer cancel()
+	sqlStatement := `
+  UPDATE outbox
+  SET dispatched = $2,lasterror = ''
+  WHERE eventid = $1 AND dispatched IS NULL
+  `
+	return o.exec(ctx, sqlStatement, id, at)
+}
+
+func (o *Outbox) Retry(ctx context.Context, id int, next time.Time, reason string) error {
+	ctx, cancel := querycontext(ctx, o.timeout)
+	defer cancel()
+	sqlStatement := `
+  UPDATE outbox
+  SET nextattempt = $2,lasterror = $3
+  WHERE eventid = $1 AND dispatched IS NULL
+  `
+	return o.exec(ctx, sqlStatement, id, nulldate(next), reason)
+}
+
+func (o *Outbox) exec(ctx context.Context, sqlStatement string, args ...any) error {
+	result, err := o.db.ExecContext(ctx, sqlStatement, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOutboxEvent(t *testing.T) {
+	event, err := controllers.Outbox.Create(context.Background(), models.OutboxEvent{Subscriber: utils.RandString(10), Kind: "ticket.opened", Payload: []byte(`{"kind":"ticket.opened","ticket_id":1}`)})
+	require.NoError(t, err)
+	require.NotZero(t, event.Eventid)
+	require.JSONEq(t, `{"kind":"ticket.opened","ticket_id":1}`, string(event.Payload))
+	require.True(t, event.Pending())
+	// the payload has to be json
+	_, err = controllers.Outbox.Create(context.Background(), models.OutboxEvent{Subscriber: utils.RandString(10), Kind: "ticket.opened", Payload: []byte("ticket")})
+	require.Error(t, err)
+}
+
+func TestClaimOutboxConcurrently(t *testing.T) {
+	const total = 20
+	subscriber := utils.RandString(10)
+	for i := 0; i < total; i++ {
+		_, err := controllers.Outbox.Create(context.Background(), models.OutboxEvent{Subscriber: subscriber, Kind: "record.created", Payload: []byte(`{}`)})
+		require.NoError(t, err)
+	}
+	// the servers claiming at once skip the events another one is claiming,none is claimed twice
+	due := time.Now().Add(time.Minute)
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		claimed = make(map[int]int)
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			events, err := controllers.Outbox.Claim(context.Background(), due, due.Add(time.Hour), total)
+			require.NoError(t, err)
+			mu.Lock()
+			defer mu.Unlock()
+			for _, event := range events {
+				if event.Subscriber == subscriber {
+					claimed[event.Eventid]++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for id, times := range claimed {
+		require.Equal(t, 1, times, "event %d", id)
+	}
+}
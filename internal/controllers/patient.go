@@ -2,24 +2,25 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
-	"log"
 	"time"
 
 	"github.com/patienttracker/internal/models"
 )
 
 type Patient struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (p Patient) Create(patient models.Patient) (models.Patient, error) {
+func (p Patient) Create(ctx context.Context, patient models.Patient) (models.Patient, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   INSERT INTO patient (username,hashed_password,full_name,email,dob,contact,bloodgroup,about,verified,avatar,ischild) 
   VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
   RETURNING *;
   `
-	err := p.db.QueryRow(sqlStatement, patient.Username, patient.Hashed_password,
+	err := p.db.QueryRowContext(ctx, sqlStatement, patient.Username, patient.Hashed_password,
 		patient.Full_name, patient.Email, patient.Dob, patient.Contact, patient.Bloodgroup, patient.About, patient.Verified, patient.Avatar, patient.Ischild).Scan(
 		&patient.Patientid,
 		&patient.Username,
@@ -35,17 +36,19 @@ func (p Patient) Create(patient models.Patient) (models.Patient, error) {
 		&patient.Password_change_at,
 		&patient.Created_at,
 		&patient.Ischild)
-	return patient, err
+	return patient, dberror(err)
 
 }
 
-func (p Patient) Find(id int) (models.Patient, error) {
+func (p Patient) Find(ctx context.Context, id int) (models.Patient, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM patient
   WHERE patient.patientid = $1 LIMIT 1
   `
 	var patient models.Patient
-	err := p.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&patient.Patientid,
 		&patient.Username,
 		&patient.Hashed_password,
@@ -63,13 +66,15 @@ func (p Patient) Find(id int) (models.Patient, error) {
 	return patient, err
 }
 
-func (p Patient) FindbyEmail(email string) (models.Patient, error) {
+func (p Patient) FindbyEmail(ctx context.Context, email string) (models.Patient, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM patient
   WHERE patient.email = $1 LIMIT 1
   `
 	var patient models.Patient
-	err := p.db.QueryRowContext(context.Background(), sqlStatement, email).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, email).Scan(
 		&patient.Patientid,
 		&patient.Username,
 		&patient.Hashed_password,
@@ -87,7 +92,9 @@ func (p Patient) FindbyEmail(email string) (models.Patient, error) {
 	return patient, err
 }
 
-func (p Patient) FindAll(args models.Filters) ([]models.Patient, *models.Metadata, error) {
+func (p Patient) FindAll(ctx context.Context, args models.Filters) ([]models.Patient, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
@@ -96,9 +103,9 @@ func (p Patient) FindAll(args models.Filters) ([]models.Patient, *models.Metadat
  LIMIT $1
  OFFSET $2
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, args.Limit(), args.Offset())
+	rows, err := p.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
 	if err != nil {
-		log.Fatal(err)
+		return nil, &metadata, err
 	}
 	defer rows.Close()
 	var items []models.Patient
@@ -129,22 +136,26 @@ func (p Patient) FindAll(args models.Filters) ([]models.Patient, *models.Metadat
 	return items, &metadata, nil
 }
 
-func (p Patient) Delete(id int) error {
+func (p Patient) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM patient
   WHERE patient.patientid = $1
   `
-	_, err := p.db.Exec(sqlStatement, id)
+	_, err := p.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (p Patient) Update(patient models.Patient) (models.Patient, error) {
+func (p Patient) Update(ctx context.Context, patient models.Patient) (models.Patient, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE patient
 SET username = $2, full_name = $3, email = $4,dob=$5,contact=$6,bloodgroup=$7,hashed_password=$8,password_changed_at=$9,about=$10,verified=$11,avatar=$12,ischild=$13
 WHERE patientid = $1
 RETURNING patientid,full_name,username,email,dob,contact,bloodgroup,ischild;
   `
 	var user models.Patient
-	err := p.db.QueryRow(sqlStatement, patient.Patientid, patient.Username, patient.Full_name, patient.Email, patient.Dob, patient.Contact, patient.Bloodgroup, patient.Hashed_password, patient.Password_change_at, patient.About, patient.Verified, patient.Avatar, patient.Ischild).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, patient.Patientid, patient.Username, patient.Full_name, patient.Email, patient.Dob, patient.Contact, patient.Bloodgroup, patient.Hashed_password, patient.Password_change_at, patient.About, patient.Verified, patient.Avatar, patient.Ischild).Scan(
 		&user.Patientid,
 		&user.Full_name,
 		&user.Username,
@@ -154,9 +165,11 @@ RETURNING patientid,full_name,username,email,dob,contact,bloodgroup,ischild;
 		&user.Bloodgroup,
 		&user.Ischild,
 	)
-	return user, err
+	return user, dberror(err)
 }
-func (p Patient) Filter(username string, filters models.Filters) ([]*models.Patient, *models.Metadata, error) {
+func (p Patient) Filter(ctx context.Context, username string, filters models.Filters) ([]*models.Patient, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var metadata models.Metadata
 	counter := 0
 	query := `
@@ -164,8 +177,6 @@ SELECT count(*) OVER(),patientid,full_name,username,email,dob,contact,bloodgroup
 FROM patient
 WHERE (username ILIKE '%' || $1 || '%' OR $1 = '')
 ORDER BY patientid ASC LIMIT $2 OFFSET $3`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
 	// Pass the title and genres as the placeholder parameter values.
 	rows, err := p.db.QueryContext(ctx, query, username, filters.Limit(), filters.Offset())
 	if err != nil {
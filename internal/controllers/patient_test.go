@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"github.com/patienttracker/internal/models"
 	"github.com/patienttracker/internal/utils"
 	"github.com/stretchr/testify/require"
@@ -60,7 +61,7 @@ func TestCreatePatient(t *testing.T) {
 		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
-			user, err := controllers.Patient.Create(scenario.input)
+			user, err := controllers.Patient.Create(context.Background(), scenario.input)
 			require.NoError(t, err)
 			require.Equal(t, patient.Username, user.Username)
 		})
@@ -69,9 +70,9 @@ func TestCreatePatient(t *testing.T) {
 
 func TestFindPatient(t *testing.T) {
 	patient := RandPatient()
-	user, err := controllers.Patient.Create(patient)
+	user, err := controllers.Patient.Create(context.Background(), patient)
 	require.NoError(t, err)
-	patient1, err := controllers.Patient.Find(user.Patientid)
+	patient1, err := controllers.Patient.Find(context.Background(), user.Patientid)
 	require.NoError(t, err)
 	require.NotEmpty(t, patient)
 	require.Equal(t, patient1.Email, user.Email)
@@ -79,9 +80,9 @@ func TestFindPatient(t *testing.T) {
 
 func TestFindPatientbyEmail(t *testing.T) {
 	patient := RandPatient()
-	user, err := controllers.Patient.Create(patient)
+	user, err := controllers.Patient.Create(context.Background(), patient)
 	require.NoError(t, err)
-	patient1, err := controllers.Patient.FindbyEmail(user.Email)
+	patient1, err := controllers.Patient.FindbyEmail(context.Background(), user.Email)
 	require.NoError(t, err)
 	require.NotEmpty(t, patient)
 	require.Equal(t, patient1.Email, user.Email)
@@ -90,14 +91,14 @@ func TestFindPatientbyEmail(t *testing.T) {
 func TestListPatients(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		patient := RandPatient()
-		_, err := controllers.Patient.Create(patient)
+		_, err := controllers.Patient.Create(context.Background(), patient)
 		require.NoError(t, err)
 	}
 	args := models.Filters{
 		PageSize: 5,
 		Page:     1,
 	}
-	patients, _, err := controllers.Patient.FindAll(args)
+	patients, _, err := controllers.Patient.FindAll(context.Background(), args)
 	require.NoError(t, err)
 	for _, v := range patients {
 		require.NotNil(t, v)
@@ -111,21 +112,21 @@ func TestListPatients(t *testing.T) {
 
 func TestDeletePatient(t *testing.T) {
 	patient := RandPatient()
-	user, err := controllers.Patient.Create(patient)
+	user, err := controllers.Patient.Create(context.Background(), patient)
 	require.NoError(t, err)
-	err = controllers.Patient.Delete(user.Patientid)
+	err = controllers.Patient.Delete(context.Background(), user.Patientid)
 	require.NoError(t, err)
-	user2, err := controllers.Patient.Find(user.Patientid)
+	user2, err := controllers.Patient.Find(context.Background(), user.Patientid)
 	require.Error(t, err)
 	require.Empty(t, user2)
 }
 
 func TestUpdatePatient(t *testing.T) {
 	patient := RandPatient()
-	user, err := controllers.Patient.Create(patient)
+	user, err := controllers.Patient.Create(context.Background(), patient)
 	require.NoError(t, err)
 	patientupd := RandUpdPatient(user.Patientid)
-	update, err := controllers.Patient.Update(patientupd)
+	update, err := controllers.Patient.Update(context.Background(), patientupd)
 	require.NoError(t, err)
 	require.Equal(t, patientupd.Email, update.Email)
 }
@@ -2,22 +2,25 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
+	"time"
 
 	"github.com/patienttracker/internal/models"
 )
 
 type PatientRecords struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (p PatientRecords) Create(patientrecords models.Patientrecords) (models.Patientrecords, error) {
+func (p PatientRecords) Create(ctx context.Context, patientrecords models.Patientrecords) (models.Patientrecords, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   INSERT INTO patientrecords (patientid,date,height,bloodpressure,heartrate,temperature,weight,doctorid,additional,nurseid) 
 VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
   RETURNING *
   `
-	err := p.db.QueryRow(sqlStatement, patientrecords.Patienid, patientrecords.Date,
+	err := p.db.QueryRowContext(ctx, sqlStatement, patientrecords.Patienid, patientrecords.Date,
 		patientrecords.Height, patientrecords.Bp, patientrecords.HeartRate, patientrecords.Temperature, patientrecords.Weight, patientrecords.Doctorid, patientrecords.Additional, patientrecords.Nurseid).Scan(
 		&patientrecords.Recordid,
 		&patientrecords.Patienid,
@@ -34,13 +37,15 @@ VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
 
 }
 
-func (p PatientRecords) Find(id int) (models.Patientrecords, error) {
+func (p PatientRecords) Find(ctx context.Context, id int) (models.Patientrecords, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
 	SELECT * FROM patientrecords
   WHERE recordid = $1 LIMIT 1
   `
 	var record models.Patientrecords
-	err := p.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&record.Recordid,
 		&record.Patienid,
 		&record.Date,
@@ -55,7 +60,9 @@ func (p PatientRecords) Find(id int) (models.Patientrecords, error) {
 	return record, err
 }
 
-func (p PatientRecords) FindAll(args models.Filters) ([]models.Patientrecords, *models.Metadata, error) {
+func (p PatientRecords) FindAll(ctx context.Context, args models.Filters) ([]models.Patientrecords, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
@@ -64,7 +71,7 @@ func (p PatientRecords) FindAll(args models.Filters) ([]models.Patientrecords, *
  LIMIT $1
  OFFSET $2
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, args.Limit(), args.Offset())
+	rows, err := p.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
 	if err != nil {
 		return []models.Patientrecords{}, &metadata, err
 	}
@@ -99,14 +106,16 @@ func (p PatientRecords) FindAll(args models.Filters) ([]models.Patientrecords, *
 	return items, &metadata, nil
 }
 
-func (p PatientRecords) FindAllByPatient(id int) ([]models.Patientrecords, error) {
+func (p PatientRecords) FindAllByPatient(ctx context.Context, id int) ([]models.Patientrecords, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
 SELECT * FROM patientrecords
 WHERE patientid = $1
  ORDER BY recordid
  
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, id)
+	rows, err := p.db.QueryContext(ctx, sqlStatement, id)
 	if err != nil {
 		return []models.Patientrecords{}, err
 	}
@@ -139,13 +148,15 @@ WHERE patientid = $1
 	return items, nil
 }
 
-func (p PatientRecords) FindAllByDoctor(id int) ([]models.Patientrecords, error) {
+func (p PatientRecords) FindAllByDoctor(ctx context.Context, id int) ([]models.Patientrecords, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
 SELECT * FROM patientrecords
 WHERE doctorid = $1
 ORDER BY recordid
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, id)
+	rows, err := p.db.QueryContext(ctx, sqlStatement, id)
 	if err != nil {
 		return []models.Patientrecords{}, err
 	}
@@ -177,13 +188,15 @@ ORDER BY recordid
 	}
 	return items, nil
 }
-func (p PatientRecords) FindAllByNurse(id int) ([]models.Patientrecords, error) {
+func (p PatientRecords) FindAllByNurse(ctx context.Context, id int) ([]models.Patientrecords, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
 SELECT * FROM patientrecords
 WHERE nurseid = $1
 ORDER BY recordid
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, id)
+	rows, err := p.db.QueryContext(ctx, sqlStatement, id)
 	if err != nil {
 		return []models.Patientrecords{}, err
 	}
@@ -215,23 +228,27 @@ ORDER BY recordid
 	}
 	return items, nil
 }
-func (p PatientRecords) Delete(id int) error {
+func (p PatientRecords) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM patientrecords
   WHERE recordid = $1
   `
-	_, err := p.db.Exec(sqlStatement, id)
+	_, err := p.db.ExecContext(ctx, sqlStatement, id)
 
 	return err
 }
 
-func (p PatientRecords) Update(record models.Patientrecords) (models.Patientrecords, error) {
+func (p PatientRecords) Update(ctx context.Context, record models.Patientrecords) (models.Patientrecords, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE patientrecords
 SET height = $2, bloodpressure = $3, temperature = $4,weight=$5,additional=$6
 WHERE recordid = $1
 RETURNING *;
   `
 	var precord models.Patientrecords
-	err := p.db.QueryRow(sqlStatement, record.Recordid, record.Height, record.Bp, record.Temperature, record.Weight, record.Additional).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, record.Recordid, record.Height, record.Bp, record.Temperature, record.Weight, record.Additional).Scan(
 		&precord.Recordid,
 		&precord.Patienid,
 		&precord.Date,
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/patienttracker/internal/models"
@@ -11,9 +12,9 @@ import (
 func RandPatientRecord() models.Patientrecords {
 	patient := RandPatient()
 	doc := RandDoctor()
-	pat, _ := controllers.Patient.Create(patient)
-	physician, _ := controllers.Doctors.Create(doc)
-	nurse, _ := controllers.Nurse.Create(RandNurse())
+	pat, _ := controllers.Patient.Create(context.Background(), patient)
+	physician, _ := controllers.Doctors.Create(context.Background(), doc)
+	nurse, _ := controllers.Nurse.Create(context.Background(), RandNurse())
 	return models.Patientrecords{
 		Patienid:    pat.Patientid,
 		Doctorid:    physician.Physicianid,
@@ -41,16 +42,16 @@ func RandUpdPatientrec(id int) models.Patientrecords {
 
 func TestCreatePatientRecords(t *testing.T) {
 	record := RandPatientRecord()
-	precord, err := controllers.Records.Create(record)
+	precord, err := controllers.Records.Create(context.Background(), record)
 	require.NoError(t, err)
 	require.Equal(t, record.Bp, precord.Bp)
 }
 
 func TestFindPatientRecord(t *testing.T) {
 	record := RandPatientRecord()
-	precord, err := controllers.Records.Create(record)
+	precord, err := controllers.Records.Create(context.Background(), record)
 	require.NoError(t, err)
-	precord1, err := controllers.Records.Find(precord.Recordid)
+	precord1, err := controllers.Records.Find(context.Background(), precord.Recordid)
 	require.NoError(t, err)
 	require.NotEmpty(t, precord1)
 	require.Equal(t, precord, precord1)
@@ -59,14 +60,14 @@ func TestFindPatientRecord(t *testing.T) {
 func TestListPatientRecords(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		record := RandPatientRecord()
-		_, err := controllers.Records.Create(record)
+		_, err := controllers.Records.Create(context.Background(), record)
 		require.NoError(t, err)
 	}
 	args := models.Filters{
 		PageSize: 5,
 		Page:     1,
 	}
-	records, _, err := controllers.Records.FindAll(args)
+	records, _, err := controllers.Records.FindAll(context.Background(), args)
 	require.NoError(t, err)
 	for _, v := range records {
 		require.NotNil(t, v)
@@ -80,10 +81,10 @@ func TestListPatientRecordsbyDoctor(t *testing.T) {
 	record := RandPatientRecord()
 	for i := 0; i < 5; i++ {
 
-		_, err := controllers.Records.Create(record)
+		_, err := controllers.Records.Create(context.Background(), record)
 		require.NoError(t, err)
 	}
-	records, err := controllers.Records.FindAllByDoctor(record.Doctorid)
+	records, err := controllers.Records.FindAllByDoctor(context.Background(), record.Doctorid)
 	require.NoError(t, err)
 	for _, v := range records {
 		require.NotNil(t, v)
@@ -97,10 +98,10 @@ func TestListPatientRecordsbyPatient(t *testing.T) {
 	record := RandPatientRecord()
 	for i := 0; i < 5; i++ {
 
-		_, err := controllers.Records.Create(record)
+		_, err := controllers.Records.Create(context.Background(), record)
 		require.NoError(t, err)
 	}
-	records, err := controllers.Records.FindAllByPatient(record.Patienid)
+	records, err := controllers.Records.FindAllByPatient(context.Background(), record.Patienid)
 	require.NoError(t, err)
 	for _, v := range records {
 		require.NotNil(t, v)
@@ -112,21 +113,21 @@ func TestListPatientRecordsbyPatient(t *testing.T) {
 
 func TestDeletePatientRecord(t *testing.T) {
 	record := RandPatientRecord()
-	precord, err := controllers.Records.Create(record)
+	precord, err := controllers.Records.Create(context.Background(), record)
 	require.NoError(t, err)
-	err = controllers.Records.Delete(precord.Recordid)
+	err = controllers.Records.Delete(context.Background(), precord.Recordid)
 	require.NoError(t, err)
-	precord1, err := controllers.Records.Find(precord.Recordid)
+	precord1, err := controllers.Records.Find(context.Background(), precord.Recordid)
 	require.Error(t, err)
 	require.Empty(t, precord1)
 }
 
 func TestUpdatePatientRecord(t *testing.T) {
 	record := RandPatientRecord()
-	precord, err := controllers.Records.Create(record)
+	precord, err := controllers.Records.Create(context.Background(), record)
 	require.NoError(t, err)
 	nrecord := RandUpdPatientrec(precord.Recordid)
-	update, err := controllers.Records.Update(nrecord)
+	update, err := controllers.Records.Update(context.Background(), nrecord)
 	require.NoError(t, err)
 	require.NotEqual(t, precord.Weight, update.Weight)
 }
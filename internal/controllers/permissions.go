@@ -2,22 +2,24 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
 	"github.com/patienttracker/internal/models"
-	"log"
+	"time"
 )
 
 type Permissions struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (p *Permissions) Create(perm models.Permissions) (models.Permissions, error) {
+func (p *Permissions) Create(ctx context.Context, perm models.Permissions) (models.Permissions, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   INSERT INTO permissions (permission,roleid) 
   VALUES($1,$2)
   RETURNING *
   `
-	err := p.db.QueryRow(sqlStatement, perm.Permission, perm.Roleid).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, perm.Permission, perm.Roleid).Scan(
 		&perm.Permissionid,
 		&perm.Permission,
 		&perm.Roleid,
@@ -26,28 +28,32 @@ func (p *Permissions) Create(perm models.Permissions) (models.Permissions, error
 
 }
 
-func (p *Permissions) Find(id int) (models.Permissions, error) {
+func (p *Permissions) Find(ctx context.Context, id int) (models.Permissions, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM permissions
   WHERE permissions.permissionid = $1
   `
 	var perm models.Permissions
-	err := p.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&perm.Permissionid,
 		&perm.Permission,
 		&perm.Roleid,
 	)
 	return perm, err
 }
-func (p *Permissions) FindbyRoleId(id int) ([]models.Permissions, error) {
+func (p *Permissions) FindbyRoleId(ctx context.Context, id int) ([]models.Permissions, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
  SELECT * FROM permissions
  WHERE permissions.roleid = $1
  ORDER BY permissionid
   `
-	rows, err := p.db.Query(sqlStatement, id)
+	rows, err := p.db.QueryContext(ctx, sqlStatement, id)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	var items []models.Permissions
@@ -72,14 +78,16 @@ func (p *Permissions) FindbyRoleId(id int) ([]models.Permissions, error) {
 
 }
 
-func (p *Permissions) FindAll() ([]models.Permissions, error) {
+func (p *Permissions) FindAll(ctx context.Context) ([]models.Permissions, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
  SELECT * FROM permissions
  ORDER BY permissionid
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement)
+	rows, err := p.db.QueryContext(ctx, sqlStatement)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	var items []models.Permissions
@@ -103,22 +111,26 @@ func (p *Permissions) FindAll() ([]models.Permissions, error) {
 	return items, nil
 }
 
-func (p *Permissions) Delete(id int) error {
+func (p *Permissions) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM permissions 
   WHERE permissionid  = $1
   `
-	_, err := p.db.Exec(sqlStatement, id)
+	_, err := p.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (p *Permissions) Update(perms models.Permissions) (models.Permissions, error) {
+func (p *Permissions) Update(ctx context.Context, perms models.Permissions) (models.Permissions, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE permissions
 SET permission=$2,roleid=$3
 WHERE permissionid = $1
 RETURNING *;
   `
 	var perm models.Permissions
-	err := p.db.QueryRow(sqlStatement, perms.Permissionid, perms.Permission, perms.Roleid).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, perms.Permissionid, perms.Permission, perms.Roleid).Scan(
 		&perm.Permissionid,
 		&perm.Permission,
 		&perm.Roleid,
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/patienttracker/internal/models"
@@ -26,17 +27,17 @@ func CreatePerm(t *testing.T) models.Permissions {
 }
 func TestCreatePermissions(t *testing.T) {
 	perm := CreatePerm(t)
-	nperm, err := controllers.Permissions.Create(perm)
+	nperm, err := controllers.Permissions.Create(context.Background(), perm)
 	require.NoError(t, err)
 	require.Equal(t, perm.Permission, nperm.Permission)
 }
 
 func TestFindPermission(t *testing.T) {
 	perm := CreatePerm(t)
-	nperm, err := controllers.Permissions.Create(perm)
+	nperm, err := controllers.Permissions.Create(context.Background(), perm)
 	require.NoError(t, err)
 	require.Equal(t, perm.Permission, nperm.Permission)
-	fperm, err := controllers.Permissions.Find(nperm.Permissionid)
+	fperm, err := controllers.Permissions.Find(context.Background(), nperm.Permissionid)
 	require.NoError(t, err)
 	require.Equal(t, nperm, fperm)
 }
@@ -44,12 +45,12 @@ func TestFindPermission(t *testing.T) {
 func TestListPermissions(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		perm := CreatePerm(t)
-		nperm, err := controllers.Permissions.Create(perm)
+		nperm, err := controllers.Permissions.Create(context.Background(), perm)
 		require.NoError(t, err)
 		require.Equal(t, perm.Permission, nperm.Permission)
 	}
 
-	perms, err := controllers.Permissions.FindAll()
+	perms, err := controllers.Permissions.FindAll(context.Background())
 	require.NoError(t, err)
 	for _, v := range perms {
 		require.NotNil(t, v)
@@ -65,12 +66,12 @@ func TestListPermissionsbyRoles(t *testing.T) {
 			Permission: utils.RandString(5),
 			Roleid:     r.Roleid,
 		}
-		nperm, err := controllers.Permissions.Create(perm)
+		nperm, err := controllers.Permissions.Create(context.Background(), perm)
 		require.NoError(t, err)
 		require.Equal(t, perm.Permission, nperm.Permission)
 	}
 
-	perms, err := controllers.Permissions.FindbyRoleId(r.Roleid)
+	perms, err := controllers.Permissions.FindbyRoleId(context.Background(), r.Roleid)
 	require.NoError(t, err)
 	for _, v := range perms {
 		require.NotNil(t, v)
@@ -80,23 +81,23 @@ func TestListPermissionsbyRoles(t *testing.T) {
 
 func TestDeletePermissions(t *testing.T) {
 	perm := CreatePerm(t)
-	nperm, err := controllers.Permissions.Create(perm)
+	nperm, err := controllers.Permissions.Create(context.Background(), perm)
 	require.NoError(t, err)
 	require.Equal(t, perm.Permission, nperm.Permission)
-	err = controllers.Permissions.Delete(nperm.Permissionid)
+	err = controllers.Permissions.Delete(context.Background(), nperm.Permissionid)
 	require.NoError(t, err)
-	fperm, err := controllers.Permissions.Find(nperm.Permissionid)
+	fperm, err := controllers.Permissions.Find(context.Background(), nperm.Permissionid)
 	require.Error(t, err)
 	require.Empty(t, fperm)
 }
 
 func TestUpdatePermissions(t *testing.T) {
 	perm := CreatePerm(t)
-	nperm, err := controllers.Permissions.Create(perm)
+	nperm, err := controllers.Permissions.Create(context.Background(), perm)
 	require.NoError(t, err)
 	require.Equal(t, perm.Permission, nperm.Permission)
 	perm1 := UpdatePerm(nperm.Permissionid, t)
-	perm2, err := controllers.Permissions.Update(perm1)
+	perm2, err := controllers.Permissions.Update(context.Background(), perm1)
 	require.NoError(t, err)
 	require.NotEqual(t, nperm.Permission, perm2.Permission)
 }
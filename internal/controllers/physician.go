@@ -2,14 +2,14 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"github.com/patienttracker/internal/models"
 )
 
 type Physician struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
 /*
@@ -20,13 +20,15 @@ type Physician struct {
 	Update(patient UpdatePatient) (Patient, error)
 */
 
-func (p Physician) Create(physician models.Physician) (models.Physician, error) {
+func (p Physician) Create(ctx context.Context, physician models.Physician) (models.Physician, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   INSERT INTO physician (username,hashed_password,full_name,email,contact,departmentname,about,verified,avatar) 
   VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
   RETURNING *
   `
-	err := p.db.QueryRow(sqlStatement, physician.Username, physician.Hashed_password,
+	err := p.db.QueryRowContext(ctx, sqlStatement, physician.Username, physician.Hashed_password,
 		physician.Full_name, physician.Email, physician.Contact, physician.Departmentname, physician.About, physician.Verified, physician.Avatar).Scan(
 		&physician.Physicianid,
 		&physician.Username,
@@ -42,19 +44,21 @@ func (p Physician) Create(physician models.Physician) (models.Physician, error)
 		&physician.Departmentname,
 	)
 	if err != nil {
-		return models.Physician{}, err
+		return models.Physician{}, dberror(err)
 	}
 	return physician, nil
 
 }
 
-func (p Physician) Find(id int) (models.Physician, error) {
+func (p Physician) Find(ctx context.Context, id int) (models.Physician, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM physician
   WHERE physician.doctorid = $1
   `
 	var doc models.Physician
-	err := p.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&doc.Physicianid,
 		&doc.Username,
 		&doc.Hashed_password,
@@ -71,13 +75,15 @@ func (p Physician) Find(id int) (models.Physician, error) {
 	return doc, err
 }
 
-func (p Physician) FindbyEmail(email string) (models.Physician, error) {
+func (p Physician) FindbyEmail(ctx context.Context, email string) (models.Physician, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM physician
   WHERE physician.email = $1
   `
 	var doc models.Physician
-	err := p.db.QueryRowContext(context.Background(), sqlStatement, email).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, email).Scan(
 		&doc.Physicianid,
 		&doc.Username,
 		&doc.Hashed_password,
@@ -94,7 +100,9 @@ func (p Physician) FindbyEmail(email string) (models.Physician, error) {
 	return doc, err
 }
 
-func (p Physician) FindDoctorsbyDept(dept string, args models.Filters) ([]models.Physician, *models.Metadata, error) {
+func (p Physician) FindDoctorsbyDept(ctx context.Context, dept string, args models.Filters) ([]models.Physician, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
@@ -104,7 +112,7 @@ func (p Physician) FindDoctorsbyDept(dept string, args models.Filters) ([]models
 	LIMIT $2
 	OFFSET $3
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, dept, args.Limit(), args.Offset())
+	rows, err := p.db.QueryContext(ctx, sqlStatement, dept, args.Limit(), args.Offset())
 	if err != nil {
 		return []models.Physician{}, &metadata, err
 	}
@@ -137,7 +145,9 @@ func (p Physician) FindDoctorsbyDept(dept string, args models.Filters) ([]models
 	return items, &metadata, nil
 }
 
-func (p Physician) FindAll(args models.Filters) ([]models.Physician, *models.Metadata, error) {
+func (p Physician) FindAll(ctx context.Context, args models.Filters) ([]models.Physician, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
@@ -146,7 +156,7 @@ func (p Physician) FindAll(args models.Filters) ([]models.Physician, *models.Met
  LIMIT $1
  OFFSET $2
   `
-	rows, err := p.db.QueryContext(context.Background(), sqlStatement, args.Limit(), args.Offset())
+	rows, err := p.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
 	if err != nil {
 		return []models.Physician{}, &metadata, err
 	}
@@ -178,22 +188,26 @@ func (p Physician) FindAll(args models.Filters) ([]models.Physician, *models.Met
 	return items, &metadata, nil
 }
 
-func (p Physician) Delete(id int) error {
+func (p Physician) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM physician
   WHERE doctorid  = $1
   `
-	_, err := p.db.Exec(sqlStatement, id)
+	_, err := p.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (p Physician) Update(doctor models.Physician) (models.Physician, error) {
+func (p Physician) Update(ctx context.Context, doctor models.Physician) (models.Physician, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE physician
 SET username = $2, full_name = $3, email = $4,hashed_password=$5,password_changed_at=$6,contact = $7,departmentname=$8,about = $9,verified = $10,avatar = $11
 WHERE doctorid = $1
 RETURNING doctorid,full_name,username,email,contact,departmentname;
   `
 	var doc models.Physician
-	err := p.db.QueryRow(sqlStatement, doctor.Physicianid, doctor.Username, doctor.Full_name, doctor.Email, doctor.Hashed_password, doctor.Password_changed_at, doctor.Contact, doctor.Departmentname, doctor.About, doctor.Verified, doctor.Avatar).Scan(
+	err := p.db.QueryRowContext(ctx, sqlStatement, doctor.Physicianid, doctor.Username, doctor.Full_name, doctor.Email, doctor.Hashed_password, doctor.Password_changed_at, doctor.Contact, doctor.Departmentname, doctor.About, doctor.Verified, doctor.Avatar).Scan(
 		&doc.Physicianid,
 		&doc.Full_name,
 		&doc.Username,
@@ -202,12 +216,14 @@ RETURNING doctorid,full_name,username,email,contact,departmentname;
 		&doc.Departmentname,
 	)
 	if err != nil {
-		return doc, err
+		return doc, dberror(err)
 	}
 	return doc, nil
 }
 
-func (p Physician) Filter(username string, departmentname string, filters models.Filters) ([]*models.Physician, *models.Metadata, error) {
+func (p Physician) Filter(ctx context.Context, username string, departmentname string, filters models.Filters) ([]*models.Physician, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, p.timeout)
+	defer cancel()
 	var metadata models.Metadata
 	counter := 0
 	query := `
@@ -216,8 +232,6 @@ FROM physician
 WHERE (username ILIKE '%' || $1 || '%' OR $1 = '')
 AND (departmentname ILIKE '%' || $2 || '%' OR $2 = '')
 ORDER BY doctorid ASC LIMIT $3 OFFSET $4`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
 	// Pass the title and genres as the placeholder parameter values.
 	rows, err := p.db.QueryContext(ctx, query, username, departmentname, filters.Limit(), filters.Offset())
 	if err != nil {
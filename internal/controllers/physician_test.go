@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"github.com/patienttracker/internal/models"
 	"github.com/patienttracker/internal/utils"
 	"github.com/stretchr/testify/require"
@@ -12,7 +13,7 @@ func RandDoctor() models.Physician {
 	username := utils.RandUsername(6)
 	email := utils.RandEmail(5)
 	fname := utils.Randfullname()
-	deptname, _ := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	deptname, _ := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	return models.Physician{
 		Username:        username,
 		Full_name:       fname,
@@ -29,7 +30,7 @@ func RandUpdDoctor(id int) models.Physician {
 	email := utils.RandEmail(5)
 	fname := utils.Randfullname()
 	//date := utils.Randate()
-	deptname, _ := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	deptname, _ := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	return models.Physician{
 		Physicianid:         id,
 		Username:            username,
@@ -57,7 +58,7 @@ func TestCreateDoc(t *testing.T) {
 		},
 	} {
 		t.Run(scenario.description, func(t *testing.T) {
-			user, err := controllers.Doctors.Create(scenario.input)
+			user, err := controllers.Doctors.Create(context.Background(), scenario.input)
 			require.NoError(t, err)
 			require.Equal(t, doc.Contact, user.Contact)
 		})
@@ -67,9 +68,9 @@ func TestCreateDoc(t *testing.T) {
 
 func TestFindDoc(t *testing.T) {
 	doc := RandDoctor()
-	user, err := controllers.Doctors.Create(doc)
+	user, err := controllers.Doctors.Create(context.Background(), doc)
 	require.NoError(t, err)
-	newdoc, err := controllers.Doctors.Find(user.Physicianid)
+	newdoc, err := controllers.Doctors.Find(context.Background(), user.Physicianid)
 	require.NoError(t, err)
 	require.NotEmpty(t, newdoc)
 	require.Equal(t, newdoc, user)
@@ -77,16 +78,16 @@ func TestFindDoc(t *testing.T) {
 
 func TestFindDocbyEmail(t *testing.T) {
 	doc := RandDoctor()
-	user, err := controllers.Doctors.Create(doc)
+	user, err := controllers.Doctors.Create(context.Background(), doc)
 	require.NoError(t, err)
-	newdoc, err := controllers.Doctors.FindbyEmail(user.Email)
+	newdoc, err := controllers.Doctors.FindbyEmail(context.Background(), user.Email)
 	require.NoError(t, err)
 	require.NotEmpty(t, newdoc)
 	require.Equal(t, newdoc, user)
 }
 func TestFindDocbyDept(t *testing.T) {
 	var user models.Physician
-	deptname, _ := controllers.Department.Create(models.Department{Departmentname: utils.RandString(6)})
+	deptname, _ := controllers.Department.Create(context.Background(), models.Department{Departmentname: utils.RandString(6)})
 	for i := 0; i < 5; i++ {
 		username := utils.RandUsername(6)
 		email := utils.RandEmail(5)
@@ -99,13 +100,13 @@ func TestFindDocbyDept(t *testing.T) {
 			Contact:         utils.RandContact(10),
 			Departmentname:  deptname.Departmentname,
 		}
-		user, _ = controllers.Doctors.Create(doc)
+		user, _ = controllers.Doctors.Create(context.Background(), doc)
 	}
 	args := models.Filters{
 		PageSize: 5,
 		Page:     1,
 	}
-	newdoc, _, err := controllers.Doctors.FindDoctorsbyDept(user.Departmentname, args)
+	newdoc, _, err := controllers.Doctors.FindDoctorsbyDept(context.Background(), user.Departmentname, args)
 	require.NoError(t, err)
 	require.NotEmpty(t, newdoc)
 	for _, v := range newdoc {
@@ -119,14 +120,14 @@ func TestFindDocbyDept(t *testing.T) {
 func TestListDocs(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		doc := RandDoctor()
-		_, err := controllers.Doctors.Create(doc)
+		_, err := controllers.Doctors.Create(context.Background(), doc)
 		require.NoError(t, err)
 	}
 	args := models.Filters{
 		PageSize: 5,
 		Page:     1,
 	}
-	docs, _, err := controllers.Doctors.FindAll(args)
+	docs, _, err := controllers.Doctors.FindAll(context.Background(), args)
 	require.NoError(t, err)
 	for _, v := range docs {
 		require.NotNil(t, v)
@@ -139,21 +140,21 @@ func TestListDocs(t *testing.T) {
 
 func TestDeleteDoc(t *testing.T) {
 	doc := RandDoctor()
-	newdoc, err := controllers.Doctors.Create(doc)
+	newdoc, err := controllers.Doctors.Create(context.Background(), doc)
 	require.NoError(t, err)
-	err = controllers.Doctors.Delete(newdoc.Physicianid)
+	err = controllers.Doctors.Delete(context.Background(), newdoc.Physicianid)
 	require.NoError(t, err)
-	user2, err := controllers.Doctors.Find(newdoc.Physicianid)
+	user2, err := controllers.Doctors.Find(context.Background(), newdoc.Physicianid)
 	require.Error(t, err)
 	require.Empty(t, user2)
 }
 
 func TestUpdateDoc(t *testing.T) {
 	doc := RandDoctor()
-	user, err := controllers.Doctors.Create(doc)
+	user, err := controllers.Doctors.Create(context.Background(), doc)
 	require.NoError(t, err)
 	docupd := RandUpdDoctor(user.Physicianid)
-	update, err := controllers.Doctors.Update(docupd)
+	update, err := controllers.Doctors.Update(context.Background(), docupd)
 	require.NoError(t, err)
 	require.Equal(t, docupd.Email, update.Email)
 }
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Reminder struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func scanreminder(row scanner) (models.Reminder, error) {
+	var reminder models.Reminder
+	var seconds int64
+	var sent sql.NullTime
+	err := row.Scan(
+		&reminder.Reminderid,
+		&reminder.Appointmentid,
+		&seconds,
+		&reminder.Due,
+		&sent,
+		&reminder.CreatedAt,
+	)
+	reminder.Offset = time.Duration(seconds) * time.Second
+	reminder.Sent = sent.Time
+	return reminder, err
+}
+
+func (r *Reminder) Create(ctx context.Context, reminder models.Reminder) (models.Reminder, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO reminders (appointmentid,seconds,due)
+  VALUES($1,$2,$3)
+  RETURNING *
+  `
+	reminder, err := scanreminder(r.db.QueryRowContext(ctx, sqlStatement, reminder.Appointmentid, int64(reminder.Offset/time.Second), reminder.Due))
+	return reminder, dberror(err)
+}
+
+// FindbyAppointment lists the reminders of the appointment by the time they're due
+func (r *Reminder) FindbyAppointment(ctx context.Context, id int) ([]models.Reminder, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT * FROM reminders
+ WHERE appointmentid = $1
+ ORDER BY due,reminderid
+  `
+	return r.list(ctx, sqlStatement, id)
+}
+
+func (r *Reminder) FindDue(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT * FROM reminders
+ WHERE sent IS NULL AND due <= $1
+ ORDER BY due,reminderid
+ LIMIT $2
+  `
+	return r.list(ctx, sqlStatement, now, limit)
+}
+
+func (r *Reminder) list(ctx context.Context, sqlStatement string, args ...any) ([]models.Reminder, error) {
+	rows, err := r.db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.Reminder
+	for rows.Next() {
+		i, err := scanreminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Reminder) MarkSent(ctx context.Context, id int, at time.Time) error {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
+	sqlStatement := `
+  UPDATE reminders
+  SET sent = $2
+  WHERE reminderid = $1 AND sent IS NULL
+  `
+	result, err := r.db.ExecContext(ctx, sqlStatement, id, at)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *Reminder) DeletePending(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
+	sqlStatement := `DELETE FROM reminders
+  WHERE appointmentid = $1 AND sent IS NULL
+  `
+	_, err := r.db.ExecContext(ctx, sqlStatement, id)
+	return err
+}
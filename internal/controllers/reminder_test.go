@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateReminder(t *testing.T) {
+	appointment := CreateAppointment()
+	due := appointment.Appointmentdate.Add(-24 * time.Hour)
+	reminder, err := controllers.Reminders.Create(context.Background(), models.Reminder{Appointmentid: appointment.Appointmentid, Offset: 24 * time.Hour, Due: due})
+	require.NoError(t, err)
+	require.NotZero(t, reminder.Reminderid)
+	require.Equal(t, 24*time.Hour, reminder.Offset)
+	require.True(t, reminder.Pending())
+	_, err = controllers.Reminders.Create(context.Background(), models.Reminder{Appointmentid: appointment.Appointmentid, Offset: 24 * time.Hour, Due: due})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+}
+
+func TestMarkReminderSent(t *testing.T) {
+	appointment := CreateAppointment()
+	reminder, err := controllers.Reminders.Create(context.Background(), models.Reminder{Appointmentid: appointment.Appointmentid, Offset: time.Hour, Due: appointment.Appointmentdate.Add(-time.Hour)})
+	require.NoError(t, err)
+	require.NoError(t, controllers.Reminders.MarkSent(context.Background(), reminder.Reminderid, time.Now()))
+	require.ErrorIs(t, controllers.Reminders.MarkSent(context.Background(), reminder.Reminderid, time.Now()), sql.ErrNoRows)
+	require.NoError(t, controllers.Reminders.DeletePending(context.Background(), appointment.Appointmentid))
+	reminders, err := controllers.Reminders.FindbyAppointment(context.Background(), appointment.Appointmentid)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	require.False(t, reminders[0].Pending())
+}
@@ -2,61 +2,69 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
-	"log"
+	"time"
 
 	"github.com/patienttracker/internal/models"
 )
 
 type Roles struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (r *Roles) Create(roles models.Roles) (models.Roles, error) {
+func (r *Roles) Create(ctx context.Context, roles models.Roles) (models.Roles, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
 	sqlStatement := `
   INSERT INTO roles (role) 
   VALUES($1)
   RETURNING *
   `
-	err := r.db.QueryRow(sqlStatement, roles.Role).Scan(
+	err := r.db.QueryRowContext(ctx, sqlStatement, roles.Role).Scan(
 		&roles.Roleid,
 		&roles.Role,
 	)
-	return roles, err
+	return roles, dberror(err)
 }
 
-func (r *Roles) Find(id int) (models.Roles, error) {
+func (r *Roles) Find(ctx context.Context, id int) (models.Roles, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM roles
   WHERE roles.roleid = $1
   `
 	var role models.Roles
-	err := r.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	err := r.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&role.Roleid,
 		&role.Role,
 	)
 	return role, err
 }
-func (r *Roles) FindbyRole(rolename string) (models.Roles, error) {
+func (r *Roles) FindbyRole(ctx context.Context, rolename string) (models.Roles, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM roles
   WHERE roles.role = $1
   `
 	var role models.Roles
-	err := r.db.QueryRowContext(context.Background(), sqlStatement, rolename).Scan(
+	err := r.db.QueryRowContext(ctx, sqlStatement, rolename).Scan(
 		&role.Roleid,
 		&role.Role,
 	)
 	return role, err
 }
-func (r *Roles) FindAll() ([]models.Roles, error) {
+func (r *Roles) FindAll(ctx context.Context) ([]models.Roles, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
 	sqlStatement := `
  SELECT * FROM roles
  ORDER BY roleid
   `
-	rows, err := r.db.QueryContext(context.Background(), sqlStatement)
+	rows, err := r.db.QueryContext(ctx, sqlStatement)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	var items []models.Roles
@@ -79,24 +87,28 @@ func (r *Roles) FindAll() ([]models.Roles, error) {
 	return items, nil
 }
 
-func (r *Roles) Delete(id int) error {
+func (r *Roles) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM roles 
   WHERE roleid  = $1
   `
-	_, err := r.db.Exec(sqlStatement, id)
+	_, err := r.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (r *Roles) Update(role models.Roles) (models.Roles, error) {
+func (r *Roles) Update(ctx context.Context, role models.Roles) (models.Roles, error) {
+	ctx, cancel := querycontext(ctx, r.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE roles
 SET role = $2
 WHERE roleid = $1
 RETURNING *
   `
 	var rol models.Roles
-	err := r.db.QueryRow(sqlStatement, role.Roleid, role.Role).Scan(
+	err := r.db.QueryRowContext(ctx, sqlStatement, role.Roleid, role.Role).Scan(
 		&rol.Roleid,
 		&rol.Role,
 	)
-	return rol, err
+	return rol, dberror(err)
 }
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/patienttracker/internal/models"
@@ -23,7 +24,7 @@ func UpdateRole(id int) models.Roles {
 
 func TestCreateRole(t *testing.T) {
 	roles := CreateRoles()
-	role, err := controllers.Roles.Create(roles)
+	role, err := controllers.Roles.Create(context.Background(), roles)
 	require.NoError(t, err)
 	require.Equal(t, roles.Role, role.Role)
 
@@ -31,10 +32,10 @@ func TestCreateRole(t *testing.T) {
 
 func TestFindRole(t *testing.T) {
 	roles := CreateRoles()
-	role, err := controllers.Roles.Create(roles)
+	role, err := controllers.Roles.Create(context.Background(), roles)
 	require.NoError(t, err)
 	require.Equal(t, roles.Role, role.Role)
-	r, err := controllers.Roles.Find(role.Roleid)
+	r, err := controllers.Roles.Find(context.Background(), role.Roleid)
 	require.NoError(t, err)
 	require.Equal(t, r, role)
 }
@@ -42,12 +43,12 @@ func TestFindRole(t *testing.T) {
 func TestListRoles(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		roles := CreateRoles()
-		role, err := controllers.Roles.Create(roles)
+		role, err := controllers.Roles.Create(context.Background(), roles)
 		require.NoError(t, err)
 		require.Equal(t, roles.Role, role.Role)
 	}
 
-	roles, err := controllers.Roles.FindAll()
+	roles, err := controllers.Roles.FindAll(context.Background())
 	require.NoError(t, err)
 	for _, v := range roles {
 		require.NotNil(t, v)
@@ -58,23 +59,23 @@ func TestListRoles(t *testing.T) {
 
 func TestDeleteRole(t *testing.T) {
 	roles := CreateRoles()
-	role, err := controllers.Roles.Create(roles)
+	role, err := controllers.Roles.Create(context.Background(), roles)
 	require.NoError(t, err)
 	require.Equal(t, roles.Role, role.Role)
-	err = controllers.Roles.Delete(role.Roleid)
+	err = controllers.Roles.Delete(context.Background(), role.Roleid)
 	require.NoError(t, err)
-	rolez, err := controllers.Roles.Find(role.Roleid)
+	rolez, err := controllers.Roles.Find(context.Background(), role.Roleid)
 	require.Error(t, err)
 	require.Empty(t, rolez)
 }
 
 func TestUpdateRole(t *testing.T) {
 	roles := CreateRoles()
-	role, err := controllers.Roles.Create(roles)
+	role, err := controllers.Roles.Create(context.Background(), roles)
 	require.NoError(t, err)
 	require.Equal(t, roles.Role, role.Role)
 	role1 := UpdateRole(role.Roleid)
-	role2, err := controllers.Roles.Update(role1)
+	role2, err := controllers.Roles.Update(context.Background(), role1)
 	require.NoError(t, err)
 	require.NotEqual(t, role.Role, role2.Role)
 }
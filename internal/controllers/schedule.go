@@ -3,70 +3,103 @@ package controllers
 import (
 	"context"
 	"database/sql"
-	"log"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/patienttracker/internal/models"
 )
 
+// Schedule keeps the blocks of a schedule in the schedule_block table,
+// writing a schedule takes several statements so run them in a unit of work.
 type Schedule struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (s Schedule) Create(schedule models.Schedule) (models.Schedule, error) {
+func (s Schedule) Create(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
 	sqlStatement := `
-  INSERT INTO schedule (doctorid,starttime,endtime,active) 
-  VALUES($1,$2,$3,$4)
-  RETURNING *
+  INSERT INTO schedule (doctorid,starttime,endtime,active,validfrom,validuntil)
+  VALUES($1,$2,$3,$4,$5,$6)
+  RETURNING scheduleid,doctorid,starttime,endtime,active,validfrom,validuntil
   `
-	err := s.db.QueryRow(sqlStatement, schedule.Doctorid, schedule.Starttime, schedule.Endtime, schedule.Active).Scan(
+	var validfrom, validuntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, sqlStatement, schedule.Doctorid, schedule.Starttime, schedule.Endtime, schedule.Active, nulldate(schedule.Validfrom), nulldate(schedule.Validuntil)).Scan(
 		&schedule.Scheduleid,
 		&schedule.Doctorid,
 		&schedule.Starttime,
 		&schedule.Endtime,
 		&schedule.Active,
+		&validfrom,
+		&validuntil,
 	)
+	if err != nil {
+		return schedule, err
+	}
+	schedule.Validfrom, schedule.Validuntil = validfrom.Time, validuntil.Time
+	schedule.Blocks, err = s.setblocks(ctx, schedule.Scheduleid, schedule.Blocks)
 	return schedule, err
 
 }
 
-func (s Schedule) Find(id int) (models.Schedule, error) {
+func (s Schedule) Find(ctx context.Context, id int) (models.Schedule, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
 	sqlStatement := `
-  SELECT * FROM schedule
+  SELECT scheduleid,doctorid,starttime,endtime,active,validfrom,validuntil FROM schedule
   WHERE schedule.scheduleid = $1
   `
 	var schedule models.Schedule
-	err := s.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	var validfrom, validuntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&schedule.Scheduleid,
 		&schedule.Doctorid,
 		&schedule.Starttime,
 		&schedule.Endtime,
 		&schedule.Active,
+		&validfrom,
+		&validuntil,
 	)
-	return schedule, err
+	if err != nil {
+		return schedule, err
+	}
+	schedule.Validfrom, schedule.Validuntil = validfrom.Time, validuntil.Time
+	schedules := []models.Schedule{schedule}
+	if err := s.loadblocks(ctx, schedules); err != nil {
+		return schedule, err
+	}
+	return schedules[0], nil
 }
-func (s Schedule) FindbyDoctor(id int) ([]models.Schedule, error) {
+func (s Schedule) FindbyDoctor(ctx context.Context, id int) ([]models.Schedule, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
 	sqlStatement := `
- SELECT scheduleid,doctorid,starttime,endtime,active FROM schedule
+ SELECT scheduleid,doctorid,starttime,endtime,active,validfrom,validuntil FROM schedule
  WHERE schedule.doctorid = $1
  ORDER BY scheduleid
   `
-	rows, err := s.db.Query(sqlStatement, id)
+	rows, err := s.db.QueryContext(ctx, sqlStatement, id)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	var items []models.Schedule
 	for rows.Next() {
 		var schedule models.Schedule
+		var validfrom, validuntil sql.NullTime
 		if err := rows.Scan(
 			&schedule.Scheduleid,
 			&schedule.Doctorid,
 			&schedule.Starttime,
 			&schedule.Endtime,
 			&schedule.Active,
+			&validfrom,
+			&validuntil,
 		); err != nil {
 			return nil, err
 		}
+		schedule.Validfrom, schedule.Validuntil = validfrom.Time, validuntil.Time
 		items = append(items, schedule)
 	}
 	if err := rows.Close(); err != nil {
@@ -75,26 +108,32 @@ func (s Schedule) FindbyDoctor(id int) ([]models.Schedule, error) {
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	if err := s.loadblocks(ctx, items); err != nil {
+		return nil, err
+	}
 	return items, nil
 
 }
-func (s Schedule) FindAll(args models.Filters) ([]models.Schedule, *models.Metadata, error) {
+func (s Schedule) FindAll(ctx context.Context, args models.Filters) ([]models.Schedule, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
 	var count = 0
 	var metadata models.Metadata
 	sqlStatement := `
- SELECT  count(*) OVER(),scheduleid,doctorid,starttime,endtime,active FROM schedule
+ SELECT  count(*) OVER(),scheduleid,doctorid,starttime,endtime,active,validfrom,validuntil FROM schedule
  ORDER BY scheduleid
  LIMIT $1
  OFFSET $2
   `
-	rows, err := s.db.QueryContext(context.Background(), sqlStatement, args.Limit(), args.Offset())
+	rows, err := s.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
 	if err != nil {
-		log.Fatal(err)
+		return nil, &metadata, err
 	}
 	defer rows.Close()
 	var items []models.Schedule
 	for rows.Next() {
 		var schedule models.Schedule
+		var validfrom, validuntil sql.NullTime
 		if err := rows.Scan(
 			&count,
 			&schedule.Scheduleid,
@@ -102,9 +141,12 @@ func (s Schedule) FindAll(args models.Filters) ([]models.Schedule, *models.Metad
 			&schedule.Starttime,
 			&schedule.Endtime,
 			&schedule.Active,
+			&validfrom,
+			&validuntil,
 		); err != nil {
 			return nil, &metadata, err
 		}
+		schedule.Validfrom, schedule.Validuntil = validfrom.Time, validuntil.Time
 		items = append(items, schedule)
 	}
 	if err := rows.Close(); err != nil {
@@ -113,31 +155,104 @@ func (s Schedule) FindAll(args models.Filters) ([]models.Schedule, *models.Metad
 	if err := rows.Err(); err != nil {
 		return nil, &metadata, err
 	}
+	if err := s.loadblocks(ctx, items); err != nil {
+		return nil, &metadata, err
+	}
 	metadata = models.CalculateMetadata(count, args.Page, args.PageSize)
 	return items, &metadata, nil
 }
 
-func (s Schedule) Delete(id int) error {
-	sqlStatement := `DELETE FROM schedule 
+func (s Schedule) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `DELETE FROM schedule
   WHERE scheduleid  = $1
   `
-	_, err := s.db.Exec(sqlStatement, id)
+	_, err := s.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (s Schedule) Update(schedule models.Schedule) (models.Schedule, error) {
+// Update replaces the blocks of the schedule with those of the update
+func (s Schedule) Update(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE schedule
-SET starttime = $2,endtime=$3,active=$4
+SET starttime = $2,endtime=$3,active=$4,validfrom=$5,validuntil=$6
 WHERE scheduleid = $1
-RETURNING *;
+RETURNING scheduleid,doctorid,starttime,endtime,active,validfrom,validuntil;
   `
 	var sched models.Schedule
-	err := s.db.QueryRow(sqlStatement, schedule.Scheduleid, schedule.Starttime, schedule.Endtime, schedule.Active).Scan(
+	var validfrom, validuntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, sqlStatement, schedule.Scheduleid, schedule.Starttime, schedule.Endtime, schedule.Active, nulldate(schedule.Validfrom), nulldate(schedule.Validuntil)).Scan(
 		&sched.Scheduleid,
 		&sched.Doctorid,
 		&sched.Starttime,
 		&sched.Endtime,
 		&sched.Active,
+		&validfrom,
+		&validuntil,
 	)
+	if err != nil {
+		return sched, err
+	}
+	sched.Validfrom, sched.Validuntil = validfrom.Time, validuntil.Time
+	sched.Blocks, err = s.setblocks(ctx, sched.Scheduleid, schedule.Blocks)
 	return sched, err
 }
+
+// setblocks replaces the blocks of the schedule id
+func (s Schedule) setblocks(ctx context.Context, id int, blocks []models.Block) ([]models.Block, error) {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM schedule_block WHERE scheduleid = $1`, id); err != nil {
+		return nil, err
+	}
+	sqlStatement := `
+  INSERT INTO schedule_block (scheduleid,weekday,starttime,endtime)
+  VALUES($1,$2,$3,$4)
+  `
+	for _, block := range blocks {
+		if _, err := s.db.ExecContext(ctx, sqlStatement, id, block.Weekday, block.Starttime, block.Endtime); err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// loadblocks fills in the blocks of the schedules with a single query
+func (s Schedule) loadblocks(ctx context.Context, schedules []models.Schedule) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(schedules))
+	byid := make(map[int]*models.Schedule, len(schedules))
+	for i := range schedules {
+		ids = append(ids, int64(schedules[i].Scheduleid))
+		byid[schedules[i].Scheduleid] = &schedules[i]
+	}
+	sqlStatement := `
+ SELECT scheduleid,weekday,starttime,endtime FROM schedule_block
+ WHERE scheduleid = ANY($1)
+ ORDER BY blockid
+  `
+	rows, err := s.db.QueryContext(ctx, sqlStatement, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var block models.Block
+		if err := rows.Scan(&id, &block.Weekday, &block.Starttime, &block.Endtime); err != nil {
+			return err
+		}
+		byid[id].Blocks = append(byid[id].Blocks, block)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// nulldate stores the zero time as NULL,an open end of the validity of a schedule or an event due at once
+func nulldate(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
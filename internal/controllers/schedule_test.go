@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"github.com/patienttracker/internal/models"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -27,9 +28,9 @@ func UpdateSchedule(id int) models.Schedule {
 
 func TestCreateSchedule(t *testing.T) {
 	doc := RandDoctor()
-	doctor, _ := controllers.Doctors.Create(doc)
+	doctor, _ := controllers.Doctors.Create(context.Background(), doc)
 	schedule := CreateSchedule(doctor.Physicianid)
-	schedul, err := controllers.Schedule.Create(schedule)
+	schedul, err := controllers.Schedule.Create(context.Background(), schedule)
 	require.NoError(t, err)
 	require.Equal(t, schedul.Doctorid, schedule.Doctorid)
 
@@ -37,11 +38,11 @@ func TestCreateSchedule(t *testing.T) {
 
 func TestFindSchedule(t *testing.T) {
 	doc := RandDoctor()
-	doctor, _ := controllers.Doctors.Create(doc)
+	doctor, _ := controllers.Doctors.Create(context.Background(), doc)
 	schedule := CreateSchedule(doctor.Physicianid)
-	schedul, err := controllers.Schedule.Create(schedule)
+	schedul, err := controllers.Schedule.Create(context.Background(), schedule)
 	require.NoError(t, err)
-	work, err := controllers.Schedule.Find(schedul.Scheduleid)
+	work, err := controllers.Schedule.Find(context.Background(), schedul.Scheduleid)
 	require.NoError(t, err)
 	require.Equal(t, work, schedul)
 }
@@ -49,13 +50,13 @@ func TestFindSchedule(t *testing.T) {
 func TestFindScheduleByDoctor(t *testing.T) {
 	var sched models.Schedule
 	doc := RandDoctor()
-	doctor, _ := controllers.Doctors.Create(doc)
+	doctor, _ := controllers.Doctors.Create(context.Background(), doc)
 	schedule := CreateSchedule(doctor.Physicianid)
 	for i := 0; i < 5; i++ {
-		sched, _ = controllers.Schedule.Create(schedule)
+		sched, _ = controllers.Schedule.Create(context.Background(), schedule)
 		require.NotEmpty(t, sched)
 	}
-	schedules, err := controllers.Schedule.FindbyDoctor(sched.Doctorid)
+	schedules, err := controllers.Schedule.FindbyDoctor(context.Background(), sched.Doctorid)
 	require.NoError(t, err)
 	for _, v := range schedules {
 		require.NotNil(t, v)
@@ -67,16 +68,16 @@ func TestFindScheduleByDoctor(t *testing.T) {
 func TestListSchedule(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		doc := RandDoctor()
-		doctor, _ := controllers.Doctors.Create(doc)
+		doctor, _ := controllers.Doctors.Create(context.Background(), doc)
 		schedule := CreateSchedule(doctor.Physicianid)
-		_, err := controllers.Schedule.Create(schedule)
+		_, err := controllers.Schedule.Create(context.Background(), schedule)
 		require.NoError(t, err)
 	}
 	args := models.Filters{
 		PageSize: 5,
 		Page:     1,
 	}
-	schedules, _, err := controllers.Schedule.FindAll(args)
+	schedules, _, err := controllers.Schedule.FindAll(context.Background(), args)
 	require.NoError(t, err)
 	for _, v := range schedules {
 		require.NotNil(t, v)
@@ -88,25 +89,25 @@ func TestListSchedule(t *testing.T) {
 
 func TestDeleteSchedule(t *testing.T) {
 	doc := RandDoctor()
-	doctor, _ := controllers.Doctors.Create(doc)
+	doctor, _ := controllers.Doctors.Create(context.Background(), doc)
 	schedule := CreateSchedule(doctor.Physicianid)
-	schedul, err := controllers.Schedule.Create(schedule)
+	schedul, err := controllers.Schedule.Create(context.Background(), schedule)
 	require.NoError(t, err)
-	err = controllers.Schedule.Delete(schedul.Scheduleid)
+	err = controllers.Schedule.Delete(context.Background(), schedul.Scheduleid)
 	require.NoError(t, err)
-	work, err := controllers.Schedule.Find(schedule.Scheduleid)
+	work, err := controllers.Schedule.Find(context.Background(), schedule.Scheduleid)
 	require.Error(t, err)
 	require.Empty(t, work)
 }
 
 func TestUpdateSchedule(t *testing.T) {
 	doc := RandDoctor()
-	doctor, _ := controllers.Doctors.Create(doc)
+	doctor, _ := controllers.Doctors.Create(context.Background(), doc)
 	schedule := CreateSchedule(doctor.Physicianid)
-	schedul, err := controllers.Schedule.Create(schedule)
+	schedul, err := controllers.Schedule.Create(context.Background(), schedule)
 	require.NoError(t, err)
 	schedule1 := UpdateSchedule(schedul.Scheduleid)
-	schedule2, err := controllers.Schedule.Update(schedule1)
+	schedule2, err := controllers.Schedule.Update(context.Background(), schedule1)
 	require.NoError(t, err)
 	require.NotEqual(t, schedule2.Starttime, schedule2.Endtime)
 }
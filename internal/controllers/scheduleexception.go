@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type ScheduleException struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func scanexception(row scanner) (models.ScheduleException, error) {
+	var exception models.ScheduleException
+	err := row.Scan(
+		&exception.Exceptionid,
+		&exception.Doctorid,
+		&exception.Starttime,
+		&exception.Endtime,
+		&exception.Reason,
+	)
+	return exception, err
+}
+
+func (e *ScheduleException) Create(ctx context.Context, exception models.ScheduleException) (models.ScheduleException, error) {
+	ctx, cancel := querycontext(ctx, e.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO schedule_exception (doctorid,starttime,endtime,reason)
+  VALUES($1,$2,$3,$4)
+  RETURNING *
+  `
+	return scanexception(e.db.QueryRowContext(ctx, sqlStatement, exception.Doctorid, exception.Starttime, exception.Endtime, exception.Reason))
+}
+
+func (e *ScheduleException) Find(ctx context.Context, id int) (models.ScheduleException, error) {
+	ctx, cancel := querycontext(ctx, e.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM schedule_exception
+  WHERE exceptionid = $1
+  `
+	return scanexception(e.db.QueryRowContext(ctx, sqlStatement, id))
+}
+
+// FindbyDoctor lists the exceptions of the doctor by their start
+func (e *ScheduleException) FindbyDoctor(ctx context.Context, id int) ([]models.ScheduleException, error) {
+	ctx, cancel := querycontext(ctx, e.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT * FROM schedule_exception
+ WHERE doctorid = $1
+ ORDER BY starttime,exceptionid
+  `
+	rows, err := e.db.QueryContext(ctx, sqlStatement, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.ScheduleException
+	for rows.Next() {
+		i, err := scanexception(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (e *ScheduleException) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, e.timeout)
+	defer cancel()
+	sqlStatement := `DELETE FROM schedule_exception
+  WHERE exceptionid = $1
+  `
+	_, err := e.db.ExecContext(ctx, sqlStatement, id)
+	return err
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateScheduleException(id int) models.ScheduleException {
+	start := time.Now().UTC().Truncate(time.Second).Add(48 * time.Hour)
+	return models.ScheduleException{
+		Doctorid:  id,
+		Starttime: start,
+		Endtime:   start.Add(8 * time.Hour),
+		Reason:    "holiday",
+	}
+}
+
+func TestCreateScheduleException(t *testing.T) {
+	doctor, _ := controllers.Doctors.Create(context.Background(), RandDoctor())
+	exception, err := controllers.Exceptions.Create(context.Background(), CreateScheduleException(doctor.Physicianid))
+	require.NoError(t, err)
+	require.NotZero(t, exception.Exceptionid)
+	require.Equal(t, doctor.Physicianid, exception.Doctorid)
+	require.Equal(t, "holiday", exception.Reason)
+}
+
+func TestFindScheduleExceptionByDoctor(t *testing.T) {
+	doctor, _ := controllers.Doctors.Create(context.Background(), RandDoctor())
+	for i := 0; i < 3; i++ {
+		_, err := controllers.Exceptions.Create(context.Background(), CreateScheduleException(doctor.Physicianid))
+		require.NoError(t, err)
+	}
+	exceptions, err := controllers.Exceptions.FindbyDoctor(context.Background(), doctor.Physicianid)
+	require.NoError(t, err)
+	require.Len(t, exceptions, 3)
+	for _, v := range exceptions {
+		require.Equal(t, doctor.Physicianid, v.Doctorid)
+	}
+}
+
+func TestDeleteScheduleException(t *testing.T) {
+	doctor, _ := controllers.Doctors.Create(context.Background(), RandDoctor())
+	exception, err := controllers.Exceptions.Create(context.Background(), CreateScheduleException(doctor.Physicianid))
+	require.NoError(t, err)
+	require.NoError(t, controllers.Exceptions.Delete(context.Background(), exception.Exceptionid))
+	_, err = controllers.Exceptions.Find(context.Background(), exception.Exceptionid)
+	require.Error(t, err)
+}
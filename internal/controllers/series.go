@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Series struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func scanseries(row scanner) (models.Series, error) {
+	var series models.Series
+	var minutes int64
+	var until sql.NullTime
+	err := row.Scan(
+		&series.Seriesid,
+		&series.Doctorid,
+		&series.Patientid,
+		&series.Start,
+		&minutes,
+		&series.Interval,
+		&series.Unit,
+		&series.Count,
+		&until,
+		&series.CreatedAt,
+	)
+	series.Duration = time.Duration(minutes) * time.Minute
+	series.Until = until.Time
+	return series, err
+}
+
+func (s *Series) Create(ctx context.Context, series models.Series) (models.Series, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO appointment_series (doctorid,patientid,starttime,minutes,every,unit,occurrences,until)
+  VALUES($1,$2,$3,$4,$5,$6,$7,$8)
+  RETURNING *
+  `
+	return scanseries(s.db.QueryRowContext(ctx, sqlStatement, series.Doctorid, series.Patientid, series.Start, int64(series.Duration/time.Minute),
+		series.Interval, series.Unit, series.Count, nulldate(series.Until)))
+}
+
+func (s *Series) Find(ctx context.Context, id int) (models.Series, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM appointment_series
+  WHERE seriesid = $1
+  `
+	return scanseries(s.db.QueryRowContext(ctx, sqlStatement, id))
+}
+
+func (s *Series) Update(ctx context.Context, series models.Series) (models.Series, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `
+  UPDATE appointment_series
+  SET starttime = $2,minutes = $3
+  WHERE seriesid = $1
+  RETURNING *
+  `
+	return scanseries(s.db.QueryRowContext(ctx, sqlStatement, series.Seriesid, series.Start, int64(series.Duration/time.Minute)))
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSeries(t *testing.T) {
+	appointment := CreateAppointment()
+	series, err := controllers.Series.Create(context.Background(), models.Series{
+		Doctorid:   appointment.Doctorid,
+		Patientid:  appointment.Patientid,
+		Start:      appointment.Appointmentdate,
+		Duration:   30 * time.Minute,
+		Recurrence: models.Recurrence{Interval: 1, Unit: models.UnitWeek, Count: 6},
+	})
+	require.NoError(t, err)
+	require.NotZero(t, series.Seriesid)
+	require.Equal(t, 30*time.Minute, series.Duration)
+	require.Equal(t, models.Recurrence{Interval: 1, Unit: models.UnitWeek, Count: 6}, series.Recurrence)
+}
+
+func TestFindAllBySeries(t *testing.T) {
+	appointment := CreateAppointment()
+	series, err := controllers.Series.Create(context.Background(), models.Series{
+		Doctorid:   appointment.Doctorid,
+		Patientid:  appointment.Patientid,
+		Start:      appointment.Appointmentdate,
+		Duration:   time.Hour,
+		Recurrence: models.Recurrence{Interval: 2, Unit: models.UnitDay, Count: 3},
+	})
+	require.NoError(t, err)
+	for _, occurrence := range series.Occurrences(time.UTC) {
+		_, err := controllers.Appointment.Create(context.Background(), occurrence)
+		require.NoError(t, err)
+	}
+	occurrences, err := controllers.Appointment.FindAllBySeries(context.Background(), series.Seriesid)
+	require.NoError(t, err)
+	require.Len(t, occurrences, 3)
+	for i, occurrence := range occurrences {
+		require.Equal(t, series.Seriesid, occurrence.Seriesid)
+		require.True(t, appointment.Appointmentdate.AddDate(0, 0, 2*i).Equal(occurrence.Appointmentdate))
+	}
+}
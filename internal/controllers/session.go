@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/patienttracker/internal/models"
+)
+
+type Session struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scansession(row scanner) (models.Session, error) {
+	var session models.Session
+	err := row.Scan(
+		&session.Id,
+		&session.AccountType,
+		&session.AccountId,
+		&session.Username,
+		&session.Kind,
+		&session.RefreshTokenId,
+		&session.UserAgent,
+		&session.ClientIp,
+		&session.Revoked,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	return session, err
+}
+
+func (s *Session) Create(ctx context.Context, session models.Session) (models.Session, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO sessions (id,account_type,account_id,username,kind,refresh_token_id,user_agent,client_ip,expires_at) 
+  VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
+  RETURNING *
+  `
+	session, err := scansession(s.db.QueryRowContext(ctx, sqlStatement, session.Id, session.AccountType, session.AccountId, session.Username,
+		session.Kind, session.RefreshTokenId, session.UserAgent, session.ClientIp, session.ExpiresAt))
+	return session, dberror(err)
+}
+
+func (s *Session) Find(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM sessions
+  WHERE sessions.id = $1
+  `
+	return scansession(s.db.QueryRowContext(ctx, sqlStatement, id))
+}
+
+func (s *Session) FindbyRefreshToken(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM sessions
+  WHERE sessions.refresh_token_id = $1
+  `
+	return scansession(s.db.QueryRowContext(ctx, sqlStatement, id))
+}
+
+// FindAll lists the active sessions,newest first
+func (s *Session) FindAll(ctx context.Context, args models.Filters) ([]models.Session, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	var count = 0
+	var metadata models.Metadata
+	sqlStatement := `
+ SELECT count(*) OVER(),* FROM sessions
+ WHERE revoked = false AND expires_at > now()
+ ORDER BY created_at DESC
+ LIMIT $1
+ OFFSET $2
+  `
+	rows, err := s.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
+	if err != nil {
+		return nil, &metadata, err
+	}
+	defer rows.Close()
+	var items []models.Session
+	for rows.Next() {
+		var i models.Session
+		if err := rows.Scan(
+			&count,
+			&i.Id,
+			&i.AccountType,
+			&i.AccountId,
+			&i.Username,
+			&i.Kind,
+			&i.RefreshTokenId,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.Revoked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, &metadata, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, &metadata, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &metadata, err
+	}
+	metadata = models.CalculateMetadata(count, args.Page, args.PageSize)
+	return items, &metadata, nil
+}
+
+// FindAllByAccount lists the active sessions of a single account
+func (s *Session) FindAllByAccount(ctx context.Context, accounttype string, id int) ([]models.Session, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT * FROM sessions
+ WHERE account_type = $1 AND account_id = $2 AND revoked = false AND expires_at > now()
+ ORDER BY created_at DESC
+  `
+	rows, err := s.db.QueryContext(ctx, sqlStatement, accounttype, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.Session
+	for rows.Next() {
+		i, err := scansession(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Rotate replaces the refresh token presented of an active session,it returns sql.ErrNoRows
+// when the session was revoked or the token presented was rotated in the meantime.
+func (s *Session) Rotate(ctx context.Context, id uuid.UUID, presented uuid.UUID, refreshtokenid uuid.UUID, expiresat time.Time) (models.Session, error) {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `UPDATE sessions
+SET refresh_token_id = $3, expires_at = $4
+WHERE sessions.id = $1 AND refresh_token_id = $2 AND revoked = false
+RETURNING *
+  `
+	session, err := scansession(s.db.QueryRowContext(ctx, sqlStatement, id, presented, refreshtokenid, expiresat))
+	return session, dberror(err)
+}
+
+func (s *Session) Revoke(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `UPDATE sessions
+SET revoked = true
+WHERE id = $1
+  `
+	_, err := s.db.ExecContext(ctx, sqlStatement, id)
+	return err
+}
+
+func (s *Session) RevokeAllByAccount(ctx context.Context, accounttype string, id int) error {
+	ctx, cancel := querycontext(ctx, s.timeout)
+	defer cancel()
+	sqlStatement := `UPDATE sessions
+SET revoked = true
+WHERE account_type = $1 AND account_id = $2
+  `
+	_, err := s.db.ExecContext(ctx, sqlStatement, accounttype, id)
+	return err
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateSession() models.Session {
+	return models.Session{
+		Id:             uuid.New(),
+		AccountType:    "patient",
+		AccountId:      utils.Randid(1, 10000),
+		Username:       utils.RandUsername(6),
+		Kind:           models.SessionToken,
+		RefreshTokenId: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	s := CreateSession()
+	session, err := controllers.Session.Create(context.Background(), s)
+	require.NoError(t, err)
+	require.Equal(t, s.Id, session.Id)
+	require.Equal(t, s.RefreshTokenId, session.RefreshTokenId)
+	require.True(t, session.Active())
+}
+
+func TestRotateSession(t *testing.T) {
+	session, err := controllers.Session.Create(context.Background(), CreateSession())
+	require.NoError(t, err)
+	refreshtokenid := uuid.New()
+	rotated, err := controllers.Session.Rotate(context.Background(), session.Id, session.RefreshTokenId.UUID, refreshtokenid, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, refreshtokenid, rotated.RefreshTokenId.UUID)
+	_, err = controllers.Session.FindbyRefreshToken(context.Background(), session.RefreshTokenId.UUID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	found, err := controllers.Session.FindbyRefreshToken(context.Background(), refreshtokenid)
+	require.NoError(t, err)
+	require.Equal(t, session.Id, found.Id)
+}
+
+func TestRevokeSession(t *testing.T) {
+	session, err := controllers.Session.Create(context.Background(), CreateSession())
+	require.NoError(t, err)
+	require.NoError(t, controllers.Session.Revoke(context.Background(), session.Id))
+	revoked, err := controllers.Session.Find(context.Background(), session.Id)
+	require.NoError(t, err)
+	require.False(t, revoked.Active())
+	_, err = controllers.Session.Rotate(context.Background(), session.Id, session.RefreshTokenId.UUID, uuid.New(), time.Now().Add(time.Hour))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRevokeAllSessionsByAccount(t *testing.T) {
+	s := CreateSession()
+	for i := 0; i < 3; i++ {
+		s.Id = uuid.New()
+		s.RefreshTokenId = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		_, err := controllers.Session.Create(context.Background(), s)
+		require.NoError(t, err)
+	}
+	sessions, err := controllers.Session.FindAllByAccount(context.Background(), s.AccountType, s.AccountId)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	require.NoError(t, controllers.Session.RevokeAllByAccount(context.Background(), s.AccountType, s.AccountId))
+	sessions, err = controllers.Session.FindAllByAccount(context.Background(), s.AccountType, s.AccountId)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
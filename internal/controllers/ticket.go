@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Ticket struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+const ticketcolumns = `ticketid,patientid,nurseid,doctorid,status,acuity,createdat,attendedat`
+
+func scanticket(row scanner, dest ...any) (models.Ticket, error) {
+	var ticket models.Ticket
+	var doctor sql.NullInt64
+	var attended sql.NullTime
+	err := row.Scan(append(dest,
+		&ticket.Ticketid,
+		&ticket.Patientid,
+		&ticket.Nurseid,
+		&doctor,
+		&ticket.Status,
+		&ticket.Acuity,
+		&ticket.CreatedAt,
+		&attended,
+	)...)
+	ticket.Doctorid = int(doctor.Int64)
+	ticket.AttendedAt = attended.Time
+	return ticket, err
+}
+
+func nullid(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+func (t *Ticket) Create(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO tickets (patientid,nurseid,doctorid,status,acuity)
+  VALUES($1,$2,$3,$4,$5)
+  RETURNING ` + ticketcolumns
+	ticket, err := scanticket(t.db.QueryRowContext(ctx, sqlStatement, ticket.Patientid, ticket.Nurseid, nullid(ticket.Doctorid), ticket.Status, ticket.Acuity))
+	return ticket, dberror(err)
+}
+
+func (t *Ticket) Find(ctx context.Context, id int) (models.Ticket, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT ` + ticketcolumns + ` FROM tickets
+  WHERE ticketid = $1
+  `
+	return scanticket(t.db.QueryRowContext(ctx, sqlStatement, id))
+}
+
+func (t *Ticket) FindAll(ctx context.Context, args models.Filters) ([]models.Ticket, *models.Metadata, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	var count = 0
+	var metadata models.Metadata
+	sqlStatement := `
+	SELECT count(*) OVER(),` + ticketcolumns + ` FROM tickets
+	ORDER BY ticketid
+	LIMIT $1
+	OFFSET $2
+  `
+	rows, err := t.db.QueryContext(ctx, sqlStatement, args.Limit(), args.Offset())
+	if err != nil {
+		return nil, &metadata, err
+	}
+	defer rows.Close()
+	var items []models.Ticket
+	for rows.Next() {
+		i, err := scanticket(rows, &count)
+		if err != nil {
+			return nil, &metadata, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, &metadata, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &metadata, err
+	}
+	metadata = models.CalculateMetadata(count, args.Page, args.PageSize)
+	return items, &metadata, nil
+}
+
+// FindbyNurse lists the queue of the nurse,the most urgent tickets first then the oldest
+func (t *Ticket) FindbyNurse(ctx context.Context, id int) ([]models.Ticket, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT ` + ticketcolumns + ` FROM tickets
+ WHERE nurseid = $1
+ ORDER BY acuity,createdat,ticketid
+  `
+	return t.list(ctx, sqlStatement, id)
+}
+
+// FindbyDoctor lists the tickets referred to the doctor,the most urgent first then the oldest
+func (t *Ticket) FindbyDoctor(ctx context.Context, id int) ([]models.Ticket, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT ` + ticketcolumns + ` FROM tickets
+ WHERE doctorid = $1
+ ORDER BY acuity,createdat,ticketid
+  `
+	return t.list(ctx, sqlStatement, id)
+}
+
+func (t *Ticket) FindbyPatient(ctx context.Context, id int) ([]models.Ticket, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT ` + ticketcolumns + ` FROM tickets
+ WHERE patientid = $1
+ ORDER BY ticketid
+  `
+	return t.list(ctx, sqlStatement, id)
+}
+
+func (t *Ticket) list(ctx context.Context, sqlStatement string, args ...any) ([]models.Ticket, error) {
+	rows, err := t.db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.Ticket
+	for rows.Next() {
+		i, err := scanticket(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (t *Ticket) Update(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+  UPDATE tickets
+  SET doctorid = $2,status = $3,acuity = $4,attendedat = $5
+  WHERE ticketid = $1
+  RETURNING ` + ticketcolumns
+	ticket, err := scanticket(t.db.QueryRowContext(ctx, sqlStatement, ticket.Ticketid, nullid(ticket.Doctorid), ticket.Status, ticket.Acuity, nulldate(ticket.AttendedAt)))
+	return ticket, dberror(err)
+}
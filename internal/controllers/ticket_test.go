@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateTicket(t *testing.T, acuity models.Acuity) models.Ticket {
+	patient, err := controllers.Patient.Create(context.Background(), RandPatient())
+	require.NoError(t, err)
+	nurse, err := controllers.Nurse.Create(context.Background(), RandNurse())
+	require.NoError(t, err)
+	ticket, err := controllers.Tickets.Create(context.Background(), models.Ticket{
+		Patientid: patient.Patientid,
+		Nurseid:   nurse.Id,
+		Status:    models.TicketOpen,
+		Acuity:    acuity,
+	})
+	require.NoError(t, err)
+	return ticket
+}
+
+func TestCreateTicket(t *testing.T) {
+	ticket := CreateTicket(t, models.MostUrgent)
+	require.NotZero(t, ticket.Ticketid)
+	require.Zero(t, ticket.Doctorid)
+	require.Equal(t, models.TicketOpen, ticket.Status)
+	require.Equal(t, models.MostUrgent, ticket.Acuity)
+	require.True(t, ticket.AttendedAt.IsZero())
+	// the table checks the acuity is within the triage scale
+	_, err := controllers.Tickets.Create(context.Background(), models.Ticket{Patientid: ticket.Patientid, Nurseid: ticket.Nurseid, Status: models.TicketOpen, Acuity: 6})
+	require.Error(t, err)
+}
+
+func TestAttendTicket(t *testing.T) {
+	ticket := CreateTicket(t, models.DefaultAcuity)
+	doctor, err := controllers.Doctors.Create(context.Background(), RandDoctor())
+	require.NoError(t, err)
+	ticket.Doctorid, ticket.Status, ticket.AttendedAt = doctor.Physicianid, models.TicketAttended, time.Now()
+	updated, err := controllers.Tickets.Update(context.Background(), ticket)
+	require.NoError(t, err)
+	require.Equal(t, doctor.Physicianid, updated.Doctorid)
+	require.Equal(t, models.TicketAttended, updated.Status)
+	require.False(t, updated.AttendedAt.IsZero())
+	queue, err := controllers.Tickets.FindbyDoctor(context.Background(), doctor.Physicianid)
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	require.Equal(t, ticket.Ticketid, queue[0].Ticketid)
+}
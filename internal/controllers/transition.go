@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Transition struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func scantransition(row scanner) (models.Transition, error) {
+	var transition models.Transition
+	err := row.Scan(
+		&transition.Transitionid,
+		&transition.Appointmentid,
+		&transition.From,
+		&transition.To,
+		&transition.AccountType,
+		&transition.AccountId,
+		&transition.Reason,
+		&transition.CreatedAt,
+	)
+	return transition, err
+}
+
+func (t *Transition) Create(ctx context.Context, transition models.Transition) (models.Transition, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO appointment_transition (appointmentid,fromstatus,tostatus,accounttype,accountid,reason)
+  VALUES($1,$2,$3,$4,$5,$6)
+  RETURNING *
+  `
+	return scantransition(t.db.QueryRowContext(ctx, sqlStatement, transition.Appointmentid, transition.From, transition.To, transition.AccountType, transition.AccountId, transition.Reason))
+}
+
+// FindbyAppointment lists the transitions of the appointment oldest first
+func (t *Transition) FindbyAppointment(ctx context.Context, id int) ([]models.Transition, error) {
+	ctx, cancel := querycontext(ctx, t.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT * FROM appointment_transition
+ WHERE appointmentid = $1
+ ORDER BY transitionid
+  `
+	rows, err := t.db.QueryContext(ctx, sqlStatement, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.Transition
+	for rows.Next() {
+		i, err := scantransition(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
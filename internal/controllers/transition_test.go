@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateTransition(t *testing.T) {
+	appointment := CreateAppointment()
+	transition, err := controllers.Transitions.Create(context.Background(), models.Transition{
+		Appointmentid: appointment.Appointmentid,
+		From:          models.StatusRequested,
+		To:            models.StatusCancelled,
+		Actor:         models.Actor{AccountType: "patient", AccountId: appointment.Patientid},
+		Reason:        "feeling better",
+	})
+	require.NoError(t, err)
+	require.NotZero(t, transition.Transitionid)
+	require.Equal(t, models.StatusCancelled, transition.To)
+	require.Equal(t, "feeling better", transition.Reason)
+	require.False(t, transition.CreatedAt.IsZero())
+}
+
+func TestFindTransitionsByAppointment(t *testing.T) {
+	appointment := CreateAppointment()
+	for _, to := range []models.Status{models.StatusApproved, models.StatusCompleted} {
+		_, err := controllers.Transitions.Create(context.Background(), models.Transition{
+			Appointmentid: appointment.Appointmentid,
+			To:            to,
+			Actor:         models.Actor{AccountType: "physician", AccountId: appointment.Doctorid},
+		})
+		require.NoError(t, err)
+	}
+	transitions, err := controllers.Transitions.FindbyAppointment(context.Background(), appointment.Appointmentid)
+	require.NoError(t, err)
+	require.Len(t, transitions, 2)
+	require.Equal(t, models.StatusApproved, transitions[0].To)
+	require.Equal(t, models.StatusCompleted, transitions[1].To)
+}
@@ -2,69 +2,77 @@ package controllers
 
 import (
 	"context"
-	"database/sql"
 	"github.com/patienttracker/internal/models"
-	"log"
+	"time"
 )
 
 type Users struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func (u *Users) Create(users models.Users) (models.Users, error) {
+func (u *Users) Create(ctx context.Context, users models.Users) (models.Users, error) {
+	ctx, cancel := querycontext(ctx, u.timeout)
+	defer cancel()
 	sqlStatement := `
   INSERT INTO users (email,password,roleid) 
   VALUES($1,$2,$3)
   RETURNING *
   `
-	err := u.db.QueryRow(sqlStatement, users.Email, users.Password, users.Roleid).Scan(
+	err := u.db.QueryRowContext(ctx, sqlStatement, users.Email, users.Password, users.Roleid).Scan(
 		&users.Id,
 		&users.Email,
 		&users.Password,
 		&users.Roleid,
 	)
-	return users, err
+	return users, dberror(err)
 
 }
 
-func (u *Users) Find(id int) (models.Users, error) {
+func (u *Users) Find(ctx context.Context, id int) (models.Users, error) {
+	ctx, cancel := querycontext(ctx, u.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM users
   WHERE users.id = $1
   `
 	var user models.Users
-	err := u.db.QueryRowContext(context.Background(), sqlStatement, id).Scan(
+	err := u.db.QueryRowContext(ctx, sqlStatement, id).Scan(
 		&user.Id,
 		&user.Email,
 		&user.Password,
 		&user.Roleid,
 	)
-	return user, err
+	return user, dberror(err)
 }
 
-func (u *Users) FindbyEmail(email string) (models.Users, error) {
+func (u *Users) FindbyEmail(ctx context.Context, email string) (models.Users, error) {
+	ctx, cancel := querycontext(ctx, u.timeout)
+	defer cancel()
 	sqlStatement := `
   SELECT * FROM users
   WHERE users.email = $1
   `
 	var user models.Users
-	err := u.db.QueryRowContext(context.Background(), sqlStatement, email).Scan(
+	err := u.db.QueryRowContext(ctx, sqlStatement, email).Scan(
 		&user.Id,
 		&user.Email,
 		&user.Password,
 		&user.Roleid,
 	)
-	return user, err
+	return user, dberror(err)
 }
-func (u *Users) FindbyRoleId(id int) ([]models.Users, error) {
+func (u *Users) FindbyRoleId(ctx context.Context, id int) ([]models.Users, error) {
+	ctx, cancel := querycontext(ctx, u.timeout)
+	defer cancel()
 	sqlStatement := `
  SELECT * FROM users
  WHERE users.roleid = $1
  ORDER BY id
   `
-	rows, err := u.db.Query(sqlStatement, id)
+	rows, err := u.db.QueryContext(ctx, sqlStatement, id)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	var items []models.Users
@@ -90,14 +98,16 @@ func (u *Users) FindbyRoleId(id int) ([]models.Users, error) {
 
 }
 
-func (u *Users) FindAll() ([]models.Users, error) {
+func (u *Users) FindAll(ctx context.Context) ([]models.Users, error) {
+	ctx, cancel := querycontext(ctx, u.timeout)
+	defer cancel()
 	sqlStatement := `
  SELECT * FROM users
  ORDER BY id 
   `
-	rows, err := u.db.QueryContext(context.Background(), sqlStatement)
+	rows, err := u.db.QueryContext(ctx, sqlStatement)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	var items []models.Users
@@ -122,26 +132,30 @@ func (u *Users) FindAll() ([]models.Users, error) {
 	return items, nil
 }
 
-func (u *Users) Delete(id int) error {
+func (u *Users) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, u.timeout)
+	defer cancel()
 	sqlStatement := `DELETE FROM users 
   WHERE id  = $1
   `
-	_, err := u.db.Exec(sqlStatement, id)
+	_, err := u.db.ExecContext(ctx, sqlStatement, id)
 	return err
 }
 
-func (u *Users) Update(users models.Users) (models.Users, error) {
+func (u *Users) Update(ctx context.Context, users models.Users) (models.Users, error) {
+	ctx, cancel := querycontext(ctx, u.timeout)
+	defer cancel()
 	sqlStatement := `UPDATE users
 SET email=$2,password=$3,roleid=$4
 WHERE users.id = $1
 RETURNING *;
   `
 	var user models.Users
-	err := u.db.QueryRow(sqlStatement, users.Id, users.Email, users.Password, users.Roleid).Scan(
+	err := u.db.QueryRowContext(ctx, sqlStatement, users.Id, users.Email, users.Password, users.Roleid).Scan(
 		&user.Id,
 		&user.Email,
 		&user.Password,
 		&user.Roleid,
 	)
-	return user, err
+	return user, dberror(err)
 }
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/patienttracker/internal/models"
@@ -10,7 +11,7 @@ import (
 
 func CreateRole(t *testing.T) models.Roles {
 	role := CreateRoles()
-	r, err := controllers.Roles.Create(role)
+	r, err := controllers.Roles.Create(context.Background(), role)
 	require.NoError(t, err)
 	return r
 }
@@ -35,7 +36,7 @@ func CreateUser(t *testing.T) models.Users {
 }
 func TestCreateUser(t *testing.T) {
 	user := CreateUser(t)
-	nuser, err := controllers.Users.Create(user)
+	nuser, err := controllers.Users.Create(context.Background(), user)
 	require.NoError(t, err)
 	require.Equal(t, user.Email, nuser.Email)
 
@@ -43,10 +44,10 @@ func TestCreateUser(t *testing.T) {
 
 func TestFindUser(t *testing.T) {
 	user := CreateUser(t)
-	nuser, err := controllers.Users.Create(user)
+	nuser, err := controllers.Users.Create(context.Background(), user)
 	require.NoError(t, err)
 	require.Equal(t, user.Email, nuser.Email)
-	fuser, err := controllers.Users.Find(nuser.Id)
+	fuser, err := controllers.Users.Find(context.Background(), nuser.Id)
 	require.NoError(t, err)
 	require.Equal(t, nuser, fuser)
 }
@@ -54,12 +55,12 @@ func TestFindUser(t *testing.T) {
 func TestListUser(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		user := CreateUser(t)
-		nuser, err := controllers.Users.Create(user)
+		nuser, err := controllers.Users.Create(context.Background(), user)
 		require.NoError(t, err)
 		require.Equal(t, user.Email, nuser.Email)
 	}
 
-	users, err := controllers.Users.FindAll()
+	users, err := controllers.Users.FindAll(context.Background())
 	require.NoError(t, err)
 	for _, v := range users {
 		require.NotNil(t, v)
@@ -76,12 +77,12 @@ func TestListUserbyRoles(t *testing.T) {
 			Password: utils.RandString(6),
 			Roleid:   r.Roleid,
 		}
-		nuser, err := controllers.Users.Create(user)
+		nuser, err := controllers.Users.Create(context.Background(), user)
 		require.NoError(t, err)
 		require.Equal(t, user.Email, nuser.Email)
 	}
 
-	users, err := controllers.Users.FindbyRoleId(r.Roleid)
+	users, err := controllers.Users.FindbyRoleId(context.Background(), r.Roleid)
 	require.NoError(t, err)
 	for _, v := range users {
 		require.NotNil(t, v)
@@ -91,23 +92,23 @@ func TestListUserbyRoles(t *testing.T) {
 
 func TestDeleteUser(t *testing.T) {
 	user := CreateUser(t)
-	nuser, err := controllers.Users.Create(user)
+	nuser, err := controllers.Users.Create(context.Background(), user)
 	require.NoError(t, err)
 	require.Equal(t, user.Email, nuser.Email)
-	err = controllers.Users.Delete(nuser.Id)
+	err = controllers.Users.Delete(context.Background(), nuser.Id)
 	require.NoError(t, err)
-	fuser, err := controllers.Users.Find(nuser.Id)
+	fuser, err := controllers.Users.Find(context.Background(), nuser.Id)
 	require.Error(t, err)
 	require.Empty(t, fuser)
 }
 
 func TestUpdateUser(t *testing.T) {
 	user := CreateUser(t)
-	nuser, err := controllers.Users.Create(user)
+	nuser, err := controllers.Users.Create(context.Background(), user)
 	require.NoError(t, err)
 	require.Equal(t, user.Email, nuser.Email)
 	user1 := UpdateUser(nuser.Id, t)
-	user2, err := controllers.Users.Update(user1)
+	user2, err := controllers.Users.Update(context.Background(), user1)
 	require.NoError(t, err)
 	require.NotEqual(t, nuser.Email, user2.Email)
 }
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Waitlist struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func scanwaitlist(row scanner) (models.Waitlist, error) {
+	var waitlist models.Waitlist
+	var minutes int64
+	var token sql.NullString
+	var start, expires sql.NullTime
+	err := row.Scan(
+		&waitlist.Waitlistid,
+		&waitlist.Patientid,
+		&waitlist.Doctorid,
+		&waitlist.Day,
+		&minutes,
+		&waitlist.CreatedAt,
+		&token,
+		&start,
+		&expires,
+	)
+	waitlist.Duration = time.Duration(minutes) * time.Minute
+	waitlist.Offer = models.Offer{Token: token.String, Starttime: start.Time, Expires: expires.Time}
+	return waitlist, err
+}
+
+func (w *Waitlist) Create(ctx context.Context, waitlist models.Waitlist) (models.Waitlist, error) {
+	ctx, cancel := querycontext(ctx, w.timeout)
+	defer cancel()
+	sqlStatement := `
+  INSERT INTO waitlist (patientid,doctorid,day,minutes)
+  VALUES($1,$2,$3,$4)
+  RETURNING *
+  `
+	waitlist, err := scanwaitlist(w.db.QueryRowContext(ctx, sqlStatement, waitlist.Patientid, waitlist.Doctorid, waitlist.Day, int64(waitlist.Duration/time.Minute)))
+	return waitlist, dberror(err)
+}
+
+func (w *Waitlist) Find(ctx context.Context, id int) (models.Waitlist, error) {
+	ctx, cancel := querycontext(ctx, w.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM waitlist
+  WHERE waitlistid = $1
+  `
+	return scanwaitlist(w.db.QueryRowContext(ctx, sqlStatement, id))
+}
+
+// FindbyOffer returns the entry the offer with token was made to
+func (w *Waitlist) FindbyOffer(ctx context.Context, token string) (models.Waitlist, error) {
+	ctx, cancel := querycontext(ctx, w.timeout)
+	defer cancel()
+	sqlStatement := `
+  SELECT * FROM waitlist
+  WHERE offertoken = $1
+  `
+	return scanwaitlist(w.db.QueryRowContext(ctx, sqlStatement, token))
+}
+
+// FindbyDoctor lists the patients waiting for the doctor on day in the order they joined
+func (w *Waitlist) FindbyDoctor(ctx context.Context, id int, day time.Time) ([]models.Waitlist, error) {
+	ctx, cancel := querycontext(ctx, w.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT * FROM waitlist
+ WHERE doctorid = $1 AND day = $2
+ ORDER BY waitlistid
+  `
+	return w.list(ctx, sqlStatement, id, day)
+}
+
+// FindbyPatient lists the days the patient is waiting for by day
+func (w *Waitlist) FindbyPatient(ctx context.Context, id int) ([]models.Waitlist, error) {
+	ctx, cancel := querycontext(ctx, w.timeout)
+	defer cancel()
+	sqlStatement := `
+ SELECT * FROM waitlist
+ WHERE patientid = $1
+ ORDER BY day,waitlistid
+  `
+	return w.list(ctx, sqlStatement, id)
+}
+
+func (w *Waitlist) list(ctx context.Context, sqlStatement string, args ...any) ([]models.Waitlist, error) {
+	rows, err := w.db.QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []models.Waitlist
+	for rows.Next() {
+		i, err := scanwaitlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Update writes the offer made to the entry,the rest of it doesn't change
+func (w *Waitlist) Update(ctx context.Context, waitlist models.Waitlist) (models.Waitlist, error) {
+	ctx, cancel := querycontext(ctx, w.timeout)
+	defer cancel()
+	sqlStatement := `
+  UPDATE waitlist
+  SET offertoken = $2,offerstart = $3,offerexpires = $4
+  WHERE waitlistid = $1
+  RETURNING *
+  `
+	token := sql.NullString{String: waitlist.Offer.Token, Valid: waitlist.Offer.Token != ""}
+	waitlist, err := scanwaitlist(w.db.QueryRowContext(ctx, sqlStatement, waitlist.Waitlistid, token, nulldate(waitlist.Offer.Starttime), nulldate(waitlist.Offer.Expires)))
+	return waitlist, dberror(err)
+}
+
+func (w *Waitlist) Delete(ctx context.Context, id int) error {
+	ctx, cancel := querycontext(ctx, w.timeout)
+	defer cancel()
+	sqlStatement := `DELETE FROM waitlist
+  WHERE waitlistid = $1
+  `
+	_, err := w.db.ExecContext(ctx, sqlStatement, id)
+	return err
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWaitlist(t *testing.T) {
+	appointment := CreateAppointment()
+	day := models.Day(time.Now().Add(48*time.Hour), time.UTC)
+	waitlist, err := controllers.Waitlists.Create(context.Background(), models.Waitlist{
+		Patientid: appointment.Patientid,
+		Doctorid:  appointment.Doctorid,
+		Day:       day,
+		Duration:  30 * time.Minute,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, waitlist.Waitlistid)
+	require.True(t, day.Equal(waitlist.Day))
+	require.Equal(t, 30*time.Minute, waitlist.Duration)
+	_, err = controllers.Waitlists.Create(context.Background(), waitlist)
+	require.ErrorIs(t, err, models.ErrDuplicate)
+}
+
+func TestOfferWaitlist(t *testing.T) {
+	appointment := CreateAppointment()
+	waitlist, err := controllers.Waitlists.Create(context.Background(), models.Waitlist{
+		Patientid: appointment.Patientid,
+		Doctorid:  appointment.Doctorid,
+		Day:       models.Day(appointment.Appointmentdate, time.UTC),
+		Duration:  appointment.Duration,
+	})
+	require.NoError(t, err)
+	waitlist.Offer = models.Offer{Token: utils.RandString(20), Starttime: appointment.Appointmentdate, Expires: time.Now().Add(time.Hour)}
+	_, err = controllers.Waitlists.Update(context.Background(), waitlist)
+	require.NoError(t, err)
+	found, err := controllers.Waitlists.FindbyOffer(context.Background(), waitlist.Offer.Token)
+	require.NoError(t, err)
+	require.Equal(t, waitlist.Waitlistid, found.Waitlistid)
+	require.True(t, found.Offer.Pending(time.Now()))
+}
@@ -0,0 +1,276 @@
+// Package db embeds the sql migrations and applies them.
+// The applied version is kept in the schema_migrations table in the same format as
+// golang-migrate,databases migrated with the old make targets are picked up as is.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+// arbitrary key of the advisory lock held while migrating so that
+// two instances starting at once don't apply the same migration
+const lockkey = 7265432
+
+var ErrDirty = errors.New("database is dirty, fix the failed migration by hand then force its version")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns the embedded migrations sorted by version
+func Migrations() ([]Migration, error) {
+	return parse(files, "migrations")
+}
+
+// parse reads migrations named <version>_<name>.up.sql & <version>_<name>.down.sql
+func parse(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byversion := make(map[int]*Migration)
+	for _, entry := range entries {
+		filename := entry.Name()
+		base, direction := strings.TrimSuffix(filename, ".sql"), ""
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			base, direction = strings.TrimSuffix(base, ".up"), "up"
+		case strings.HasSuffix(base, ".down"):
+			base, direction = strings.TrimSuffix(base, ".down"), "down"
+		default:
+			return nil, fmt.Errorf("%s: expected a .up.sql or .down.sql suffix", filename)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%s: expected a positive version prefix", filename)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byversion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byversion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("%s: version %d is already used by %s", filename, version, m.Name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byversion))
+	for _, m := range byversion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(conn *sql.DB) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: conn, migrations: migrations}, nil
+}
+
+// Status lists the migrations with the version the database is at,0 when none were applied
+type Status struct {
+	Version    int
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Pending returns the migrations newer than the current version
+func (s Status) Pending() []Migration {
+	pending := make([]Migration, 0)
+	for _, m := range s.Migrations {
+		if m.Version > s.Version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+func (m *Migrator) Status() (Status, error) {
+	var status Status
+	err := m.withLock(func(conn *sql.Conn) error {
+		var err error
+		status.Version, status.Dirty, err = version(conn)
+		return err
+	})
+	status.Migrations = m.migrations
+	return status, err
+}
+
+// Up applies every pending migration and returns how many were applied
+func (m *Migrator) Up() (int, error) {
+	applied := 0
+	err := m.withLock(func(conn *sql.Conn) error {
+		current, dirty, err := version(conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if err := apply(conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the last steps migrations and returns how many were rolled back
+func (m *Migrator) Down(steps int) (int, error) {
+	rolledback := 0
+	err := m.withLock(func(conn *sql.Conn) error {
+		current, dirty, err := version(conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		for i := len(m.migrations) - 1; i >= 0 && rolledback < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			previous := 0
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := apply(conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			rolledback++
+		}
+		return nil
+	})
+	return rolledback, err
+}
+
+// Force sets the version without running any migration and clears the dirty flag,
+// it's meant to recover after a failed migration was fixed by hand.
+func (m *Migrator) Force(v int) error {
+	if v != 0 && !m.known(v) {
+		return fmt.Errorf("unknown migration version %d", v)
+	}
+	return m.withLock(func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(context.Background(), nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := setversion(tx, v); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+func (m *Migrator) known(v int) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == v {
+			return true
+		}
+	}
+	return false
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockkey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockkey)
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func version(conn *sql.Conn) (int, bool, error) {
+	var v int
+	var dirty bool
+	err := conn.QueryRowContext(context.Background(), `SELECT version,dirty FROM schema_migrations LIMIT 1`).Scan(&v, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return v, dirty, err
+}
+
+// apply runs the migration and records the new version in one transaction,
+// postgres ddl is transactional so a failed migration leaves nothing behind.
+func apply(conn *sql.Conn, query string, v int) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if strings.TrimSpace(query) != "" {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	if err := setversion(tx, v); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setversion stores v as the only row,version 0 is stored as no row at all
+func setversion(tx *sql.Tx, v int) error {
+	if _, err := tx.Exec(`DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if v == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`INSERT INTO schema_migrations (version,dirty) VALUES ($1,false)`, v)
+	return err
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/lib/pq"
+	"github.com/patienttracker/internal/config"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, m := range migrations {
+		require.Equal(t, i+1, m.Version)
+		require.NotEmpty(t, m.Up)
+		require.NotEmpty(t, m.Down, m.Name)
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/000002_second.up.sql":   {Data: []byte("up 2")},
+		"m/000002_second.down.sql": {Data: []byte("down 2")},
+		"m/000001_first.up.sql":    {Data: []byte("up 1")},
+	}
+	migrations, err := parse(fsys, "m")
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	require.Equal(t, Migration{Version: 1, Name: "first", Up: "up 1"}, migrations[0])
+	require.Equal(t, "down 2", migrations[1].Down)
+
+	invalid := []fstest.MapFS{
+		{"m/000001_first.sql": {}},
+		{"m/first.up.sql": {}},
+		{"m/000001_first.down.sql": {}},
+		{"m/000001_first.up.sql": {Data: []byte("up")}, "m/000001_other.up.sql": {Data: []byte("up")}},
+	}
+	for _, fsys := range invalid {
+		_, err := parse(fsys, "m")
+		require.Error(t, err)
+	}
+}
+
+// testdb connects to a throwaway schema so the migrations can be rolled back
+// without touching the schema the other packages test against
+func testdb(t *testing.T) *sql.DB {
+	uri := config.Defaults().Database.URI
+	conn, err := sql.Open("postgres", uri)
+	require.NoError(t, err)
+	if err := conn.Ping(); err != nil {
+		t.Skip("postgres is not available: ", err)
+	}
+	schema := "migrate_" + utils.RandString(8)
+	_, err = conn.Exec(fmt.Sprintf(`CREATE SCHEMA %q`, schema))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Exec(fmt.Sprintf(`DROP SCHEMA %q CASCADE`, schema))
+		conn.Close()
+	})
+	db, err := sql.Open("postgres", uri+"&search_path="+schema+",public")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	conn := testdb(t)
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+	latest := migrator.migrations[len(migrator.migrations)-1].Version
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	require.Equal(t, len(migrator.migrations), applied)
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	require.Equal(t, latest, status.Version)
+	require.Empty(t, status.Pending())
+
+	// nothing left to apply
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	require.Zero(t, applied)
+
+	rolledback, err := migrator.Down(2)
+	require.NoError(t, err)
+	require.Equal(t, 2, rolledback)
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	require.Equal(t, latest-2, status.Version)
+	require.Len(t, status.Pending(), 2)
+
+	rolledback, err = migrator.Down(len(migrator.migrations))
+	require.NoError(t, err)
+	require.Equal(t, latest-2, rolledback)
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	require.Zero(t, status.Version)
+
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	require.Equal(t, len(migrator.migrations), applied)
+}
+
+func TestMigrateDirty(t *testing.T) {
+	conn := testdb(t)
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	// a database left dirty by a failed golang-migrate run
+	_, err = conn.Exec(`UPDATE schema_migrations SET dirty = true`)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.ErrorIs(t, err, ErrDirty)
+
+	require.Error(t, migrator.Force(999))
+	require.NoError(t, migrator.Force(3))
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	require.Equal(t, 3, status.Version)
+	require.False(t, status.Dirty)
+}
@@ -0,0 +1,15 @@
+CREATE TABLE "sessions" (
+  "id" uuid PRIMARY KEY,
+  "account_type" varchar NOT NULL,
+  "account_id" integer NOT NULL,
+  "username" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "refresh_token_id" uuid UNIQUE,
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "revoked" boolean NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "sessions" ("account_type", "account_id");
@@ -0,0 +1,6 @@
+ALTER TABLE "department" DROP COLUMN IF EXISTS "durations";
+ALTER TABLE "appointment" ADD COLUMN "duration" varchar;
+UPDATE "appointment" SET "duration" = (extract(epoch from "endtime" - "appointmentdate")::bigint / 3600) || 'h'
+  || (extract(epoch from "endtime" - "appointmentdate")::bigint % 3600 / 60) || 'm';
+ALTER TABLE "appointment" ALTER COLUMN "duration" SET NOT NULL;
+ALTER TABLE "appointment" DROP COLUMN IF EXISTS "endtime";
@@ -0,0 +1,13 @@
+-- appointments keep their end instead of a duration string,the duration is end - start
+ALTER TABLE "appointment" ADD COLUMN "endtime" timestamp;
+UPDATE "appointment" SET "endtime" = "appointmentdate"
+  + COALESCE(substring("duration" from '(\d+)h')::int, 0) * interval '1 hour'
+  + COALESCE(substring("duration" from '(\d+)m(?!s)')::int, 0) * interval '1 minute'
+  + COALESCE(substring("duration" from '([\d.]+)s')::numeric, 0) * interval '1 second';
+ALTER TABLE "appointment" ALTER COLUMN "endtime" SET NOT NULL;
+ALTER TABLE "appointment" ADD CONSTRAINT "appointment_range" CHECK ("endtime" > "appointmentdate");
+ALTER TABLE "appointment" DROP COLUMN "duration";
+CREATE INDEX ON "appointment" ("doctorid", "appointmentdate");
+
+-- the appointment durations a department offers in minutes,empty allows any duration
+ALTER TABLE "department" ADD COLUMN "durations" integer[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS schedule_exception;
+DROP TABLE IF EXISTS schedule_block;
+ALTER TABLE "schedule" DROP COLUMN IF EXISTS "validuntil";
+ALTER TABLE "schedule" DROP COLUMN IF EXISTS "validfrom";
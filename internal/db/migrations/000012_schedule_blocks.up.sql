@@ -0,0 +1,24 @@
+ALTER TABLE "schedule" ADD COLUMN "validfrom" date;
+ALTER TABLE "schedule" ADD COLUMN "validuntil" date;
+
+-- the weekly working hours,weekday is 0 for sunday like go's time.Weekday
+CREATE TABLE "schedule_block" (
+  "blockid" SERIAL PRIMARY KEY,
+  "scheduleid" integer NOT NULL,
+  "weekday" smallint NOT NULL CHECK ("weekday" BETWEEN 0 AND 6),
+  "starttime" varchar NOT NULL,
+  "endtime" varchar NOT NULL
+);
+CREATE INDEX ON "schedule_block" ("scheduleid");
+ALTER TABLE "schedule_block" ADD FOREIGN KEY ("scheduleid") REFERENCES "schedule" ("scheduleid") ON DELETE CASCADE;
+
+CREATE TABLE "schedule_exception" (
+  "exceptionid" SERIAL PRIMARY KEY,
+  "doctorid" integer NOT NULL,
+  "starttime" timestamp NOT NULL,
+  "endtime" timestamp NOT NULL,
+  "reason" varchar NOT NULL DEFAULT '',
+  CHECK ("endtime" > "starttime")
+);
+CREATE INDEX ON "schedule_exception" ("doctorid", "starttime");
+ALTER TABLE "schedule_exception" ADD FOREIGN KEY ("doctorid") REFERENCES "physician" ("doctorid") ON DELETE CASCADE;
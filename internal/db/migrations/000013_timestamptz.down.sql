@@ -0,0 +1,8 @@
+ALTER TABLE "patientrecords"
+  ALTER COLUMN "date" TYPE timestamp USING "date" AT TIME ZONE 'UTC';
+ALTER TABLE "schedule_exception"
+  ALTER COLUMN "starttime" TYPE timestamp USING "starttime" AT TIME ZONE 'UTC',
+  ALTER COLUMN "endtime" TYPE timestamp USING "endtime" AT TIME ZONE 'UTC';
+ALTER TABLE "appointment"
+  ALTER COLUMN "appointmentdate" TYPE timestamp USING "appointmentdate" AT TIME ZONE 'UTC',
+  ALTER COLUMN "endtime" TYPE timestamp USING "endtime" AT TIME ZONE 'UTC';
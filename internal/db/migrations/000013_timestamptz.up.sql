@@ -0,0 +1,9 @@
+-- the times are kept as instants,the values written so far were utc wall clock times
+ALTER TABLE "appointment"
+  ALTER COLUMN "appointmentdate" TYPE timestamptz USING "appointmentdate" AT TIME ZONE 'UTC',
+  ALTER COLUMN "endtime" TYPE timestamptz USING "endtime" AT TIME ZONE 'UTC';
+ALTER TABLE "schedule_exception"
+  ALTER COLUMN "starttime" TYPE timestamptz USING "starttime" AT TIME ZONE 'UTC',
+  ALTER COLUMN "endtime" TYPE timestamptz USING "endtime" AT TIME ZONE 'UTC';
+ALTER TABLE "patientrecords"
+  ALTER COLUMN "date" TYPE timestamptz USING "date" AT TIME ZONE 'UTC';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS appointment_transition;
+ALTER TABLE "appointment" ADD COLUMN "approval" boolean NOT NULL DEFAULT false;
+UPDATE "appointment" SET "approval" = "status" IN ('approved', 'rescheduled');
+ALTER TABLE "appointment" ALTER COLUMN "approval" DROP DEFAULT;
+ALTER TABLE "appointment" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,19 @@
+-- the approval flag becomes the status of the appointment,approved appointments stay approved
+ALTER TABLE "appointment" ADD COLUMN "status" varchar NOT NULL DEFAULT 'requested'
+  CHECK ("status" IN ('requested', 'approved', 'rescheduled', 'cancelled', 'completed', 'no_show'));
+UPDATE "appointment" SET "status" = 'approved' WHERE "approval";
+ALTER TABLE "appointment" DROP COLUMN "approval";
+
+-- every change of status,who made it and why
+CREATE TABLE "appointment_transition" (
+  "transitionid" SERIAL PRIMARY KEY,
+  "appointmentid" integer NOT NULL,
+  "fromstatus" varchar NOT NULL,
+  "tostatus" varchar NOT NULL,
+  "accounttype" varchar NOT NULL,
+  "accountid" integer NOT NULL,
+  "reason" varchar NOT NULL DEFAULT '',
+  "createdat" timestamptz NOT NULL DEFAULT (now())
+);
+CREATE INDEX ON "appointment_transition" ("appointmentid");
+ALTER TABLE "appointment_transition" ADD FOREIGN KEY ("appointmentid") REFERENCES "appointment" ("appointmentid") ON DELETE CASCADE;
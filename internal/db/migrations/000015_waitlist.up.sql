@@ -0,0 +1,17 @@
+-- patients waiting for a slot with a doctor on a day,a freed slot is offered
+-- to them one at a time through the token emailed to them
+CREATE TABLE "waitlist" (
+  "waitlistid" SERIAL PRIMARY KEY,
+  "patientid" integer NOT NULL,
+  "doctorid" integer NOT NULL,
+  "day" date NOT NULL,
+  "minutes" integer NOT NULL CHECK ("minutes" > 0),
+  "createdat" timestamptz NOT NULL DEFAULT (now()),
+  "offertoken" varchar UNIQUE,
+  "offerstart" timestamptz,
+  "offerexpires" timestamptz,
+  UNIQUE ("patientid", "doctorid", "day")
+);
+CREATE INDEX ON "waitlist" ("doctorid", "day");
+ALTER TABLE "waitlist" ADD FOREIGN KEY ("patientid") REFERENCES "patient" ("patientid") ON DELETE CASCADE;
+ALTER TABLE "waitlist" ADD FOREIGN KEY ("doctorid") REFERENCES "physician" ("doctorid") ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE "appointment" DROP COLUMN IF EXISTS "seriesid";
+DROP TABLE IF EXISTS appointment_series;
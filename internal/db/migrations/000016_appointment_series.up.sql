@@ -0,0 +1,21 @@
+-- appointments booked together,every occurrence is an appointment pointing back at its series
+CREATE TABLE "appointment_series" (
+  "seriesid" SERIAL PRIMARY KEY,
+  "doctorid" integer NOT NULL,
+  "patientid" integer NOT NULL,
+  "starttime" timestamptz NOT NULL,
+  "minutes" integer NOT NULL CHECK ("minutes" > 0),
+  "every" integer NOT NULL CHECK ("every" > 0),
+  "unit" varchar NOT NULL CHECK ("unit" IN ('day', 'week')),
+  "occurrences" integer NOT NULL DEFAULT 0,
+  "until" date,
+  "createdat" timestamptz NOT NULL DEFAULT (now())
+);
+ALTER TABLE "appointment_series" ADD FOREIGN KEY ("doctorid") REFERENCES "physician" ("doctorid") ON DELETE CASCADE;
+ALTER TABLE "appointment_series" ADD FOREIGN KEY ("patientid") REFERENCES "patient" ("patientid") ON DELETE CASCADE;
+
+-- an occurrence deleted on its own leaves the rest of the series alone,
+-- deleting the series leaves its appointments as ones booked on their own
+ALTER TABLE "appointment" ADD COLUMN "seriesid" integer;
+CREATE INDEX ON "appointment" ("seriesid");
+ALTER TABLE "appointment" ADD FOREIGN KEY ("seriesid") REFERENCES "appointment_series" ("seriesid") ON DELETE SET NULL;
@@ -0,0 +1,9 @@
+-- the secret addresses calendar apps subscribe to,the token is the whole credential
+CREATE TABLE "calendar_feeds" (
+  "token" varchar PRIMARY KEY,
+  "account_type" varchar NOT NULL,
+  "account_id" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "calendar_feeds" ("account_type", "account_id");
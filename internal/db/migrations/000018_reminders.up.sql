@@ -0,0 +1,14 @@
+-- the emails reminding of an appointment,one per offset before it starts.
+-- a reminder is sent once,moving the appointment drops the ones that weren't
+-- sent and the reminders for its new time are added.
+CREATE TABLE "reminders" (
+  "reminderid" SERIAL PRIMARY KEY,
+  "appointmentid" integer NOT NULL,
+  "seconds" integer NOT NULL CHECK ("seconds" > 0),
+  "due" timestamptz NOT NULL,
+  "sent" timestamptz,
+  "createdat" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("appointmentid", "seconds", "due")
+);
+CREATE INDEX ON "reminders" ("due") WHERE "sent" IS NULL;
+ALTER TABLE "reminders" ADD FOREIGN KEY ("appointmentid") REFERENCES "appointment" ("appointmentid") ON DELETE CASCADE;
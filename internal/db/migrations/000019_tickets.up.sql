@@ -0,0 +1,19 @@
+-- the triage queues of the nurses,they used to live in redis without timestamps.
+-- a queue lists the most urgent tickets first then the oldest.
+CREATE TABLE "tickets" (
+  "ticketid" SERIAL PRIMARY KEY,
+  "patientid" integer NOT NULL,
+  "nurseid" integer NOT NULL,
+  "doctorid" integer,
+  "status" varchar NOT NULL DEFAULT 'open'
+    CHECK ("status" IN ('open', 'in_progress', 'attended', 'closed')),
+  "acuity" integer NOT NULL DEFAULT 3 CHECK ("acuity" BETWEEN 1 AND 5),
+  "createdat" timestamptz NOT NULL DEFAULT (now()),
+  "attendedat" timestamptz
+);
+CREATE INDEX ON "tickets" ("nurseid", "acuity", "createdat");
+CREATE INDEX ON "tickets" ("doctorid", "acuity", "createdat");
+CREATE INDEX ON "tickets" ("patientid");
+ALTER TABLE "tickets" ADD FOREIGN KEY ("patientid") REFERENCES "patient" ("patientid") ON DELETE CASCADE;
+ALTER TABLE "tickets" ADD FOREIGN KEY ("nurseid") REFERENCES "nurse" ("id") ON DELETE CASCADE;
+ALTER TABLE "tickets" ADD FOREIGN KEY ("doctorid") REFERENCES "physician" ("doctorid") ON DELETE SET NULL;
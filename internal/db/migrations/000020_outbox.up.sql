@@ -0,0 +1,15 @@
+-- the events waiting to be handed to their subscribers,one row per subscriber.
+-- a row is written in the same transaction as the change it tells about and
+-- is handed to the subscriber until it succeeds or the attempts run out.
+CREATE TABLE "outbox" (
+  "eventid" SERIAL PRIMARY KEY,
+  "subscriber" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "createdat" timestamptz NOT NULL DEFAULT (now()),
+  "attempts" integer NOT NULL DEFAULT 0,
+  "nextattempt" timestamptz DEFAULT (now()),
+  "dispatched" timestamptz,
+  "lasterror" varchar NOT NULL DEFAULT ''
+);
+CREATE INDEX ON "outbox" ("nextattempt") WHERE "dispatched" IS NULL;
@@ -0,0 +1,140 @@
+package events
+
+// This is the events the service layer publishes & the in process bus the dashboards
+// subscribe to so nurses & doctors see new tickets and appointments without a refresh.
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Kind names what happened,it's the event name of the server-sent events
+	Kind string
+
+	// Event is something that changed,the fields are zero when they don't apply.
+	// It's kept as json in the outbox until its subscribers handled it.
+	Event struct {
+		// ID increases with every event published on the bus,a stream resumes after the last one it got
+		ID            uint64 `json:"-"`
+		Kind          Kind   `json:"kind"`
+		Ticketid      int    `json:"ticket_id,omitempty"`
+		Appointmentid int    `json:"appointment_id,omitempty"`
+		Recordid      int    `json:"record_id,omitempty"`
+		Waitlistid    int    `json:"waitlist_id,omitempty"`
+		Reminderid    int    `json:"reminder_id,omitempty"`
+		Patientid     int    `json:"patient_id,omitempty"`
+		Nurseid       int    `json:"nurse_id,omitempty"`
+		Doctorid      int    `json:"doctor_id,omitempty"`
+		AccountType   string `json:"account_type,omitempty"`
+		Email         string `json:"email,omitempty"`
+		// Start & Duration are the slot an appointment freed,it may be gone by the time the event is handled
+		Start    time.Time     `json:"start,omitempty"`
+		Duration time.Duration `json:"duration,omitempty"`
+		At       time.Time     `json:"at"`
+	}
+
+	// Bus hands every event published to the subscribers it matches,a subscriber too slow to
+	// keep up misses events instead of holding up the publisher. A nil Bus drops the events.
+	Bus struct {
+		mu          sync.Mutex
+		lastid      uint64
+		recent      []Event
+		subscribers map[*subscriber]struct{}
+	}
+
+	subscriber struct {
+		ch    chan Event
+		match func(Event) bool
+	}
+)
+
+// the kinds of events
+const (
+	PatientRegistered      Kind = "patient.registered"
+	PasswordResetRequested Kind = "password.reset_requested"
+	AppointmentBooked      Kind = "appointment.booked"
+	AppointmentChanged     Kind = "appointment.changed"
+	SlotFreed              Kind = "appointment.slot_freed"
+	ReminderDue            Kind = "appointment.reminder_due"
+	SlotOffered            Kind = "waitlist.slot_offered"
+	OfferExpired           Kind = "waitlist.offer_expired"
+	RecordCreated          Kind = "record.created"
+	TicketOpened           Kind = "ticket.opened"
+	TicketMoved            Kind = "ticket.moved"
+	TicketAttended         Kind = "ticket.attended"
+)
+
+const (
+	// keep is how many of the latest events the bus holds on to for the streams resuming
+	keep = 256
+	// buffer is how many events a subscriber can fall behind by
+	buffer = 32
+)
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[*subscriber]struct{})}
+}
+
+// Publish numbers the event & hands it to the subscribers it matches
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastid++
+	e.ID = b.lastid
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	b.recent = append(b.recent, e)
+	if len(b.recent) > keep {
+		b.recent = b.recent[len(b.recent)-keep:]
+	}
+	for s := range b.subscribers {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
+
+// LastID is the id of the latest event published,a page rendered now subscribes after it
+func (b *Bus) LastID() uint64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastid
+}
+
+// Subscribe returns the events match accepts,starting with the ones after the event with id
+// after that the bus still holds. Calling cancel stops the events & closes the channel.
+func (b *Bus) Subscribe(after uint64, match func(Event) bool) (<-chan Event, func()) {
+	s := &subscriber{ch: make(chan Event, buffer+keep), match: match}
+	if b == nil {
+		close(s.ch)
+		return s.ch, func() {}
+	}
+	b.mu.Lock()
+	for _, e := range b.recent {
+		if e.ID > after && match(e) {
+			s.ch <- e
+		}
+	}
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, s)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+	}
+}
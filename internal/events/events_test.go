@@ -0,0 +1,52 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	ch, cancel := bus.Subscribe(bus.LastID(), func(e Event) bool { return e.Nurseid == 1 })
+	bus.Publish(Event{Kind: TicketOpened, Ticketid: 1, Nurseid: 1})
+	bus.Publish(Event{Kind: TicketOpened, Ticketid: 2, Nurseid: 2})
+	bus.Publish(Event{Kind: TicketMoved, Ticketid: 1, Nurseid: 1})
+	first := <-ch
+	require.Equal(t, uint64(1), first.ID)
+	require.Equal(t, TicketOpened, first.Kind)
+	require.False(t, first.At.IsZero())
+	require.Equal(t, uint64(3), (<-ch).ID)
+	require.Equal(t, uint64(3), bus.LastID())
+	cancel()
+	cancel()
+	_, ok := <-ch
+	require.False(t, ok)
+	// nothing is handed to a subscriber that left
+	bus.Publish(Event{Kind: TicketMoved, Ticketid: 1, Nurseid: 1})
+}
+
+func TestSubscribeResumes(t *testing.T) {
+	bus := NewBus()
+	for i := 1; i <= keep+10; i++ {
+		bus.Publish(Event{Kind: AppointmentChanged, Appointmentid: i})
+	}
+	ch, cancel := bus.Subscribe(uint64(keep+5), func(Event) bool { return true })
+	defer cancel()
+	require.Equal(t, keep+6, (<-ch).Appointmentid)
+	require.Len(t, ch, 4)
+	// the events the bus no longer holds are gone
+	old, cancelold := bus.Subscribe(1, func(Event) bool { return true })
+	defer cancelold()
+	require.Equal(t, 11, (<-old).Appointmentid)
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Kind: TicketOpened})
+	require.Zero(t, bus.LastID())
+	ch, cancel := bus.Subscribe(0, func(Event) bool { return true })
+	defer cancel()
+	_, ok := <-ch
+	require.False(t, ok)
+}
@@ -0,0 +1,117 @@
+package ical
+
+// This is a small iCalendar (RFC 5545) writer,it only writes the events calendar apps need to show appointments.
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type .ics files and feeds are served with
+const ContentType = "text/calendar; charset=utf-8"
+
+// the times are written in UTC so the calendar apps don't need the zone definitions
+const timeLayout = "20060102T150405Z"
+
+type (
+	// Calendar is a VCALENDAR holding events
+	Calendar struct {
+		// Name is what the calendar apps call a subscribed feed
+		Name   string
+		Events []Event
+	}
+
+	// Event is a VEVENT,Sequence is bumped whenever the event changes so apps replace their copy
+	Event struct {
+		UID         string
+		Summary     string
+		Description string
+		Location    string
+		Start       time.Time
+		End         time.Time
+		Cancelled   bool
+		Sequence    int
+		Updated     time.Time
+	}
+)
+
+// WriteTo writes the calendar to w with its lines folded & ended the way RFC 5545 wants
+func (c Calendar) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		buf.WriteString(fold(name + ":" + value))
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//patienttracker//appointments//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	for _, event := range c.Events {
+		updated := event.Updated
+		if updated.IsZero() {
+			updated = time.Now()
+		}
+		line("BEGIN", "VEVENT")
+		line("UID", escape(event.UID))
+		line("DTSTAMP", updated.UTC().Format(timeLayout))
+		line("DTSTART", event.Start.UTC().Format(timeLayout))
+		line("DTEND", event.End.UTC().Format(timeLayout))
+		line("SEQUENCE", fmt.Sprint(event.Sequence))
+		line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escape(event.Location))
+		}
+		if event.Cancelled {
+			line("STATUS", "CANCELLED")
+		} else {
+			line("STATUS", "CONFIRMED")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return buf.WriteTo(w)
+}
+
+// Bytes returns the calendar as the contents of an .ics file
+func (c Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	c.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// escape escapes the characters a text value can't hold as they are
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// fold splits a content line into lines of at most 75 octets,the lines after the first start with a space.
+// It doesn't split a multibyte character.
+func fold(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !startsrune(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// the space starting the next line counts towards its length
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
+
+// startsrune reports whether b is the first byte of a utf-8 encoded character
+func startsrune(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,36 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCalendar(t *testing.T) {
+	nairobi := time.FixedZone("EAT", 3*60*60)
+	start := time.Date(2023, 12, 12, 10, 0, 0, 0, nairobi)
+	calendar := Calendar{Name: "Dr. Who, appointments", Events: []Event{
+		{UID: "appointment-1@patienttracker", Summary: "Appointment; follow up", Start: start, End: start.Add(30 * time.Minute), Updated: start},
+		{UID: "appointment-2@patienttracker", Summary: "Appointment", Start: start, End: start.Add(time.Hour), Cancelled: true, Sequence: 2, Updated: start},
+	}}
+	out := string(calendar.Bytes())
+	require.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	require.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	require.Contains(t, out, "X-WR-CALNAME:Dr. Who\\, appointments\r\n")
+	require.Contains(t, out, "DTSTART:20231212T070000Z\r\nDTEND:20231212T073000Z\r\n")
+	require.Contains(t, out, "SUMMARY:Appointment\\; follow up\r\n")
+	require.Contains(t, out, "SEQUENCE:2\r\nSUMMARY:Appointment\r\nSTATUS:CANCELLED\r\n")
+	require.Equal(t, 2, strings.Count(out, "BEGIN:VEVENT\r\n"))
+}
+
+func TestFold(t *testing.T) {
+	require.Equal(t, "SUMMARY:short\r\n", fold("SUMMARY:short"))
+	long := "DESCRIPTION:" + strings.Repeat("é", 60)
+	folded := fold(long)
+	for _, line := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), 75)
+	}
+	require.Equal(t, long, strings.ReplaceAll(strings.TrimSuffix(folded, "\r\n"), "\r\n ", ""))
+}
@@ -1,87 +1,98 @@
 package inmem
 
 import (
-	"errors"
+	"context"
+	"database/sql"
+	"sort"
 	"sync"
 
 	"github.com/patienttracker/internal/models"
-	"github.com/patienttracker/internal/utils"
 )
 
 type Appointment struct {
-	mu   sync.RWMutex
-	data map[int]models.Appointment
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Appointment
 }
 
-func (a *Appointment) Create(apntmnt models.Appointment) (models.Appointment, error) {
+func (a *Appointment) Create(ctx context.Context, apntmnt models.Appointment) (models.Appointment, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	apntmnt.Appointmentid = utils.Randid(1, 10000)
+	a.lastid++
+	apntmnt.Appointmentid = a.lastid
 	a.data[apntmnt.Appointmentid] = apntmnt
 	return a.data[apntmnt.Appointmentid], nil
 }
-func (a *Appointment) Find(id int) (models.Appointment, error) {
+func (a *Appointment) Find(ctx context.Context, id int) (models.Appointment, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 	if val, ok := a.data[id]; ok {
 		return val, nil
 	}
-	return models.Appointment{}, errors.New(" not such appointment")
+	return models.Appointment{}, sql.ErrNoRows
 }
 
-// offset shouldn't be greater than limit
-func (a *Appointment) FindAll(data models.Filters) ([]models.Appointment, *models.Metadata, error) {
-	count := 0
-	var metadata models.Metadata
+func (a *Appointment) FindAll(ctx context.Context, filters models.Filters) ([]models.Appointment, *models.Metadata, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	c := make([]models.Appointment, data.Offset(), data.Limit())
-	for _, val := range a.data {
-		count++
-		c = append(c, val)
-	}
-	metadata = models.CalculateMetadata(count, data.Page, data.PageSize)
-	return c, &metadata, nil
+	items, metadata := page(sorted(a.data, all[models.Appointment]), filters)
+	return items, metadata, nil
 }
 func (a *Appointment) Count() (int, error) {
-	count := len(a.data)
-	return count, nil
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.data), nil
 }
 
-func (a *Appointment) FindAllByDoctor(id int) ([]models.Appointment, error) {
+func (a *Appointment) FindAllByDoctor(ctx context.Context, id int) ([]models.Appointment, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	c := make([]models.Appointment, 0)
-	for _, val := range a.data {
-		if val.Doctorid == id {
-			c = append(c, val)
-		}
-	}
-	return c, nil
+	return sorted(a.data, func(val models.Appointment) bool {
+		return val.Doctorid == id
+	}), nil
 }
 
-func (a *Appointment) FindAllByPatient(id int) ([]models.Appointment, error) {
+func (a *Appointment) FindAllByPatient(ctx context.Context, id int) ([]models.Appointment, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	c := make([]models.Appointment, 0)
-	for _, val := range a.data {
-		if val.Patientid == id {
-			c = append(c, val)
-		}
-	}
-	return c, nil
+	return sorted(a.data, func(val models.Appointment) bool {
+		return val.Patientid == id
+	}), nil
+}
+
+func (a *Appointment) FindAllBySeries(ctx context.Context, id int) ([]models.Appointment, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	items := sorted(a.data, func(val models.Appointment) bool {
+		return id != 0 && val.Seriesid == id
+	})
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Appointmentdate.Before(items[j].Appointmentdate)
+	})
+	return items, nil
 }
 
-func (a *Appointment) Delete(id int) error {
+func (a *Appointment) Delete(ctx context.Context, id int) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	delete(a.data, id)
 	return nil
 }
 
-func (a *Appointment) Update(apntmnt models.Appointment) (models.Appointment, error) {
+func (a *Appointment) Update(ctx context.Context, apntmnt models.Appointment) (models.Appointment, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	old, ok := a.data[apntmnt.Appointmentid]
+	if !ok {
+		return models.Appointment{}, sql.ErrNoRows
+	}
+	// like the postgres controller an appointment can't move to another doctor,patient or series
+	apntmnt.Doctorid, apntmnt.Patientid, apntmnt.Seriesid = old.Doctorid, old.Patientid, old.Seriesid
 	a.data[apntmnt.Appointmentid] = apntmnt
 	return a.data[apntmnt.Appointmentid], nil
 }
+
+// Lock has nothing to do,the memory units of work already run one at a time
+func (a *Appointment) Lock(ctx context.Context, doctorid, patientid int) error {
+	return ctx.Err()
+}
@@ -0,0 +1,62 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type CalendarFeed struct {
+	mu   sync.RWMutex
+	data map[string]models.CalendarFeed
+}
+
+func (c *CalendarFeed) Create(ctx context.Context, feed models.CalendarFeed) (models.CalendarFeed, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.data[feed.Token]; ok {
+		return models.CalendarFeed{}, models.ErrDuplicate
+	}
+	for _, val := range c.data {
+		if val.AccountType == feed.AccountType && val.AccountId == feed.AccountId {
+			return models.CalendarFeed{}, models.ErrDuplicate
+		}
+	}
+	feed.CreatedAt = time.Now()
+	c.data[feed.Token] = feed
+	return feed, nil
+}
+
+func (c *CalendarFeed) FindbyToken(ctx context.Context, token string) (models.CalendarFeed, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if val, ok := c.data[token]; ok {
+		return val, nil
+	}
+	return models.CalendarFeed{}, sql.ErrNoRows
+}
+
+func (c *CalendarFeed) FindbyAccount(ctx context.Context, accounttype string, id int) (models.CalendarFeed, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, val := range c.data {
+		if val.AccountType == accounttype && val.AccountId == id {
+			return val, nil
+		}
+	}
+	return models.CalendarFeed{}, sql.ErrNoRows
+}
+
+func (c *CalendarFeed) DeleteByAccount(ctx context.Context, accounttype string, id int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, val := range c.data {
+		if val.AccountType == accounttype && val.AccountId == id {
+			delete(c.data, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package inmem
+
+import (
+	"testing"
+
+	"github.com/patienttracker/internal/repotest"
+)
+
+func TestRepositoryContract(t *testing.T) {
+	store := NewMockStore()
+	repotest.Run(t, store.Repositories())
+}
+
+func TestUnitOfWorkContract(t *testing.T) {
+	store := NewMockStore()
+	repotest.UnitOfWork(t, store.UnitOfWork, store.Repositories())
+}
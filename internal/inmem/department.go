@@ -1,75 +1,82 @@
 package inmem
 
 import (
-	"errors"
+	"context"
+	"database/sql"
 	"sync"
 
 	"github.com/patienttracker/internal/models"
-	"github.com/patienttracker/internal/utils"
 )
 
 type Department struct {
-	mu   sync.RWMutex
-	data map[int]models.Department
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Department
 }
 
-func (d *Department) Create(dept models.Department) (models.Department, error) {
+func (d *Department) Create(ctx context.Context, dept models.Department) (models.Department, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	dept.Departmentid = utils.Randid(1, 10000)
+	if err := unique(d.data, 0, dept, departmentname); err != nil {
+		return models.Department{}, err
+	}
+	d.lastid++
+	dept.Departmentid = d.lastid
 	d.data[dept.Departmentid] = dept
 	return d.data[dept.Departmentid], nil
 }
-func (d *Department) Find(id int) (models.Department, error) {
+
+func departmentname(val models.Department) string { return val.Departmentname }
+
+func (d *Department) Find(ctx context.Context, id int) (models.Department, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 	if val, ok := d.data[id]; ok {
 		return val, nil
 	}
-	return models.Department{}, errors.New(" no such department")
+	return models.Department{}, sql.ErrNoRows
 }
+
 func (d *Department) Count() (int, error) {
-	count := len(d.data)
-	return count, nil
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.data), nil
 }
 
-// offset shouldn't be greater than limit
-func (d *Department) FindAll(data models.Filters) ([]models.Department, *models.Metadata, error) {
-	count := 0
-	var metadata models.Metadata
+func (d *Department) FindAll(ctx context.Context, filters models.Filters) ([]models.Department, *models.Metadata, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	c := make([]models.Department, data.Offset(), data.Limit())
-	for _, val := range d.data {
-		count++
-		c = append(c, val)
-	}
-	metadata = models.CalculateMetadata(count, data.Page, data.PageSize)
-	return c, &metadata, nil
+	items, metadata := page(sorted(d.data, all[models.Department]), filters)
+	return items, metadata, nil
 }
 
-func (d *Department) FindbyName(name string) (models.Department, error) {
+func (d *Department) FindbyName(ctx context.Context, name string) (models.Department, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	//c := make([]models.Department, 0)
 	for _, val := range d.data {
 		if val.Departmentname == name {
 			return val, nil
 		}
 	}
-	return models.Department{}, errors.New("no such department")
+	return models.Department{}, sql.ErrNoRows
 }
 
-func (d *Department) Delete(id int) error {
+func (d *Department) Delete(ctx context.Context, id int) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	delete(d.data, id)
 	return nil
 }
 
-func (d *Department) Update(dept models.Department) (models.Department, error) {
+func (d *Department) Update(ctx context.Context, dept models.Department) (models.Department, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if _, ok := d.data[dept.Departmentid]; !ok {
+		return models.Department{}, sql.ErrNoRows
+	}
+	if err := unique(d.data, dept.Departmentid, dept, departmentname); err != nil {
+		return models.Department{}, err
+	}
 	d.data[dept.Departmentid] = dept
 	return d.data[dept.Departmentid], nil
 }
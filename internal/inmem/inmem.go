@@ -1,6 +1,12 @@
+// Package inmem implements every repository in memory,it backs the memory
+// storage driver and the tests that don't need postgres.
 package inmem
 
 import (
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
 	"github.com/patienttracker/internal/models"
 )
 
@@ -8,19 +14,46 @@ type Memstore struct {
 	PatientMemStore     *Patient
 	RecordMemStore      *PatientRecords
 	DoctorMemStore      *Doctor
+	NurseMemStore       *Nurse
 	DepartmentMemStore  *Department
 	AppointmentMemStore *Appointment
+	SeriesMemStore      *Series
 	ScheduleMemStore    *Schedule
+	ExceptionMemStore   *ScheduleException
+	TransitionMemStore  *Transition
+	ReminderMemStore    *Reminder
+	WaitlistMemStore    *Waitlist
+	TicketMemStore      *Ticket
+	RolesMemStore       *Roles
+	UsersMemStore       *Users
+	PermissionsMemStore *Permissions
+	SessionMemStore     *Session
+	CalendarMemStore    *CalendarFeed
+	OutboxMemStore      *Outbox
+	UnitOfWork          *UnitOfWork
 }
 
 func NewMockStore() Memstore {
 	patientmap := make(map[int]models.Patient)
 	doctormap := make(map[int]models.Physician)
+	nursemap := make(map[int]models.Nurse)
 	deptmap := make(map[int]models.Department)
 	recordmap := make(map[int]models.Patientrecords)
 	appointmentmap := make(map[int]models.Appointment)
+	seriesmap := make(map[int]models.Series)
 	schedulemap := make(map[int]models.Schedule)
-	return Memstore{
+	exceptionmap := make(map[int]models.ScheduleException)
+	transitionmap := make(map[int]models.Transition)
+	remindermap := make(map[int]models.Reminder)
+	waitlistmap := make(map[int]models.Waitlist)
+	ticketmap := make(map[int]models.Ticket)
+	rolesmap := make(map[int]models.Roles)
+	usersmap := make(map[int]models.Users)
+	permissionsmap := make(map[int]models.Permissions)
+	sessionmap := make(map[uuid.UUID]models.Session)
+	calendarmap := make(map[string]models.CalendarFeed)
+	outboxmap := make(map[int]models.OutboxEvent)
+	store := Memstore{
 		PatientMemStore: &Patient{
 			data: patientmap,
 		},
@@ -30,14 +63,147 @@ func NewMockStore() Memstore {
 		DoctorMemStore: &Doctor{
 			data: doctormap,
 		},
+		NurseMemStore: &Nurse{
+			data: nursemap,
+		},
 		DepartmentMemStore: &Department{
 			data: deptmap,
 		},
 		AppointmentMemStore: &Appointment{
 			data: appointmentmap,
 		},
+		SeriesMemStore: &Series{
+			data: seriesmap,
+		},
 		ScheduleMemStore: &Schedule{
 			data: schedulemap,
 		},
+		ExceptionMemStore: &ScheduleException{
+			data: exceptionmap,
+		},
+		TransitionMemStore: &Transition{
+			data: transitionmap,
+		},
+		ReminderMemStore: &Reminder{
+			data: remindermap,
+		},
+		WaitlistMemStore: &Waitlist{
+			data: waitlistmap,
+		},
+		TicketMemStore: &Ticket{
+			data: ticketmap,
+		},
+		RolesMemStore: &Roles{
+			data: rolesmap,
+		},
+		UsersMemStore: &Users{
+			data: usersmap,
+		},
+		PermissionsMemStore: &Permissions{
+			data: permissionsmap,
+		},
+		SessionMemStore: &Session{
+			data: sessionmap,
+		},
+		CalendarMemStore: &CalendarFeed{
+			data: calendarmap,
+		},
+		OutboxMemStore: &Outbox{
+			data: outboxmap,
+		},
 	}
+	store.UnitOfWork = &UnitOfWork{store: store}
+	return store
+}
+
+// Repositories returns the stores as models repositories
+func (m Memstore) Repositories() models.Repositories {
+	return models.Repositories{
+		Patients:     m.PatientMemStore,
+		Doctors:      m.DoctorMemStore,
+		Nurses:       m.NurseMemStore,
+		Departments:  m.DepartmentMemStore,
+		Appointments: m.AppointmentMemStore,
+		Series:       m.SeriesMemStore,
+		Schedules:    m.ScheduleMemStore,
+		Exceptions:   m.ExceptionMemStore,
+		Transitions:  m.TransitionMemStore,
+		Reminders:    m.ReminderMemStore,
+		Waitlists:    m.WaitlistMemStore,
+		Records:      m.RecordMemStore,
+		Tickets:      m.TicketMemStore,
+		Roles:        m.RolesMemStore,
+		Users:        m.UsersMemStore,
+		Permissions:  m.PermissionsMemStore,
+		Sessions:     m.SessionMemStore,
+		Calendars:    m.CalendarMemStore,
+		Outbox:       m.OutboxMemStore,
+	}
+}
+
+// sorted returns the values kept by keep ordered by id,like the ORDER BY of the postgres controllers.
+// The caller must hold the lock.
+func sorted[T any](data map[int]T, keep func(T) bool) []T {
+	ids := make([]int, 0, len(data))
+	for id, val := range data {
+		if keep(val) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	items := make([]T, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, data[id])
+	}
+	return items
+}
+
+func all[T any](T) bool { return true }
+
+// unique returns models.ErrDuplicate when a record other than id has the same key as val,
+// it stands in for the UNIQUE constraints of the schema. The caller must hold the lock.
+func unique[T any](data map[int]T, id int, val T, keys ...func(T) string) error {
+	for other, existing := range data {
+		if other == id {
+			continue
+		}
+		for _, key := range keys {
+			if key(existing) == key(val) {
+				return models.ErrDuplicate
+			}
+		}
+	}
+	return nil
+}
+
+// page slices out the page of items asked for by filters and computes its metadata
+func page[T any](items []T, filters models.Filters) ([]T, *models.Metadata) {
+	metadata := models.CalculateMetadata(len(items), filters.Page, filters.PageSize)
+	start := filters.Offset()
+	if start < 0 {
+		start = 0
+	}
+	if start >= len(items) {
+		// postgres counts the rows with the page itself,past the last page there's nothing to count
+		return nil, &models.Metadata{}
+	}
+	end := start + filters.Limit()
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], &metadata
+}
+
+// pointers converts items for the Filter methods which return pointers
+func pointers[T any](items []T) []*T {
+	c := make([]*T, 0, len(items))
+	for i := range items {
+		c = append(c, &items[i])
+	}
+	return c
+}
+
+// matches is the ILIKE substring search of the postgres Filter queries,an empty search matches everything
+func matches(value, search string) bool {
+	return search == "" || strings.Contains(strings.ToLower(value), strings.ToLower(search))
 }
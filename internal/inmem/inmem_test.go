@@ -0,0 +1,96 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAllPagination(t *testing.T) {
+	store := NewMockStore()
+	for i := 0; i < 7; i++ {
+		_, err := store.PatientMemStore.Create(context.Background(), models.Patient{
+			Username: fmt.Sprintf("patient%d", i),
+			Email:    fmt.Sprintf("patient%d@example.com", i),
+			Contact:  fmt.Sprint(i),
+		})
+		require.NoError(t, err)
+	}
+	patients, metadata, err := store.PatientMemStore.FindAll(context.Background(), models.Filters{Page: 1, PageSize: 3})
+	require.NoError(t, err)
+	require.Len(t, patients, 3)
+	require.Equal(t, []int{1, 2, 3}, []int{patients[0].Patientid, patients[1].Patientid, patients[2].Patientid})
+	require.Equal(t, models.Metadata{CurrentPage: 1, PageSize: 3, FirstPage: 1, LastPage: 3, TotalRecords: 7}, *metadata)
+
+	patients, metadata, err = store.PatientMemStore.FindAll(context.Background(), models.Filters{Page: 3, PageSize: 3})
+	require.NoError(t, err)
+	require.Len(t, patients, 1)
+	require.Equal(t, 7, patients[0].Patientid)
+	require.Equal(t, 3, metadata.CurrentPage)
+
+	patients, _, err = store.PatientMemStore.FindAll(context.Background(), models.Filters{Page: 4, PageSize: 3})
+	require.NoError(t, err)
+	require.Empty(t, patients)
+}
+
+func TestFilter(t *testing.T) {
+	store := NewMockStore()
+	for _, doc := range []models.Physician{
+		{Username: "Alice", Email: "alice@example.com", Contact: "1", Departmentname: "surgery"},
+		{Username: "alina", Email: "alina@example.com", Contact: "2", Departmentname: "pediatrics"},
+		{Username: "bob", Email: "bob@example.com", Contact: "3", Departmentname: "surgery"},
+	} {
+		_, err := store.DoctorMemStore.Create(context.Background(), doc)
+		require.NoError(t, err)
+	}
+	doctors, metadata, err := store.DoctorMemStore.Filter(context.Background(), "ali", "", models.Filters{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, doctors, 2)
+	require.Equal(t, 2, metadata.TotalRecords)
+
+	doctors, _, err = store.DoctorMemStore.Filter(context.Background(), "ALI", "surg", models.Filters{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, doctors, 1)
+	require.Equal(t, "Alice", doctors[0].Username)
+
+	bydept, _, err := store.DoctorMemStore.FindDoctorsbyDept(context.Background(), "surgery", models.Filters{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, bydept, 1)
+	require.Equal(t, 1, bydept[0].Physicianid)
+}
+
+func TestNotFound(t *testing.T) {
+	store := NewMockStore()
+	_, err := store.NurseMemStore.Find(context.Background(), 1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.NurseMemStore.Update(context.Background(), models.Nurse{Id: 1})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.RolesMemStore.FindbyRole(context.Background(), "admin")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.UsersMemStore.FindbyEmail(context.Background(), "admin@example.com")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, store.PermissionsMemStore.Delete(context.Background(), 1))
+}
+
+func TestRbac(t *testing.T) {
+	store := NewMockStore()
+	role, err := store.RolesMemStore.Create(context.Background(), models.Roles{Role: "admin"})
+	require.NoError(t, err)
+	_, err = store.PermissionsMemStore.Create(context.Background(), models.Permissions{Permission: "admin", Roleid: role.Roleid})
+	require.NoError(t, err)
+	_, err = store.PermissionsMemStore.Create(context.Background(), models.Permissions{Permission: "patient:read", Roleid: role.Roleid + 1})
+	require.NoError(t, err)
+	user, err := store.UsersMemStore.Create(context.Background(), models.Users{Email: "admin@example.com", Roleid: role.Roleid})
+	require.NoError(t, err)
+
+	permissions, err := store.PermissionsMemStore.FindbyRoleId(context.Background(), role.Roleid)
+	require.NoError(t, err)
+	require.Len(t, permissions, 1)
+	users, err := store.UsersMemStore.FindbyRoleId(context.Background(), role.Roleid)
+	require.NoError(t, err)
+	require.Equal(t, []models.Users{user}, users)
+}
@@ -0,0 +1,93 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Nurse struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Nurse
+}
+
+func (n *Nurse) Create(ctx context.Context, nurse models.Nurse) (models.Nurse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.unique(0, nurse); err != nil {
+		return models.Nurse{}, err
+	}
+	n.lastid++
+	nurse.Id = n.lastid
+	nurse.Created_at = time.Now()
+	n.data[nurse.Id] = nurse
+	return n.data[nurse.Id], nil
+}
+
+func (n *Nurse) unique(id int, nurse models.Nurse) error {
+	return unique(n.data, id, nurse,
+		func(val models.Nurse) string { return val.Username },
+		func(val models.Nurse) string { return val.Email })
+}
+
+func (n *Nurse) Find(ctx context.Context, id int) (models.Nurse, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if val, ok := n.data[id]; ok {
+		return val, nil
+	}
+	return models.Nurse{}, sql.ErrNoRows
+}
+
+func (n *Nurse) FindbyEmail(ctx context.Context, email string) (models.Nurse, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, val := range n.data {
+		if val.Email == email {
+			return val, nil
+		}
+	}
+	return models.Nurse{}, sql.ErrNoRows
+}
+
+func (n *Nurse) FindAll(ctx context.Context, filters models.Filters) ([]models.Nurse, *models.Metadata, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	items, metadata := page(sorted(n.data, all[models.Nurse]), filters)
+	return items, metadata, nil
+}
+
+func (n *Nurse) Filter(ctx context.Context, username string, filters models.Filters) ([]*models.Nurse, *models.Metadata, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	items, metadata := page(sorted(n.data, func(val models.Nurse) bool {
+		return matches(val.Username, username)
+	}), filters)
+	return pointers(items), metadata, nil
+}
+
+func (n *Nurse) Delete(ctx context.Context, id int) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.data, id)
+	return nil
+}
+
+func (n *Nurse) Update(ctx context.Context, nurse models.Nurse) (models.Nurse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	old, ok := n.data[nurse.Id]
+	if !ok {
+		return models.Nurse{}, sql.ErrNoRows
+	}
+	if err := n.unique(nurse.Id, nurse); err != nil {
+		return models.Nurse{}, err
+	}
+	nurse.Created_at = old.Created_at
+	n.data[nurse.Id] = nurse
+	return n.data[nurse.Id], nil
+}
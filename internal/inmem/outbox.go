@@ -0,0 +1,85 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Outbox struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.OutboxEvent
+}
+
+func (o *Outbox) Create(ctx context.Context, event models.OutboxEvent) (models.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lastid++
+	event.Eventid = o.lastid
+	event.CreatedAt = time.Now()
+	event.Attempts = 0
+	if event.NextAttempt.IsZero() {
+		event.NextAttempt = event.CreatedAt
+	}
+	event.Dispatched = time.Time{}
+	event.LastError = ""
+	o.data[event.Eventid] = event
+	return o.data[event.Eventid], nil
+}
+
+func (o *Outbox) Find(ctx context.Context, id int) (models.OutboxEvent, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if val, ok := o.data[id]; ok {
+		return val, nil
+	}
+	return models.OutboxEvent{}, sql.ErrNoRows
+}
+
+func (o *Outbox) Claim(ctx context.Context, now, until time.Time, limit int) ([]models.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	items := sorted(o.data, func(val models.OutboxEvent) bool {
+		return val.Pending() && !val.NextAttempt.After(now)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	for i, item := range items {
+		item.Attempts++
+		item.NextAttempt = until
+		o.data[item.Eventid] = item
+		items[i] = item
+	}
+	return items, nil
+}
+
+func (o *Outbox) MarkDispatched(ctx context.Context, id int, at time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	event, ok := o.data[id]
+	if !ok || !event.Dispatched.IsZero() {
+		return sql.ErrNoRows
+	}
+	event.Dispatched = at
+	event.LastError = ""
+	o.data[id] = event
+	return nil
+}
+
+func (o *Outbox) Retry(ctx context.Context, id int, next time.Time, reason string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	event, ok := o.data[id]
+	if !ok || !event.Dispatched.IsZero() {
+		return sql.ErrNoRows
+	}
+	event.NextAttempt = next
+	event.LastError = reason
+	o.data[id] = event
+	return nil
+}
@@ -1,39 +1,54 @@
 package inmem
 
 import (
-	"errors"
+	"context"
+	"database/sql"
 	"sync"
+	"time"
 
 	"github.com/patienttracker/internal/models"
-	"github.com/patienttracker/internal/utils"
 )
 
 type Patient struct {
-	mu   sync.RWMutex
-	data map[int]models.Patient
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Patient
 }
 
-func (p *Patient) Create(patient models.Patient) (models.Patient, error) {
+func (p *Patient) Create(ctx context.Context, patient models.Patient) (models.Patient, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	patient.Patientid = utils.Randid(1, 10000)
+	if err := p.unique(0, patient); err != nil {
+		return models.Patient{}, err
+	}
+	p.lastid++
+	patient.Patientid = p.lastid
+	patient.Created_at = time.Now()
 	p.data[patient.Patientid] = patient
 	return p.data[patient.Patientid], nil
 }
-func (p *Patient) Find(id int) (models.Patient, error) {
+func (p *Patient) unique(id int, patient models.Patient) error {
+	return unique(p.data, id, patient,
+		func(val models.Patient) string { return val.Username },
+		func(val models.Patient) string { return val.Email },
+		func(val models.Patient) string { return val.Contact })
+}
+
+func (p *Patient) Find(ctx context.Context, id int) (models.Patient, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	if val, ok := p.data[id]; ok {
 		return val, nil
 	}
-	return models.Patient{}, errors.New("patient not found")
+	return models.Patient{}, sql.ErrNoRows
 }
 func (p *Patient) Count() (int, error) {
-	count := len(p.data)
-	return count, nil
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.data), nil
 }
 
-func (p *Patient) FindbyEmail(email string) (models.Patient, error) {
+func (p *Patient) FindbyEmail(ctx context.Context, email string) (models.Patient, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	for _, val := range p.data {
@@ -41,43 +56,42 @@ func (p *Patient) FindbyEmail(email string) (models.Patient, error) {
 			return val, nil
 		}
 	}
-	return models.Patient{}, errors.New("patient not found")
+	return models.Patient{}, sql.ErrNoRows
 }
-func (d *Patient) Filter(full_name string, filters models.Filters) ([]*models.Patient, *models.Metadata, error) {
-	d.mu.RLock()
-	defer d.mu.RUnlock()
-	c := make([]*models.Patient, filters.Offset(), filters.Limit())
-	for _, val := range d.data {
-		c = append(c, &val)
-	}
-	return c, &models.Metadata{}, nil
+func (p *Patient) Filter(ctx context.Context, username string, filters models.Filters) ([]*models.Patient, *models.Metadata, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	items, metadata := page(sorted(p.data, func(val models.Patient) bool {
+		return matches(val.Username, username)
+	}), filters)
+	return pointers(items), metadata, nil
 }
 
-// offset shouldn't be greater than limit
-func (p *Patient) FindAll(data models.Filters) ([]models.Patient, *models.Metadata, error) {
-	count := 0
-	var metadata models.Metadata
+func (p *Patient) FindAll(ctx context.Context, filters models.Filters) ([]models.Patient, *models.Metadata, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	c := make([]models.Patient, data.Offset(), data.Limit())
-	for _, val := range p.data {
-		count++
-		c = append(c, val)
-	}
-	metadata = models.CalculateMetadata(count, data.Page, data.PageSize)
-	return c, &metadata, nil
+	items, metadata := page(sorted(p.data, all[models.Patient]), filters)
+	return items, metadata, nil
 }
 
-func (p *Patient) Delete(id int) error {
+func (p *Patient) Delete(ctx context.Context, id int) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	delete(p.data, id)
 	return nil
 }
 
-func (p *Patient) Update(patient models.Patient) (models.Patient, error) {
+func (p *Patient) Update(ctx context.Context, patient models.Patient) (models.Patient, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	old, ok := p.data[patient.Patientid]
+	if !ok {
+		return models.Patient{}, sql.ErrNoRows
+	}
+	if err := p.unique(patient.Patientid, patient); err != nil {
+		return models.Patient{}, err
+	}
+	patient.Created_at = old.Created_at
 	p.data[patient.Patientid] = patient
 	return p.data[patient.Patientid], nil
 }
@@ -1,95 +1,89 @@
 package inmem
 
 import (
-	"errors"
+	"context"
+	"database/sql"
 	"sync"
 
 	"github.com/patienttracker/internal/models"
-	"github.com/patienttracker/internal/utils"
 )
 
 type PatientRecords struct {
-	mu   sync.RWMutex
-	data map[int]models.Patientrecords
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Patientrecords
 }
 
-func (p *PatientRecords) Create(records models.Patientrecords) (models.Patientrecords, error) {
+func (p *PatientRecords) Create(ctx context.Context, records models.Patientrecords) (models.Patientrecords, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	records.Recordid = utils.Randid(1, 10000)
+	p.lastid++
+	records.Recordid = p.lastid
 	p.data[records.Recordid] = records
 	return p.data[records.Recordid], nil
 }
-func (p *PatientRecords) Find(id int) (models.Patientrecords, error) {
+func (p *PatientRecords) Find(ctx context.Context, id int) (models.Patientrecords, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 	if val, ok := p.data[id]; ok {
 		return val, nil
 	}
-	return models.Patientrecords{}, errors.New(" not such record")
+	return models.Patientrecords{}, sql.ErrNoRows
 }
 
-// offset shouldn't be greater than limit
-func (p *PatientRecords) FindAll(data models.Filters) ([]models.Patientrecords, *models.Metadata, error) {
-	count := 0
-	var metadata models.Metadata
+func (p *PatientRecords) FindAll(ctx context.Context, filters models.Filters) ([]models.Patientrecords, *models.Metadata, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	c := make([]models.Patientrecords, data.Offset(), data.Limit())
-	for _, val := range p.data {
-		c = append(c, val)
-	}
-	metadata = models.CalculateMetadata(count, data.Page, data.PageSize)
-	return c, &metadata, nil
+	items, metadata := page(sorted(p.data, all[models.Patientrecords]), filters)
+	return items, metadata, nil
 }
 
-func (p *PatientRecords) FindAllByDoctor(id int) ([]models.Patientrecords, error) {
+func (p *PatientRecords) FindAllByDoctor(ctx context.Context, id int) ([]models.Patientrecords, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	c := make([]models.Patientrecords, 0)
-	for _, val := range p.data {
-		if val.Doctorid == id {
-			c = append(c, val)
-		}
-	}
-	return c, nil
+	return sorted(p.data, func(val models.Patientrecords) bool {
+		return val.Doctorid == id
+	}), nil
 }
 func (p *PatientRecords) Count() (int, error) {
-	count := len(p.data)
-	return count, nil
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.data), nil
 }
-func (p *PatientRecords) FindAllByPatient(id int) ([]models.Patientrecords, error) {
+
+func (p *PatientRecords) FindAllByPatient(ctx context.Context, id int) ([]models.Patientrecords, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	c := make([]models.Patientrecords, 0)
-	for _, val := range p.data {
-		if val.Patienid == id {
-			c = append(c, val)
-		}
-	}
-	return c, nil
+	return sorted(p.data, func(val models.Patientrecords) bool {
+		return val.Patienid == id
+	}), nil
 }
-func (p *PatientRecords) FindAllByNurse(id int) ([]models.Patientrecords, error) {
+
+func (p *PatientRecords) FindAllByNurse(ctx context.Context, id int) ([]models.Patientrecords, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	c := make([]models.Patientrecords, 0)
-	for _, val := range p.data {
-		if val.Nurseid == id {
-			c = append(c, val)
-		}
-	}
-	return c, nil
+	return sorted(p.data, func(val models.Patientrecords) bool {
+		return val.Nurseid == id
+	}), nil
 }
-func (p *PatientRecords) Delete(id int) error {
+
+func (p *PatientRecords) Delete(ctx context.Context, id int) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	delete(p.data, id)
 	return nil
 }
 
-func (p *PatientRecords) Update(record models.Patientrecords) (models.Patientrecords, error) {
+func (p *PatientRecords) Update(ctx context.Context, record models.Patientrecords) (models.Patientrecords, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	old, ok := p.data[record.Recordid]
+	if !ok {
+		return models.Patientrecords{}, sql.ErrNoRows
+	}
+	// only the measurements can change,like the postgres controller
+	record.Patienid, record.Date, record.HeartRate = old.Patienid, old.Date, old.HeartRate
+	record.Doctorid, record.Nurseid = old.Doctorid, old.Nurseid
 	p.data[record.Recordid] = record
 	return p.data[record.Recordid], nil
 }
@@ -1,84 +1,84 @@
 package inmem
 
 import (
-	"errors"
+	"context"
+	"database/sql"
 	"sync"
+	"time"
 
 	"github.com/patienttracker/internal/models"
-	"github.com/patienttracker/internal/utils"
 )
 
 type Doctor struct {
-	mu   sync.RWMutex
-	data map[int]models.Physician
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Physician
 }
 
-func (d *Doctor) Create(doc models.Physician) (models.Physician, error) {
+func (d *Doctor) Create(ctx context.Context, doc models.Physician) (models.Physician, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	doc.Physicianid = utils.Randid(1, 10000)
+	if err := d.unique(0, doc); err != nil {
+		return models.Physician{}, err
+	}
+	d.lastid++
+	doc.Physicianid = d.lastid
+	doc.Created_at = time.Now()
 	d.data[doc.Physicianid] = doc
 	return d.data[doc.Physicianid], nil
 }
-func (d *Doctor) Find(id int) (models.Physician, error) {
+func (d *Doctor) unique(id int, doc models.Physician) error {
+	return unique(d.data, id, doc,
+		func(val models.Physician) string { return val.Username },
+		func(val models.Physician) string { return val.Email },
+		func(val models.Physician) string { return val.Contact })
+}
+
+func (d *Doctor) Find(ctx context.Context, id int) (models.Physician, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 	if val, ok := d.data[id]; ok {
 		return val, nil
 	}
-	return models.Physician{}, errors.New("doctor not found")
+	return models.Physician{}, sql.ErrNoRows
 }
 
 func (d *Doctor) Count() (int, error) {
-	count := len(d.data)
-	return count, nil
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.data), nil
 }
 
-// offset shouldn't be greater than limit
-func (d *Doctor) FindAll(data models.Filters) ([]models.Physician, *models.Metadata, error) {
-	count := 0
-	var metadata models.Metadata
+func (d *Doctor) FindAll(ctx context.Context, filters models.Filters) ([]models.Physician, *models.Metadata, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	c := make([]models.Physician, data.Offset(), data.Limit())
-	for _, val := range d.data {
-		count++
-		c = append(c, val)
-	}
-	metadata = models.CalculateMetadata(count, data.Page, data.PageSize)
-	return c, &metadata, nil
+	items, metadata := page(sorted(d.data, all[models.Physician]), filters)
+	return items, metadata, nil
 }
-func (d *Doctor) Filter(full_name string, departmentname string, filters models.Filters) ([]*models.Physician, *models.Metadata, error) {
+func (d *Doctor) Filter(ctx context.Context, username string, departmentname string, filters models.Filters) ([]*models.Physician, *models.Metadata, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	c := make([]*models.Physician, filters.Offset(), filters.Limit())
-	for _, val := range d.data {
-		c = append(c, &val)
-	}
-	return c, &models.Metadata{}, nil
+	items, metadata := page(sorted(d.data, func(val models.Physician) bool {
+		return matches(val.Username, username) && matches(val.Departmentname, departmentname)
+	}), filters)
+	return pointers(items), metadata, nil
 }
-func (d *Doctor) FindDoctorsbyDept(dept string, doc models.Filters) ([]models.Physician, *models.Metadata, error) {
-	count := 0
-	var metadata models.Metadata
+func (d *Doctor) FindDoctorsbyDept(ctx context.Context, dept string, filters models.Filters) ([]models.Physician, *models.Metadata, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	c := make([]models.Physician, doc.Offset(), doc.Limit())
-	for _, val := range d.data {
-		if val.Departmentname == dept {
-			c = append(c, val)
-		}
-	}
-	metadata = models.CalculateMetadata(count, doc.Page, doc.PageSize)
-	return c, &metadata, nil
+	items, metadata := page(sorted(d.data, func(val models.Physician) bool {
+		return val.Departmentname == dept
+	}), filters)
+	return items, metadata, nil
 }
-func (d *Doctor) Delete(id int) error {
+func (d *Doctor) Delete(ctx context.Context, id int) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	delete(d.data, id)
 	return nil
 }
 
-func (d *Doctor) FindbyEmail(email string) (models.Physician, error) {
+func (d *Doctor) FindbyEmail(ctx context.Context, email string) (models.Physician, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 	for _, val := range d.data {
@@ -86,12 +86,20 @@ func (d *Doctor) FindbyEmail(email string) (models.Physician, error) {
 			return val, nil
 		}
 	}
-	return models.Physician{}, errors.New("patient not found")
+	return models.Physician{}, sql.ErrNoRows
 }
 
-func (d *Doctor) Update(doc models.Physician) (models.Physician, error) {
+func (d *Doctor) Update(ctx context.Context, doc models.Physician) (models.Physician, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	old, ok := d.data[doc.Physicianid]
+	if !ok {
+		return models.Physician{}, sql.ErrNoRows
+	}
+	if err := d.unique(doc.Physicianid, doc); err != nil {
+		return models.Physician{}, err
+	}
+	doc.Created_at = old.Created_at
 	d.data[doc.Physicianid] = doc
 	return d.data[doc.Physicianid], nil
 }
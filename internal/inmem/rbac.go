@@ -0,0 +1,204 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Roles struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Roles
+}
+
+func (r *Roles) Create(ctx context.Context, role models.Roles) (models.Roles, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := unique(r.data, 0, role, rolename); err != nil {
+		return models.Roles{}, err
+	}
+	r.lastid++
+	role.Roleid = r.lastid
+	r.data[role.Roleid] = role
+	return role, nil
+}
+
+func rolename(val models.Roles) string { return val.Role }
+
+func (r *Roles) Find(ctx context.Context, id int) (models.Roles, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if val, ok := r.data[id]; ok {
+		return val, nil
+	}
+	return models.Roles{}, sql.ErrNoRows
+}
+
+func (r *Roles) FindbyRole(ctx context.Context, rolename string) (models.Roles, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, val := range r.data {
+		if val.Role == rolename {
+			return val, nil
+		}
+	}
+	return models.Roles{}, sql.ErrNoRows
+}
+
+func (r *Roles) FindAll(ctx context.Context) ([]models.Roles, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sorted(r.data, all[models.Roles]), nil
+}
+
+func (r *Roles) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.data, id)
+	return nil
+}
+
+func (r *Roles) Update(ctx context.Context, role models.Roles) (models.Roles, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.data[role.Roleid]; !ok {
+		return models.Roles{}, sql.ErrNoRows
+	}
+	if err := unique(r.data, role.Roleid, role, rolename); err != nil {
+		return models.Roles{}, err
+	}
+	r.data[role.Roleid] = role
+	return role, nil
+}
+
+type Users struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Users
+}
+
+func (u *Users) Create(ctx context.Context, user models.Users) (models.Users, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := unique(u.data, 0, user, useremail); err != nil {
+		return models.Users{}, err
+	}
+	u.lastid++
+	user.Id = u.lastid
+	u.data[user.Id] = user
+	return user, nil
+}
+
+func useremail(val models.Users) string { return val.Email }
+
+func (u *Users) Find(ctx context.Context, id int) (models.Users, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if val, ok := u.data[id]; ok {
+		return val, nil
+	}
+	return models.Users{}, sql.ErrNoRows
+}
+
+func (u *Users) FindAll(ctx context.Context) ([]models.Users, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return sorted(u.data, all[models.Users]), nil
+}
+
+func (u *Users) FindbyEmail(ctx context.Context, email string) (models.Users, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	for _, val := range u.data {
+		if val.Email == email {
+			return val, nil
+		}
+	}
+	return models.Users{}, sql.ErrNoRows
+}
+
+func (u *Users) FindbyRoleId(ctx context.Context, id int) ([]models.Users, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return sorted(u.data, func(val models.Users) bool {
+		return val.Roleid == id
+	}), nil
+}
+
+func (u *Users) Delete(ctx context.Context, id int) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.data, id)
+	return nil
+}
+
+func (u *Users) Update(ctx context.Context, user models.Users) (models.Users, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.data[user.Id]; !ok {
+		return models.Users{}, sql.ErrNoRows
+	}
+	if err := unique(u.data, user.Id, user, useremail); err != nil {
+		return models.Users{}, err
+	}
+	u.data[user.Id] = user
+	return user, nil
+}
+
+type Permissions struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Permissions
+}
+
+func (p *Permissions) Create(ctx context.Context, permission models.Permissions) (models.Permissions, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastid++
+	permission.Permissionid = p.lastid
+	p.data[permission.Permissionid] = permission
+	return permission, nil
+}
+
+func (p *Permissions) Find(ctx context.Context, id int) (models.Permissions, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if val, ok := p.data[id]; ok {
+		return val, nil
+	}
+	return models.Permissions{}, sql.ErrNoRows
+}
+
+func (p *Permissions) FindAll(ctx context.Context) ([]models.Permissions, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return sorted(p.data, all[models.Permissions]), nil
+}
+
+func (p *Permissions) FindbyRoleId(ctx context.Context, id int) ([]models.Permissions, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return sorted(p.data, func(val models.Permissions) bool {
+		return val.Roleid == id
+	}), nil
+}
+
+func (p *Permissions) Delete(ctx context.Context, id int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.data, id)
+	return nil
+}
+
+func (p *Permissions) Update(ctx context.Context, permission models.Permissions) (models.Permissions, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.data[permission.Permissionid]; !ok {
+		return models.Permissions{}, sql.ErrNoRows
+	}
+	p.data[permission.Permissionid] = permission
+	return permission, nil
+}
@@ -0,0 +1,88 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Reminder struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Reminder
+}
+
+// reminderdue is the UNIQUE (appointmentid,seconds,due) constraint of the reminders table
+func reminderdue(r models.Reminder) string {
+	return fmt.Sprint(r.Appointmentid, r.Offset, r.Due.UnixNano())
+}
+
+// bydue orders the reminders by the time they're due,the order they were added breaks ties
+func bydue(items []models.Reminder) []models.Reminder {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Due.Before(items[j].Due)
+	})
+	return items
+}
+
+func (r *Reminder) Create(ctx context.Context, reminder models.Reminder) (models.Reminder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := unique(r.data, 0, reminder, reminderdue); err != nil {
+		return models.Reminder{}, err
+	}
+	r.lastid++
+	reminder.Reminderid = r.lastid
+	reminder.Sent = time.Time{}
+	reminder.CreatedAt = time.Now()
+	r.data[reminder.Reminderid] = reminder
+	return r.data[reminder.Reminderid], nil
+}
+
+func (r *Reminder) FindbyAppointment(ctx context.Context, id int) ([]models.Reminder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return bydue(sorted(r.data, func(val models.Reminder) bool {
+		return val.Appointmentid == id
+	})), nil
+}
+
+func (r *Reminder) FindDue(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	items := bydue(sorted(r.data, func(val models.Reminder) bool {
+		return val.Pending() && !val.Due.After(now)
+	}))
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (r *Reminder) MarkSent(ctx context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reminder, ok := r.data[id]
+	if !ok || !reminder.Pending() {
+		return sql.ErrNoRows
+	}
+	reminder.Sent = at
+	r.data[id] = reminder
+	return nil
+}
+
+func (r *Reminder) DeletePending(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, val := range r.data {
+		if val.Appointmentid == id && val.Pending() {
+			delete(r.data, key)
+		}
+	}
+	return nil
+}
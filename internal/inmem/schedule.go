@@ -1,75 +1,73 @@
 package inmem
 
 import (
-	"errors"
+	"context"
+	"database/sql"
 	"sync"
 
 	"github.com/patienttracker/internal/models"
-	"github.com/patienttracker/internal/utils"
 )
 
 type Schedule struct {
-	mu   sync.RWMutex
-	data map[int]models.Schedule
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Schedule
 }
 
-func (s *Schedule) Create(schedule models.Schedule) (models.Schedule, error) {
+func (s *Schedule) Create(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	schedule.Scheduleid = utils.Randid(1, 10000)
+	s.lastid++
+	schedule.Scheduleid = s.lastid
 	s.data[schedule.Scheduleid] = schedule
 	return s.data[schedule.Scheduleid], nil
 }
-func (s *Schedule) Find(id int) (models.Schedule, error) {
+
+func (s *Schedule) Find(ctx context.Context, id int) (models.Schedule, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if val, ok := s.data[id]; ok {
 		return val, nil
 	}
-	return models.Schedule{}, errors.New(" not such schedule")
+	return models.Schedule{}, sql.ErrNoRows
 }
+
 func (s *Schedule) Count() (int, error) {
-	count := len(s.data)
-	return count, nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data), nil
 }
 
-// offset shouldn't be greater than limit
-func (s *Schedule) FindAll(data models.Filters) ([]models.Schedule, *models.Metadata, error) {
-	count := 0
-	var metadata models.Metadata
+func (s *Schedule) FindAll(ctx context.Context, filters models.Filters) ([]models.Schedule, *models.Metadata, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	c := make([]models.Schedule, data.Offset(), data.Limit())
-	for _, val := range s.data {
-		count++
-		c = append(c, val)
-	}
-	metadata = models.CalculateMetadata(count, data.Page, data.PageSize)
-	return c, &metadata, nil
+	items, metadata := page(sorted(s.data, all[models.Schedule]), filters)
+	return items, metadata, nil
 }
 
-func (s *Schedule) FindbyDoctor(id int) ([]models.Schedule, error) {
+func (s *Schedule) FindbyDoctor(ctx context.Context, id int) ([]models.Schedule, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	c := make([]models.Schedule, 0)
-	for _, val := range s.data {
-		if val.Doctorid == id {
-			c = append(c, val)
-		}
-	}
-	return c, nil
+	return sorted(s.data, func(val models.Schedule) bool {
+		return val.Doctorid == id
+	}), nil
 }
 
-func (s *Schedule) Delete(id int) error {
+func (s *Schedule) Delete(ctx context.Context, id int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.data, id)
 	return nil
 }
 
-func (s *Schedule) Update(schedule models.Schedule) (models.Schedule, error) {
+func (s *Schedule) Update(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	old, ok := s.data[schedule.Scheduleid]
+	if !ok {
+		return models.Schedule{}, sql.ErrNoRows
+	}
+	schedule.Doctorid = old.Doctorid
 	s.data[schedule.Scheduleid] = schedule
 	return s.data[schedule.Scheduleid], nil
 }
@@ -0,0 +1,53 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type ScheduleException struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.ScheduleException
+}
+
+func (e *ScheduleException) Create(ctx context.Context, exception models.ScheduleException) (models.ScheduleException, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastid++
+	exception.Exceptionid = e.lastid
+	e.data[exception.Exceptionid] = exception
+	return e.data[exception.Exceptionid], nil
+}
+
+func (e *ScheduleException) Find(ctx context.Context, id int) (models.ScheduleException, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if val, ok := e.data[id]; ok {
+		return val, nil
+	}
+	return models.ScheduleException{}, sql.ErrNoRows
+}
+
+func (e *ScheduleException) FindbyDoctor(ctx context.Context, id int) ([]models.ScheduleException, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	items := sorted(e.data, func(val models.ScheduleException) bool {
+		return val.Doctorid == id
+	})
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Starttime.Before(items[j].Starttime)
+	})
+	return items, nil
+}
+
+func (e *ScheduleException) Delete(ctx context.Context, id int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.data, id)
+	return nil
+}
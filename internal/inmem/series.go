@@ -0,0 +1,48 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Series struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Series
+}
+
+func (s *Series) Create(ctx context.Context, series models.Series) (models.Series, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastid++
+	series.Seriesid = s.lastid
+	series.CreatedAt = time.Now()
+	s.data[series.Seriesid] = series
+	return s.data[series.Seriesid], nil
+}
+
+func (s *Series) Find(ctx context.Context, id int) (models.Series, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if val, ok := s.data[id]; ok {
+		return val, nil
+	}
+	return models.Series{}, sql.ErrNoRows
+}
+
+func (s *Series) Update(ctx context.Context, series models.Series) (models.Series, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.data[series.Seriesid]
+	if !ok {
+		return models.Series{}, sql.ErrNoRows
+	}
+	// like the postgres controller only the start & the duration change
+	old.Start, old.Duration = series.Start, series.Duration
+	s.data[old.Seriesid] = old
+	return old, nil
+}
@@ -0,0 +1,126 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/patienttracker/internal/models"
+)
+
+type Session struct {
+	mu   sync.RWMutex
+	data map[uuid.UUID]models.Session
+}
+
+func (s *Session) Create(ctx context.Context, session models.Session) (models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[session.Id]; ok || s.refreshtokenused(session.Id, session.RefreshTokenId) {
+		return models.Session{}, models.ErrDuplicate
+	}
+	session.CreatedAt = time.Now()
+	s.data[session.Id] = session
+	return session, nil
+}
+
+func (s *Session) Find(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if val, ok := s.data[id]; ok {
+		return val, nil
+	}
+	return models.Session{}, sql.ErrNoRows
+}
+
+func (s *Session) FindbyRefreshToken(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, val := range s.data {
+		if val.RefreshTokenId.Valid && val.RefreshTokenId.UUID == id {
+			return val, nil
+		}
+	}
+	return models.Session{}, sql.ErrNoRows
+}
+
+// refreshtokenused reports whether a session other than id holds the refresh token,the caller must hold the lock
+func (s *Session) refreshtokenused(id uuid.UUID, refreshtokenid uuid.NullUUID) bool {
+	if !refreshtokenid.Valid {
+		return false
+	}
+	for key, val := range s.data {
+		if key != id && val.RefreshTokenId == refreshtokenid {
+			return true
+		}
+	}
+	return false
+}
+
+// active returns the active sessions newest first,the caller must hold the lock
+func (s *Session) active(keep func(models.Session) bool) []models.Session {
+	var items []models.Session
+	for _, val := range s.data {
+		if val.Active() && keep(val) {
+			items = append(items, val)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.After(items[j].CreatedAt) })
+	return items
+}
+
+func (s *Session) FindAll(ctx context.Context, filters models.Filters) ([]models.Session, *models.Metadata, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items, metadata := page(s.active(all[models.Session]), filters)
+	return items, metadata, nil
+}
+
+func (s *Session) FindAllByAccount(ctx context.Context, accounttype string, id int) ([]models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active(func(val models.Session) bool {
+		return val.AccountType == accounttype && val.AccountId == id
+	}), nil
+}
+
+func (s *Session) Rotate(ctx context.Context, id uuid.UUID, presented uuid.UUID, refreshtokenid uuid.UUID, expiresat time.Time) (models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	val, ok := s.data[id]
+	if !ok || val.Revoked || val.RefreshTokenId != (uuid.NullUUID{UUID: presented, Valid: true}) {
+		return models.Session{}, sql.ErrNoRows
+	}
+	if s.refreshtokenused(id, uuid.NullUUID{UUID: refreshtokenid, Valid: true}) {
+		return models.Session{}, models.ErrDuplicate
+	}
+	val.RefreshTokenId = uuid.NullUUID{UUID: refreshtokenid, Valid: true}
+	val.ExpiresAt = expiresat
+	s.data[id] = val
+	return val, nil
+}
+
+func (s *Session) Revoke(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if val, ok := s.data[id]; ok {
+		val.Revoked = true
+		s.data[id] = val
+	}
+	return nil
+}
+
+func (s *Session) RevokeAllByAccount(ctx context.Context, accounttype string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, val := range s.data {
+		if val.AccountType == accounttype && val.AccountId == id {
+			val.Revoked = true
+			s.data[key] = val
+		}
+	}
+	return nil
+}
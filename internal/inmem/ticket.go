@@ -0,0 +1,94 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Ticket struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Ticket
+}
+
+// queue orders the tickets the most urgent first then the oldest,like the queues of the postgres controller
+func queue(items []models.Ticket) []models.Ticket {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Acuity != items[j].Acuity {
+			return items[i].Acuity < items[j].Acuity
+		}
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items
+}
+
+func (t *Ticket) Create(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastid++
+	ticket.Ticketid = t.lastid
+	ticket.CreatedAt = time.Now()
+	t.data[ticket.Ticketid] = ticket
+	return t.data[ticket.Ticketid], nil
+}
+
+func (t *Ticket) Find(ctx context.Context, id int) (models.Ticket, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if val, ok := t.data[id]; ok {
+		return val, nil
+	}
+	return models.Ticket{}, sql.ErrNoRows
+}
+
+func (t *Ticket) FindAll(ctx context.Context, filters models.Filters) ([]models.Ticket, *models.Metadata, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	items, metadata := page(sorted(t.data, all[models.Ticket]), filters)
+	return items, metadata, nil
+}
+
+func (t *Ticket) FindbyNurse(ctx context.Context, id int) ([]models.Ticket, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return queue(sorted(t.data, func(val models.Ticket) bool {
+		return val.Nurseid == id
+	})), nil
+}
+
+func (t *Ticket) FindbyDoctor(ctx context.Context, id int) ([]models.Ticket, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return queue(sorted(t.data, func(val models.Ticket) bool {
+		return val.Doctorid == id
+	})), nil
+}
+
+func (t *Ticket) FindbyPatient(ctx context.Context, id int) ([]models.Ticket, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return sorted(t.data, func(val models.Ticket) bool {
+		return val.Patientid == id
+	}), nil
+}
+
+func (t *Ticket) Update(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	old, ok := t.data[ticket.Ticketid]
+	if !ok {
+		return models.Ticket{}, sql.ErrNoRows
+	}
+	// like the postgres controller the patient,nurse & creation time don't change
+	old.Doctorid = ticket.Doctorid
+	old.Status = ticket.Status
+	old.Acuity = ticket.Acuity
+	old.AttendedAt = ticket.AttendedAt
+	t.data[old.Ticketid] = old
+	return old, nil
+}
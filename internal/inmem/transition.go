@@ -0,0 +1,33 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Transition struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Transition
+}
+
+func (t *Transition) Create(ctx context.Context, transition models.Transition) (models.Transition, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastid++
+	transition.Transitionid = t.lastid
+	transition.CreatedAt = time.Now()
+	t.data[transition.Transitionid] = transition
+	return t.data[transition.Transitionid], nil
+}
+
+func (t *Transition) FindbyAppointment(ctx context.Context, id int) ([]models.Transition, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return sorted(t.data, func(val models.Transition) bool {
+		return val.Appointmentid == id
+	}), nil
+}
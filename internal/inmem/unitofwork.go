@@ -0,0 +1,74 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/patienttracker/internal/models"
+)
+
+// UnitOfWork runs one unit of work at a time and puts back the data of every store when it fails.
+// Unlike a postgres transaction it doesn't isolate anything,a write made outside the unit of work
+// while it runs is undone with it. That's good enough for demos & tests which the memory driver is for.
+type UnitOfWork struct {
+	mu    sync.Mutex
+	store Memstore
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(models.Repositories) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	s := u.store
+	undo := []func(){
+		snapshot(&s.PatientMemStore.mu, s.PatientMemStore.data),
+		snapshot(&s.RecordMemStore.mu, s.RecordMemStore.data),
+		snapshot(&s.DoctorMemStore.mu, s.DoctorMemStore.data),
+		snapshot(&s.NurseMemStore.mu, s.NurseMemStore.data),
+		snapshot(&s.DepartmentMemStore.mu, s.DepartmentMemStore.data),
+		snapshot(&s.AppointmentMemStore.mu, s.AppointmentMemStore.data),
+		snapshot(&s.SeriesMemStore.mu, s.SeriesMemStore.data),
+		snapshot(&s.ScheduleMemStore.mu, s.ScheduleMemStore.data),
+		snapshot(&s.ExceptionMemStore.mu, s.ExceptionMemStore.data),
+		snapshot(&s.TransitionMemStore.mu, s.TransitionMemStore.data),
+		snapshot(&s.ReminderMemStore.mu, s.ReminderMemStore.data),
+		snapshot(&s.WaitlistMemStore.mu, s.WaitlistMemStore.data),
+		snapshot(&s.TicketMemStore.mu, s.TicketMemStore.data),
+		snapshot(&s.RolesMemStore.mu, s.RolesMemStore.data),
+		snapshot(&s.UsersMemStore.mu, s.UsersMemStore.data),
+		snapshot(&s.PermissionsMemStore.mu, s.PermissionsMemStore.data),
+		snapshot(&s.SessionMemStore.mu, s.SessionMemStore.data),
+		snapshot(&s.CalendarMemStore.mu, s.CalendarMemStore.data),
+		snapshot(&s.OutboxMemStore.mu, s.OutboxMemStore.data),
+	}
+	if err := fn(s.Repositories()); err != nil {
+		for _, restore := range undo {
+			restore()
+		}
+		return err
+	}
+	return nil
+}
+
+// snapshot copies data and returns the func putting the copy back,
+// the ids handed out meanwhile aren't reused just like a rolled back postgres sequence.
+func snapshot[K comparable, V any](mu *sync.RWMutex, data map[K]V) func() {
+	mu.RLock()
+	saved := make(map[K]V, len(data))
+	for key, val := range data {
+		saved[key] = val
+	}
+	mu.RUnlock()
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for key := range data {
+			delete(data, key)
+		}
+		for key, val := range saved {
+			data[key] = val
+		}
+	}
+}
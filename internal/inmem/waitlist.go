@@ -0,0 +1,97 @@
+package inmem
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+type Waitlist struct {
+	mu     sync.RWMutex
+	lastid int
+	data   map[int]models.Waitlist
+}
+
+// patientday is the UNIQUE (patientid,doctorid,day) constraint of the waitlist table
+func patientday(w models.Waitlist) string {
+	return fmt.Sprint(w.Patientid, w.Doctorid, w.Day.Format("2006-01-02"))
+}
+
+func (w *Waitlist) Create(ctx context.Context, waitlist models.Waitlist) (models.Waitlist, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := unique(w.data, 0, waitlist, patientday); err != nil {
+		return models.Waitlist{}, err
+	}
+	w.lastid++
+	waitlist.Waitlistid = w.lastid
+	waitlist.CreatedAt = time.Now()
+	waitlist.Offer = models.Offer{}
+	w.data[waitlist.Waitlistid] = waitlist
+	return w.data[waitlist.Waitlistid], nil
+}
+
+func (w *Waitlist) Find(ctx context.Context, id int) (models.Waitlist, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if val, ok := w.data[id]; ok {
+		return val, nil
+	}
+	return models.Waitlist{}, sql.ErrNoRows
+}
+
+func (w *Waitlist) FindbyOffer(ctx context.Context, token string) (models.Waitlist, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, val := range w.data {
+		if token != "" && val.Offer.Token == token {
+			return val, nil
+		}
+	}
+	return models.Waitlist{}, sql.ErrNoRows
+}
+
+func (w *Waitlist) FindbyDoctor(ctx context.Context, id int, day time.Time) ([]models.Waitlist, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return sorted(w.data, func(val models.Waitlist) bool {
+		return val.Doctorid == id && val.Day.Equal(day)
+	}), nil
+}
+
+func (w *Waitlist) FindbyPatient(ctx context.Context, id int) ([]models.Waitlist, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	items := sorted(w.data, func(val models.Waitlist) bool {
+		return val.Patientid == id
+	})
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Day.Before(items[j].Day)
+	})
+	return items, nil
+}
+
+func (w *Waitlist) Update(ctx context.Context, waitlist models.Waitlist) (models.Waitlist, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	old, ok := w.data[waitlist.Waitlistid]
+	if !ok {
+		return models.Waitlist{}, sql.ErrNoRows
+	}
+	// like the postgres controller only the offer changes
+	old.Offer = waitlist.Offer
+	w.data[old.Waitlistid] = old
+	return old, nil
+}
+
+func (w *Waitlist) Delete(ctx context.Context, id int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.data, id)
+	return nil
+}
@@ -2,6 +2,8 @@ package mailer
 
 import (
 	"bytes"
+	"io"
+
 	templates "github.com/patienttracker/template"
 	"gopkg.in/gomail.v2"
 )
@@ -12,13 +14,20 @@ type Mailer struct {
 	tmp    *templates.Template
 }
 
+// Attachment is a file sent along with an email e.g the .ics of an appointment
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
 func NewMailer(port int, sender, host, username, password string) Mailer {
 	dialer := gomail.NewDialer(host, port, username, password)
 	tmp := templates.New()
 	return Mailer{sender: sender, dial: dialer, tmp: tmp}
 }
 
-func (mail *Mailer) Send(recipient string, subject, template string, data interface{}) error {
+func (mail *Mailer) Send(recipient string, subject, template string, data interface{}, attachments ...Attachment) error {
 	htmlBody := new(bytes.Buffer)
 	err := mail.tmp.Render(htmlBody, template, data)
 	if err != nil {
@@ -29,6 +38,15 @@ func (mail *Mailer) Send(recipient string, subject, template string, data interf
 	m.SetHeader("To", recipient)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/html", htmlBody.String())
+	for _, attachment := range attachments {
+		content := attachment.Data
+		m.Attach(attachment.Name,
+			gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(content)
+				return err
+			}))
+	}
 	if err := mail.dial.DialAndSend(m); err != nil {
 		return err
 	}
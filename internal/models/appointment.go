@@ -1,26 +1,133 @@
 package models
 
 //Appointment table model
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type (
+	// Appointment runs from Appointmentdate for Duration,it's stored as a start/end range
 	Appointment struct {
 		Appointmentid   int
 		Doctorid        int
 		Patientid       int
 		Appointmentdate time.Time
-		Duration        string
-		Approval        bool
+		Duration        time.Duration
+		Status          Status
 		Outbound        bool
+		// Seriesid is the series the appointment was booked in,zero when it was booked on its own
+		Seriesid int
+	}
+
+	// Status is where the appointment is in its lifecycle,see CanMoveTo for the moves allowed
+	Status string
+
+	// Actor is the account moving an appointment along,its type is one of the account types of a Session
+	Actor struct {
+		AccountType string
+		AccountId   int
+	}
+
+	// Transition records a move of an appointment from one status to another & who made it
+	Transition struct {
+		Transitionid  int
+		Appointmentid int
+		From          Status
+		To            Status
+		Actor
+		Reason    string
+		CreatedAt time.Time
 	}
+
 	//AppointmentRepository represent the Appointment repository contract
 	AppointmentRepository interface {
-		Create(appointment Appointment) (Appointment, error)
-		Find(id int) (Appointment, error)
-		FindAll(Filters) ([]Appointment, *Metadata, error)
-		Delete(id int) error
-		Update(update Appointment) (Appointment, error)
-		FindAllByDoctor(id int) ([]Appointment, error)
-		FindAllByPatient(id int) ([]Appointment, error)
+		Create(ctx context.Context, appointment Appointment) (Appointment, error)
+		Find(ctx context.Context, id int) (Appointment, error)
+		FindAll(context.Context, Filters) ([]Appointment, *Metadata, error)
+		Delete(ctx context.Context, id int) error
+		Update(ctx context.Context, update Appointment) (Appointment, error)
+		FindAllByDoctor(ctx context.Context, id int) ([]Appointment, error)
+		FindAllByPatient(ctx context.Context, id int) ([]Appointment, error)
+		// FindAllBySeries lists the occurrences of the series by their date
+		FindAllBySeries(ctx context.Context, id int) ([]Appointment, error)
+		// Lock holds the booking locks of the doctor & the patient until the unit of work it runs in ends,
+		// a booking checks for clashes before inserting so two bookings must not interleave.
+		Lock(ctx context.Context, doctorid, patientid int) error
+	}
+
+	// TransitionRepository represent the Transition repository contract,the history is only ever appended to
+	TransitionRepository interface {
+		Create(ctx context.Context, transition Transition) (Transition, error)
+		FindbyAppointment(ctx context.Context, id int) ([]Transition, error)
 	}
 )
+
+// the statuses of an appointment
+const (
+	StatusRequested   Status = "requested"
+	StatusApproved    Status = "approved"
+	StatusRescheduled Status = "rescheduled"
+	StatusCancelled   Status = "cancelled"
+	StatusCompleted   Status = "completed"
+	StatusNoShow      Status = "no_show"
+)
+
+// Statuses lists every status in lifecycle order
+var Statuses = []Status{StatusRequested, StatusApproved, StatusRescheduled, StatusCancelled, StatusCompleted, StatusNoShow}
+
+// transitions are the statuses each status can move to,
+// cancelled,completed & no_show are final.
+var transitions = map[Status][]Status{
+	StatusRequested:   {StatusApproved, StatusCancelled},
+	StatusApproved:    {StatusRescheduled, StatusCancelled, StatusCompleted, StatusNoShow},
+	StatusRescheduled: {StatusApproved, StatusRescheduled, StatusCancelled, StatusCompleted, StatusNoShow},
+}
+
+// Valid reports whether s is one of the statuses
+func (s Status) Valid() bool {
+	for _, status := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanMoveTo reports whether an appointment can go from s to status
+func (s Status) CanMoveTo(status Status) bool {
+	for _, next := range transitions[s] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Final reports whether the appointment can't move on from s
+func (s Status) Final() bool {
+	return s.Valid() && len(transitions[s]) == 0
+}
+
+// Holds reports whether an appointment in status s keeps its time slot,
+// nothing else may be booked over an approved or rescheduled appointment.
+func (s Status) Holds() bool {
+	return s == StatusApproved || s == StatusRescheduled
+}
+
+// Approved reports whether the appointment holds its time slot,
+// a rescheduled appointment stays approved at its new time.
+func (a Appointment) Approved() bool {
+	return a.Status.Holds()
+}
+
+// End is the time the appointment is over
+func (a Appointment) End() time.Time {
+	return a.Appointmentdate.Add(a.Duration)
+}
+
+// Overlaps reports whether the two appointments share any time,
+// one ending exactly when the other starts doesn't count.
+func (a Appointment) Overlaps(other Appointment) bool {
+	return a.Appointmentdate.Before(other.End()) && other.Appointmentdate.Before(a.End())
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// CalendarFeed is the secret address an account's calendar app subscribes to,
+	// an account has one feed at a time and resetting it stops the old address from working.
+	CalendarFeed struct {
+		Token       string
+		AccountType string
+		AccountId   int
+		CreatedAt   time.Time
+	}
+
+	// CalendarFeedRepository represent the CalendarFeed repository contract
+	CalendarFeedRepository interface {
+		// Create errors with ErrDuplicate when the account already has a feed
+		Create(ctx context.Context, feed CalendarFeed) (CalendarFeed, error)
+		FindbyToken(ctx context.Context, token string) (CalendarFeed, error)
+		FindbyAccount(ctx context.Context, accounttype string, id int) (CalendarFeed, error)
+		DeleteByAccount(ctx context.Context, accounttype string, id int) error
+	}
+)
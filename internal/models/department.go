@@ -1,17 +1,37 @@
 package models
 
+import (
+	"context"
+	"time"
+)
+
 type (
 	Department struct {
 		Departmentid   int
 		Departmentname string
+		// Durations the appointments with the department's doctors may last,any duration when empty
+		Durations []time.Duration
 	}
 
 	Departmentrepository interface {
-		Create(Department) (Department, error)
-		Find(id int) (Department, error)
-		FindbyName(name string) (Department, error)
-		FindAll(Filters) ([]Department, *Metadata, error)
-		Delete(id int) error
-		Update(Department) (Department, error)
+		Create(context.Context, Department) (Department, error)
+		Find(ctx context.Context, id int) (Department, error)
+		FindbyName(ctx context.Context, name string) (Department, error)
+		FindAll(context.Context, Filters) ([]Department, *Metadata, error)
+		Delete(ctx context.Context, id int) error
+		Update(context.Context, Department) (Department, error)
 	}
 )
+
+// Allows reports whether an appointment lasting duration can be booked with the department
+func (d Department) Allows(duration time.Duration) bool {
+	if len(d.Durations) == 0 {
+		return true
+	}
+	for _, allowed := range d.Durations {
+		if allowed == duration {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+package models
+
+import "errors"
+
+// Every repository reports a missing record with sql.ErrNoRows,whatever the backend,
+// and a record clashing with a unique column (email,username,contact...) with ErrDuplicate.
+var ErrDuplicate = errors.New("record already exists")
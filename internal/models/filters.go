@@ -8,11 +8,11 @@ type (
 		Page     int
 	}
 	Metadata struct {
-		CurrentPage  int
-		PageSize     int
-		FirstPage    int
-		LastPage     int
-		TotalRecords int
+		CurrentPage  int `json:"current_page,omitempty"`
+		PageSize     int `json:"page_size,omitempty"`
+		FirstPage    int `json:"first_page,omitempty"`
+		LastPage     int `json:"last_page,omitempty"`
+		TotalRecords int `json:"total_records,omitempty"`
 	}
 )
 
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 //physician model
 
@@ -18,12 +21,12 @@ type (
 
 	//Physicianrepository represent the Physician repository contract
 	Nurserepository interface {
-		Create(Nurse) (Nurse, error)
-		Find(id int) (Nurse, error)
-		FindbyEmail(email string) (Nurse, error)
-		FindAll(Filters) ([]Nurse, *Metadata, error)
-		Filter(string, Filters) ([]*Nurse, *Metadata, error)
-		Delete(id int) error
-		Update(Nurse) (Nurse, error)
+		Create(context.Context, Nurse) (Nurse, error)
+		Find(ctx context.Context, id int) (Nurse, error)
+		FindbyEmail(ctx context.Context, email string) (Nurse, error)
+		FindAll(context.Context, Filters) ([]Nurse, *Metadata, error)
+		Filter(context.Context, string, Filters) ([]*Nurse, *Metadata, error)
+		Delete(ctx context.Context, id int) error
+		Update(context.Context, Nurse) (Nurse, error)
 	}
 )
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// OutboxEvent is an event waiting to be handed to one of its subscribers,it's written in the same
+	// transaction as the change it tells about so the change & its side effects happen together or not at all.
+	OutboxEvent struct {
+		Eventid    int
+		Subscriber string
+		Kind       string
+		// Payload is the event as json
+		Payload   []byte
+		CreatedAt time.Time
+		// Attempts counts the times the event was handed to the subscriber
+		Attempts int
+		// NextAttempt is when the event is due,it's zero once the event was given up on
+		NextAttempt time.Time
+		// Dispatched is zero until the subscriber handled the event
+		Dispatched time.Time
+		// LastError is why the last attempt failed
+		LastError string
+	}
+
+	// OutboxRepository represent the OutboxEvent repository contract
+	OutboxRepository interface {
+		// Create makes the event due at its NextAttempt,at once when it's zero
+		Create(ctx context.Context, event OutboxEvent) (OutboxEvent, error)
+		Find(ctx context.Context, id int) (OutboxEvent, error)
+		// Claim takes up to limit events due by now that weren't dispatched,the oldest first. Each claim
+		// counts an attempt & the event isn't due again before until,a server dying while handling it
+		// leaves it to the next claim.
+		Claim(ctx context.Context, now, until time.Time, limit int) ([]OutboxEvent, error)
+		// MarkDispatched errors with sql.ErrNoRows when the event is missing or was already dispatched
+		MarkDispatched(ctx context.Context, id int, at time.Time) error
+		// Retry records why the attempt failed & when the event is due again,a zero next gives up on it
+		Retry(ctx context.Context, id int, next time.Time, reason string) error
+	}
+)
+
+// Pending reports whether the event is still to be handed to its subscriber
+func (e OutboxEvent) Pending() bool {
+	return e.Dispatched.IsZero() && !e.NextAttempt.IsZero()
+}
@@ -1,7 +1,10 @@
 package models
 
 // Patient model struct
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type (
 	Patient struct {
@@ -24,11 +27,11 @@ type (
 
 // PatientRepository represent the Patient repository contract
 type PatientRepository interface {
-	Create(patient Patient) (Patient, error)
-	Find(id int) (Patient, error)
-	FindbyEmail(email string) (Patient, error)
-	Filter(string, Filters) ([]*Patient, *Metadata, error)
-	FindAll(Filters) ([]Patient, *Metadata, error)
-	Delete(id int) error
-	Update(patient Patient) (Patient, error)
+	Create(ctx context.Context, patient Patient) (Patient, error)
+	Find(ctx context.Context, id int) (Patient, error)
+	FindbyEmail(ctx context.Context, email string) (Patient, error)
+	Filter(context.Context, string, Filters) ([]*Patient, *Metadata, error)
+	FindAll(context.Context, Filters) ([]Patient, *Metadata, error)
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, patient Patient) (Patient, error)
 }
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // patient record model
 type (
@@ -22,12 +25,12 @@ type (
 
 // Patientrecordsrepository represent the Patientrecords repository contract
 type Patientrecordsrepository interface {
-	Create(patientrecords Patientrecords) (Patientrecords, error)
-	Find(id int) (Patientrecords, error)
-	FindAll(Filters) ([]Patientrecords, *Metadata, error)
-	FindAllByDoctor(id int) ([]Patientrecords, error)
-	FindAllByPatient(id int) ([]Patientrecords, error)
-	FindAllByNurse(id int) ([]Patientrecords, error)
-	Delete(id int) error
-	Update(record Patientrecords) (Patientrecords, error)
+	Create(ctx context.Context, patientrecords Patientrecords) (Patientrecords, error)
+	Find(ctx context.Context, id int) (Patientrecords, error)
+	FindAll(context.Context, Filters) ([]Patientrecords, *Metadata, error)
+	FindAllByDoctor(ctx context.Context, id int) ([]Patientrecords, error)
+	FindAllByPatient(ctx context.Context, id int) ([]Patientrecords, error)
+	FindAllByNurse(ctx context.Context, id int) ([]Patientrecords, error)
+	Delete(ctx context.Context, id int) error
+	Update(ctx context.Context, record Patientrecords) (Patientrecords, error)
 }
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 //physician model
 
@@ -30,13 +33,13 @@ type (
 
 	//Physicianrepository represent the Physician repository contract
 	Physicianrepository interface {
-		Create(physician Physician) (Physician, error)
-		Find(id int) (Physician, error)
-		FindbyEmail(email string) (Physician, error)
-		FindAll(Filters) ([]Physician, *Metadata, error)
-		Filter(string, string, Filters) ([]*Physician, *Metadata, error)
-		FindDoctorsbyDept(string, Filters) ([]Physician, *Metadata, error)
-		Delete(id int) error
-		Update(physician Physician) (Physician, error)
+		Create(ctx context.Context, physician Physician) (Physician, error)
+		Find(ctx context.Context, id int) (Physician, error)
+		FindbyEmail(ctx context.Context, email string) (Physician, error)
+		FindAll(context.Context, Filters) ([]Physician, *Metadata, error)
+		Filter(context.Context, string, string, Filters) ([]*Physician, *Metadata, error)
+		FindDoctorsbyDept(context.Context, string, Filters) ([]Physician, *Metadata, error)
+		Delete(ctx context.Context, id int) error
+		Update(ctx context.Context, physician Physician) (Physician, error)
 	}
 )
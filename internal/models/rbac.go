@@ -1,5 +1,7 @@
 package models
 
+import "context"
+
 type (
 	Roles struct {
 		Roleid int
@@ -19,29 +21,29 @@ type (
 )
 
 type RolesRepository interface {
-	Create(Roles) (Roles, error)
-	Find(int) (Roles, error)
-	FindbyRole(string) (Roles, error)
-	FindAll() ([]Roles, error)
-	Delete(int) error
-	Update(Roles) (Roles, error)
+	Create(context.Context, Roles) (Roles, error)
+	Find(context.Context, int) (Roles, error)
+	FindbyRole(context.Context, string) (Roles, error)
+	FindAll(ctx context.Context) ([]Roles, error)
+	Delete(context.Context, int) error
+	Update(context.Context, Roles) (Roles, error)
 }
 
 type PermissionsRepository interface {
-	Create(Permissions) (Permissions, error)
-	Find(int) (Permissions, error)
-	FindAll() ([]Permissions, error)
-	FindbyRoleId(int) ([]Permissions, error)
-	Delete(int) error
-	Update(Permissions) (Permissions, error)
+	Create(context.Context, Permissions) (Permissions, error)
+	Find(context.Context, int) (Permissions, error)
+	FindAll(ctx context.Context) ([]Permissions, error)
+	FindbyRoleId(context.Context, int) ([]Permissions, error)
+	Delete(context.Context, int) error
+	Update(context.Context, Permissions) (Permissions, error)
 }
 
 type UsersRepository interface {
-	Create(Users) (Users, error)
-	Find(int) (Users, error)
-	FindAll() ([]Users, error)
-	FindbyEmail(string) (Users, error)
-	FindbyRoleId(int) ([]Users, error)
-	Delete(int) error
-	Update(Users) (Users, error)
+	Create(context.Context, Users) (Users, error)
+	Find(context.Context, int) (Users, error)
+	FindAll(ctx context.Context) ([]Users, error)
+	FindbyEmail(context.Context, string) (Users, error)
+	FindbyRoleId(context.Context, int) ([]Users, error)
+	Delete(context.Context, int) error
+	Update(context.Context, Users) (Users, error)
 }
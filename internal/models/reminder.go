@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Reminder is the email reminding of an appointment Offset before it starts,
+	// it's sent once and a reminder that was sent stays as the record of it.
+	Reminder struct {
+		Reminderid    int
+		Appointmentid int
+		Offset        time.Duration
+		// Due is when the reminder is sent,the start of the appointment less the offset
+		Due time.Time
+		// Sent is zero until the reminder is sent
+		Sent      time.Time
+		CreatedAt time.Time
+	}
+
+	// ReminderRepository represent the Reminder repository contract
+	ReminderRepository interface {
+		// Create errors with ErrDuplicate when the appointment already has the reminder due at the same time
+		Create(ctx context.Context, reminder Reminder) (Reminder, error)
+		FindbyAppointment(ctx context.Context, id int) ([]Reminder, error)
+		// FindDue lists up to limit reminders due by now that weren't sent,the oldest first
+		FindDue(ctx context.Context, now time.Time, limit int) ([]Reminder, error)
+		// MarkSent errors with sql.ErrNoRows when the reminder is missing or was already sent,
+		// whoever marks it first is the one sending it.
+		MarkSent(ctx context.Context, id int, at time.Time) error
+		// DeletePending drops the reminders of the appointment that weren't sent
+		DeletePending(ctx context.Context, id int) error
+	}
+)
+
+// Pending reports whether the reminder hasn't been sent
+func (r Reminder) Pending() bool {
+	return r.Sent.IsZero()
+}
@@ -1,23 +1,196 @@
 package models
 
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ClockLayout is the format of the working hours,they're kept to the minute
+const ClockLayout = "15:04"
+
 // Schedule model
 type (
 	//Schedule struct hold the column type found in our Schedule table
 	Schedule struct {
 		Scheduleid int
 		Doctorid   int
-		Starttime  string
-		Endtime    string
-		Active     bool
+		// Starttime & Endtime are the daily working hours,they apply to every day when there are no Blocks
+		Starttime string
+		Endtime   string
+		Active    bool
+		// Validfrom & Validuntil are the first & last days the schedule applies to,a zero time leaves that side open
+		Validfrom  time.Time
+		Validuntil time.Time
+		// Blocks are the working hours of each weekday,a day may have several e.g around a lunch break
+		Blocks []Block
+	}
+
+	// Block is a stretch of working hours on a weekday
+	Block struct {
+		Weekday   time.Weekday
+		Starttime string
+		Endtime   string
+	}
+
+	// ScheduleException is time the doctor is away e.g a holiday or leave,nothing can be booked during it
+	ScheduleException struct {
+		Exceptionid int
+		Doctorid    int
+		Starttime   time.Time
+		Endtime     time.Time
+		Reason      string
+	}
+
+	// Slot is a free stretch of time an appointment with the doctor can be booked in
+	Slot struct {
+		Doctorid  int
+		Starttime time.Time
+		Endtime   time.Time
 	}
 
 	//UpdateSchedule repository that holds the schedule model methods
 	Schedulerepositroy interface {
-		Create(schedule Schedule) (Schedule, error)
-		Find(id int) (Schedule, error)
-		FindAll(Filters) ([]Schedule, *Metadata, error)
-		FindbyDoctor(id int) ([]Schedule, error)
-		Delete(id int) error
-		Update(schedule Schedule) (Schedule, error)
+		Create(ctx context.Context, schedule Schedule) (Schedule, error)
+		Find(ctx context.Context, id int) (Schedule, error)
+		FindAll(context.Context, Filters) ([]Schedule, *Metadata, error)
+		FindbyDoctor(ctx context.Context, id int) ([]Schedule, error)
+		Delete(ctx context.Context, id int) error
+		Update(ctx context.Context, schedule Schedule) (Schedule, error)
+	}
+
+	// ScheduleExceptionRepository represent the ScheduleException repository contract
+	ScheduleExceptionRepository interface {
+		Create(ctx context.Context, exception ScheduleException) (ScheduleException, error)
+		Find(ctx context.Context, id int) (ScheduleException, error)
+		FindbyDoctor(ctx context.Context, id int) ([]ScheduleException, error)
+		Delete(ctx context.Context, id int) error
 	}
 )
+
+// ParseClock returns how long after midnight a time such as 14:30 is
+func ParseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse(ClockLayout, clock)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// date drops the clock of t keeping its calendar day
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ValidOn reports whether the schedule applies on the day of t
+func (s Schedule) ValidOn(t time.Time) bool {
+	day := date(t)
+	if !s.Validfrom.IsZero() && day.Before(date(s.Validfrom)) {
+		return false
+	}
+	if !s.Validuntil.IsZero() && day.After(date(s.Validuntil)) {
+		return false
+	}
+	return true
+}
+
+// ValidityOverlaps reports whether there's a day both schedules apply to
+func (s Schedule) ValidityOverlaps(other Schedule) bool {
+	startsbefore := func(a, b Schedule) bool {
+		// a starts before b ends
+		return a.Validfrom.IsZero() || b.Validuntil.IsZero() || !date(a.Validfrom).After(date(b.Validuntil))
+	}
+	return startsbefore(s, other) && startsbefore(other, s)
+}
+
+// BlocksOn returns the working hours of weekday sorted by their start,
+// the daily hours when the schedule has no blocks at all.
+func (s Schedule) BlocksOn(weekday time.Weekday) []Block {
+	if len(s.Blocks) == 0 {
+		return []Block{{Weekday: weekday, Starttime: s.Starttime, Endtime: s.Endtime}}
+	}
+	blocks := make([]Block, 0)
+	for _, block := range s.Blocks {
+		if block.Weekday == weekday {
+			blocks = append(blocks, block)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		start, _ := ParseClock(blocks[i].Starttime)
+		other, _ := ParseClock(blocks[j].Starttime)
+		return start < other
+	})
+	return blocks
+}
+
+// Covers reports whether the doctor works from start to end according to the schedule,
+// back to back blocks count as one so an appointment may run from one into the next.
+// The working hours are wall clock times in the location of start,so a block keeps
+// its hours across a daylight saving change even though it lasts longer or shorter that day.
+func (s Schedule) Covers(start, end time.Time) bool {
+	if !s.Active || !s.ValidOn(start) || end.Before(start) {
+		return false
+	}
+	for _, hours := range s.hoursOn(start) {
+		if !start.Before(hours[0]) && !end.After(hours[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// SlotsOn splits the working hours on the day of day into back to back slots lasting duration,
+// what's left at the end of the hours too short for a slot is left out.
+// The slots are in the location of day like the hours Covers reads.
+func (s Schedule) SlotsOn(day time.Time, duration time.Duration) []Slot {
+	if duration <= 0 || !s.Active || !s.ValidOn(day) {
+		return nil
+	}
+	var slots []Slot
+	for _, hours := range s.hoursOn(day) {
+		for start := hours[0]; !start.Add(duration).After(hours[1]); start = start.Add(duration) {
+			slots = append(slots, Slot{
+				Doctorid:  s.Doctorid,
+				Starttime: start,
+				Endtime:   start.Add(duration),
+			})
+		}
+	}
+	return slots
+}
+
+// hoursOn merges the blocks on the day of day that touch or overlap into the
+// times they start & end,blocks that don't parse are left out.
+func (s Schedule) hoursOn(day time.Time) [][2]time.Time {
+	var hours [][2]time.Time
+	for _, block := range s.BlocksOn(day.Weekday()) {
+		from, err := ParseClock(block.Starttime)
+		if err != nil {
+			continue
+		}
+		to, err := ParseClock(block.Endtime)
+		if err != nil || to <= from {
+			continue
+		}
+		start, end := clockOn(day, from), clockOn(day, to)
+		if last := len(hours) - 1; last >= 0 && !start.After(hours[last][1]) {
+			if end.After(hours[last][1]) {
+				hours[last][1] = end
+			}
+			continue
+		}
+		hours = append(hours, [2]time.Time{start, end})
+	}
+	return hours
+}
+
+// clockOn returns the wall clock time clock on the day of day,on a day the clocks
+// change that isn't the same as midnight + clock
+func clockOn(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(clock/time.Minute), 0, 0, day.Location())
+}
+
+// Overlaps reports whether the doctor is away at any time between start and end
+func (e ScheduleException) Overlaps(start, end time.Time) bool {
+	return start.Before(e.Endtime) && e.Starttime.Before(end)
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// MaxOccurrences is the most appointments a series books,a recurrence ending on a day far off stops there
+const MaxOccurrences = 52
+
+type (
+	// Series is a run of appointments booked together e.g the follow ups of a treatment,
+	// its appointments point back at it through their Seriesid
+	Series struct {
+		Seriesid  int
+		Doctorid  int
+		Patientid int
+		// Start is the first occurrence,the others are at the same wall clock time of the clinic
+		Start    time.Time
+		Duration time.Duration
+		Recurrence
+		CreatedAt time.Time
+	}
+
+	// Recurrence repeats an appointment every Interval days or weeks,
+	// either Count times or up to and including the day of Until
+	Recurrence struct {
+		Interval int
+		Unit     Unit
+		Count    int
+		Until    time.Time
+	}
+
+	// Unit is what the interval of a recurrence counts
+	Unit string
+
+	// SeriesRepository represent the Series repository contract,the occurrences are found through the appointments
+	SeriesRepository interface {
+		Create(ctx context.Context, series Series) (Series, error)
+		Find(ctx context.Context, id int) (Series, error)
+		// Update writes the start & the duration of the series,the recurrence is set once
+		Update(ctx context.Context, series Series) (Series, error)
+	}
+)
+
+const (
+	UnitDay  Unit = "day"
+	UnitWeek Unit = "week"
+)
+
+// Valid reports whether the recurrence repeats forwards and ends either after a count or on a day
+func (r Recurrence) Valid() bool {
+	if r.Interval < 1 || (r.Unit != UnitDay && r.Unit != UnitWeek) {
+		return false
+	}
+	if r.Count > 0 {
+		return r.Count <= MaxOccurrences && r.Until.IsZero()
+	}
+	return !r.Until.IsZero()
+}
+
+// ValidFrom is Valid for a series starting at start,the day of Until mustn't be before the day of start
+func (r Recurrence) ValidFrom(start time.Time) bool {
+	return r.Valid() && len(r.Dates(start)) > 0
+}
+
+// Dates returns when the occurrences starting at start are,
+// they keep the wall clock of start in its location across daylight saving changes.
+func (r Recurrence) Dates(start time.Time) []time.Time {
+	step := r.Interval
+	if r.Unit == UnitWeek {
+		step *= 7
+	}
+	// the day of Until is taken as it's written,it's the same calendar day in any zone
+	// so it's compared with the day of each occurrence in the location of start
+	until := time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), 0, 0, 0, 0, start.Location())
+	var dates []time.Time
+	for i := 0; len(dates) < MaxOccurrences; i++ {
+		if r.Count > 0 && i == r.Count {
+			break
+		}
+		next := start.AddDate(0, 0, i*step)
+		day := time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, start.Location())
+		if !r.Until.IsZero() && day.After(until) {
+			break
+		}
+		dates = append(dates, next)
+	}
+	return dates
+}
+
+// Occurrences returns the appointments of the series,its start is read in loc
+func (s Series) Occurrences(loc *time.Location) []Appointment {
+	var occurrences []Appointment
+	for _, start := range s.Dates(s.Start.In(loc)) {
+		occurrences = append(occurrences, Appointment{
+			Doctorid:        s.Doctorid,
+			Patientid:       s.Patientid,
+			Appointmentdate: start,
+			Duration:        s.Duration,
+			Status:          StatusApproved,
+			Seriesid:        s.Seriesid,
+		})
+	}
+	return occurrences
+}
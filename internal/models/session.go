@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//session model
+
+type (
+	//Session is a server side login,api clients hold its refresh token while
+	//browsers keep its id in their cookie session.
+	Session struct {
+		Id             uuid.UUID
+		AccountType    string
+		AccountId      int
+		Username       string
+		Kind           string
+		RefreshTokenId uuid.NullUUID
+		UserAgent      string
+		ClientIp       string
+		Revoked        bool
+		ExpiresAt      time.Time
+		CreatedAt      time.Time
+	}
+
+	//Sessionrepository represent the Session repository contract
+	Sessionrepository interface {
+		Create(context.Context, Session) (Session, error)
+		Find(ctx context.Context, id uuid.UUID) (Session, error)
+		FindbyRefreshToken(ctx context.Context, id uuid.UUID) (Session, error)
+		FindAll(context.Context, Filters) ([]Session, *Metadata, error)
+		FindAllByAccount(ctx context.Context, accounttype string, id int) ([]Session, error)
+		// Rotate replaces the refresh token presented with refreshtokenid,sql.ErrNoRows means the session
+		// was revoked or its refresh token was rotated by someone else in the meantime
+		Rotate(ctx context.Context, id uuid.UUID, presented uuid.UUID, refreshtokenid uuid.UUID, expiresat time.Time) (Session, error)
+		Revoke(ctx context.Context, id uuid.UUID) error
+		RevokeAllByAccount(ctx context.Context, accounttype string, id int) error
+	}
+)
+
+// the kinds of sessions
+const (
+	SessionToken  = "token"
+	SessionCookie = "cookie"
+)
+
+// Active reports whether the session can still be used
+func (s Session) Active() bool {
+	return !s.Revoked && time.Now().Before(s.ExpiresAt)
+}
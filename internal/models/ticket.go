@@ -0,0 +1,152 @@
+package models
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Ticket is a patient waiting in a nurse's triage queue,the nurse takes the vitals
+	// and refers the patient to a doctor by recording them.
+	Ticket struct {
+		Ticketid  int
+		Patientid int
+		Nurseid   int
+		// Doctorid is the doctor the patient was referred to,zero until the nurse picks one
+		Doctorid int
+		Status   TicketStatus
+		Acuity   Acuity
+		// CreatedAt is when the ticket joined the queue,AttendedAt is zero until the nurse attends to it
+		CreatedAt  time.Time
+		AttendedAt time.Time
+	}
+
+	// TicketStatus is where the ticket is in the queue,see CanMoveTo for the moves allowed
+	TicketStatus string
+
+	// Acuity is how urgent the patient is from 1,the most urgent,to 5
+	Acuity int
+
+	// TicketRepository represent the Ticket repository contract,
+	// the queues of the nurses & doctors list the most urgent tickets first then the oldest.
+	TicketRepository interface {
+		Create(ctx context.Context, ticket Ticket) (Ticket, error)
+		Find(ctx context.Context, id int) (Ticket, error)
+		FindAll(ctx context.Context, filters Filters) ([]Ticket, *Metadata, error)
+		FindbyNurse(ctx context.Context, id int) ([]Ticket, error)
+		FindbyDoctor(ctx context.Context, id int) ([]Ticket, error)
+		// FindbyPatient lists the tickets of the patient oldest first
+		FindbyPatient(ctx context.Context, id int) ([]Ticket, error)
+		// Update writes the status,acuity,doctor & attended time of the ticket
+		Update(ctx context.Context, ticket Ticket) (Ticket, error)
+	}
+)
+
+// the statuses of a ticket
+const (
+	TicketOpen       TicketStatus = "open"
+	TicketInProgress TicketStatus = "in_progress"
+	TicketAttended   TicketStatus = "attended"
+	TicketClosed     TicketStatus = "closed"
+)
+
+// TicketStatuses lists every status of a ticket in queue order
+var TicketStatuses = []TicketStatus{TicketOpen, TicketInProgress, TicketAttended, TicketClosed}
+
+// tickettransitions are the statuses each status can move to,closed is final.
+// A ticket taken up by mistake goes back to the queue.
+var tickettransitions = map[TicketStatus][]TicketStatus{
+	TicketOpen:       {TicketInProgress, TicketAttended, TicketClosed},
+	TicketInProgress: {TicketOpen, TicketAttended, TicketClosed},
+	TicketAttended:   {TicketClosed},
+}
+
+// the acuities a ticket can have,a ticket opened without one is DefaultAcuity
+const (
+	MostUrgent    Acuity = 1
+	LeastUrgent   Acuity = 5
+	DefaultAcuity Acuity = 3
+)
+
+// Valid reports whether s is one of the statuses
+func (s TicketStatus) Valid() bool {
+	for _, status := range TicketStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CanMoveTo reports whether a ticket in status s may move to status
+func (s TicketStatus) CanMoveTo(status TicketStatus) bool {
+	for _, next := range tickettransitions[s] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Waiting reports whether the ticket is still in the queue
+func (s TicketStatus) Waiting() bool {
+	return s == TicketOpen || s == TicketInProgress
+}
+
+// Valid reports whether a is within the triage scale
+func (a Acuity) Valid() bool {
+	return a >= MostUrgent && a <= LeastUrgent
+}
+
+// VitalsAcuity is the acuity the vitals of the record call for,vitals in the danger zone of an adult
+// make the patient more urgent while normal ones are LeastUrgent and leave the acuity to the nurse.
+// Vitals that weren't taken are zero and don't count.
+func VitalsAcuity(record Patientrecords) Acuity {
+	acuity := LeastUrgent
+	raise := func(a Acuity) {
+		if a < acuity {
+			acuity = a
+		}
+	}
+	if rate := record.HeartRate; rate > 0 {
+		switch {
+		case rate >= 150 || rate < 40:
+			raise(MostUrgent)
+		case rate > 100 || rate < 50:
+			raise(2)
+		}
+	}
+	if temp := record.Temperature; temp > 0 {
+		switch {
+		case temp >= 41 || temp <= 32:
+			raise(MostUrgent)
+		case temp >= 39 || temp < 35:
+			raise(2)
+		case temp >= 38:
+			raise(3)
+		}
+	}
+	if systolic, ok := systolic(record.Bp); ok {
+		switch {
+		case systolic >= 220 || systolic < 80:
+			raise(MostUrgent)
+		case systolic >= 180 || systolic < 90:
+			raise(2)
+		case systolic >= 160:
+			raise(3)
+		}
+	}
+	return acuity
+}
+
+// systolic reads the systolic pressure of a systolic/diastolic blood pressure
+func systolic(bp string) (int, bool) {
+	value, _, ok := strings.Cut(bp, "/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	return n, err == nil && n > 0
+}
@@ -0,0 +1,34 @@
+package models
+
+import "context"
+
+type (
+	// Repositories groups one repository of each kind
+	Repositories struct {
+		Patients     PatientRepository
+		Doctors      Physicianrepository
+		Nurses       Nurserepository
+		Departments  Departmentrepository
+		Appointments AppointmentRepository
+		Series       SeriesRepository
+		Schedules    Schedulerepositroy
+		Exceptions   ScheduleExceptionRepository
+		Transitions  TransitionRepository
+		Reminders    ReminderRepository
+		Waitlists    WaitlistRepository
+		Records      Patientrecordsrepository
+		Tickets      TicketRepository
+		Roles        RolesRepository
+		Users        UsersRepository
+		Permissions  PermissionsRepository
+		Sessions     Sessionrepository
+		Calendars    CalendarFeedRepository
+		Outbox       OutboxRepository
+	}
+
+	// UnitOfWork runs fn with repositories bound to a single transaction,
+	// everything fn wrote is committed when it returns nil and rolled back otherwise.
+	UnitOfWork interface {
+		Do(ctx context.Context, fn func(Repositories) error) error
+	}
+)
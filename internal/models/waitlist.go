@@ -0,0 +1,62 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Waitlist is a patient waiting for a slot with the doctor to free up on Day
+	Waitlist struct {
+		Waitlistid int
+		Patientid  int
+		Doctorid   int
+		// Day is the calendar day in the clinic's zone,its clock is dropped like the validity of a schedule
+		Day       time.Time
+		Duration  time.Duration
+		CreatedAt time.Time
+		// Offer is the freed slot emailed to the patient,its token is empty when nothing was offered
+		Offer Offer
+	}
+
+	// Offer is a freed slot held for a patient on the waitlist until it expires
+	Offer struct {
+		Token     string
+		Starttime time.Time
+		Expires   time.Time
+	}
+
+	// WaitlistRepository represent the Waitlist repository contract,
+	// a patient waits for a doctor once per day
+	WaitlistRepository interface {
+		Create(ctx context.Context, waitlist Waitlist) (Waitlist, error)
+		Find(ctx context.Context, id int) (Waitlist, error)
+		FindbyOffer(ctx context.Context, token string) (Waitlist, error)
+		FindbyDoctor(ctx context.Context, id int, day time.Time) ([]Waitlist, error)
+		FindbyPatient(ctx context.Context, id int) ([]Waitlist, error)
+		Update(ctx context.Context, waitlist Waitlist) (Waitlist, error)
+		Delete(ctx context.Context, id int) error
+	}
+)
+
+// Day drops the clock of t in loc keeping its calendar day there
+func Day(t time.Time, loc *time.Location) time.Time {
+	return date(t.In(loc))
+}
+
+// Pending reports whether the offer is still held for the patient at now
+func (o Offer) Pending(now time.Time) bool {
+	return o.Token != "" && now.Before(o.Expires)
+}
+
+// Appointment is the appointment accepting the offer books,
+// the clinic offered the slot so it's approved straight away
+func (w Waitlist) Appointment() Appointment {
+	return Appointment{
+		Doctorid:        w.Doctorid,
+		Patientid:       w.Patientid,
+		Appointmentdate: w.Offer.Starttime,
+		Duration:        w.Duration,
+		Status:          StatusApproved,
+	}
+}
@@ -0,0 +1,236 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func patientid(p models.Patient) int      { return p.Patientid }
+func patientptrid(p *models.Patient) int  { return p.Patientid }
+func doctorid(d models.Physician) int     { return d.Physicianid }
+func doctorptrid(d *models.Physician) int { return d.Physicianid }
+func nurseid(n models.Nurse) int          { return n.Id }
+func nurseptrid(n *models.Nurse) int      { return n.Id }
+
+func Patients(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Patients
+	first := createPatient(t, r)
+	second := createPatient(t, r)
+	require.Greater(t, second.Patientid, first.Patientid)
+
+	found, err := repo.Find(ctx, first.Patientid)
+	require.NoError(t, err)
+	require.Equal(t, first.Username, found.Username)
+	require.Equal(t, first.Email, found.Email)
+	require.Equal(t, first.Contact, found.Contact)
+	require.False(t, found.Created_at.IsZero())
+	found, err = repo.FindbyEmail(ctx, second.Email)
+	require.NoError(t, err)
+	require.Equal(t, second.Patientid, found.Patientid)
+
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.FindbyEmail(ctx, utils.RandEmail(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Update(ctx, models.Patient{Patientid: missing, Username: utils.RandUsername(12)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, clash := range []func(p *models.Patient){
+		func(p *models.Patient) { p.Email = first.Email },
+		func(p *models.Patient) { p.Username = first.Username },
+		func(p *models.Patient) { p.Contact = first.Contact },
+	} {
+		duplicate := second
+		duplicate.Username, duplicate.Email, duplicate.Contact = utils.RandUsername(12), utils.RandEmail(12), utils.RandContact(12)
+		clash(&duplicate)
+		_, err = repo.Create(ctx, duplicate)
+		require.ErrorIs(t, err, models.ErrDuplicate)
+		duplicate.Patientid = second.Patientid
+		_, err = repo.Update(ctx, duplicate)
+		require.ErrorIs(t, err, models.ErrDuplicate)
+	}
+
+	second.Full_name = utils.Randfullname()
+	second.Verified = true
+	_, err = repo.Update(ctx, second)
+	require.NoError(t, err)
+	found, err = repo.Find(ctx, second.Patientid)
+	require.NoError(t, err)
+	require.Equal(t, second.Full_name, found.Full_name)
+	require.True(t, found.Verified)
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 1, 2, patientid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 1})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	// the username search is a case insensitive substring match
+	prefix := utils.RandString(10)
+	for i := 0; i < 3; i++ {
+		_, err := repo.Create(ctx, models.Patient{
+			Username:   prefix + utils.RandString(4),
+			Email:      utils.RandEmail(12),
+			Contact:    utils.RandContact(12),
+			Bloodgroup: "B+",
+		})
+		require.NoError(t, err)
+	}
+	filtered, metadata, err := repo.Filter(ctx, strings.ToUpper(prefix), models.Filters{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, filtered, 2)
+	require.Equal(t, 3, metadata.TotalRecords)
+	require.Equal(t, 2, metadata.LastPage)
+	checkAscending(t, filtered, patientptrid)
+	filtered, _, err = repo.Filter(ctx, prefix, models.Filters{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+
+	require.NoError(t, repo.Delete(ctx, first.Patientid))
+	_, err = repo.Find(ctx, first.Patientid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Delete(ctx, first.Patientid))
+}
+
+func Doctors(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Doctors
+	dept := createDepartment(t, r)
+	first := createDoctor(t, r, dept)
+	second := createDoctor(t, r, dept)
+	require.Greater(t, second.Physicianid, first.Physicianid)
+
+	found, err := repo.Find(ctx, first.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, first.Username, found.Username)
+	require.Equal(t, dept.Departmentname, found.Departmentname)
+	require.False(t, found.Created_at.IsZero())
+	found, err = repo.FindbyEmail(ctx, second.Email)
+	require.NoError(t, err)
+	require.Equal(t, second.Physicianid, found.Physicianid)
+
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.FindbyEmail(ctx, utils.RandEmail(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Update(ctx, models.Physician{Physicianid: missing, Departmentname: dept.Departmentname})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, clash := range []func(d *models.Physician){
+		func(d *models.Physician) { d.Email = first.Email },
+		func(d *models.Physician) { d.Username = first.Username },
+		func(d *models.Physician) { d.Contact = first.Contact },
+	} {
+		duplicate := second
+		duplicate.Username, duplicate.Email, duplicate.Contact = utils.RandUsername(12), utils.RandEmail(12), utils.RandContact(12)
+		clash(&duplicate)
+		_, err = repo.Create(ctx, duplicate)
+		require.ErrorIs(t, err, models.ErrDuplicate)
+		duplicate.Physicianid = second.Physicianid
+		_, err = repo.Update(ctx, duplicate)
+		require.ErrorIs(t, err, models.ErrDuplicate)
+	}
+
+	second.About = utils.RandString(20)
+	_, err = repo.Update(ctx, second)
+	require.NoError(t, err)
+	found, err = repo.Find(ctx, second.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, second.About, found.About)
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 1, 2, doctorid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 1})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	third := createDoctor(t, r, dept)
+	bydept, metadata, err := repo.FindDoctorsbyDept(ctx, dept.Departmentname, models.Filters{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int{first.Physicianid, second.Physicianid}, ids(bydept, doctorid))
+	require.Equal(t, models.Metadata{CurrentPage: 1, PageSize: 2, FirstPage: 1, LastPage: 2, TotalRecords: 3}, *metadata)
+	bydept, _, err = repo.FindDoctorsbyDept(ctx, dept.Departmentname, models.Filters{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int{third.Physicianid}, ids(bydept, doctorid))
+
+	filtered, metadata, err := repo.Filter(ctx, "", strings.ToUpper(dept.Departmentname), models.Filters{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, []int{first.Physicianid, second.Physicianid, third.Physicianid}, ids(filtered, doctorptrid))
+	require.Equal(t, 3, metadata.TotalRecords)
+	filtered, _, err = repo.Filter(ctx, third.Username, dept.Departmentname, models.Filters{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, []int{third.Physicianid}, ids(filtered, doctorptrid))
+
+	require.NoError(t, repo.Delete(ctx, first.Physicianid))
+	_, err = repo.Find(ctx, first.Physicianid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func Nurses(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Nurses
+	first := createNurse(t, r)
+	second := createNurse(t, r)
+	require.Greater(t, second.Id, first.Id)
+
+	found, err := repo.Find(ctx, first.Id)
+	require.NoError(t, err)
+	require.Equal(t, first.Username, found.Username)
+	require.False(t, found.Created_at.IsZero())
+	found, err = repo.FindbyEmail(ctx, second.Email)
+	require.NoError(t, err)
+	require.Equal(t, second.Id, found.Id)
+
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.FindbyEmail(ctx, utils.RandEmail(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Update(ctx, models.Nurse{Id: missing})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, clash := range []func(n *models.Nurse){
+		func(n *models.Nurse) { n.Email = first.Email },
+		func(n *models.Nurse) { n.Username = first.Username },
+	} {
+		duplicate := second
+		duplicate.Username, duplicate.Email = utils.RandUsername(12), utils.RandEmail(12)
+		clash(&duplicate)
+		_, err = repo.Create(ctx, duplicate)
+		require.ErrorIs(t, err, models.ErrDuplicate)
+		duplicate.Id = second.Id
+		_, err = repo.Update(ctx, duplicate)
+		require.ErrorIs(t, err, models.ErrDuplicate)
+	}
+
+	second.Full_name = utils.Randfullname()
+	_, err = repo.Update(ctx, second)
+	require.NoError(t, err)
+	found, err = repo.Find(ctx, second.Id)
+	require.NoError(t, err)
+	require.Equal(t, second.Full_name, found.Full_name)
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 1, 2, nurseid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 1})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	filtered, metadata, err := repo.Filter(ctx, strings.ToUpper(second.Username), models.Filters{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, []int{second.Id}, ids(filtered, nurseptrid))
+	require.Equal(t, 1, metadata.TotalRecords)
+
+	require.NoError(t, repo.Delete(ctx, first.Id))
+	_, err = repo.Find(ctx, first.Id)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
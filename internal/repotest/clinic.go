@@ -0,0 +1,685 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func departmentid(d models.Department) int       { return d.Departmentid }
+func scheduleid(s models.Schedule) int           { return s.Scheduleid }
+func appointmentid(a models.Appointment) int     { return a.Appointmentid }
+func recordid(r models.Patientrecords) int       { return r.Recordid }
+func exceptionid(e models.ScheduleException) int { return e.Exceptionid }
+func transitionid(t models.Transition) int       { return t.Transitionid }
+func waitlistid(w models.Waitlist) int           { return w.Waitlistid }
+func reminderid(r models.Reminder) int           { return r.Reminderid }
+func ticketid(t models.Ticket) int               { return t.Ticketid }
+
+// now is truncated to the second,the timestamp columns don't keep the monotonic clock
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func Departments(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Departments
+	first := createDepartment(t, r)
+	second := createDepartment(t, r)
+	require.Greater(t, second.Departmentid, first.Departmentid)
+
+	found, err := repo.Find(ctx, first.Departmentid)
+	require.NoError(t, err)
+	require.Equal(t, first, found)
+	found, err = repo.FindbyName(ctx, second.Departmentname)
+	require.NoError(t, err)
+	require.Equal(t, second, found)
+
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.FindbyName(ctx, utils.RandString(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Update(ctx, models.Department{Departmentid: missing, Departmentname: utils.RandString(12)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = repo.Create(ctx, models.Department{Departmentname: first.Departmentname})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	_, err = repo.Update(ctx, models.Department{Departmentid: second.Departmentid, Departmentname: first.Departmentname})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+
+	second.Departmentname = utils.RandString(12)
+	second.Durations = []time.Duration{30 * time.Minute, time.Hour}
+	updated, err := repo.Update(ctx, second)
+	require.NoError(t, err)
+	require.Equal(t, second, updated)
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 1, 2, departmentid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 1})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	require.NoError(t, repo.Delete(ctx, first.Departmentid))
+	_, err = repo.Find(ctx, first.Departmentid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Delete(ctx, first.Departmentid))
+}
+
+func Schedules(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Schedules
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	other := createDoctor(t, r, createDepartment(t, r))
+	var created []models.Schedule
+	for _, doctorid := range []int{doctor.Physicianid, other.Physicianid, doctor.Physicianid} {
+		schedule, err := repo.Create(ctx, models.Schedule{Doctorid: doctorid, Starttime: "08:00", Endtime: "17:00", Active: true})
+		require.NoError(t, err)
+		require.NotZero(t, schedule.Scheduleid)
+		created = append(created, schedule)
+	}
+
+	found, err := repo.Find(ctx, created[0].Scheduleid)
+	require.NoError(t, err)
+	require.Equal(t, created[0], found)
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Update(ctx, models.Schedule{Scheduleid: missing})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	bydoctor, err := repo.FindbyDoctor(ctx, doctor.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[0].Scheduleid, created[2].Scheduleid}, ids(bydoctor, scheduleid))
+
+	// the doctor a schedule belongs to can't be changed
+	update := created[0]
+	update.Doctorid = other.Physicianid
+	update.Starttime, update.Endtime, update.Active = "09:00", "13:00", false
+	updated, err := repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.Equal(t, doctor.Physicianid, updated.Doctorid)
+	require.Equal(t, "09:00", updated.Starttime)
+	require.Equal(t, "13:00", updated.Endtime)
+	require.False(t, updated.Active)
+
+	// the blocks are replaced on update,the validity is kept to the day
+	update = updated
+	update.Validfrom = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	update.Validuntil = time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC)
+	update.Blocks = []models.Block{
+		{Weekday: time.Monday, Starttime: "08:00", Endtime: "12:00"},
+		{Weekday: time.Monday, Starttime: "13:00", Endtime: "17:30"},
+		{Weekday: time.Friday, Starttime: "09:15", Endtime: "14:45"},
+	}
+	updated, err = repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.Equal(t, update, updated)
+	found, err = repo.Find(ctx, update.Scheduleid)
+	require.NoError(t, err)
+	require.Equal(t, update, found)
+	update.Blocks = update.Blocks[2:]
+	update.Validuntil = time.Time{}
+	_, err = repo.Update(ctx, update)
+	require.NoError(t, err)
+	found, err = repo.Find(ctx, update.Scheduleid)
+	require.NoError(t, err)
+	require.Equal(t, update, found)
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 2, 3, scheduleid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 2})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	require.NoError(t, repo.Delete(ctx, created[0].Scheduleid))
+	_, err = repo.Find(ctx, created[0].Scheduleid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Delete(ctx, created[0].Scheduleid))
+}
+
+func ScheduleExceptions(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Exceptions
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	other := createDoctor(t, r, createDepartment(t, r))
+	start := now().Add(72 * time.Hour)
+	// created out of order,the doctor's exceptions are listed by their start
+	var created []models.ScheduleException
+	for i, doctorid := range []int{doctor.Physicianid, other.Physicianid, doctor.Physicianid} {
+		exception, err := repo.Create(ctx, models.ScheduleException{
+			Doctorid:  doctorid,
+			Starttime: start.Add(time.Duration(2-i) * 24 * time.Hour),
+			Endtime:   start.Add(time.Duration(2-i)*24*time.Hour + 8*time.Hour),
+			Reason:    "leave",
+		})
+		require.NoError(t, err)
+		require.NotZero(t, exception.Exceptionid)
+		created = append(created, exception)
+	}
+	checkAscending(t, created, exceptionid)
+
+	found, err := repo.Find(ctx, created[0].Exceptionid)
+	require.NoError(t, err)
+	require.Equal(t, created[0], found)
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	bydoctor, err := repo.FindbyDoctor(ctx, doctor.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[2].Exceptionid, created[0].Exceptionid}, ids(bydoctor, exceptionid))
+	bydoctor, err = repo.FindbyDoctor(ctx, missing)
+	require.NoError(t, err)
+	require.Empty(t, bydoctor)
+
+	require.NoError(t, repo.Delete(ctx, created[0].Exceptionid))
+	_, err = repo.Find(ctx, created[0].Exceptionid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Delete(ctx, created[0].Exceptionid))
+}
+
+func Appointments(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Appointments
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	patient := createPatient(t, r)
+	other := createPatient(t, r)
+	date := now().Add(24 * time.Hour)
+	var created []models.Appointment
+	for i, patientid := range []int{patient.Patientid, other.Patientid, patient.Patientid} {
+		appointment, err := repo.Create(ctx, models.Appointment{
+			Doctorid:        doctor.Physicianid,
+			Patientid:       patientid,
+			Appointmentdate: date.Add(time.Duration(i) * time.Hour),
+			Duration:        time.Hour,
+			Status:          models.StatusRequested,
+		})
+		require.NoError(t, err)
+		require.NotZero(t, appointment.Appointmentid)
+		require.Equal(t, models.StatusRequested, appointment.Status)
+		created = append(created, appointment)
+	}
+
+	found, err := repo.Find(ctx, created[1].Appointmentid)
+	require.NoError(t, err)
+	require.Equal(t, other.Patientid, found.Patientid)
+	require.True(t, created[1].Appointmentdate.Equal(found.Appointmentdate))
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Update(ctx, models.Appointment{Appointmentid: missing, Appointmentdate: date, Duration: time.Hour})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	bydoctor, err := repo.FindAllByDoctor(ctx, doctor.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, ids(created, appointmentid), ids(bydoctor, appointmentid))
+	bypatient, err := repo.FindAllByPatient(ctx, patient.Patientid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[0].Appointmentid, created[2].Appointmentid}, ids(bypatient, appointmentid))
+	bypatient, err = repo.FindAllByPatient(ctx, missing)
+	require.NoError(t, err)
+	require.Empty(t, bypatient)
+
+	// the doctor and the patient of an appointment can't be changed
+	update := created[0]
+	update.Doctorid, update.Patientid = missing, other.Patientid
+	update.Appointmentdate = date.Add(48 * time.Hour)
+	update.Duration, update.Status, update.Outbound = 90*time.Minute, models.StatusApproved, true
+	updated, err := repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.Equal(t, doctor.Physicianid, updated.Doctorid)
+	require.Equal(t, patient.Patientid, updated.Patientid)
+	require.True(t, update.Appointmentdate.Equal(updated.Appointmentdate))
+	require.Equal(t, 90*time.Minute, updated.Duration)
+	require.Equal(t, models.StatusApproved, updated.Status)
+	require.True(t, updated.Outbound)
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 2, 3, appointmentid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 2})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	require.NoError(t, repo.Delete(ctx, created[0].Appointmentid))
+	_, err = repo.Find(ctx, created[0].Appointmentid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Delete(ctx, created[0].Appointmentid))
+}
+
+func Series(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Series
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	patient := createPatient(t, r)
+	start := now().Add(24 * time.Hour)
+	series, err := repo.Create(ctx, models.Series{
+		Doctorid:   doctor.Physicianid,
+		Patientid:  patient.Patientid,
+		Start:      start,
+		Duration:   30 * time.Minute,
+		Recurrence: models.Recurrence{Interval: 2, Unit: models.UnitWeek, Count: 3},
+	})
+	require.NoError(t, err)
+	require.NotZero(t, series.Seriesid)
+	require.True(t, start.Equal(series.Start))
+	require.Equal(t, models.Recurrence{Interval: 2, Unit: models.UnitWeek, Count: 3}, series.Recurrence)
+	require.False(t, series.CreatedAt.IsZero())
+	until := time.Date(2031, time.March, 1, 0, 0, 0, 0, time.UTC)
+	bydate, err := repo.Create(ctx, models.Series{
+		Doctorid:   doctor.Physicianid,
+		Patientid:  patient.Patientid,
+		Start:      start,
+		Duration:   time.Hour,
+		Recurrence: models.Recurrence{Interval: 10, Unit: models.UnitDay, Until: until},
+	})
+	require.NoError(t, err)
+	require.Zero(t, bydate.Count)
+	require.True(t, until.Equal(bydate.Until))
+
+	found, err := repo.Find(ctx, series.Seriesid)
+	require.NoError(t, err)
+	require.Equal(t, series.Doctorid, found.Doctorid)
+	require.Equal(t, series.Recurrence, found.Recurrence)
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// only the start and the duration of a series can be updated
+	update := series
+	update.Start, update.Duration = start.Add(2*time.Hour), time.Hour
+	update.Recurrence = models.Recurrence{Interval: 1, Unit: models.UnitDay, Count: 10}
+	updated, err := repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.True(t, update.Start.Equal(updated.Start))
+	require.Equal(t, time.Hour, updated.Duration)
+	require.Equal(t, series.Recurrence, updated.Recurrence)
+	_, err = repo.Update(ctx, models.Series{Seriesid: missing})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the occurrences are listed by their date,appointments booked on their own are left out
+	var occurrences []models.Appointment
+	for _, appointment := range []models.Appointment{
+		{Appointmentdate: start.Add(14 * 24 * time.Hour), Seriesid: series.Seriesid},
+		{Appointmentdate: start.Add(time.Hour)},
+		{Appointmentdate: start, Seriesid: series.Seriesid},
+	} {
+		appointment.Doctorid, appointment.Patientid = doctor.Physicianid, patient.Patientid
+		appointment.Duration, appointment.Status = 30*time.Minute, models.StatusApproved
+		created, err := r.Appointments.Create(ctx, appointment)
+		require.NoError(t, err)
+		require.Equal(t, appointment.Seriesid, created.Seriesid)
+		occurrences = append(occurrences, created)
+	}
+	byseries, err := r.Appointments.FindAllBySeries(ctx, series.Seriesid)
+	require.NoError(t, err)
+	require.Equal(t, []int{occurrences[2].Appointmentid, occurrences[0].Appointmentid}, ids(byseries, appointmentid))
+	byseries, err = r.Appointments.FindAllBySeries(ctx, missing)
+	require.NoError(t, err)
+	require.Empty(t, byseries)
+
+	// an occurrence stays in its series when it's moved
+	moved := occurrences[0]
+	moved.Seriesid = bydate.Seriesid
+	moved.Appointmentdate = moved.Appointmentdate.Add(24 * time.Hour)
+	moved, err = r.Appointments.Update(ctx, moved)
+	require.NoError(t, err)
+	require.Equal(t, series.Seriesid, moved.Seriesid)
+	stored, err := r.Appointments.Find(ctx, moved.Appointmentid)
+	require.NoError(t, err)
+	require.Equal(t, series.Seriesid, stored.Seriesid)
+}
+
+func Transitions(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Transitions
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	patient := createPatient(t, r)
+	var appointments []models.Appointment
+	for i := 0; i < 2; i++ {
+		appointment, err := r.Appointments.Create(ctx, models.Appointment{
+			Doctorid:        doctor.Physicianid,
+			Patientid:       patient.Patientid,
+			Appointmentdate: now().Add(time.Duration(24+i) * time.Hour),
+			Duration:        time.Hour,
+			Status:          models.StatusRequested,
+		})
+		require.NoError(t, err)
+		appointments = append(appointments, appointment)
+	}
+	physician := models.Actor{AccountType: "physician", AccountId: doctor.Physicianid}
+	moves := []models.Transition{
+		{Appointmentid: appointments[0].Appointmentid, From: models.StatusRequested, To: models.StatusApproved, Actor: physician},
+		{Appointmentid: appointments[1].Appointmentid, From: models.StatusRequested, To: models.StatusCancelled, Actor: models.Actor{AccountType: "patient", AccountId: patient.Patientid}, Reason: "travelling"},
+		{Appointmentid: appointments[0].Appointmentid, From: models.StatusApproved, To: models.StatusCompleted, Actor: physician},
+	}
+	var created []models.Transition
+	for _, move := range moves {
+		transition, err := repo.Create(ctx, move)
+		require.NoError(t, err)
+		require.NotZero(t, transition.Transitionid)
+		require.False(t, transition.CreatedAt.IsZero())
+		require.Equal(t, move.Reason, transition.Reason)
+		require.Equal(t, move.Actor, transition.Actor)
+		created = append(created, transition)
+	}
+	checkAscending(t, created, transitionid)
+
+	// the history of an appointment is listed oldest first
+	history, err := repo.FindbyAppointment(ctx, appointments[0].Appointmentid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[0].Transitionid, created[2].Transitionid}, ids(history, transitionid))
+	require.Equal(t, models.StatusApproved, history[0].To)
+	require.Equal(t, models.StatusCompleted, history[1].To)
+	history, err = repo.FindbyAppointment(ctx, missing)
+	require.NoError(t, err)
+	require.Empty(t, history)
+}
+
+func Reminders(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Reminders
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	patient := createPatient(t, r)
+	start := now().Add(48 * time.Hour)
+	appointment, err := r.Appointments.Create(ctx, models.Appointment{
+		Doctorid:        doctor.Physicianid,
+		Patientid:       patient.Patientid,
+		Appointmentdate: start,
+		Duration:        time.Hour,
+		Status:          models.StatusApproved,
+	})
+	require.NoError(t, err)
+	var created []models.Reminder
+	for _, offset := range []time.Duration{time.Hour, 24 * time.Hour, 72 * time.Hour} {
+		reminder, err := repo.Create(ctx, models.Reminder{Appointmentid: appointment.Appointmentid, Offset: offset, Due: start.Add(-offset)})
+		require.NoError(t, err)
+		require.NotZero(t, reminder.Reminderid)
+		require.Equal(t, offset, reminder.Offset)
+		require.True(t, start.Add(-offset).Equal(reminder.Due))
+		require.True(t, reminder.Pending())
+		require.False(t, reminder.CreatedAt.IsZero())
+		created = append(created, reminder)
+	}
+	// an appointment has one reminder per offset & time
+	_, err = repo.Create(ctx, models.Reminder{Appointmentid: appointment.Appointmentid, Offset: time.Hour, Due: start.Add(-time.Hour)})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+
+	// the reminders of an appointment are listed by the time they're due
+	reminders, err := repo.FindbyAppointment(ctx, appointment.Appointmentid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[2].Reminderid, created[1].Reminderid, created[0].Reminderid}, ids(reminders, reminderid))
+	reminders, err = repo.FindbyAppointment(ctx, missing)
+	require.NoError(t, err)
+	require.Empty(t, reminders)
+
+	// other tests may have reminders due too,only the ones of this appointment are checked
+	ofappointment := func(reminders []models.Reminder) []int {
+		var items []int
+		for _, reminder := range reminders {
+			if reminder.Appointmentid == appointment.Appointmentid {
+				items = append(items, reminder.Reminderid)
+			}
+		}
+		return items
+	}
+	due, err := repo.FindDue(ctx, start.Add(-24*time.Hour), math.MaxInt32)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[2].Reminderid, created[1].Reminderid}, ofappointment(due))
+	due, err = repo.FindDue(ctx, start, 1)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	// a reminder is marked sent once
+	sent := now()
+	require.NoError(t, repo.MarkSent(ctx, created[2].Reminderid, sent))
+	require.ErrorIs(t, repo.MarkSent(ctx, created[2].Reminderid, sent), sql.ErrNoRows)
+	require.ErrorIs(t, repo.MarkSent(ctx, missing, sent), sql.ErrNoRows)
+	due, err = repo.FindDue(ctx, start.Add(-24*time.Hour), math.MaxInt32)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[1].Reminderid}, ofappointment(due))
+
+	// dropping the pending reminders keeps the record of the one sent
+	require.NoError(t, repo.DeletePending(ctx, appointment.Appointmentid))
+	reminders, err = repo.FindbyAppointment(ctx, appointment.Appointmentid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[2].Reminderid}, ids(reminders, reminderid))
+	require.False(t, reminders[0].Pending())
+	require.True(t, sent.Equal(reminders[0].Sent))
+	_, err = repo.Create(ctx, models.Reminder{Appointmentid: appointment.Appointmentid, Offset: 72 * time.Hour, Due: start.Add(-72 * time.Hour)})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+}
+
+func Waitlists(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Waitlists
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	patient := createPatient(t, r)
+	other := createPatient(t, r)
+	day := models.Day(now().Add(24*time.Hour), time.UTC)
+	var created []models.Waitlist
+	for _, entry := range []models.Waitlist{
+		{Patientid: patient.Patientid, Doctorid: doctor.Physicianid, Day: day.AddDate(0, 0, 1), Duration: time.Hour},
+		{Patientid: other.Patientid, Doctorid: doctor.Physicianid, Day: day, Duration: 30 * time.Minute},
+		{Patientid: patient.Patientid, Doctorid: doctor.Physicianid, Day: day, Duration: time.Hour},
+	} {
+		waitlist, err := repo.Create(ctx, entry)
+		require.NoError(t, err)
+		require.NotZero(t, waitlist.Waitlistid)
+		require.True(t, entry.Day.Equal(waitlist.Day))
+		require.Equal(t, entry.Duration, waitlist.Duration)
+		require.False(t, waitlist.CreatedAt.IsZero())
+		require.Equal(t, models.Offer{}, waitlist.Offer)
+		created = append(created, waitlist)
+	}
+	// a patient waits for a doctor once per day
+	_, err := repo.Create(ctx, models.Waitlist{Patientid: patient.Patientid, Doctorid: doctor.Physicianid, Day: day, Duration: 30 * time.Minute})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+
+	found, err := repo.Find(ctx, created[1].Waitlistid)
+	require.NoError(t, err)
+	require.Equal(t, other.Patientid, found.Patientid)
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the patients waiting on a day are listed in the order they joined
+	waiting, err := repo.FindbyDoctor(ctx, doctor.Physicianid, day)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[1].Waitlistid, created[2].Waitlistid}, ids(waiting, waitlistid))
+	waiting, err = repo.FindbyDoctor(ctx, missing, day)
+	require.NoError(t, err)
+	require.Empty(t, waiting)
+	bypatient, err := repo.FindbyPatient(ctx, patient.Patientid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[2].Waitlistid, created[0].Waitlistid}, ids(bypatient, waitlistid))
+
+	// only the offer of an entry can be updated
+	offer := models.Offer{Token: utils.RandString(20), Starttime: now().Add(25 * time.Hour), Expires: now().Add(2 * time.Hour)}
+	update := created[1]
+	update.Patientid, update.Duration, update.Offer = patient.Patientid, time.Hour, offer
+	updated, err := repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.Equal(t, other.Patientid, updated.Patientid)
+	require.Equal(t, 30*time.Minute, updated.Duration)
+	require.Equal(t, offer.Token, updated.Offer.Token)
+	require.True(t, offer.Starttime.Equal(updated.Offer.Starttime))
+	require.True(t, offer.Expires.Equal(updated.Offer.Expires))
+	_, err = repo.Update(ctx, models.Waitlist{Waitlistid: missing})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	found, err = repo.FindbyOffer(ctx, offer.Token)
+	require.NoError(t, err)
+	require.Equal(t, created[1].Waitlistid, found.Waitlistid)
+	_, err = repo.FindbyOffer(ctx, utils.RandString(20))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// withdrawing the offer clears it
+	update.Offer = models.Offer{}
+	updated, err = repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.Equal(t, models.Offer{}, updated.Offer)
+	_, err = repo.FindbyOffer(ctx, offer.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, repo.Delete(ctx, created[1].Waitlistid))
+	_, err = repo.Find(ctx, created[1].Waitlistid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Delete(ctx, created[1].Waitlistid))
+}
+
+func Records(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Records
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	patient := createPatient(t, r)
+	nurse := createNurse(t, r)
+	other := createNurse(t, r)
+	date := now()
+	var created []models.Patientrecords
+	for _, nurseid := range []int{nurse.Id, other.Id, nurse.Id} {
+		record, err := repo.Create(ctx, models.Patientrecords{
+			Patienid:    patient.Patientid,
+			Date:        date,
+			Height:      170,
+			Bp:          "120/80",
+			HeartRate:   70,
+			Temperature: 37,
+			Weight:      "70kg",
+			Doctorid:    doctor.Physicianid,
+			Additional:  utils.RandString(10),
+			Nurseid:     nurseid,
+		})
+		require.NoError(t, err)
+		require.NotZero(t, record.Recordid)
+		created = append(created, record)
+	}
+
+	found, err := repo.Find(ctx, created[0].Recordid)
+	require.NoError(t, err)
+	require.Equal(t, created[0].Additional, found.Additional)
+	require.True(t, date.Equal(found.Date))
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Update(ctx, models.Patientrecords{Recordid: missing})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	bypatient, err := repo.FindAllByPatient(ctx, patient.Patientid)
+	require.NoError(t, err)
+	require.Equal(t, ids(created, recordid), ids(bypatient, recordid))
+	bydoctor, err := repo.FindAllByDoctor(ctx, doctor.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, ids(created, recordid), ids(bydoctor, recordid))
+	bynurse, err := repo.FindAllByNurse(ctx, nurse.Id)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[0].Recordid, created[2].Recordid}, ids(bynurse, recordid))
+
+	// who took the record,when and the heart rate can't be changed
+	update := created[0]
+	update.Patienid, update.Doctorid, update.Nurseid = missing, missing, other.Id
+	update.Date, update.HeartRate = date.Add(time.Hour), 90
+	update.Height, update.Bp, update.Temperature, update.Weight, update.Additional = 171, "130/85", 38, "71kg", "fever"
+	updated, err := repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.Equal(t, patient.Patientid, updated.Patienid)
+	require.Equal(t, doctor.Physicianid, updated.Doctorid)
+	require.Equal(t, nurse.Id, updated.Nurseid)
+	require.True(t, date.Equal(updated.Date))
+	require.Equal(t, 70, updated.HeartRate)
+	require.Equal(t, 171, updated.Height)
+	require.Equal(t, "130/85", updated.Bp)
+	require.Equal(t, 38, updated.Temperature)
+	require.Equal(t, "71kg", updated.Weight)
+	require.Equal(t, "fever", updated.Additional)
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 2, 3, recordid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 2})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	require.NoError(t, repo.Delete(ctx, created[0].Recordid))
+	_, err = repo.Find(ctx, created[0].Recordid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Delete(ctx, created[0].Recordid))
+}
+
+func Tickets(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Tickets
+	doctor := createDoctor(t, r, createDepartment(t, r))
+	patient := createPatient(t, r)
+	other := createPatient(t, r)
+	nurse := createNurse(t, r)
+	var created []models.Ticket
+	for _, entry := range []models.Ticket{
+		{Patientid: patient.Patientid, Nurseid: nurse.Id, Status: models.TicketOpen, Acuity: models.DefaultAcuity},
+		{Patientid: other.Patientid, Nurseid: nurse.Id, Status: models.TicketOpen, Acuity: models.MostUrgent},
+		{Patientid: patient.Patientid, Nurseid: nurse.Id, Doctorid: doctor.Physicianid, Status: models.TicketOpen, Acuity: models.DefaultAcuity},
+	} {
+		ticket, err := repo.Create(ctx, entry)
+		require.NoError(t, err)
+		require.NotZero(t, ticket.Ticketid)
+		require.Equal(t, entry.Doctorid, ticket.Doctorid)
+		require.Equal(t, models.TicketOpen, ticket.Status)
+		require.Equal(t, entry.Acuity, ticket.Acuity)
+		require.False(t, ticket.CreatedAt.IsZero())
+		require.True(t, ticket.AttendedAt.IsZero())
+		created = append(created, ticket)
+	}
+
+	found, err := repo.Find(ctx, created[1].Ticketid)
+	require.NoError(t, err)
+	require.Equal(t, other.Patientid, found.Patientid)
+	require.Equal(t, nurse.Id, found.Nurseid)
+	require.Zero(t, found.Doctorid)
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the queue lists the most urgent first then the oldest
+	queue, err := repo.FindbyNurse(ctx, nurse.Id)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[1].Ticketid, created[0].Ticketid, created[2].Ticketid}, ids(queue, ticketid))
+	queue, err = repo.FindbyDoctor(ctx, doctor.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[2].Ticketid}, ids(queue, ticketid))
+	queue, err = repo.FindbyNurse(ctx, missing)
+	require.NoError(t, err)
+	require.Empty(t, queue)
+	bypatient, err := repo.FindbyPatient(ctx, patient.Patientid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[0].Ticketid, created[2].Ticketid}, ids(bypatient, ticketid))
+
+	// the patient,nurse & creation time of a ticket can't be changed
+	attended := now()
+	update := created[0]
+	update.Patientid, update.Nurseid, update.CreatedAt = other.Patientid, missing, attended.Add(time.Hour)
+	update.Doctorid, update.Status, update.Acuity, update.AttendedAt = doctor.Physicianid, models.TicketAttended, 2, attended
+	updated, err := repo.Update(ctx, update)
+	require.NoError(t, err)
+	require.Equal(t, patient.Patientid, updated.Patientid)
+	require.Equal(t, nurse.Id, updated.Nurseid)
+	require.True(t, created[0].CreatedAt.Equal(updated.CreatedAt))
+	require.Equal(t, doctor.Physicianid, updated.Doctorid)
+	require.Equal(t, models.TicketAttended, updated.Status)
+	require.Equal(t, models.Acuity(2), updated.Acuity)
+	require.True(t, attended.Equal(updated.AttendedAt))
+	_, err = repo.Update(ctx, models.Ticket{Ticketid: missing, Status: models.TicketClosed, Acuity: models.DefaultAcuity})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the doctor the ticket was referred to sees it in their queue
+	queue, err = repo.FindbyDoctor(ctx, doctor.Physicianid)
+	require.NoError(t, err)
+	require.Equal(t, []int{created[0].Ticketid, created[2].Ticketid}, ids(queue, ticketid))
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	checkFirstPage(t, items, metadata, 2, 3, ticketid)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 2})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+}
@@ -0,0 +1,104 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func eventid(e models.OutboxEvent) int { return e.Eventid }
+
+// mine keeps the events of the subscriber,the other tests sharing the database leave events of their own
+func mine(items []models.OutboxEvent, subscriber string) []models.OutboxEvent {
+	var kept []models.OutboxEvent
+	for _, item := range items {
+		if item.Subscriber == subscriber {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func Outbox(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Outbox
+	subscriber := utils.RandString(10)
+	var created []models.OutboxEvent
+	for _, kind := range []string{"patient.registered", "record.created", "ticket.opened"} {
+		event, err := repo.Create(ctx, models.OutboxEvent{Subscriber: subscriber, Kind: kind, Payload: []byte(`{"kind":"` + kind + `"}`)})
+		require.NoError(t, err)
+		require.NotZero(t, event.Eventid)
+		require.Equal(t, subscriber, event.Subscriber)
+		require.Equal(t, kind, event.Kind)
+		require.Zero(t, event.Attempts)
+		require.Empty(t, event.LastError)
+		require.False(t, event.CreatedAt.IsZero())
+		require.True(t, event.Pending())
+		created = append(created, event)
+	}
+
+	// an event created for later isn't due before then
+	later, err := repo.Create(ctx, models.OutboxEvent{Subscriber: subscriber, Kind: "waitlist.offer_expired", Payload: []byte(`{}`), NextAttempt: now().AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	require.True(t, later.Pending())
+
+	found, err := repo.Find(ctx, created[1].Eventid)
+	require.NoError(t, err)
+	require.Equal(t, "record.created", found.Kind)
+	require.JSONEq(t, `{"kind":"record.created"}`, string(found.Payload))
+	_, err = repo.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// a claim leases the events due,the oldest first
+	due := now().Add(time.Minute)
+	until := due.Add(time.Hour)
+	claimed, err := repo.Claim(ctx, due, until, 1000)
+	require.NoError(t, err)
+	claimed = mine(claimed, subscriber)
+	require.Equal(t, ids(created, eventid), ids(claimed, eventid))
+	for _, event := range claimed {
+		require.Equal(t, 1, event.Attempts)
+		require.True(t, until.Equal(event.NextAttempt))
+	}
+	// the events leased aren't claimed again before the lease is up
+	claimed, err = repo.Claim(ctx, due, until.Add(time.Hour), 1000)
+	require.NoError(t, err)
+	require.Empty(t, mine(claimed, subscriber))
+
+	require.NoError(t, repo.MarkDispatched(ctx, created[0].Eventid, due))
+	require.ErrorIs(t, repo.MarkDispatched(ctx, created[0].Eventid, due), sql.ErrNoRows)
+	require.ErrorIs(t, repo.MarkDispatched(ctx, missing, due), sql.ErrNoRows)
+	found, err = repo.Find(ctx, created[0].Eventid)
+	require.NoError(t, err)
+	require.True(t, due.Equal(found.Dispatched))
+	require.False(t, found.Pending())
+
+	// a failed attempt is due again at next,a zero next gives up on the event
+	next := until.Add(time.Hour)
+	require.NoError(t, repo.Retry(ctx, created[1].Eventid, next, "mail server down"))
+	found, err = repo.Find(ctx, created[1].Eventid)
+	require.NoError(t, err)
+	require.Equal(t, "mail server down", found.LastError)
+	require.True(t, next.Equal(found.NextAttempt))
+	require.True(t, found.Pending())
+	require.NoError(t, repo.Retry(ctx, created[2].Eventid, time.Time{}, "no such address"))
+	found, err = repo.Find(ctx, created[2].Eventid)
+	require.NoError(t, err)
+	require.True(t, found.NextAttempt.IsZero())
+	require.True(t, found.Dispatched.IsZero())
+	require.False(t, found.Pending())
+	require.ErrorIs(t, repo.Retry(ctx, created[0].Eventid, next, "dispatched"), sql.ErrNoRows)
+	require.ErrorIs(t, repo.Retry(ctx, missing, next, "missing"), sql.ErrNoRows)
+
+	claimed, err = repo.Claim(ctx, next, next.Add(time.Hour), 1000)
+	require.NoError(t, err)
+	claimed = mine(claimed, subscriber)
+	require.Equal(t, []int{created[1].Eventid}, ids(claimed, eventid))
+	require.Equal(t, 2, claimed[0].Attempts)
+	require.Equal(t, "mail server down", claimed[0].LastError)
+}
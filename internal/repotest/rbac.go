@@ -0,0 +1,103 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func userid(u models.Users) int             { return u.Id }
+func permissionid(p models.Permissions) int { return p.Permissionid }
+
+// Rbac runs the contract of the roles,users & permissions repositories
+func Rbac(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	role, err := r.Roles.Create(ctx, models.Roles{Role: utils.RandString(12)})
+	require.NoError(t, err)
+	other, err := r.Roles.Create(ctx, models.Roles{Role: utils.RandString(12)})
+	require.NoError(t, err)
+	require.Greater(t, other.Roleid, role.Roleid)
+
+	found, err := r.Roles.FindbyRole(ctx, role.Role)
+	require.NoError(t, err)
+	require.Equal(t, role, found)
+	_, err = r.Roles.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Roles.FindbyRole(ctx, utils.RandString(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Roles.Update(ctx, models.Roles{Roleid: missing, Role: utils.RandString(12)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Roles.Create(ctx, models.Roles{Role: role.Role})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	_, err = r.Roles.Update(ctx, models.Roles{Roleid: other.Roleid, Role: role.Role})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	roles, err := r.Roles.FindAll(ctx)
+	require.NoError(t, err)
+	checkAscending(t, roles, func(r models.Roles) int { return r.Roleid })
+
+	user, err := r.Users.Create(ctx, models.Users{Email: utils.RandEmail(12), Password: utils.RandString(8), Roleid: role.Roleid})
+	require.NoError(t, err)
+	second, err := r.Users.Create(ctx, models.Users{Email: utils.RandEmail(12), Password: utils.RandString(8), Roleid: role.Roleid})
+	require.NoError(t, err)
+	founduser, err := r.Users.FindbyEmail(ctx, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, user, founduser)
+	_, err = r.Users.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Users.FindbyEmail(ctx, utils.RandEmail(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Users.Update(ctx, models.Users{Id: missing, Email: utils.RandEmail(12), Roleid: role.Roleid})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Users.Create(ctx, models.Users{Email: user.Email, Password: utils.RandString(8), Roleid: role.Roleid})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	_, err = r.Users.Update(ctx, models.Users{Id: second.Id, Email: user.Email, Password: second.Password, Roleid: role.Roleid})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	byrole, err := r.Users.FindbyRoleId(ctx, role.Roleid)
+	require.NoError(t, err)
+	require.Equal(t, []int{user.Id, second.Id}, ids(byrole, userid))
+	second.Roleid = other.Roleid
+	updateduser, err := r.Users.Update(ctx, second)
+	require.NoError(t, err)
+	require.Equal(t, second, updateduser)
+	byrole, err = r.Users.FindbyRoleId(ctx, role.Roleid)
+	require.NoError(t, err)
+	require.Equal(t, []int{user.Id}, ids(byrole, userid))
+	users, err := r.Users.FindAll(ctx)
+	require.NoError(t, err)
+	checkAscending(t, users, userid)
+
+	permission, err := r.Permissions.Create(ctx, models.Permissions{Permission: "patients:read", Roleid: role.Roleid})
+	require.NoError(t, err)
+	// permissions aren't unique,a role may be granted the same one twice
+	again, err := r.Permissions.Create(ctx, models.Permissions{Permission: "patients:read", Roleid: role.Roleid})
+	require.NoError(t, err)
+	_, err = r.Permissions.Find(ctx, missing)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Permissions.Update(ctx, models.Permissions{Permissionid: missing, Permission: "patients:write", Roleid: role.Roleid})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	permissions, err := r.Permissions.FindbyRoleId(ctx, role.Roleid)
+	require.NoError(t, err)
+	require.Equal(t, []int{permission.Permissionid, again.Permissionid}, ids(permissions, permissionid))
+	again.Permission = "patients:write"
+	updatedpermission, err := r.Permissions.Update(ctx, again)
+	require.NoError(t, err)
+	require.Equal(t, again, updatedpermission)
+	permissions, err = r.Permissions.FindAll(ctx)
+	require.NoError(t, err)
+	checkAscending(t, permissions, permissionid)
+
+	require.NoError(t, r.Permissions.Delete(ctx, permission.Permissionid))
+	_, err = r.Permissions.Find(ctx, permission.Permissionid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, r.Users.Delete(ctx, user.Id))
+	_, err = r.Users.Find(ctx, user.Id)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, r.Roles.Delete(ctx, other.Roleid))
+	_, err = r.Roles.Find(ctx, other.Roleid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, r.Roles.Delete(ctx, other.Roleid))
+}
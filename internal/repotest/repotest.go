@@ -0,0 +1,134 @@
+// Package repotest is the contract every storage backend has to honour.
+// The postgres controllers and the in memory stores both run it from their tests,
+// so a behaviour difference between them shows up as a failing test.
+package repotest
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// missing is an id no backend hands out during a test run
+const missing = math.MaxInt32
+
+type Repositories = models.Repositories
+
+// Run runs the contract of every repository
+func Run(t *testing.T, r Repositories) {
+	t.Run("Patients", func(t *testing.T) { Patients(t, r) })
+	t.Run("Doctors", func(t *testing.T) { Doctors(t, r) })
+	t.Run("Nurses", func(t *testing.T) { Nurses(t, r) })
+	t.Run("Departments", func(t *testing.T) { Departments(t, r) })
+	t.Run("Schedules", func(t *testing.T) { Schedules(t, r) })
+	t.Run("ScheduleExceptions", func(t *testing.T) { ScheduleExceptions(t, r) })
+	t.Run("Appointments", func(t *testing.T) { Appointments(t, r) })
+	t.Run("Series", func(t *testing.T) { Series(t, r) })
+	t.Run("Transitions", func(t *testing.T) { Transitions(t, r) })
+	t.Run("Reminders", func(t *testing.T) { Reminders(t, r) })
+	t.Run("Waitlists", func(t *testing.T) { Waitlists(t, r) })
+	t.Run("Records", func(t *testing.T) { Records(t, r) })
+	t.Run("Tickets", func(t *testing.T) { Tickets(t, r) })
+	t.Run("Rbac", func(t *testing.T) { Rbac(t, r) })
+	t.Run("Sessions", func(t *testing.T) { Sessions(t, r) })
+	t.Run("CalendarFeeds", func(t *testing.T) { CalendarFeeds(t, r) })
+	t.Run("Outbox", func(t *testing.T) { Outbox(t, r) })
+}
+
+// checkFirstPage checks the first page of a listing against its metadata.
+// Other tests may write to the same tables at the same time so only what
+// a single page says about itself is checked.
+func checkFirstPage[T any](t *testing.T, items []T, metadata *models.Metadata, pagesize, created int, id func(T) int) {
+	t.Helper()
+	require.GreaterOrEqual(t, metadata.TotalRecords, created)
+	require.Equal(t, 1, metadata.CurrentPage)
+	require.Equal(t, 1, metadata.FirstPage)
+	require.Equal(t, pagesize, metadata.PageSize)
+	require.Equal(t, (metadata.TotalRecords+pagesize-1)/pagesize, metadata.LastPage)
+	if metadata.TotalRecords < pagesize {
+		require.Len(t, items, metadata.TotalRecords)
+	} else {
+		require.Len(t, items, pagesize)
+	}
+	checkAscending(t, items, id)
+}
+
+// checkPastLastPage checks a page after the last one is empty,the metadata is empty
+// too since postgres counts the rows of the page itself
+func checkPastLastPage[T any](t *testing.T, items []T, metadata *models.Metadata) {
+	t.Helper()
+	require.Empty(t, items)
+	require.Equal(t, models.Metadata{}, *metadata)
+}
+
+// checkAscending checks the items are ordered by id like every listing of the schema
+func checkAscending[T any](t *testing.T, items []T, id func(T) int) {
+	t.Helper()
+	for i := 1; i < len(items); i++ {
+		require.Less(t, id(items[i-1]), id(items[i]))
+	}
+}
+
+func ids[T any, K comparable](items []T, id func(T) K) []K {
+	c := make([]K, 0, len(items))
+	for _, item := range items {
+		c = append(c, id(item))
+	}
+	return c
+}
+
+func createPatient(t *testing.T, r Repositories) models.Patient {
+	t.Helper()
+	patient, err := r.Patients.Create(context.Background(), models.Patient{
+		Username:        utils.RandUsername(12),
+		Full_name:       utils.Randfullname(),
+		Email:           utils.RandEmail(12),
+		Dob:             utils.Randate(),
+		Contact:         utils.RandContact(12),
+		Bloodgroup:      "A+",
+		Hashed_password: utils.RandString(8),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, patient.Patientid)
+	return patient
+}
+
+func createDepartment(t *testing.T, r Repositories) models.Department {
+	t.Helper()
+	dept, err := r.Departments.Create(context.Background(), models.Department{Departmentname: utils.RandString(12)})
+	require.NoError(t, err)
+	require.NotZero(t, dept.Departmentid)
+	return dept
+}
+
+func createDoctor(t *testing.T, r Repositories, dept models.Department) models.Physician {
+	t.Helper()
+	doctor, err := r.Doctors.Create(context.Background(), models.Physician{
+		Username:        utils.RandUsername(12),
+		Full_name:       utils.Randfullname(),
+		Email:           utils.RandEmail(12),
+		Contact:         utils.RandContact(12),
+		Hashed_password: utils.RandString(8),
+		Departmentname:  dept.Departmentname,
+	})
+	require.NoError(t, err)
+	require.NotZero(t, doctor.Physicianid)
+	return doctor
+}
+
+func createNurse(t *testing.T, r Repositories) models.Nurse {
+	t.Helper()
+	nurse, err := r.Nurses.Create(context.Background(), models.Nurse{
+		Username:        utils.RandUsername(12),
+		Full_name:       utils.Randfullname(),
+		Email:           utils.RandEmail(12),
+		Hashed_password: utils.RandString(8),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, nurse.Id)
+	return nurse
+}
@@ -0,0 +1,148 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/patienttracker/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func sessionid(s models.Session) uuid.UUID { return s.Id }
+
+func createSession(t *testing.T, r Repositories, accountid int, expiresat time.Time) models.Session {
+	t.Helper()
+	session, err := r.Sessions.Create(context.Background(), models.Session{
+		Id:             uuid.New(),
+		AccountType:    "patient",
+		AccountId:      accountid,
+		Username:       "repotest",
+		Kind:           models.SessionToken,
+		RefreshTokenId: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		ExpiresAt:      expiresat,
+	})
+	require.NoError(t, err)
+	require.False(t, session.CreatedAt.IsZero())
+	return session
+}
+
+func Sessions(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Sessions
+	// sessions aren't tied to a row of the account tables,any id unused by other tests will do
+	accountid := int(uuid.New().ID() >> 1)
+	expiresat := now().Add(time.Hour)
+	first := createSession(t, r, accountid, expiresat)
+	time.Sleep(10 * time.Millisecond)
+	second := createSession(t, r, accountid, expiresat)
+	expired := createSession(t, r, accountid, now().Add(-time.Hour))
+
+	found, err := repo.Find(ctx, first.Id)
+	require.NoError(t, err)
+	require.Equal(t, first.RefreshTokenId, found.RefreshTokenId)
+	require.True(t, expiresat.Equal(found.ExpiresAt))
+	found, err = repo.FindbyRefreshToken(ctx, second.RefreshTokenId.UUID)
+	require.NoError(t, err)
+	require.Equal(t, second.Id, found.Id)
+
+	_, err = repo.Find(ctx, uuid.New())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.FindbyRefreshToken(ctx, uuid.New())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.Rotate(ctx, uuid.New(), uuid.New(), uuid.New(), expiresat)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	duplicate := second
+	duplicate.RefreshTokenId = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	_, err = repo.Create(ctx, duplicate)
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	duplicate.Id = uuid.New()
+	duplicate.RefreshTokenId = first.RefreshTokenId
+	_, err = repo.Create(ctx, duplicate)
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	_, err = repo.Rotate(ctx, second.Id, second.RefreshTokenId.UUID, first.RefreshTokenId.UUID, expiresat)
+	require.ErrorIs(t, err, models.ErrDuplicate)
+
+	// only the active sessions are listed,newest first
+	sessions, err := repo.FindAllByAccount(ctx, "patient", accountid)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{second.Id, first.Id}, ids(sessions, sessionid))
+	require.NotContains(t, ids(sessions, sessionid), expired.Id)
+
+	refreshtokenid := uuid.New()
+	rotated, err := repo.Rotate(ctx, first.Id, first.RefreshTokenId.UUID, refreshtokenid, expiresat.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, uuid.NullUUID{UUID: refreshtokenid, Valid: true}, rotated.RefreshTokenId)
+	require.True(t, expiresat.Add(time.Hour).Equal(rotated.ExpiresAt))
+	_, err = repo.FindbyRefreshToken(ctx, first.RefreshTokenId.UUID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	// the token rotated away can't be rotated again,only one of two requests presenting it wins
+	_, err = repo.Rotate(ctx, first.Id, first.RefreshTokenId.UUID, uuid.New(), expiresat)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	found, err = repo.Find(ctx, first.Id)
+	require.NoError(t, err)
+	require.Equal(t, rotated.RefreshTokenId, found.RefreshTokenId)
+
+	require.NoError(t, repo.Revoke(ctx, first.Id))
+	found, err = repo.Find(ctx, first.Id)
+	require.NoError(t, err)
+	require.True(t, found.Revoked)
+	// a revoked session can't be rotated back to life
+	_, err = repo.Rotate(ctx, first.Id, refreshtokenid, uuid.New(), expiresat)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, repo.Revoke(ctx, uuid.New()))
+
+	items, metadata, err := repo.FindAll(ctx, models.Filters{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, 1, metadata.CurrentPage)
+	require.GreaterOrEqual(t, metadata.TotalRecords, 1)
+	items, metadata, err = repo.FindAll(ctx, models.Filters{Page: metadata.LastPage + 100, PageSize: 1})
+	require.NoError(t, err)
+	checkPastLastPage(t, items, metadata)
+
+	require.NoError(t, repo.RevokeAllByAccount(ctx, "patient", accountid))
+	sessions, err = repo.FindAllByAccount(ctx, "patient", accountid)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func CalendarFeeds(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	repo := r.Calendars
+	// like sessions the feeds aren't tied to a row of the account tables
+	accountid := int(uuid.New().ID() >> 1)
+	feed, err := repo.Create(ctx, models.CalendarFeed{Token: uuid.NewString(), AccountType: "physician", AccountId: accountid})
+	require.NoError(t, err)
+	require.False(t, feed.CreatedAt.IsZero())
+	other, err := repo.Create(ctx, models.CalendarFeed{Token: uuid.NewString(), AccountType: "patient", AccountId: accountid})
+	require.NoError(t, err)
+
+	// an account has one feed at a time and a token belongs to one feed
+	_, err = repo.Create(ctx, models.CalendarFeed{Token: uuid.NewString(), AccountType: "physician", AccountId: accountid})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	_, err = repo.Create(ctx, models.CalendarFeed{Token: feed.Token, AccountType: "nurse", AccountId: accountid})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+
+	found, err := repo.FindbyToken(ctx, feed.Token)
+	require.NoError(t, err)
+	require.Equal(t, "physician", found.AccountType)
+	require.Equal(t, accountid, found.AccountId)
+	found, err = repo.FindbyAccount(ctx, "patient", accountid)
+	require.NoError(t, err)
+	require.Equal(t, other.Token, found.Token)
+	_, err = repo.FindbyToken(ctx, uuid.NewString())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.FindbyAccount(ctx, "nurse", accountid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, repo.DeleteByAccount(ctx, "physician", accountid))
+	_, err = repo.FindbyToken(ctx, feed.Token)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = repo.FindbyAccount(ctx, "patient", accountid)
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteByAccount(ctx, "physician", accountid))
+}
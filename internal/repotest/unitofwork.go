@@ -0,0 +1,74 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/patienttracker/internal/models"
+	"github.com/patienttracker/internal/utils"
+	"github.com/stretchr/testify/require"
+)
+
+// UnitOfWork checks what a unit of work writes is only kept when it succeeds,
+// r must be the repositories uow runs its transactions against.
+func UnitOfWork(t *testing.T, uow models.UnitOfWork, r Repositories) {
+	ctx := context.Background()
+	var committed models.Department
+	err := uow.Do(ctx, func(tx models.Repositories) error {
+		var err error
+		if committed, err = tx.Departments.Create(ctx, models.Department{Departmentname: utils.RandString(12)}); err != nil {
+			return err
+		}
+		// the transaction sees its own writes
+		_, err = tx.Departments.Find(ctx, committed.Departmentid)
+		return err
+	})
+	require.NoError(t, err)
+	found, err := r.Departments.Find(ctx, committed.Departmentid)
+	require.NoError(t, err)
+	require.Equal(t, committed, found)
+
+	failed := errors.New("failed")
+	var dept models.Department
+	var role models.Roles
+	err = uow.Do(ctx, func(tx models.Repositories) error {
+		var err error
+		dept, err = tx.Departments.Create(ctx, models.Department{Departmentname: utils.RandString(12)})
+		require.NoError(t, err)
+		role, err = tx.Roles.Create(ctx, models.Roles{Role: utils.RandString(12)})
+		require.NoError(t, err)
+		_, err = tx.Departments.Update(ctx, models.Department{Departmentid: committed.Departmentid, Departmentname: utils.RandString(12)})
+		require.NoError(t, err)
+		return failed
+	})
+	require.ErrorIs(t, err, failed)
+	_, err = r.Departments.Find(ctx, dept.Departmentid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = r.Roles.Find(ctx, role.Roleid)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	found, err = r.Departments.Find(ctx, committed.Departmentid)
+	require.NoError(t, err)
+	require.Equal(t, committed, found)
+
+	// a failed statement rolls back the ones before it
+	rolename := utils.RandString(12)
+	err = uow.Do(ctx, func(tx models.Repositories) error {
+		if _, err := tx.Roles.Create(ctx, models.Roles{Role: rolename}); err != nil {
+			return err
+		}
+		_, err := tx.Departments.Create(ctx, models.Department{Departmentname: committed.Departmentname})
+		return err
+	})
+	require.ErrorIs(t, err, models.ErrDuplicate)
+	_, err = r.Roles.FindbyRole(ctx, rolename)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = uow.Do(cancelled, func(tx models.Repositories) error {
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/models"
+)
+
+// newtoken returns a random token for a calendar feed or a waitlist offer,it's the only credential
+// of the feed or the offer so unlike the other tokens it comes from crypto/rand.
+func newtoken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CalendarFeed returns the calendar feed of the account creating it the first time,
+// only doctors & patients have appointments to subscribe to.
+func (service *Service) CalendarFeed(ctx context.Context, actor models.Actor) (models.CalendarFeed, error) {
+	if actor.AccountType != auth.AccountPhysician && actor.AccountType != auth.AccountPatient {
+		return models.CalendarFeed{}, ErrNotAuthorized
+	}
+	feed, err := service.CalendarService.FindbyAccount(ctx, actor.AccountType, actor.AccountId)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return feed, err
+	}
+	feed, err = service.createfeed(ctx, actor)
+	if errors.Is(err, models.ErrDuplicate) {
+		// another request created the feed first
+		return service.CalendarService.FindbyAccount(ctx, actor.AccountType, actor.AccountId)
+	}
+	return feed, err
+}
+
+// ResetCalendarFeed gives the account a new feed,the address of the old one stops working
+func (service *Service) ResetCalendarFeed(ctx context.Context, actor models.Actor) (models.CalendarFeed, error) {
+	if actor.AccountType != auth.AccountPhysician && actor.AccountType != auth.AccountPatient {
+		return models.CalendarFeed{}, ErrNotAuthorized
+	}
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.CalendarFeed, error) {
+		if err := tx.CalendarService.DeleteByAccount(ctx, actor.AccountType, actor.AccountId); err != nil {
+			return models.CalendarFeed{}, err
+		}
+		return tx.createfeed(ctx, actor)
+	})
+}
+
+func (service *Service) createfeed(ctx context.Context, actor models.Actor) (models.CalendarFeed, error) {
+	token, err := newtoken()
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+	return service.CalendarService.Create(ctx, models.CalendarFeed{Token: token, AccountType: actor.AccountType, AccountId: actor.AccountId})
+}
+
+// FeedAppointments returns the feed with the token and the appointments of its account,
+// sql.ErrNoRows is returned for a token that was reset or never existed.
+func (service *Service) FeedAppointments(ctx context.Context, token string) (models.CalendarFeed, []models.Appointment, error) {
+	feed, err := service.CalendarService.FindbyToken(ctx, token)
+	if err != nil {
+		return models.CalendarFeed{}, nil, err
+	}
+	var appointments []models.Appointment
+	switch feed.AccountType {
+	case auth.AccountPhysician:
+		appointments, err = service.AppointmentService.FindAllByDoctor(ctx, feed.AccountId)
+	case auth.AccountPatient:
+		appointments, err = service.AppointmentService.FindAllByPatient(ctx, feed.AccountId)
+	default:
+		err = ErrNotAuthorized
+	}
+	if err != nil {
+		return models.CalendarFeed{}, nil, err
+	}
+	return feed, appointments, nil
+}
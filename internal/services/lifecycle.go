@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/models"
+)
+
+// TransitionAppointment moves the appointment to status to on behalf of actor & records the move.
+// Patients may only cancel their own appointments,cancelling needs a reason and an appointment
+// can't be completed or missed before it starts. Approving an appointment claims its slot so
+// it's checked like a booking,rescheduling goes through the updates as it needs a new time.
+func (service *Service) TransitionAppointment(ctx context.Context, id int, to models.Status, actor models.Actor, reason string) (models.Appointment, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Appointment, error) {
+		appointment, err := tx.AppointmentService.Find(ctx, id)
+		if err != nil {
+			return models.Appointment{}, err
+		}
+		if err := tx.lockbooking(ctx, appointment); err != nil {
+			return models.Appointment{}, err
+		}
+		// the status is checked as it is once the lock is held,a transition committed while waiting
+		// for it would otherwise be overwritten
+		appointment, err = tx.AppointmentService.Find(ctx, id)
+		if err != nil {
+			return models.Appointment{}, err
+		}
+		if to == models.StatusRescheduled || !appointment.Status.CanMoveTo(to) {
+			return models.Appointment{}, ErrInvalidTransition
+		}
+		if actor.AccountType == auth.AccountPatient && (to != models.StatusCancelled || actor.AccountId != appointment.Patientid) {
+			return models.Appointment{}, ErrNotAuthorized
+		}
+		reason = strings.TrimSpace(reason)
+		switch to {
+		case models.StatusCancelled:
+			if reason == "" {
+				return models.Appointment{}, ErrReasonRequired
+			}
+		case models.StatusCompleted, models.StatusNoShow:
+			if time.Now().Before(appointment.Appointmentdate) {
+				return models.Appointment{}, ErrNotStarted
+			}
+		case models.StatusApproved:
+			if err := tx.checkapproval(ctx, appointment); err != nil {
+				return models.Appointment{}, err
+			}
+		}
+		from := appointment.Status
+		appointment.Status = to
+		updated, err := tx.saveappointment(ctx, appointment)
+		if err != nil {
+			return models.Appointment{}, err
+		}
+		if to == models.StatusCancelled {
+			if err := tx.freeslot(ctx, updated); err != nil {
+				return models.Appointment{}, err
+			}
+		}
+		return updated, tx.record(ctx, updated.Appointmentid, from, to, actor, reason)
+	})
+}
+
+// DeleteAppointment deletes the appointment,the slot it held is offered to the waitlist of its doctor
+func (service *Service) DeleteAppointment(ctx context.Context, id int) error {
+	return service.atomically(ctx, func(tx *Service) error {
+		appointment, err := tx.AppointmentService.Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.AppointmentService.Delete(ctx, id); err != nil {
+			return err
+		}
+		return tx.freeslot(ctx, appointment)
+	})
+}
+
+// checkapproval errors when the appointment can't hold its slot,
+// a requested appointment only had the approved ones checked against it when it was booked.
+func (service *Service) checkapproval(ctx context.Context, appointment models.Appointment) error {
+	if appointment.Approved() || appointment.Outbound {
+		return nil
+	}
+	if err := service.checkavailability(ctx, appointment); err != nil {
+		return err
+	}
+	if err := service.checkoffers(ctx, appointment); err != nil {
+		return err
+	}
+	appointments, err := service.AppointmentService.FindAllByDoctor(ctx, appointment.Doctorid)
+	if err != nil {
+		return err
+	}
+	patientappointments, err := service.AppointmentService.FindAllByPatient(ctx, appointment.Patientid)
+	if err != nil {
+		return err
+	}
+	return checkbooked(append(appointments, patientappointments...), appointment)
+}
+
+// updateappointment writes the new time of the appointment keeping its status,
+// an appointment holding its slot is rescheduled to the new time while a requested one stays requested.
+func (service *Service) updateappointment(ctx context.Context, appointment models.Appointment, actor models.Actor) (models.Appointment, error) {
+	current, err := service.AppointmentService.Find(ctx, appointment.Appointmentid)
+	if err != nil {
+		return models.Appointment{}, err
+	}
+	if current.Status.Final() {
+		return models.Appointment{}, ErrInvalidTransition
+	}
+	appointment.Status = current.Status
+	moved := !appointment.Appointmentdate.Equal(current.Appointmentdate) || appointment.Duration != current.Duration
+	if moved && current.Approved() {
+		appointment.Status = models.StatusRescheduled
+	}
+	updated, err := service.saveappointment(ctx, appointment)
+	if err != nil || !moved || !current.Approved() {
+		return updated, err
+	}
+	return updated, service.record(ctx, updated.Appointmentid, current.Status, updated.Status, actor, "")
+}
+
+// record appends the move to the history of the appointment
+func (service *Service) record(ctx context.Context, id int, from, to models.Status, actor models.Actor, reason string) error {
+	_, err := service.TransitionService.Create(ctx, models.Transition{
+		Appointmentid: id,
+		From:          from,
+		To:            to,
+		Actor:         actor,
+		Reason:        reason,
+	})
+	return err
+}
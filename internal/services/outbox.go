@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/events"
+	"github.com/patienttracker/internal/models"
+)
+
+const (
+	// handlertimeout is how long a subscriber has to handle an event before the attempt fails
+	handlertimeout = 15 * time.Second
+	// outboxbatch is how many events are claimed at once,they're handled one after the other
+	outboxbatch = 20
+	// outboxlease is how long a claimed event is left to its subscriber before it's claimed again,
+	// it outlasts a batch whose every handler times out so an event isn't handled twice at once
+	outboxlease = outboxbatch*handlertimeout + time.Minute
+	// the delay before handing an event to a subscriber again doubles from firstretry up to lastretry
+	firstretry = 5 * time.Second
+	lastretry  = time.Hour
+)
+
+// Handler handles an event on behalf of a subscriber,an error hands the event to it again later
+// so a handler has to cope with seeing the same event more than once.
+type Handler func(ctx context.Context, e events.Event) error
+
+type subscription struct {
+	handler Handler
+	// kinds are the kinds of events the subscriber gets,all of them when empty
+	kinds map[events.Kind]bool
+}
+
+// Outbox holds the subscribers the events published are written to the outbox for,
+// the events are handed to them by DispatchOutbox once the change that published them committed.
+type Outbox struct {
+	mu          sync.RWMutex
+	subscribers map[string]subscription
+	wake        chan struct{}
+	// MaxAttempts is how many times an event is handed to a subscriber before it's given up on,
+	// zero keeps trying
+	MaxAttempts int
+}
+
+func NewOutbox(maxattempts int) *Outbox {
+	return &Outbox{
+		subscribers: make(map[string]subscription),
+		wake:        make(chan struct{}, 1),
+		MaxAttempts: maxattempts,
+	}
+}
+
+// Subscribe hands the events of the kinds to handler,without kinds it gets every event.
+// The name is kept with the events in the outbox so it mustn't change between releases.
+func (o *Outbox) Subscribe(name string, handler Handler, kinds ...events.Kind) {
+	sub := subscription{handler: handler, kinds: make(map[events.Kind]bool)}
+	for _, kind := range kinds {
+		sub.kinds[kind] = true
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.subscribers[name] = sub
+}
+
+// subscribed returns the names of the subscribers of the kind sorted
+func (o *Outbox) subscribed(kind events.Kind) []string {
+	if o == nil {
+		return nil
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	var names []string
+	for name, sub := range o.subscribers {
+		if len(sub.kinds) == 0 || sub.kinds[kind] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (o *Outbox) handler(name string) (Handler, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	sub, ok := o.subscribers[name]
+	return sub.handler, ok
+}
+
+// Wake receives once events were written to the outbox,the dispatcher needn't wait for its next tick
+func (o *Outbox) Wake() <-chan struct{} {
+	return o.wake
+}
+
+func (o *Outbox) notify() {
+	if o == nil {
+		return
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// backoff is how long to wait before handing an event to its subscriber again after attempt failed
+func backoff(attempt int) time.Duration {
+	delay := firstretry
+	for i := 1; i < attempt && delay < lastretry; i++ {
+		delay *= 2
+	}
+	if delay > lastretry {
+		return lastretry
+	}
+	return delay
+}
+
+// live are the kinds of events the dashboards follow,they're put on the bus of the instance making
+// the change as soon as it commits instead of going through the outbox
+var live = map[events.Kind]bool{
+	events.TicketOpened:       true,
+	events.TicketMoved:        true,
+	events.TicketAttended:     true,
+	events.AppointmentBooked:  true,
+	events.AppointmentChanged: true,
+}
+
+// publish writes the event to the outbox once for each of its subscribers,inside a transaction
+// the event goes with the change so the subscribers only hear of what was committed.
+func (service *Service) publish(ctx context.Context, e events.Event) error {
+	return service.publishat(ctx, e, time.Time{})
+}
+
+// publishat is publish handing the event to the subscribers no sooner than at,at once when it's zero
+func (service *Service) publishat(ctx context.Context, e events.Event, at time.Time) error {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	if live[e.Kind] {
+		service.broadcast(e)
+	}
+	names := service.Outbox.subscribed(e.Kind)
+	if len(names) == 0 || service.OutboxService == nil {
+		return nil
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := service.OutboxService.Create(ctx, models.OutboxEvent{
+			Subscriber:  name,
+			Kind:        string(e.Kind),
+			Payload:     payload,
+			NextAttempt: at,
+		}); err != nil {
+			return err
+		}
+	}
+	if service.published != nil {
+		*service.published = true
+		return nil
+	}
+	service.Outbox.notify()
+	return nil
+}
+
+// DispatchOutbox hands the events due by now to their subscribers & returns how many it claimed,
+// an event whose handler fails is retried later until MaxAttempts is reached. The errors returned
+// are the ones of the outbox itself,the ones of the handlers are kept with the events.
+func (service *Service) DispatchOutbox(ctx context.Context, now time.Time) (int, error) {
+	claimed, err := service.OutboxService.Claim(ctx, now, now.Add(outboxlease), outboxbatch)
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range claimed {
+		if err := service.deliver(ctx, event); err != nil {
+			next := now.Add(backoff(event.Attempts))
+			if service.Outbox.MaxAttempts > 0 && event.Attempts >= service.Outbox.MaxAttempts {
+				next = time.Time{}
+			}
+			if err := service.OutboxService.Retry(ctx, event.Eventid, next, err.Error()); err != nil {
+				return len(claimed), err
+			}
+			continue
+		}
+		if err := service.OutboxService.MarkDispatched(ctx, event.Eventid, now); err != nil {
+			return len(claimed), err
+		}
+	}
+	return len(claimed), nil
+}
+
+func (service *Service) deliver(ctx context.Context, event models.OutboxEvent) error {
+	handler, ok := service.Outbox.handler(event.Subscriber)
+	if !ok {
+		return fmt.Errorf("no subscriber %q", event.Subscriber)
+	}
+	var e events.Event
+	if err := json.Unmarshal(event.Payload, &e); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, handlertimeout)
+	defer cancel()
+	return handler(ctx, e)
+}
+
+// broadcast puts the event on the bus the dashboards listen to,inside a transaction it waits
+// for the commit so nothing that was rolled back is published.
+func (service *Service) broadcast(e events.Event) {
+	if service.pending != nil {
+		*service.pending = append(*service.pending, e)
+		return
+	}
+	service.Events.Publish(e)
+}
+
+// RegisterPatient creates the account of the patient,the subscribers are told so they can welcome them
+func (service *Service) RegisterPatient(ctx context.Context, patient models.Patient) (models.Patient, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Patient, error) {
+		created, err := tx.PatientService.Create(ctx, patient)
+		if err != nil {
+			return models.Patient{}, err
+		}
+		return created, tx.publish(ctx, events.Event{
+			Kind:      events.PatientRegistered,
+			Patientid: created.Patientid,
+			Email:     created.Email,
+		})
+	})
+}
+
+// RequestPasswordReset tells the subscribers the owner of the email forgot the password of the account,
+// whether there's such an account is left to them so the request doesn't give it away.
+func (service *Service) RequestPasswordReset(ctx context.Context, accounttype, email string) error {
+	switch accounttype {
+	case auth.AccountPatient, auth.AccountPhysician, auth.AccountNurse, auth.AccountAdmin:
+	default:
+		return ErrNoUser
+	}
+	return service.atomically(ctx, func(tx *Service) error {
+		return tx.publish(ctx, events.Event{
+			Kind:        events.PasswordResetRequested,
+			AccountType: accounttype,
+			Email:       email,
+		})
+	})
+}
+
+// CreateRecord writes the record of the visit of the patient
+func (service *Service) CreateRecord(ctx context.Context, record models.Patientrecords) (models.Patientrecords, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Patientrecords, error) {
+		return tx.createrecord(ctx, record)
+	})
+}
+
+func (service *Service) createrecord(ctx context.Context, record models.Patientrecords) (models.Patientrecords, error) {
+	created, err := service.PatientRecordService.Create(ctx, record)
+	if err != nil {
+		return models.Patientrecords{}, err
+	}
+	return created, service.publish(ctx, events.Event{
+		Kind:      events.RecordCreated,
+		Recordid:  created.Recordid,
+		Patientid: created.Patienid,
+		Nurseid:   created.Nurseid,
+		Doctorid:  created.Doctorid,
+	})
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -11,19 +12,22 @@ import (
 	"github.com/unidoc/unipdf/v3/model"
 )
 
-func init() {
-	err := license.SetMeteredKey(`e9da5e6d147303d9b079887d1997b283af025fc02c5d0942bfd7a5a45524e13e`)
-	if err != nil {
-		panic(err)
+// setupLicense registers the unipdf metered key,without one the generated
+// pdfs carry the unlicensed watermark
+func setupLicense(key string) error {
+	if key == "" {
+		return nil
 	}
+	return license.SetMeteredKey(key)
 }
+
 func NewCreator() creator.Creator {
 
 	c := creator.New()
 
 	return *c
 }
-func (s *Service) Generate(id int, w io.Writer) {
+func (s *Service) Generate(ctx context.Context, id int, w io.Writer) {
 	font, err := model.NewStandard14Font("Helvetica")
 	if err != nil {
 		log.Fatal(err)
@@ -37,7 +41,7 @@ func (s *Service) Generate(id int, w io.Writer) {
 	// Generate front page.
 	drawFrontPage(&s.Creator, font, fontBold)
 	// Generate basic usage chapter.
-	if err := s.BasicUsage(&s.Creator, font, fontBold, id); err != nil {
+	if err := s.BasicUsage(ctx, &s.Creator, font, fontBold, id); err != nil {
 		log.Fatal(err)
 	}
 
@@ -70,7 +74,7 @@ func drawFrontPage(c *creator.Creator, font, fontBold *model.PdfFont) {
 	})
 }
 
-func (s *Service) BasicUsage(c *creator.Creator, font, fontBold *model.PdfFont, patientid int) error {
+func (s *Service) BasicUsage(ctx context.Context, c *creator.Creator, font, fontBold *model.PdfFont, patientid int) error {
 	// Create chapter.
 	ch := c.NewChapter("Records")
 	ch.SetMargins(0, 0, 50, 0)
@@ -79,7 +83,7 @@ func (s *Service) BasicUsage(c *creator.Creator, font, fontBold *model.PdfFont,
 	ch.GetHeading().SetColor(creator.ColorRGBFrom8bit(72, 86, 95))
 
 	// Draw subchapters.
-	s.ContentAlignH(c, ch, font, fontBold, patientid)
+	s.ContentAlignH(ctx, c, ch, font, fontBold, patientid)
 
 	// Draw chapter.
 	if err := c.Draw(ch); err != nil {
@@ -89,7 +93,7 @@ func (s *Service) BasicUsage(c *creator.Creator, font, fontBold *model.PdfFont,
 	return nil
 }
 
-func (s *Service) ContentAlignH(c *creator.Creator, ch *creator.Chapter, font, fontBold *model.PdfFont, patientrecord int) {
+func (s *Service) ContentAlignH(ctx context.Context, c *creator.Creator, ch *creator.Chapter, font, fontBold *model.PdfFont, patientrecord int) {
 	// Create subchapter.
 	sc := ch.NewSubchapter("Medical Records")
 	sc.SetMargins(0, 0, 30, 0)
@@ -122,7 +126,7 @@ func (s *Service) ContentAlignH(c *creator.Creator, ch *creator.Chapter, font, f
 	drawCell("#", fontBold, creator.CellHorizontalAlignmentLeft)
 	drawCell("Medical Record", fontBold, creator.CellHorizontalAlignmentCenter)
 	drawCell("Medical Value", fontBold, creator.CellHorizontalAlignmentRight)
-	record, err := s.PatientRecordService.Find(patientrecord)
+	record, err := s.PatientRecordService.Find(ctx, patientrecord)
 	if err != nil {
 		return
 	}
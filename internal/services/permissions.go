@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -40,8 +41,8 @@ func (a Permissions) Define(domainname string, perm Permissions) string {
 }
 
 // patient:create
-func (s *Service) CreatePermission(permission models.Permissions, userid int) (models.Permissions, error) {
-	permissions, err := s.GetAllPermissionsofUser(userid)
+func (s *Service) CreatePermission(ctx context.Context, permission models.Permissions, userid int) (models.Permissions, error) {
+	permissions, err := s.GetAllPermissionsofUser(ctx, userid)
 	if err != nil {
 		return models.Permissions{}, err
 	}
@@ -54,6 +55,6 @@ func (s *Service) CreatePermission(permission models.Permissions, userid int) (m
 	if admin == "" {
 		return permission, ErrForbidden
 	}
-	permission, err = s.RbacService.PermissionsService.Create(permission)
+	permission, err = s.RbacService.PermissionsService.Create(ctx, permission)
 	return permission, err
 }
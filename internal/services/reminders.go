@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/events"
+	"github.com/patienttracker/internal/models"
+)
+
+// defaultreminders are the offsets the reminders are sent at when ReminderOffsets isn't set
+var defaultreminders = []time.Duration{24 * time.Hour, time.Hour}
+
+// reminderoffsets returns how long before an appointment its reminders are sent
+func (service *Service) reminderoffsets() []time.Duration {
+	if service.ReminderOffsets == nil {
+		return defaultreminders
+	}
+	return service.ReminderOffsets
+}
+
+// createappointment writes the new appointment & schedules its reminders
+func (service *Service) createappointment(ctx context.Context, appointment models.Appointment) (models.Appointment, error) {
+	created, err := service.AppointmentService.Create(ctx, appointment)
+	if err != nil {
+		return created, err
+	}
+	if err := service.publish(ctx, appointmentevent(events.AppointmentBooked, created)); err != nil {
+		return created, err
+	}
+	return created, service.schedulereminders(ctx, created)
+}
+
+// saveappointment writes the changes to the appointment & reschedules its reminders
+func (service *Service) saveappointment(ctx context.Context, appointment models.Appointment) (models.Appointment, error) {
+	updated, err := service.AppointmentService.Update(ctx, appointment)
+	if err != nil {
+		return updated, err
+	}
+	if err := service.publish(ctx, appointmentevent(events.AppointmentChanged, updated)); err != nil {
+		return updated, err
+	}
+	return updated, service.schedulereminders(ctx, updated)
+}
+
+func appointmentevent(kind events.Kind, appointment models.Appointment) events.Event {
+	return events.Event{
+		Kind:          kind,
+		Appointmentid: appointment.Appointmentid,
+		Patientid:     appointment.Patientid,
+		Doctorid:      appointment.Doctorid,
+	}
+}
+
+// schedulereminders brings the reminders of the appointment in line with its time & status,every write
+// of an appointment goes through it. The reminders that weren't sent are dropped and one is added for
+// each offset still ahead while the appointment holds its slot,unless it was already sent for that time.
+func (service *Service) schedulereminders(ctx context.Context, appointment models.Appointment) error {
+	if err := service.ReminderService.DeletePending(ctx, appointment.Appointmentid); err != nil {
+		return err
+	}
+	if !appointment.Approved() {
+		return nil
+	}
+	sent, err := service.ReminderService.FindbyAppointment(ctx, appointment.Appointmentid)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, offset := range service.reminderoffsets() {
+		reminder := models.Reminder{
+			Appointmentid: appointment.Appointmentid,
+			Offset:        offset,
+			Due:           appointment.Appointmentdate.Add(-offset),
+		}
+		if !reminder.Due.After(now) || wassent(sent, reminder) {
+			continue
+		}
+		if _, err := service.ReminderService.Create(ctx, reminder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wassent reports whether reminder is among the reminders sent
+func wassent(sent []models.Reminder, reminder models.Reminder) bool {
+	for _, val := range sent {
+		if val.Offset == reminder.Offset && val.Due.Equal(reminder.Due) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimReminders marks up to limit reminders due by now as sent and returns how many are to be emailed,
+// a reminder is claimed once so it's emailed once even when several servers look for them.
+// Each reminder is published to the outbox in the transaction marking it sent so the subscribers
+// get it even when the server stops right after,once for the patient & once for the doctor so an
+// email failing to one of them isn't sent again to the other. The reminders of appointments that
+// are gone,no longer hold their slot or already started are claimed without being published or counted.
+// On an error the reminders claimed so far are still counted.
+func (service *Service) ClaimReminders(ctx context.Context, now time.Time, limit int) (int, error) {
+	reminders, err := service.ReminderService.FindDue(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	due := 0
+	for _, reminder := range reminders {
+		var claimed bool
+		err := service.atomically(ctx, func(tx *Service) error {
+			claimed = false
+			appointment, err := tx.AppointmentService.Find(ctx, reminder.Appointmentid)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			err = tx.ReminderService.MarkSent(ctx, reminder.Reminderid, now)
+			if errors.Is(err, sql.ErrNoRows) {
+				// another server claimed it first
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !appointment.Approved() || !appointment.Appointmentdate.After(now) {
+				return nil
+			}
+			claimed = true
+			for _, recipient := range []string{auth.AccountPatient, auth.AccountPhysician} {
+				e := appointmentevent(events.ReminderDue, appointment)
+				e.Reminderid = reminder.Reminderid
+				e.AccountType = recipient
+				if err := tx.publish(ctx, e); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return due, err
+		}
+		if claimed {
+			due++
+		}
+	}
+	return due, nil
+}
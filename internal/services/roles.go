@@ -1,14 +1,15 @@
 package services
 
 import (
+	"context"
 	"github.com/patienttracker/internal/models"
 	"strings"
 )
 
-func (service *Service) CreateRole(rolename string, roleid int) (models.Roles, error) {
+func (service *Service) CreateRole(ctx context.Context, rolename string, roleid int) (models.Roles, error) {
 	var role models.Roles
 	var err error
-	permissions, err := service.RbacService.PermissionsService.FindbyRoleId(roleid)
+	permissions, err := service.RbacService.PermissionsService.FindbyRoleId(ctx, roleid)
 	if err != nil {
 		return role, err
 	}
@@ -17,7 +18,7 @@ func (service *Service) CreateRole(rolename string, roleid int) (models.Roles, e
 	}
 	for _, permission := range permissions {
 		if strings.ToLower(permission.Permission) == Admin.toString() {
-			role, err = service.RbacService.RolesService.Create(models.Roles{
+			role, err = service.RbacService.RolesService.Create(ctx, models.Roles{
 				Role: rolename,
 			})
 			if err != nil {
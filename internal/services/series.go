@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+// Occurrence is a date of a series that couldn't be booked and why
+type Occurrence struct {
+	Date time.Time
+	Err  error
+}
+
+// SeriesError lists the occurrences of a series failing their checks,
+// nothing of the series is written when any of them fails.
+type SeriesError struct {
+	Failed []Occurrence
+}
+
+func (e *SeriesError) Error() string {
+	return fmt.Sprintf("%d of the occurrences can't be booked", len(e.Failed))
+}
+
+func (e *SeriesError) Unwrap() error {
+	return ErrSeriesConflict
+}
+
+// slotfailure reports whether err only fails the occurrence it was checked for,
+// the other errors fail the whole series.
+func slotfailure(err error) bool {
+	for _, failure := range []error{ErrInvalidSchedule, ErrNotWithinSchedule, ErrDoctorAway, ErrTimeSlotAllocated} {
+		if errors.Is(err, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkoccurrences checks every occurrence against the schedule of the doctor and against appointments,
+// the failures are collected so they can all be reported at once.
+func (service *Service) checkoccurrences(ctx context.Context, appointments, occurrences []models.Appointment) error {
+	var failed []Occurrence
+	for _, occurrence := range occurrences {
+		err := service.checkavailability(ctx, occurrence)
+		if err == nil {
+			err = service.checkoffers(ctx, occurrence)
+		}
+		if err == nil {
+			err = checkbooked(appointments, occurrence)
+		}
+		if err != nil && !slotfailure(err) {
+			return err
+		}
+		if err != nil {
+			failed = append(failed, Occurrence{Date: occurrence.Appointmentdate, Err: err})
+		}
+	}
+	if failed != nil {
+		return &SeriesError{Failed: failed}
+	}
+	return nil
+}
+
+// BookSeries books the appointments of the series for its doctor,they're approved straight away like
+// the doctor's own bookings. Every occurrence is checked before any is booked so either the whole series
+// is booked or a SeriesError reports the dates that failed.
+func (service *Service) BookSeries(ctx context.Context, series models.Series) (models.Series, []models.Appointment, error) {
+	if !series.Recurrence.ValidFrom(series.Start.In(service.Clinic())) {
+		return models.Series{}, nil, ErrInvalidRecurrence
+	}
+	var created models.Series
+	var booked []models.Appointment
+	err := service.atomically(ctx, func(tx *Service) error {
+		occurrences := series.Occurrences(tx.Clinic())
+		if len(occurrences) == 0 {
+			return ErrInvalidRecurrence
+		}
+		if err := tx.lockbooking(ctx, occurrences[0]); err != nil {
+			return err
+		}
+		if err := tx.checkduration(ctx, occurrences[0]); err != nil {
+			return err
+		}
+		appointments, err := tx.AppointmentService.FindAllByDoctor(ctx, series.Doctorid)
+		if err != nil {
+			return err
+		}
+		patientappointments, err := tx.AppointmentService.FindAllByPatient(ctx, series.Patientid)
+		if err != nil {
+			return err
+		}
+		if err := tx.checkoccurrences(ctx, append(appointments, patientappointments...), occurrences); err != nil {
+			return err
+		}
+		created, err = tx.SeriesService.Create(ctx, series)
+		if err != nil {
+			return err
+		}
+		for _, occurrence := range occurrences {
+			occurrence.Seriesid = created.Seriesid
+			appointment, err := tx.createappointment(ctx, occurrence)
+			if err != nil {
+				return err
+			}
+			booked = append(booked, appointment)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Series{}, nil, err
+	}
+	return created, booked, nil
+}
+
+// upcoming returns the occurrences of the series that haven't started and can still change
+func (service *Service) upcoming(ctx context.Context, id int) ([]models.Appointment, error) {
+	occurrences, err := service.AppointmentService.FindAllBySeries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var items []models.Appointment
+	for _, occurrence := range occurrences {
+		if occurrence.Appointmentdate.After(now) && !occurrence.Status.Final() {
+			items = append(items, occurrence)
+		}
+	}
+	return items, nil
+}
+
+// UpdateSeries moves the series to series.Start and changes its duration,the upcoming occurrences move
+// by as many days as the start does to the new wall clock time in the clinic. Like a booking every moved
+// occurrence is checked first and a SeriesError reports the dates that failed,the occurrences that
+// already passed or were cancelled stay as they are.
+func (service *Service) UpdateSeries(ctx context.Context, series models.Series, actor models.Actor) (models.Series, []models.Appointment, error) {
+	var updated models.Series
+	var moved []models.Appointment
+	err := service.atomically(ctx, func(tx *Service) error {
+		current, err := tx.SeriesService.Find(ctx, series.Seriesid)
+		if err != nil {
+			return err
+		}
+		loc := tx.Clinic()
+		start := series.Start.In(loc)
+		// the days are a whole number apart,rounding keeps a daylight saving change from dropping one
+		shift := int(models.Day(start, loc).Sub(models.Day(current.Start, loc)).Hours()+12) / 24
+		occurrences, err := tx.upcoming(ctx, current.Seriesid)
+		if err != nil {
+			return err
+		}
+		movedids := make(map[int]bool)
+		for i, occurrence := range occurrences {
+			day := occurrence.Appointmentdate.In(loc)
+			occurrence.Appointmentdate = time.Date(day.Year(), day.Month(), day.Day()+shift, start.Hour(), start.Minute(), 0, 0, loc)
+			occurrence.Duration = series.Duration
+			occurrences[i] = occurrence
+			movedids[occurrence.Appointmentid] = true
+		}
+		current.Start, current.Duration = series.Start, series.Duration
+		if len(occurrences) == 0 {
+			updated, err = tx.SeriesService.Update(ctx, current)
+			return err
+		}
+		if err := tx.lockbooking(ctx, occurrences[0]); err != nil {
+			return err
+		}
+		if err := tx.checkduration(ctx, occurrences[0]); err != nil {
+			return err
+		}
+		appointments, err := tx.AppointmentService.FindAllByDoctor(ctx, current.Doctorid)
+		if err != nil {
+			return err
+		}
+		patientappointments, err := tx.AppointmentService.FindAllByPatient(ctx, current.Patientid)
+		if err != nil {
+			return err
+		}
+		// the occurrences being moved free their old times
+		var others []models.Appointment
+		for _, appointment := range append(appointments, patientappointments...) {
+			if !movedids[appointment.Appointmentid] {
+				others = append(others, appointment)
+			}
+		}
+		if err := tx.checkoccurrences(ctx, others, occurrences); err != nil {
+			return err
+		}
+		for _, occurrence := range occurrences {
+			appointment, err := tx.updateappointment(ctx, occurrence, actor)
+			if err != nil {
+				return err
+			}
+			moved = append(moved, appointment)
+		}
+		updated, err = tx.SeriesService.Update(ctx, current)
+		return err
+	})
+	if err != nil {
+		return models.Series{}, nil, err
+	}
+	return updated, moved, nil
+}
+
+// CancelSeries cancels the upcoming occurrences of the series on behalf of actor,
+// each is cancelled through the lifecycle so the cancellations are recorded with the reason.
+func (service *Service) CancelSeries(ctx context.Context, id int, actor models.Actor, reason string) ([]models.Appointment, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrReasonRequired
+	}
+	return atomicallyReturning(ctx, service, func(tx *Service) ([]models.Appointment, error) {
+		if _, err := tx.SeriesService.Find(ctx, id); err != nil {
+			return nil, err
+		}
+		occurrences, err := tx.upcoming(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		var cancelled []models.Appointment
+		for _, occurrence := range occurrences {
+			if !occurrence.Status.CanMoveTo(models.StatusCancelled) {
+				continue
+			}
+			appointment, err := tx.TransitionAppointment(ctx, occurrence.Appointmentid, models.StatusCancelled, actor, reason)
+			if err != nil {
+				return nil, err
+			}
+			cancelled = append(cancelled, appointment)
+		}
+		return cancelled, nil
+	})
+}
@@ -1,14 +1,17 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"strconv"
-	"strings"
+	"fmt"
 	"time"
 
 	_ "github.com/lib/pq"
+	"github.com/patienttracker/internal/config"
 	"github.com/patienttracker/internal/controllers"
+	"github.com/patienttracker/internal/events"
+	"github.com/patienttracker/internal/inmem"
 	"github.com/patienttracker/internal/models"
 	"github.com/unidoc/unipdf/v3/creator"
 )
@@ -23,18 +26,44 @@ type Rbac struct {
 type Service struct {
 	DoctorService        models.Physicianrepository
 	AppointmentService   models.AppointmentRepository
+	SeriesService        models.SeriesRepository
 	ScheduleService      models.Schedulerepositroy
+	ExceptionService     models.ScheduleExceptionRepository
+	TransitionService    models.TransitionRepository
+	ReminderService      models.ReminderRepository
+	WaitlistService      models.WaitlistRepository
 	PatientService       models.PatientRepository
 	DepartmentService    models.Departmentrepository
 	NurseService         models.Nurserepository
 	PatientRecordService models.Patientrecordsrepository
+	TicketService        models.TicketRepository
 	RbacService          Rbac
-	Creator              creator.Creator
+	SessionService       models.Sessionrepository
+	CalendarService      models.CalendarFeedRepository
+	OutboxService        models.OutboxRepository
+	// UnitOfWork makes the methods writing more than once atomic,without one they write as they go
+	UnitOfWork models.UnitOfWork
+	Creator    creator.Creator
+	// Location is the time zone of the clinic,the working hours of the schedules are read in it
+	Location *time.Location
+	// OfferDuration is how long a freed slot is held for the patient on the waitlist it's offered to
+	OfferDuration time.Duration
+	// ReminderOffsets are how long before an appointment its reminders are sent,
+	// nil uses the defaults while an empty list sends none
+	ReminderOffsets []time.Duration
+	// TriageTargets are how long a patient of each acuity may wait for the nurse,
+	// from the most urgent to the least. Nil uses the defaults
+	TriageTargets []time.Duration
+	// Events is the bus the dashboards hear of the changes to tickets & appointments on
+	Events *events.Bus
+	// Outbox holds the subscribers of the events published
+	Outbox *Outbox
+	// published is set once the transaction running wrote to the outbox,the dispatcher is woken after it commits
+	published *bool
+	// pending holds the events of the transaction running for the bus,they're published once it commits
+	pending *[]events.Event
 }
 
-// t wil be the string use to format the appointment dates into 24hr string
-const t = "15:00"
-
 var (
 	ErrInvalidSchedule    = errors.New("no active shedule found for this doctor")
 	ErrTimeSlotAllocated  = errors.New("this time slot is already booked")
@@ -44,210 +73,387 @@ var (
 	ErrInvalidPermissions = errors.New("no such permission available")
 	ErrNotAuthorized      = errors.New("you don't have the required permissions to execute this task")
 	ErrForbidden          = errors.New("Forbidden")
+	ErrInvalidDuration    = errors.New("appointment duration should be a positive number of minutes")
+	ErrDurationNotAllowed = errors.New("the doctor's department doesn't offer appointments of this duration")
+	ErrDoctorAway         = errors.New("the doctor is away at this time")
+	ErrInvalidHours       = errors.New("working hours should be HH:MM times ending after they start without overlapping")
+	ErrInvalidRange       = errors.New("the search should end after it starts and span at most 31 days")
+	ErrInvalidTransition  = errors.New("the appointment can't move to this status from its current one")
+	ErrReasonRequired     = errors.New("a reason is required to cancel an appointment")
+	ErrNotStarted         = errors.New("the appointment hasn't started yet")
+	ErrPastDay            = errors.New("this day has already passed")
+	ErrOfferExpired       = errors.New("the offered slot has expired")
+	ErrInvalidRecurrence  = errors.New("a series repeats every day or week at least,either a number of times up to 52 or until a day")
+	ErrSeriesConflict     = errors.New("some occurrences of the series can't be booked")
+	ErrInvalidAcuity      = errors.New("acuity should be from 1,the most urgent,to 5")
+	ErrInvalidTicketMove  = errors.New("the ticket can't move to this status from its current one")
+	ErrNoDoctorAvailable  = errors.New("the department has no doctor to refer the patient to")
 )
 
-func NewService(conn *sql.DB) Service {
-	controllers := controllers.New(conn)
-	return Service{
+// NewService wires the repositories of the configured storage driver,
+// conn is only used by the postgres driver and may be nil with the memory driver.
+func NewService(conn *sql.DB, c config.Config) (Service, error) {
+	if err := setupLicense(c.Pdf.LicenseKey); err != nil {
+		return Service{}, fmt.Errorf("unipdf license: %w", err)
+	}
+	if c.Database.Driver == config.MemoryDriver {
+		service := NewMemService()
+		service.Location = c.Clinic.Location
+		service.OfferDuration = c.Clinic.OfferDuration
+		service.ReminderOffsets = c.Reminder.Offsets
+		service.TriageTargets = c.Triage.Targets
+		service.Outbox.MaxAttempts = c.Outbox.MaxAttempts
+		return service, nil
+	}
+	controllers := controllers.New(conn, c.Database.QueryTimeout)
+	service := Service{
 		DoctorService: controllers.Doctors, AppointmentService: &controllers.Appointment, ScheduleService: controllers.Schedule,
+		ExceptionService:     &controllers.Exceptions,
+		SeriesService:        &controllers.Series,
+		TransitionService:    &controllers.Transitions,
+		ReminderService:      &controllers.Reminders,
+		WaitlistService:      &controllers.Waitlists,
 		PatientService:       controllers.Patient,
 		DepartmentService:    controllers.Department,
 		PatientRecordService: controllers.Records,
+		TicketService:        &controllers.Tickets,
 		RbacService: Rbac{
 			RolesService:       &controllers.Roles,
 			UsersService:       &controllers.Users,
 			PermissionsService: &controllers.Permissions,
 		},
-		NurseService: &controllers.Nurse,
-		Creator:      NewCreator(),
+		NurseService:    &controllers.Nurse,
+		SessionService:  &controllers.Session,
+		CalendarService: &controllers.Calendars,
+		OutboxService:   &controllers.Outbox,
+		UnitOfWork:      controllers.UnitOfWork,
+		Creator:         NewCreator(),
+		Location:        c.Clinic.Location,
+		OfferDuration:   c.Clinic.OfferDuration,
+		ReminderOffsets: c.Reminder.Offsets,
+		TriageTargets:   c.Triage.Targets,
+		Events:          events.NewBus(),
+		Outbox:          NewOutbox(c.Outbox.MaxAttempts),
+	}
+	service.waitlist()
+	return service, nil
+}
+
+// NewMemService returns a service backed by the in memory repositories,nothing survives a restart
+func NewMemService() Service {
+	store := inmem.NewMockStore()
+	service := Service{
+		DoctorService:        store.DoctorMemStore,
+		AppointmentService:   store.AppointmentMemStore,
+		SeriesService:        store.SeriesMemStore,
+		ScheduleService:      store.ScheduleMemStore,
+		ExceptionService:     store.ExceptionMemStore,
+		TransitionService:    store.TransitionMemStore,
+		ReminderService:      store.ReminderMemStore,
+		WaitlistService:      store.WaitlistMemStore,
+		PatientService:       store.PatientMemStore,
+		DepartmentService:    store.DepartmentMemStore,
+		PatientRecordService: store.RecordMemStore,
+		TicketService:        store.TicketMemStore,
+		RbacService: Rbac{
+			RolesService:       store.RolesMemStore,
+			UsersService:       store.UsersMemStore,
+			PermissionsService: store.PermissionsMemStore,
+		},
+		NurseService:    store.NurseMemStore,
+		SessionService:  store.SessionMemStore,
+		CalendarService: store.CalendarMemStore,
+		OutboxService:   store.OutboxMemStore,
+		UnitOfWork:      store.UnitOfWork,
+		Creator:         NewCreator(),
+		Location:        time.UTC,
+		Events:          events.NewBus(),
+		Outbox:          NewOutbox(config.Defaults().Outbox.MaxAttempts),
+	}
+	service.waitlist()
+	return service
+}
+
+// Clinic returns the time zone of the clinic,UTC when Location isn't set
+func (service *Service) Clinic() *time.Location {
+	if service.Location == nil {
+		return time.UTC
 	}
+	return service.Location
 }
 
-// checks if the time scheduled falls between an appointment already booked with its duration and date
-func isTimeWithinAppointment(start, end, check time.Time) bool {
-	if check.Equal(end) && check.After(start) {
-		return true
+// atomically runs fn with a copy of the service whose repositories share one transaction,
+// a nested call joins the transaction it runs in.
+func (service *Service) atomically(ctx context.Context, fn func(tx *Service) error) error {
+	if service.UnitOfWork == nil {
+		return fn(service)
+	}
+	var published bool
+	var pending []events.Event
+	err := service.UnitOfWork.Do(ctx, func(r models.Repositories) error {
+		published = false
+		pending = nil
+		tx := *service
+		tx.published = &published
+		tx.pending = &pending
+		tx.PatientService = r.Patients
+		tx.DoctorService = r.Doctors
+		tx.NurseService = r.Nurses
+		tx.DepartmentService = r.Departments
+		tx.AppointmentService = r.Appointments
+		tx.SeriesService = r.Series
+		tx.ScheduleService = r.Schedules
+		tx.ExceptionService = r.Exceptions
+		tx.TransitionService = r.Transitions
+		tx.ReminderService = r.Reminders
+		tx.WaitlistService = r.Waitlists
+		tx.PatientRecordService = r.Records
+		tx.TicketService = r.Tickets
+		tx.RbacService = Rbac{RolesService: r.Roles, UsersService: r.Users, PermissionsService: r.Permissions}
+		tx.SessionService = r.Sessions
+		tx.CalendarService = r.Calendars
+		tx.OutboxService = r.Outbox
+		tx.UnitOfWork = nil
+		return fn(&tx)
+	})
+	if err != nil {
+		return err
 	}
-	if check.Equal(start) && check.Before(end) {
-		return true
+	if published {
+		service.Outbox.notify()
 	}
-	if start.Equal(check) {
-		return true
+	for _, e := range pending {
+		service.Events.Publish(e)
 	}
-	return check.After(start) && check.Before(end)
-}
-
-// This function checks if the time being booked is within the doctors schedule
-func isTimeWithinSchedule(start, end, booked int64) bool {
-	return booked >= start && booked < end
+	return nil
 }
 
-// this function converts time string into a float64 so something like 14:30
-// will be 14.0 then the withintimeframe will check if the time is between the doctors schedule
-func formatstring(s string) int64 {
-	newstring := strings.Split(s, ":")
-	stringtime := strings.Join(newstring, ".")
-	time, _ := strconv.ParseFloat(stringtime, 64)
-	return int64(time)
+// atomicallyReturning is atomically for the methods returning what they wrote
+func atomicallyReturning[T any](ctx context.Context, service *Service, fn func(tx *Service) (T, error)) (T, error) {
+	var result T
+	err := service.atomically(ctx, func(tx *Service) error {
+		var err error
+		result, err = fn(tx)
+		return err
+	})
+	return result, err
 }
 
-func (service *Service) getallschedules(id int) ([]models.Schedule, error) {
-	schedules, err := service.ScheduleService.FindbyDoctor(id)
+func (service *Service) getallschedules(ctx context.Context, id int) ([]models.Schedule, error) {
+	schedules, err := service.ScheduleService.FindbyDoctor(ctx, id)
 	return schedules, err
 }
 
-func (service *Service) CreateAdmin(email string, password string) (models.Users, error) {
-	hashedpass, err := HashPassword(password)
+// checkavailability errors unless the schedule of the doctor covers the whole appointment
+// and the doctor isn't away at any time during it,the schedule is read in the clinic's zone
+// whatever zone the appointment came in.
+func (service *Service) checkavailability(ctx context.Context, appointment models.Appointment) error {
+	schedules, err := service.getallschedules(ctx, appointment.Doctorid)
 	if err != nil {
-		return models.Users{}, err
+		return err
 	}
-	var role models.Roles
-	role, err = service.RbacService.RolesService.FindbyRole("admin")
-	if err != nil {
-		if err == sql.ErrNoRows {
-			role, err = service.RbacService.RolesService.Create(models.Roles{
-				Role: "admin",
-			})
-			if err != nil {
-				return models.Users{}, err
-			}
-		}
-		return models.Users{}, err
+	start := appointment.Appointmentdate.In(service.Clinic())
+	schedule, ok := checkschedule(schedules, start)
+	if !ok {
+		return ErrInvalidSchedule
 	}
-	admin, err := service.RbacService.UsersService.Create(models.Users{
-		Email:    email,
-		Password: hashedpass,
-		Roleid:   role.Roleid,
-	})
+	if !schedule.Covers(start, start.Add(appointment.Duration)) {
+		return ErrNotWithinSchedule
+	}
+	exceptions, err := service.ExceptionService.FindbyDoctor(ctx, appointment.Doctorid)
 	if err != nil {
-		return models.Users{}, err
+		return err
 	}
-	if _, err = service.RbacService.PermissionsService.Create(models.Permissions{
-		Roleid:     role.Roleid,
-		Permission: Admin.toString(),
-	}); err != nil {
-		return models.Users{}, err
+	for _, exception := range exceptions {
+		if exception.Overlaps(appointment.Appointmentdate, appointment.End()) {
+			return ErrDoctorAway
+		}
 	}
-	return admin, err
+	return nil
 }
 
-func (service *Service) UpdateRolePermissions(permissions []string, roleid int) error {
-	var oldpermissions []string
-	permissionfrequency := make(map[string]int)
-	availableperimissions, err := service.RbacService.PermissionsService.FindbyRoleId(roleid)
+func (service *Service) CreateAdmin(ctx context.Context, email string, password string) (models.Users, error) {
+	hashedpass, err := HashPassword(password)
 	if err != nil {
-		return err
+		return models.Users{}, err
 	}
-	if permissions == nil {
-		for _, permissions := range availableperimissions {
-			err = service.RbacService.PermissionsService.Delete(permissions.Permissionid)
+	// the admin role,the user & its permission are created together or not at all
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Users, error) {
+		role, err := tx.RbacService.RolesService.FindbyRole(ctx, "admin")
+		if err != nil {
+			if err == sql.ErrNoRows {
+				role, err = tx.RbacService.RolesService.Create(ctx, models.Roles{
+					Role: "admin",
+				})
+			}
 			if err != nil {
-				return err
+				return models.Users{}, err
 			}
 		}
-	}
-	for _, perm := range availableperimissions {
-		oldpermissions = append(oldpermissions, perm.Permission)
-	}
-	concatpermissions := append(oldpermissions, permissions...)
-	for _, perm := range concatpermissions {
-		permissionfrequency[perm] += 1
-	}
-	for _, perm := range oldpermissions {
-		if permissionfrequency[perm] == 1 {
-			permissionfrequency[perm] -= 1
+		admin, err := tx.RbacService.UsersService.Create(ctx, models.Users{
+			Email:    email,
+			Password: hashedpass,
+			Roleid:   role.Roleid,
+		})
+		if err != nil {
+			return models.Users{}, err
 		}
-	}
-	for permission := range permissionfrequency {
-		switch {
-		case permissionfrequency[permission] == 0:
-			var perm_ids []int
-			for _, perm := range availableperimissions {
-				if perm.Permission == permission {
-					perm_ids = append(perm_ids, perm.Permissionid)
+		if _, err = tx.RbacService.PermissionsService.Create(ctx, models.Permissions{
+			Roleid:     role.Roleid,
+			Permission: Admin.toString(),
+		}); err != nil {
+			return models.Users{}, err
+		}
+		return admin, err
+	})
+}
+
+func (service *Service) UpdateRolePermissions(ctx context.Context, permissions []string, roleid int) error {
+	return service.atomically(ctx, func(tx *Service) error {
+		var oldpermissions []string
+		permissionfrequency := make(map[string]int)
+		availableperimissions, err := tx.RbacService.PermissionsService.FindbyRoleId(ctx, roleid)
+		if err != nil {
+			return err
+		}
+		if permissions == nil {
+			for _, permissions := range availableperimissions {
+				err = tx.RbacService.PermissionsService.Delete(ctx, permissions.Permissionid)
+				if err != nil {
+					return err
 				}
 			}
-			for _, id := range perm_ids {
-				service.RbacService.PermissionsService.Delete(id)
+		}
+		for _, perm := range availableperimissions {
+			oldpermissions = append(oldpermissions, perm.Permission)
+		}
+		concatpermissions := append(oldpermissions, permissions...)
+		for _, perm := range concatpermissions {
+			permissionfrequency[perm] += 1
+		}
+		for _, perm := range oldpermissions {
+			if permissionfrequency[perm] == 1 {
+				permissionfrequency[perm] -= 1
 			}
-		case permissionfrequency[permission] == 1:
-			_, err := service.RbacService.PermissionsService.Create(models.Permissions{
-				Permission: permission,
-				Roleid:     roleid,
-			})
-			if err != nil {
-				return err
+		}
+		for permission := range permissionfrequency {
+			switch {
+			case permissionfrequency[permission] == 0:
+				var perm_ids []int
+				for _, perm := range availableperimissions {
+					if perm.Permission == permission {
+						perm_ids = append(perm_ids, perm.Permissionid)
+					}
+				}
+				for _, id := range perm_ids {
+					if err := tx.RbacService.PermissionsService.Delete(ctx, id); err != nil {
+						return err
+					}
+				}
+			case permissionfrequency[permission] == 1:
+				_, err := tx.RbacService.PermissionsService.Create(ctx, models.Permissions{
+					Permission: permission,
+					Roleid:     roleid,
+				})
+				if err != nil {
+					return err
+				}
+			case permissionfrequency[permission] == 2:
+				// Do nothing because the permissions remain the same
+			default:
 			}
-		case permissionfrequency[permission] == 2:
-			// Do nothing because the permissions remain the same
-		default:
+			delete(permissionfrequency, permission)
 		}
-		delete(permissionfrequency, permission)
-	}
-	return nil
+		return nil
+	})
 }
 
-func (service *Service) PatientBookAppointment(appointment models.Appointment) (models.Appointment, error) {
-	var appointment_created models.Appointment
-	//Start by checking the work schedule of the doctor so as to
-	//enable booking for Appointments with the Doctor within doctor's work hours
-	schedules, _ := service.getallschedules(appointment.Doctorid)
+// lockbooking holds off the other bookings of the doctor & the patient until the transaction ends,
+// otherwise two bookings of the same slot could both find it free and both be inserted.
+func (service *Service) lockbooking(ctx context.Context, appointment models.Appointment) error {
+	return service.AppointmentService.Lock(ctx, appointment.Doctorid, appointment.Patientid)
+}
 
-	if schedule, ok := checkschedule(schedules); ok {
-		//we check if the time being booked is within the working hours of doctors schedule
-		//checks if the appointment boooked is within the doctors schedule
-		//if not it errors with ErrWithinTime
-		if isTimeWithinSchedule(formatstring(schedule.Starttime), formatstring(schedule.Endtime), formatstring(appointment.Appointmentdate.Format(t))) {
-			appointments, _ := service.AppointmentService.FindAllByPatient(appointment.Patientid)
-			//add appointment after all checks have passed
-			appointment_created, err := service.addappointment(appointments, appointment)
+func (service *Service) PatientBookAppointment(ctx context.Context, appointment models.Appointment) (models.Appointment, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Appointment, error) {
+		var appointment_created models.Appointment
+		if err := tx.lockbooking(ctx, appointment); err != nil {
+			return models.Appointment{}, err
+		}
+		if err := tx.checkduration(ctx, appointment); err != nil {
 			return appointment_created, err
 		}
-		return appointment_created, ErrNotWithinSchedule
-	}
-	return appointment_created, ErrInvalidSchedule
-
+		//Start by checking the work schedule of the doctor so as to
+		//enable booking for Appointments with the Doctor within doctor's work hours
+		if err := tx.checkavailability(ctx, appointment); err != nil {
+			return appointment_created, err
+		}
+		if err := tx.checkoffers(ctx, appointment); err != nil {
+			return appointment_created, err
+		}
+		appointments, err := tx.AppointmentService.FindAllByPatient(ctx, appointment.Patientid)
+		if err != nil {
+			return appointment_created, err
+		}
+		// the slot belongs to the doctor,it mustn't clash with the doctor's other patients either
+		doctorappointments, err := tx.AppointmentService.FindAllByDoctor(ctx, appointment.Doctorid)
+		if err != nil {
+			return appointment_created, err
+		}
+		//add appointment after all checks have passed
+		return tx.addappointment(ctx, append(appointments, doctorappointments...), appointment)
+	})
 }
-func (service *Service) DoctorBookAppointment(appointment models.Appointment) (models.Appointment, error) {
-	var appointment_created models.Appointment
-	//Start by checking the work schedule of the doctor so as to
-	//enable booking for Appointments with the Doctor within doctor's work hours
-	schedules, err := service.getallschedules(appointment.Doctorid)
-	if err != nil {
-		return appointment, err
-	}
-	if schedule, ok := checkschedule(schedules); ok {
-		//we check if the time being booked is within the working hours of doctors schedule
-		//checks if the appointment boooked is within the doctors schedule
-		//if not it errors with ErrWithinTime
-
-		if isTimeWithinSchedule(formatstring(schedule.Starttime), formatstring(schedule.Endtime), formatstring(appointment.Appointmentdate.Format(t))) {
-			appointments, err := service.AppointmentService.FindAllByDoctor(appointment.Doctorid)
-			if err != nil {
-				return appointment_created, err
-			}
-			//add appointment after all checks have passed
-			appointment_created, err := service.addappointment(appointments, appointment)
+func (service *Service) DoctorBookAppointment(ctx context.Context, appointment models.Appointment) (models.Appointment, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Appointment, error) {
+		var appointment_created models.Appointment
+		if err := tx.lockbooking(ctx, appointment); err != nil {
+			return models.Appointment{}, err
+		}
+		if err := tx.checkduration(ctx, appointment); err != nil {
 			return appointment_created, err
 		}
-		return appointment_created, ErrNotWithinSchedule
-	}
-	return appointment_created, ErrInvalidSchedule
+		//Start by checking the work schedule of the doctor so as to
+		//enable booking for Appointments with the Doctor within doctor's work hours
+		if err := tx.checkavailability(ctx, appointment); err != nil {
+			return appointment_created, err
+		}
+		if err := tx.checkoffers(ctx, appointment); err != nil {
+			return appointment_created, err
+		}
+		// the patient mustn't be booked over the time they spend with another doctor either
+		appointments, err := tx.AppointmentService.FindAllByPatient(ctx, appointment.Patientid)
+		if err != nil {
+			return appointment_created, err
+		}
+		doctorappointments, err := tx.AppointmentService.FindAllByDoctor(ctx, appointment.Doctorid)
+		if err != nil {
+			return appointment_created, err
+		}
+		//add appointment after all checks have passed
+		return tx.addappointment(ctx, append(appointments, doctorappointments...), appointment)
+	})
 }
 
-// method to add an appointment
-func (service *Service) addappointment(appointments []models.Appointment, appointment models.Appointment) (models.Appointment, error) {
+// method to add an appointment,it's booked as requested unless it's approved straight away
+func (service *Service) addappointment(ctx context.Context, appointments []models.Appointment, appointment models.Appointment) (models.Appointment, error) {
 	var newappointment models.Appointment
 	var err error
+	if appointment.Status == "" {
+		appointment.Status = models.StatusRequested
+	}
+	if appointment.Status != models.StatusRequested && appointment.Status != models.StatusApproved {
+		return newappointment, ErrInvalidTransition
+	}
 	if appointment.Outbound {
-		newappointment, err = service.AppointmentService.Create(appointment)
+		newappointment, err = service.createappointment(ctx, appointment)
 		if err != nil {
 			return appointment, err
 		}
 		return newappointment, nil
 	}
 	if appointments == nil {
-		newappointment, err = service.AppointmentService.Create(appointment)
+		newappointment, err = service.createappointment(ctx, appointment)
 		if err != nil {
 			return appointment, err
 		}
@@ -256,149 +462,218 @@ func (service *Service) addappointment(appointments []models.Appointment, appoin
 	if err := checkbooked(appointments, appointment); err != nil {
 		return newappointment, err
 	}
-	newappointment, err = service.AppointmentService.Create(appointment)
+	newappointment, err = service.createappointment(ctx, appointment)
 	if err != nil {
 		return newappointment, err
 	}
 	return newappointment, nil
 }
 
+// checkbooked errors with ErrTimeSlotAllocated when the appointment overlaps one holding its slot,
+// it checks the whole range so a long appointment can't swallow a shorter one either.
 func checkbooked(appointments []models.Appointment, appointment models.Appointment) error {
 	for _, apntmnt := range appointments {
-		duration, err := time.ParseDuration(apntmnt.Duration)
-		if err != nil {
-			return err
-		}
-		endtime := apntmnt.Appointmentdate.Add(duration)
-		// checks if there's a booked slot and is approved
-		// if there's an appointment within this timeframe it errors with ErrTimeSlotAllocate
-		if isTimeWithinAppointment(apntmnt.Appointmentdate, endtime, appointment.Appointmentdate) && apntmnt.Approval && appointment.Appointmentid != apntmnt.Appointmentid {
+		if apntmnt.Approved() && appointment.Appointmentid != apntmnt.Appointmentid && appointment.Overlaps(apntmnt) {
 			return ErrTimeSlotAllocated
 		}
 	}
 	return nil
 }
 
-func (service *Service) UpdateappointmentbyDoctor(appointment models.Appointment) (models.Appointment, error) {
-	var updatedappointment models.Appointment
-	schedules, err := service.getallschedules(appointment.Doctorid)
+// checkduration errors unless the appointment lasts a whole number of minutes
+// that the department of its doctor offers
+func (service *Service) checkduration(ctx context.Context, appointment models.Appointment) error {
+	if appointment.Duration <= 0 || appointment.Duration%time.Minute != 0 {
+		return ErrInvalidDuration
+	}
+	doctor, err := service.DoctorService.Find(ctx, appointment.Doctorid)
 	if err != nil {
-		return updatedappointment, err
+		return err
 	}
-	if appointment.Outbound {
-		updatedappointment, err = service.AppointmentService.Update(appointment)
-		if err != nil {
-			return updatedappointment, err
-		}
-		return updatedappointment, nil
+	department, err := service.DepartmentService.FindbyName(ctx, doctor.Departmentname)
+	if err != nil {
+		return err
 	}
-	if schedule, ok := checkschedule(schedules); ok {
-		if isTimeWithinSchedule(formatstring(schedule.Starttime), formatstring(schedule.Endtime), formatstring(appointment.Appointmentdate.Format(t))) {
-			appointments, err := service.AppointmentService.FindAllByDoctor(appointment.Doctorid)
-			if err != nil {
-				return updatedappointment, err
-			}
-			if err := checkbooked(appointments, appointment); err != nil {
-				return updatedappointment, err
-			}
-			updatedappointment, err = service.AppointmentService.Update(appointment)
-			if err != nil {
-				return updatedappointment, err
-			}
-			return updatedappointment, nil
-		}
-		return updatedappointment, ErrNotWithinSchedule
+	if !department.Allows(appointment.Duration) {
+		return ErrDurationNotAllowed
 	}
-	return updatedappointment, ErrInvalidSchedule
+	return nil
 }
 
-func (service *Service) UpdateappointmentbyPatient(appointment models.Appointment) (models.Appointment, error) {
-	var updatedappointment models.Appointment
-	schedules, err := service.getallschedules(appointment.Doctorid)
-	if err != nil {
-		return updatedappointment, err
-	}
-	if appointment.Outbound {
-		updatedappointment, err = service.AppointmentService.Update(appointment)
+// UpdateappointmentbyDoctor moves the appointment to another time or changes its duration,
+// the status only changes through the lifecycle so moving an approved appointment reschedules it.
+func (service *Service) UpdateappointmentbyDoctor(ctx context.Context, appointment models.Appointment, actor models.Actor) (models.Appointment, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Appointment, error) {
+		return tx.moveappointment(ctx, appointment, actor)
+	})
+}
+
+// UpdateappointmentbyPatient is UpdateappointmentbyDoctor for the patient,
+// the patient can't hand the appointment to another doctor or patient so they're kept.
+func (service *Service) UpdateappointmentbyPatient(ctx context.Context, appointment models.Appointment, actor models.Actor) (models.Appointment, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Appointment, error) {
+		current, err := tx.AppointmentService.Find(ctx, appointment.Appointmentid)
 		if err != nil {
-			return updatedappointment, err
+			return models.Appointment{}, err
 		}
-		return updatedappointment, nil
+		appointment.Doctorid, appointment.Patientid = current.Doctorid, current.Patientid
+		return tx.moveappointment(ctx, appointment, actor)
+	})
+}
+
+// moveappointment checks the new time of the appointment before writing it,it mustn't clash with
+// the appointments of the doctor nor with the ones of the patient since a moved appointment keeps holding its slot.
+func (service *Service) moveappointment(ctx context.Context, appointment models.Appointment, actor models.Actor) (models.Appointment, error) {
+	if err := service.lockbooking(ctx, appointment); err != nil {
+		return models.Appointment{}, err
 	}
-	if _, ok := checkschedule(schedules); ok {
-		appointments, err := service.AppointmentService.FindAllByPatient(appointment.Patientid)
-		if err != nil {
-			return updatedappointment, err
+	if err := service.checkduration(ctx, appointment); err != nil {
+		return models.Appointment{}, err
+	}
+	if appointment.Outbound {
+		return service.updateappointment(ctx, appointment, actor)
+	}
+	if err := service.checkavailability(ctx, appointment); err != nil {
+		return models.Appointment{}, err
+	}
+	if err := service.checkoffers(ctx, appointment); err != nil {
+		return models.Appointment{}, err
+	}
+	appointments, err := service.AppointmentService.FindAllByDoctor(ctx, appointment.Doctorid)
+	if err != nil {
+		return models.Appointment{}, err
+	}
+	patientappointments, err := service.AppointmentService.FindAllByPatient(ctx, appointment.Patientid)
+	if err != nil {
+		return models.Appointment{}, err
+	}
+	if err := checkbooked(append(appointments, patientappointments...), appointment); err != nil {
+		return models.Appointment{}, err
+	}
+	return service.updateappointment(ctx, appointment, actor)
+}
+
+// validateschedule checks the working hours are minute precise times with each block ending after it starts,
+// the blocks of a weekday mustn't overlap and the validity mustn't end before it starts
+func validateschedule(schedule models.Schedule) error {
+	if len(schedule.Blocks) == 0 || schedule.Starttime != "" || schedule.Endtime != "" {
+		if !validhours(schedule.Starttime, schedule.Endtime) {
+			return ErrInvalidHours
 		}
-		if err := checkbooked(appointments, appointment); err != nil {
-			return updatedappointment, err
+	}
+	for _, block := range schedule.Blocks {
+		if block.Weekday < time.Sunday || block.Weekday > time.Saturday || !validhours(block.Starttime, block.Endtime) {
+			return ErrInvalidHours
 		}
-		if !appointment.Approval {
-			updatedappointment, err = service.AppointmentService.Update(appointment)
-			if err != nil {
-				return updatedappointment, err
+	}
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		// the blocks come sorted by their start,each has to start once the ones before it ended
+		var end time.Duration
+		for _, block := range schedule.BlocksOn(weekday) {
+			start, _ := models.ParseClock(block.Starttime)
+			if start < end {
+				return ErrInvalidHours
 			}
-			return updatedappointment, nil
+			end, _ = models.ParseClock(block.Endtime)
 		}
-		return updatedappointment, errors.New("can't update an approved appointment")
 	}
-	return updatedappointment, ErrInvalidSchedule
-
+	if !schedule.Validfrom.IsZero() && !schedule.Validuntil.IsZero() && schedule.Validuntil.Before(schedule.Validfrom) {
+		return ErrInvalidHours
+	}
+	return nil
 }
-func (service *Service) MakeSchedule(schedule models.Schedule) (models.Schedule, error) {
-	schedules, err := service.ScheduleService.FindbyDoctor(schedule.Doctorid)
+
+func validhours(starttime, endtime string) bool {
+	start, err := models.ParseClock(starttime)
 	if err != nil {
-		return models.Schedule{}, err
+		return false
 	}
-	for i := 0; i < len(schedules); i++ {
-		//checks if there's an active schedule already
-		if schedules[i].Active && schedule.Active {
-			return schedule, ErrScheduleActive
+	end, err := models.ParseClock(endtime)
+	return err == nil && end > start
+}
+
+// checkactive errors when the schedule is active along with another schedule of the doctor
+// on some day,a doctor may have several active schedules valid on different days.
+func checkactive(schedules []models.Schedule, schedule models.Schedule) error {
+	if !schedule.Active {
+		return nil
+	}
+	for _, other := range schedules {
+		if other.Scheduleid != schedule.Scheduleid && other.Active && other.ValidityOverlaps(schedule) {
+			return ErrScheduleActive
 		}
 	}
-	schedule, err = service.ScheduleService.Create(schedule)
-	if err != nil {
-		return schedule, err
-	}
-	return schedule, nil
+	return nil
 }
 
-func (service *Service) UpdateSchedule(schedule models.Schedule) (models.Schedule, error) {
-	var newschedule models.Schedule
-	schedules, err := service.ScheduleService.FindbyDoctor(schedule.Doctorid)
-	if err != nil {
-		return newschedule, err
+func (service *Service) MakeSchedule(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
+	if err := validateschedule(schedule); err != nil {
+		return schedule, err
 	}
-	for i := 0; i < len(schedules); i++ {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Schedule, error) {
+		schedules, err := tx.ScheduleService.FindbyDoctor(ctx, schedule.Doctorid)
+		if err != nil {
+			return models.Schedule{}, err
+		}
 		//checks if there's an active schedule already
-		if schedules[i].Active && schedule.Active {
-			return schedule, ErrScheduleActive
+		if err := checkactive(schedules, schedule); err != nil {
+			return schedule, err
 		}
+		return tx.ScheduleService.Create(ctx, schedule)
+	})
+}
+
+func (service *Service) UpdateSchedule(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
+	if err := validateschedule(schedule); err != nil {
+		return schedule, err
 	}
-	if _, err := service.ScheduleService.Find(schedule.Scheduleid); err == nil {
-		if newschedule, err = service.ScheduleService.Update(schedule); err != nil {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Schedule, error) {
+		var newschedule models.Schedule
+		existing, err := tx.ScheduleService.Find(ctx, schedule.Scheduleid)
+		if err != nil {
+			return newschedule, errors.New("no schedule found")
+		}
+		// the schedule stays with its doctor
+		schedule.Doctorid = existing.Doctorid
+		schedules, err := tx.ScheduleService.FindbyDoctor(ctx, schedule.Doctorid)
+		if err != nil {
 			return newschedule, err
 		}
-		return newschedule, nil
+		//checks if there's an active schedule already
+		if err := checkactive(schedules, schedule); err != nil {
+			return schedule, err
+		}
+		return tx.ScheduleService.Update(ctx, schedule)
+	})
+}
+
+// MakeScheduleException records time off of the doctor,the appointments already booked during it are left as they are
+func (service *Service) MakeScheduleException(ctx context.Context, exception models.ScheduleException) (models.ScheduleException, error) {
+	if !exception.Endtime.After(exception.Starttime) {
+		return exception, ErrInvalidHours
+	}
+	if _, err := service.DoctorService.Find(ctx, exception.Doctorid); err != nil {
+		return exception, err
 	}
-	return newschedule, errors.New("no schedule found")
+	return service.ExceptionService.Create(ctx, exception)
 }
 
-func checkschedule(schedules []models.Schedule) (models.Schedule, bool) {
+// checkschedule returns the active schedule that applies on day
+func checkschedule(schedules []models.Schedule, day time.Time) (models.Schedule, bool) {
 	for _, schedule := range schedules {
 		//we check if the time schedule being booked is active
-		if schedule.Active {
+		if schedule.Active && schedule.ValidOn(day) {
 			return schedule, true
 		}
 	}
 	return models.Schedule{}, false
 }
 
-func (s *Service) GetAllPermissionsofUser(userid int) ([]models.Permissions, error) {
-	user, err := s.RbacService.UsersService.Find(userid)
+func (s *Service) GetAllPermissionsofUser(ctx context.Context, userid int) ([]models.Permissions, error) {
+	user, err := s.RbacService.UsersService.Find(ctx, userid)
 	if err != nil {
 		return nil, errors.New("no such role")
 	}
-	permissione, err := s.RbacService.PermissionsService.FindbyRoleId(user.Roleid)
+	permissione, err := s.RbacService.PermissionsService.FindbyRoleId(ctx, user.Roleid)
 	return permissione, err
 }
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/patienttracker/internal/models"
+)
+
+// maxslotsearch is the longest stretch of time the free slots are searched in at once
+const maxslotsearch = 31 * 24 * time.Hour
+
+// defaultslot is how long the slots last when neither the caller nor the department says
+const defaultslot = 30 * time.Minute
+
+// FreeSlots lists the slots between from and to the doctor can still be booked in,
+// they last duration or the shortest duration the doctor's department offers when it's zero.
+func (service *Service) FreeSlots(ctx context.Context, doctorid int, from, to time.Time, duration time.Duration) ([]models.Slot, error) {
+	if err := checkrange(from, to); err != nil {
+		return nil, err
+	}
+	doctor, err := service.DoctorService.Find(ctx, doctorid)
+	if err != nil {
+		return nil, err
+	}
+	department, err := service.DepartmentService.FindbyName(ctx, doctor.Departmentname)
+	if err != nil {
+		return nil, err
+	}
+	duration, err = slotduration(department, duration)
+	if err != nil {
+		return nil, err
+	}
+	return service.freeslots(ctx, doctorid, from, to, duration)
+}
+
+// DepartmentFreeSlots lists the free slots of every doctor of the department by their start
+func (service *Service) DepartmentFreeSlots(ctx context.Context, departmentid int, from, to time.Time, duration time.Duration) ([]models.Slot, error) {
+	if err := checkrange(from, to); err != nil {
+		return nil, err
+	}
+	department, err := service.DepartmentService.Find(ctx, departmentid)
+	if err != nil {
+		return nil, err
+	}
+	duration, err = slotduration(department, duration)
+	if err != nil {
+		return nil, err
+	}
+	doctors, err := service.departmentdoctors(ctx, department.Departmentname)
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]models.Slot, 0)
+	for _, doctor := range doctors {
+		free, err := service.freeslots(ctx, doctor.Physicianid, from, to, duration)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, free...)
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		if slots[i].Starttime.Equal(slots[j].Starttime) {
+			return slots[i].Doctorid < slots[j].Doctorid
+		}
+		return slots[i].Starttime.Before(slots[j].Starttime)
+	})
+	return slots, nil
+}
+
+// freeslots splits the working hours of the doctor between from and to into slots,
+// leaving out those clashing with an approved appointment or time the doctor is away.
+// The days & the slots are those of the clinic's zone.
+func (service *Service) freeslots(ctx context.Context, doctorid int, from, to time.Time, duration time.Duration) ([]models.Slot, error) {
+	schedules, err := service.getallschedules(ctx, doctorid)
+	if err != nil {
+		return nil, err
+	}
+	exceptions, err := service.ExceptionService.FindbyDoctor(ctx, doctorid)
+	if err != nil {
+		return nil, err
+	}
+	appointments, err := service.AppointmentService.FindAllByDoctor(ctx, doctorid)
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]models.Slot, 0)
+	from = from.In(service.Clinic())
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()); day.Before(to); day = day.AddDate(0, 0, 1) {
+		schedule, ok := checkschedule(schedules, day)
+		if !ok {
+			continue
+		}
+		for _, slot := range schedule.SlotsOn(day, duration) {
+			if slot.Starttime.Before(from) || slot.Endtime.After(to) {
+				continue
+			}
+			if isfree(slot, appointments, exceptions) {
+				slots = append(slots, slot)
+			}
+		}
+	}
+	return slots, nil
+}
+
+// isfree reports whether booking the slot would pass the checks of the booking itself
+func isfree(slot models.Slot, appointments []models.Appointment, exceptions []models.ScheduleException) bool {
+	appointment := models.Appointment{
+		Doctorid:        slot.Doctorid,
+		Appointmentdate: slot.Starttime,
+		Duration:        slot.Endtime.Sub(slot.Starttime),
+	}
+	if checkbooked(appointments, appointment) != nil {
+		return false
+	}
+	for _, exception := range exceptions {
+		if exception.Overlaps(slot.Starttime, slot.Endtime) {
+			return false
+		}
+	}
+	return true
+}
+
+// departmentdoctors pages through all the doctors of the department
+func (service *Service) departmentdoctors(ctx context.Context, department string) ([]models.Physician, error) {
+	var doctors []models.Physician
+	filters := models.Filters{Page: 1, PageSize: 100}
+	for {
+		page, metadata, err := service.DoctorService.FindDoctorsbyDept(ctx, department, filters)
+		if err != nil {
+			return nil, err
+		}
+		doctors = append(doctors, page...)
+		if filters.Page >= metadata.LastPage {
+			return doctors, nil
+		}
+		filters.Page++
+	}
+}
+
+func checkrange(from, to time.Time) error {
+	if !to.After(from) || to.Sub(from) > maxslotsearch {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+// slotduration checks the duration like checkduration does,
+// picking the shortest the department offers when there's none
+func slotduration(department models.Department, duration time.Duration) (time.Duration, error) {
+	if duration == 0 {
+		if len(department.Durations) == 0 {
+			return defaultslot, nil
+		}
+		shortest := department.Durations[0]
+		for _, allowed := range department.Durations[1:] {
+			if allowed < shortest {
+				shortest = allowed
+			}
+		}
+		return shortest, nil
+	}
+	if duration < 0 || duration%time.Minute != 0 {
+		return 0, ErrInvalidDuration
+	}
+	if !department.Allows(duration) {
+		return 0, ErrDurationNotAllowed
+	}
+	return duration, nil
+}
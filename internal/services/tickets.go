@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/events"
+	"github.com/patienttracker/internal/models"
+)
+
+// defaulttargets are how long a patient of each acuity may wait when TriageTargets isn't set
+var defaulttargets = []time.Duration{0, 10 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour}
+
+// WaitTarget returns how long a patient of the acuity may wait for the nurse
+func (service *Service) WaitTarget(acuity models.Acuity) time.Duration {
+	targets := service.TriageTargets
+	if len(targets) != int(models.LeastUrgent) {
+		targets = defaulttargets
+	}
+	if !acuity.Valid() {
+		acuity = models.DefaultAcuity
+	}
+	return targets[acuity-models.MostUrgent]
+}
+
+// Overdue reports whether the ticket is still waiting at now past the target of its acuity
+func (service *Service) Overdue(ticket models.Ticket, now time.Time) bool {
+	return ticket.Status.Waiting() && now.Sub(ticket.CreatedAt) > service.WaitTarget(ticket.Acuity)
+}
+
+// OpenTicket puts the patient in the triage queue of the nurse,a ticket opened without an acuity
+// gets models.DefaultAcuity. The doctor is optional,the nurse refers the patient when attending.
+func (service *Service) OpenTicket(ctx context.Context, ticket models.Ticket) (models.Ticket, error) {
+	if ticket.Acuity == 0 {
+		ticket.Acuity = models.DefaultAcuity
+	}
+	if !ticket.Acuity.Valid() {
+		return models.Ticket{}, ErrInvalidAcuity
+	}
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Ticket, error) {
+		if _, err := tx.PatientService.Find(ctx, ticket.Patientid); err != nil {
+			return models.Ticket{}, err
+		}
+		if _, err := tx.NurseService.Find(ctx, ticket.Nurseid); err != nil {
+			return models.Ticket{}, err
+		}
+		if ticket.Doctorid != 0 {
+			if _, err := tx.DoctorService.Find(ctx, ticket.Doctorid); err != nil {
+				return models.Ticket{}, err
+			}
+		}
+		ticket.Status = models.TicketOpen
+		ticket.AttendedAt = time.Time{}
+		created, err := tx.TicketService.Create(ctx, ticket)
+		if err != nil {
+			return models.Ticket{}, err
+		}
+		return created, tx.publish(ctx, ticketevent(events.TicketOpened, created))
+	})
+}
+
+func ticketevent(kind events.Kind, ticket models.Ticket) events.Event {
+	return events.Event{
+		Kind:      kind,
+		Ticketid:  ticket.Ticketid,
+		Patientid: ticket.Patientid,
+		Nurseid:   ticket.Nurseid,
+		Doctorid:  ticket.Doctorid,
+	}
+}
+
+// canhandle errors unless actor is the nurse whose queue the ticket is in or an admin
+func canhandle(ticket models.Ticket, actor models.Actor) error {
+	if actor.AccountType == auth.AccountAdmin || (actor.AccountType == auth.AccountNurse && actor.AccountId == ticket.Nurseid) {
+		return nil
+	}
+	return ErrNotAuthorized
+}
+
+// MoveTicket moves the ticket to status to on behalf of actor,
+// attending a ticket goes through AttendTicket as it writes the vitals taken.
+func (service *Service) MoveTicket(ctx context.Context, id int, to models.TicketStatus, actor models.Actor) (models.Ticket, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Ticket, error) {
+		ticket, err := tx.TicketService.Find(ctx, id)
+		if err != nil {
+			return models.Ticket{}, err
+		}
+		if err := canhandle(ticket, actor); err != nil {
+			return models.Ticket{}, err
+		}
+		if to == models.TicketAttended || !ticket.Status.CanMoveTo(to) {
+			return models.Ticket{}, ErrInvalidTicketMove
+		}
+		ticket.Status = to
+		moved, err := tx.TicketService.Update(ctx, ticket)
+		if err != nil {
+			return models.Ticket{}, err
+		}
+		return moved, tx.publish(ctx, ticketevent(events.TicketMoved, moved))
+	})
+}
+
+// AttendTicket writes the record of the vitals the nurse took & refers the patient to the doctor of the record,
+// the ticket leaves the queue as attended once the record is written. The acuity the nurse gives is kept,
+// without one vitals in the danger zone make the ticket more urgent in the queue of the doctor.
+func (service *Service) AttendTicket(ctx context.Context, id int, record models.Patientrecords, acuity models.Acuity, actor models.Actor) (models.Patientrecords, error) {
+	if acuity != 0 && !acuity.Valid() {
+		return models.Patientrecords{}, ErrInvalidAcuity
+	}
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Patientrecords, error) {
+		ticket, err := tx.TicketService.Find(ctx, id)
+		if err != nil {
+			return models.Patientrecords{}, err
+		}
+		if err := canhandle(ticket, actor); err != nil {
+			return models.Patientrecords{}, err
+		}
+		if !ticket.Status.CanMoveTo(models.TicketAttended) {
+			return models.Patientrecords{}, ErrInvalidTicketMove
+		}
+		if _, err := tx.DoctorService.Find(ctx, record.Doctorid); err != nil {
+			return models.Patientrecords{}, err
+		}
+		record.Patienid = ticket.Patientid
+		record.Nurseid = ticket.Nurseid
+		created, err := tx.createrecord(ctx, record)
+		if err != nil {
+			return models.Patientrecords{}, err
+		}
+		if acuity == 0 {
+			acuity = ticket.Acuity
+			if vitals := models.VitalsAcuity(record); vitals < acuity {
+				acuity = vitals
+			}
+		}
+		ticket.Acuity = acuity
+		ticket.Doctorid = record.Doctorid
+		ticket.Status = models.TicketAttended
+		ticket.AttendedAt = time.Now()
+		attended, err := tx.TicketService.Update(ctx, ticket)
+		if err != nil {
+			return models.Patientrecords{}, err
+		}
+		return created, tx.publish(ctx, ticketevent(events.TicketAttended, attended))
+	})
+}
+
+// doctorload counts the tickets in the queue of the doctor,the ones waiting with the doctor picked
+// when they were opened and the ones referred to the doctor since the start of the day in the clinic.
+func (service *Service) doctorload(ctx context.Context, id int, now time.Time) (int, error) {
+	tickets, err := service.TicketService.FindbyDoctor(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	today := models.Day(now, service.Clinic())
+	load := 0
+	for _, ticket := range tickets {
+		if ticket.Status.Waiting() || (ticket.Status == models.TicketAttended && !ticket.AttendedAt.Before(today)) {
+			load++
+		}
+	}
+	return load, nil
+}
+
+// AssignDoctor picks the doctor of the department with the fewest patients in their queue,
+// the doctor registered first when there's a tie.
+func (service *Service) AssignDoctor(ctx context.Context, department string) (models.Physician, error) {
+	doctors, err := service.departmentdoctors(ctx, department)
+	if err != nil {
+		return models.Physician{}, err
+	}
+	if len(doctors) == 0 {
+		return models.Physician{}, ErrNoDoctorAvailable
+	}
+	now := time.Now()
+	var assigned models.Physician
+	least := -1
+	for _, doctor := range doctors {
+		load, err := service.doctorload(ctx, doctor.Physicianid, now)
+		if err != nil {
+			return models.Physician{}, err
+		}
+		if least == -1 || load < least {
+			assigned, least = doctor, load
+		}
+	}
+	return assigned, nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/patienttracker/internal/auth"
+	"github.com/patienttracker/internal/events"
+	"github.com/patienttracker/internal/models"
+)
+
+// defaultoffer is how long a freed slot is held for a patient when OfferDuration isn't set
+const defaultoffer = 2 * time.Hour
+
+// offerduration returns how long a freed slot is held for the patient it's offered to
+func (service *Service) offerduration() time.Duration {
+	if service.OfferDuration <= 0 {
+		return defaultoffer
+	}
+	return service.OfferDuration
+}
+
+// JoinWaitlist puts the patient on the waitlist of the doctor for the day of waitlist.Day in the clinic,
+// a zero duration waits for a slot as long as the shortest the doctor's department offers.
+func (service *Service) JoinWaitlist(ctx context.Context, waitlist models.Waitlist) (models.Waitlist, error) {
+	if _, err := service.PatientService.Find(ctx, waitlist.Patientid); err != nil {
+		return models.Waitlist{}, err
+	}
+	doctor, err := service.DoctorService.Find(ctx, waitlist.Doctorid)
+	if err != nil {
+		return models.Waitlist{}, err
+	}
+	department, err := service.DepartmentService.FindbyName(ctx, doctor.Departmentname)
+	if err != nil {
+		return models.Waitlist{}, err
+	}
+	waitlist.Duration, err = slotduration(department, waitlist.Duration)
+	if err != nil {
+		return models.Waitlist{}, err
+	}
+	waitlist.Day = models.Day(waitlist.Day, service.Clinic())
+	if waitlist.Day.Before(models.Day(time.Now(), service.Clinic())) {
+		return models.Waitlist{}, ErrPastDay
+	}
+	return service.WaitlistService.Create(ctx, waitlist)
+}
+
+// LeaveWaitlist takes the entry off the waitlist,patients may only take off their own
+func (service *Service) LeaveWaitlist(ctx context.Context, id int, actor models.Actor) error {
+	waitlist, err := service.WaitlistService.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if actor.AccountType == auth.AccountPatient && actor.AccountId != waitlist.Patientid {
+		return ErrNotAuthorized
+	}
+	return service.WaitlistService.Delete(ctx, id)
+}
+
+// freeslot tells the waitlist subscriber the appointment was cancelled or deleted,the slot is offered
+// once the change committed. The event carries the slot since a deleted appointment can't be found.
+func (service *Service) freeslot(ctx context.Context, appointment models.Appointment) error {
+	if appointment.Outbound {
+		return nil
+	}
+	e := appointmentevent(events.SlotFreed, appointment)
+	e.Start, e.Duration = appointment.Appointmentdate, appointment.Duration
+	return service.publish(ctx, e)
+}
+
+// waitlist offers the slots freed to the patients waiting for them,a slot whose offer expired
+// is offered to the next patient.
+func (service *Service) waitlist() {
+	offer := service.OfferFreedSlot
+	service.Outbox.Subscribe("waitlist", func(ctx context.Context, e events.Event) error {
+		_, _, err := offer(ctx, models.Appointment{
+			Appointmentid:   e.Appointmentid,
+			Doctorid:        e.Doctorid,
+			Patientid:       e.Patientid,
+			Appointmentdate: e.Start,
+			Duration:        e.Duration,
+		})
+		return err
+	}, events.SlotFreed, events.OfferExpired)
+}
+
+// OfferFreedSlot offers the time the appointment held to the first patient waiting for its doctor that day,
+// the slot starts when the appointment did and lasts as long as the patient asked for. The patients with
+// an offer pending,who were offered this slot before or whose booking wouldn't pass are skipped. False is
+// returned when nobody could be offered the slot or it's still held for someone. The offer is held until
+// it expires or the slot starts whichever comes first,the subscribers are told so the patient hears of it
+// and they're told again once it expired so the slot is offered to the next patient.
+func (service *Service) OfferFreedSlot(ctx context.Context, appointment models.Appointment) (models.Waitlist, bool, error) {
+	now := time.Now()
+	if appointment.Outbound || !appointment.Appointmentdate.After(now) {
+		return models.Waitlist{}, false, nil
+	}
+	var offered models.Waitlist
+	var ok bool
+	err := service.atomically(ctx, func(tx *Service) error {
+		waiting, err := tx.WaitlistService.FindbyDoctor(ctx, appointment.Doctorid, models.Day(appointment.Appointmentdate, tx.Clinic()))
+		if err != nil {
+			return err
+		}
+		for _, waitlist := range waiting {
+			if waitlist.Offer.Pending(now) && waitlist.Appointment().Overlaps(appointment) {
+				// the slot is already held for someone else
+				return nil
+			}
+		}
+		for _, waitlist := range waiting {
+			// a patient who let the offer of this slot expire isn't offered it again
+			again := waitlist.Offer.Token != "" && waitlist.Offer.Starttime.Equal(appointment.Appointmentdate)
+			if waitlist.Patientid == appointment.Patientid || waitlist.Duration > appointment.Duration || waitlist.Offer.Pending(now) || again {
+				continue
+			}
+			waitlist.Offer = models.Offer{Starttime: appointment.Appointmentdate}
+			free, err := tx.bookable(ctx, waitlist.Appointment())
+			if err != nil {
+				return err
+			}
+			if !free {
+				continue
+			}
+			waitlist.Offer.Token, err = newtoken()
+			if err != nil {
+				return err
+			}
+			waitlist.Offer.Expires = now.Add(tx.offerduration())
+			if waitlist.Offer.Expires.After(waitlist.Offer.Starttime) {
+				waitlist.Offer.Expires = waitlist.Offer.Starttime
+			}
+			offered, err = tx.WaitlistService.Update(ctx, waitlist)
+			if err != nil {
+				return err
+			}
+			ok = true
+			if err := tx.publish(ctx, events.Event{
+				Kind:       events.SlotOffered,
+				Waitlistid: offered.Waitlistid,
+				Patientid:  offered.Patientid,
+				Doctorid:   offered.Doctorid,
+			}); err != nil {
+				return err
+			}
+			e := appointmentevent(events.OfferExpired, appointment)
+			e.Waitlistid = offered.Waitlistid
+			e.Start, e.Duration = appointment.Appointmentdate, appointment.Duration
+			return tx.publishat(ctx, e, offered.Offer.Expires)
+		}
+		return nil
+	})
+	return offered, ok, err
+}
+
+// checkoffers errors with ErrTimeSlotAllocated when the appointment overlaps a freed slot still offered to
+// another patient,the offer holds the slot until it's taken up or expires like an approved appointment would.
+func (service *Service) checkoffers(ctx context.Context, appointment models.Appointment) error {
+	if appointment.Outbound {
+		return nil
+	}
+	waiting, err := service.WaitlistService.FindbyDoctor(ctx, appointment.Doctorid, models.Day(appointment.Appointmentdate, service.Clinic()))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, waitlist := range waiting {
+		if waitlist.Patientid != appointment.Patientid && waitlist.Offer.Pending(now) && waitlist.Appointment().Overlaps(appointment) {
+			return ErrTimeSlotAllocated
+		}
+	}
+	return nil
+}
+
+// bookable reports whether PatientBookAppointment would book the appointment,
+// the errors of the checks are only returned when they aren't about the slot itself.
+func (service *Service) bookable(ctx context.Context, appointment models.Appointment) (bool, error) {
+	if err := service.lockbooking(ctx, appointment); err != nil {
+		return false, err
+	}
+	err := service.checkavailability(ctx, appointment)
+	if errors.Is(err, ErrInvalidSchedule) || errors.Is(err, ErrNotWithinSchedule) || errors.Is(err, ErrDoctorAway) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	appointments, err := service.AppointmentService.FindAllByPatient(ctx, appointment.Patientid)
+	if err != nil {
+		return false, err
+	}
+	doctorappointments, err := service.AppointmentService.FindAllByDoctor(ctx, appointment.Doctorid)
+	if err != nil {
+		return false, err
+	}
+	return checkbooked(append(appointments, doctorappointments...), appointment) == nil, nil
+}
+
+// AcceptOffer books the slot offered with token for the patient it was offered to
+// through PatientBookAppointment and takes them off the waitlist.
+func (service *Service) AcceptOffer(ctx context.Context, token string) (models.Appointment, error) {
+	return atomicallyReturning(ctx, service, func(tx *Service) (models.Appointment, error) {
+		waitlist, err := tx.WaitlistService.FindbyOffer(ctx, token)
+		if err != nil {
+			return models.Appointment{}, err
+		}
+		if !waitlist.Offer.Pending(time.Now()) {
+			return models.Appointment{}, ErrOfferExpired
+		}
+		appointment, err := tx.PatientBookAppointment(ctx, waitlist.Appointment())
+		if err != nil {
+			return models.Appointment{}, err
+		}
+		return appointment, tx.WaitlistService.Delete(ctx, waitlist.Waitlistid)
+	})
+}
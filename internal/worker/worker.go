@@ -1,7 +1,13 @@
 package worker
 
-// This a simple work queue package which handles background process in a queue
+// This is the pool of workers running the background tasks of the server like sending emails,
+// a failing task is run again after a growing delay until it runs out of attempts and is kept
+// in the dead letters for the admins to look at or queue again.
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 )
 
@@ -9,44 +15,350 @@ type Task interface {
 	Background() error
 }
 
-type Worker struct {
-	Nworker   int
-	Task      chan Task
-	Taskqueue chan chan Task
-	quit      chan bool
+// Limited is a task that sets how many times it's run,the MaxAttempts of the pool applies otherwise
+type Limited interface {
+	Task
+	MaxAttempts() int
 }
 
-func Newworker(nworker int, taskqueue chan chan Task) Worker {
-	return Worker{
-		Nworker:   nworker,
-		Task:      make(chan Task),
-		Taskqueue: taskqueue,
-		quit:      make(chan bool),
+var (
+	ErrQueueFull   = errors.New("the task queue is full")
+	ErrStopped     = errors.New("the worker pool is stopped")
+	ErrNoSuchTask  = errors.New("no such dead letter")
+	errInterrupted = errors.New("the pool stopped before the task could run")
+)
+
+type (
+	Options struct {
+		Workers   int
+		QueueSize int
+		// MaxAttempts is how many times a task is run before it's given up on
+		MaxAttempts int
+		// the delay before running a task again doubles from Backoff up to MaxBackoff
+		Backoff    time.Duration
+		MaxBackoff time.Duration
+	}
+
+	// DeadLetter is a task that failed every attempt
+	DeadLetter struct {
+		ID       int
+		Task     string
+		Attempts int
+		Err      string
+		FailedAt time.Time
+		task     Task
+	}
+
+	// Stats is what the pool is up to
+	Stats struct {
+		// Queued are the tasks waiting for a worker
+		Queued int
+		// Retrying are the tasks waiting to run again after failing
+		Retrying int
+		Running  int
+		Workers  int
+		// the counts since the pool started,Failed counts the attempts that failed
+		Succeeded int
+		Failed    int
+		Dead      int
+	}
+
+	job struct {
+		task     Task
+		attempts int
+	}
+
+	// Pool runs the tasks submitted with a fixed number of workers started once
+	Pool struct {
+		options Options
+		queue   chan *job
+		quit    chan struct{}
+		start   sync.Once
+		stop    sync.Once
+		// pending counts the tasks accepted that didn't succeed or die yet
+		pending sync.WaitGroup
+		workers sync.WaitGroup
+
+		mu          sync.Mutex
+		stopping    bool
+		stats       Stats
+		lastid      int
+		deadletters []DeadLetter
+	}
+)
+
+const (
+	// keepdead is how many dead letters the pool holds on to,the oldest are dropped first
+	keepdead = 200
+	// the defaults of the options left zero
+	defaultbackoff    = time.Second
+	defaultmaxbackoff = 5 * time.Minute
+)
+
+func NewPool(options Options) *Pool {
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 100
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 1
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = defaultbackoff
+	}
+	if options.MaxBackoff < options.Backoff {
+		options.MaxBackoff = defaultmaxbackoff
+	}
+	return &Pool{
+		options: options,
+		queue:   make(chan *job, options.QueueSize),
+		quit:    make(chan struct{}),
 	}
 }
 
-func (w *Worker) Workqueue() {
-	go func() {
-		for {
-			w.Taskqueue <- w.Task
-			select {
-			case work := <-w.Task:
-				// retry if an error occurs when executing a background task
-				for i := 0; i < 3; i++ {
-					if err := work.Background(); err == nil {
-						return
-					}
-					time.Sleep(500 * time.Millisecond)
-				}
-			case <-w.quit:
-				return
-			}
+// Start starts the workers,calling it again does nothing
+func (p *Pool) Start() {
+	p.start.Do(func() {
+		p.workers.Add(p.options.Workers)
+		for i := 0; i < p.options.Workers; i++ {
+			go p.work()
+		}
+	})
+}
+
+// Submit queues the task,it errors instead of waiting when the queue is full
+func (p *Pool) Submit(task Task) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopping {
+		return ErrStopped
+	}
+	select {
+	case p.queue <- &job{task: task}:
+		p.pending.Add(1)
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// SubmitWait queues the task,waiting for room in the queue until ctx is done
+func (p *Pool) SubmitWait(ctx context.Context, task Task) error {
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return ErrStopped
+	}
+	// counted before waiting so Stop waits for the task too
+	p.pending.Add(1)
+	p.mu.Unlock()
+	select {
+	case p.queue <- &job{task: task}:
+		return nil
+	case <-ctx.Done():
+		p.pending.Done()
+		return ctx.Err()
+	case <-p.quit:
+		p.pending.Done()
+		return ErrStopped
+	}
+}
+
+func (p *Pool) work() {
+	defer p.workers.Done()
+	for {
+		// once the pool stops the tasks still queued are left alone
+		select {
+		case <-p.quit:
+			return
+		default:
+		}
+		select {
+		case j := <-p.queue:
+			p.run(j)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *Pool) run(j *job) {
+	p.mu.Lock()
+	p.stats.Running++
+	p.mu.Unlock()
+	err := background(j.task)
+	j.attempts++
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Running--
+	if err == nil {
+		p.stats.Succeeded++
+		p.pending.Done()
+		return
+	}
+	p.stats.Failed++
+	if j.attempts >= p.maxattempts(j.task) {
+		p.bury(j, err)
+		return
+	}
+	p.stats.Retrying++
+	time.AfterFunc(p.backoff(j.attempts), func() { p.retry(j, err) })
+}
+
+// background runs the task,a panic fails the attempt instead of taking the worker down
+func background(task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the task panicked: %v", r)
 		}
 	}()
+	return task.Background()
+}
+
+// retry puts the task back in the queue once its delay is over,it waits for room in the queue
+// unless the pool stopped in the meantime.
+func (p *Pool) retry(j *job, err error) {
+	select {
+	case <-p.quit:
+	default:
+		select {
+		case p.queue <- j:
+			p.mu.Lock()
+			p.stats.Retrying--
+			p.mu.Unlock()
+			return
+		case <-p.quit:
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Retrying--
+	p.bury(j, fmt.Errorf("%w,the last attempt failed with: %v", errInterrupted, err))
 }
 
-func (w *Worker) Stop() {
+func (p *Pool) maxattempts(task Task) int {
+	if limited, ok := task.(Limited); ok && limited.MaxAttempts() > 0 {
+		return limited.MaxAttempts()
+	}
+	return p.options.MaxAttempts
+}
+
+// backoff is how long to wait before running a task again after attempt failed
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.options.Backoff
+	for i := 1; i < attempt && delay < p.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.options.MaxBackoff {
+		return p.options.MaxBackoff
+	}
+	return delay
+}
+
+// bury moves the task to the dead letters,p.mu is held
+func (p *Pool) bury(j *job, err error) {
+	p.lastid++
+	p.deadletters = append(p.deadletters, DeadLetter{
+		ID:       p.lastid,
+		Task:     describe(j.task),
+		Attempts: j.attempts,
+		Err:      err.Error(),
+		FailedAt: time.Now(),
+		task:     j.task,
+	})
+	if len(p.deadletters) > keepdead {
+		p.deadletters = p.deadletters[len(p.deadletters)-keepdead:]
+	}
+	p.stats.Dead++
+	p.pending.Done()
+}
+
+// describe names the task in the dead letters,a task can describe itself by being a fmt.Stringer
+func describe(task Task) string {
+	if stringer, ok := task.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", task)
+}
+
+// Stats returns what the pool is up to now
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Queued = len(p.queue)
+	stats.Workers = p.options.Workers
+	return stats
+}
+
+// DeadLetters returns the tasks that failed every attempt,the latest first
+func (p *Pool) DeadLetters() []DeadLetter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	letters := make([]DeadLetter, len(p.deadletters))
+	for i, letter := range p.deadletters {
+		letters[len(letters)-1-i] = letter
+	}
+	return letters
+}
+
+// Requeue queues the task of the dead letter again with all its attempts,the letter is dropped once it's queued
+func (p *Pool) Requeue(id int) error {
+	p.mu.Lock()
+	var task Task
+	for _, letter := range p.deadletters {
+		if letter.ID == id {
+			task = letter.task
+		}
+	}
+	p.mu.Unlock()
+	if task == nil {
+		return ErrNoSuchTask
+	}
+	if err := p.Submit(task); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, letter := range p.deadletters {
+		if letter.ID == id {
+			p.deadletters = append(p.deadletters[:i], p.deadletters[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Stop refuses new tasks & waits for the ones accepted to succeed or run out of attempts,
+// once ctx is done the workers stop and the tasks left go to the dead letters.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	p.stopping = true
+	p.mu.Unlock()
+	drained := make(chan struct{})
 	go func() {
-		w.quit <- true
+		p.pending.Wait()
+		close(drained)
 	}()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.stop.Do(func() { close(p.quit) })
+	p.workers.Wait()
+	// the tasks no worker got to are kept like the ones that failed
+	for {
+		select {
+		case j := <-p.queue:
+			p.mu.Lock()
+			p.bury(j, errInterrupted)
+			p.mu.Unlock()
+		default:
+			return err
+		}
+	}
 }
@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// flaky fails until it ran fails times
+type flaky struct {
+	fails int32
+	runs  int32
+}
+
+func (f *flaky) Background() error {
+	if atomic.AddInt32(&f.runs, 1) <= f.fails {
+		return errors.New("smtp server unreachable")
+	}
+	return nil
+}
+
+// limited fails every attempt & is given up on after attempts
+type limited struct {
+	attempts int
+	runs     int32
+}
+
+func (l *limited) Background() error {
+	atomic.AddInt32(&l.runs, 1)
+	return errors.New("mailbox full")
+}
+
+func (l *limited) MaxAttempts() int { return l.attempts }
+
+func (l *limited) String() string { return "email to a full mailbox" }
+
+type taskfunc func() error
+
+func (f taskfunc) Background() error { return f() }
+
+func fastpool(workers, maxattempts int) *Pool {
+	return NewPool(Options{Workers: workers, QueueSize: 10, MaxAttempts: maxattempts, Backoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond})
+}
+
+func TestBackoff(t *testing.T) {
+	pool := NewPool(Options{Backoff: time.Second, MaxBackoff: 10 * time.Second})
+	require.Equal(t, time.Second, pool.backoff(1))
+	require.Equal(t, 2*time.Second, pool.backoff(2))
+	require.Equal(t, 8*time.Second, pool.backoff(4))
+	require.Equal(t, 10*time.Second, pool.backoff(5))
+	require.Equal(t, 10*time.Second, pool.backoff(50))
+}
+
+func TestRetry(t *testing.T) {
+	pool := fastpool(2, 5)
+	pool.Start()
+	// starting again doesn't add workers
+	pool.Start()
+	task := &flaky{fails: 3}
+	require.NoError(t, pool.Submit(task))
+	require.NoError(t, pool.Stop(context.Background()))
+	require.Equal(t, int32(4), atomic.LoadInt32(&task.runs))
+	stats := pool.Stats()
+	require.Equal(t, 2, stats.Workers)
+	require.Equal(t, 1, stats.Succeeded)
+	require.Equal(t, 3, stats.Failed)
+	require.Zero(t, stats.Dead)
+	require.Empty(t, pool.DeadLetters())
+}
+
+func TestDeadLetters(t *testing.T) {
+	pool := fastpool(1, 3)
+	pool.Start()
+	always := &flaky{fails: 100}
+	once := &limited{attempts: 1}
+	require.NoError(t, pool.Submit(always))
+	require.NoError(t, pool.Submit(once))
+	require.NoError(t, pool.Submit(taskfunc(func() error { panic("nil mailer") })))
+	require.Eventually(t, func() bool { return pool.Stats().Dead == 3 }, time.Second, time.Millisecond)
+	require.Equal(t, int32(3), atomic.LoadInt32(&always.runs))
+	require.Equal(t, int32(1), atomic.LoadInt32(&once.runs))
+
+	letters := make(map[string]DeadLetter)
+	for _, letter := range pool.DeadLetters() {
+		letters[letter.Task] = letter
+	}
+	require.Len(t, letters, 3)
+	// a task describes itself when it's a fmt.Stringer
+	require.Equal(t, 1, letters["email to a full mailbox"].Attempts)
+	require.Equal(t, "mailbox full", letters["email to a full mailbox"].Err)
+	require.Equal(t, 3, letters["*worker.flaky"].Attempts)
+	require.Equal(t, "smtp server unreachable", letters["*worker.flaky"].Err)
+	require.Contains(t, letters["worker.taskfunc"].Err, "nil mailer")
+
+	// a requeued task gets all its attempts again
+	atomic.StoreInt32(&always.fails, 0)
+	require.NoError(t, pool.Requeue(letters["*worker.flaky"].ID))
+	require.ErrorIs(t, pool.Requeue(letters["*worker.flaky"].ID), ErrNoSuchTask)
+	require.NoError(t, pool.Stop(context.Background()))
+	require.Equal(t, int32(4), atomic.LoadInt32(&always.runs))
+	require.Len(t, pool.DeadLetters(), 2)
+	require.Equal(t, 1, pool.Stats().Succeeded)
+}
+
+func TestQueueFull(t *testing.T) {
+	pool := NewPool(Options{Workers: 1, QueueSize: 1})
+	require.NoError(t, pool.Submit(&flaky{}))
+	require.ErrorIs(t, pool.Submit(&flaky{}), ErrQueueFull)
+	require.Equal(t, 1, pool.Stats().Queued)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pool.SubmitWait(ctx, &flaky{}), context.DeadlineExceeded)
+	// the workers make room for the task waiting
+	done := make(chan error)
+	go func() { done <- pool.SubmitWait(context.Background(), &flaky{}) }()
+	pool.Start()
+	require.NoError(t, <-done)
+	require.NoError(t, pool.Stop(context.Background()))
+	require.Equal(t, 2, pool.Stats().Succeeded)
+}
+
+func TestStopDrains(t *testing.T) {
+	pool := fastpool(2, 3)
+	pool.Start()
+	var ran int32
+	for i := 0; i < 10; i++ {
+		require.NoError(t, pool.Submit(taskfunc(func() error {
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&ran, 1)
+			return nil
+		})))
+	}
+	require.NoError(t, pool.Stop(context.Background()))
+	require.Equal(t, int32(10), atomic.LoadInt32(&ran))
+	require.ErrorIs(t, pool.Submit(&flaky{}), ErrStopped)
+	require.ErrorIs(t, pool.SubmitWait(context.Background(), &flaky{}), ErrStopped)
+	stats := pool.Stats()
+	require.Zero(t, stats.Queued)
+	require.Zero(t, stats.Running)
+}
+
+func TestStopDeadline(t *testing.T) {
+	pool := NewPool(Options{Workers: 1, QueueSize: 10, MaxAttempts: 5, Backoff: time.Hour})
+	pool.Start()
+	release := make(chan struct{})
+	require.NoError(t, pool.Submit(taskfunc(func() error {
+		<-release
+		return nil
+	})))
+	require.NoError(t, pool.Submit(&flaky{}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// the task blocking the worker is done once the pool told the workers to stop
+	go func() {
+		<-pool.quit
+		close(release)
+	}()
+	// the task queued behind the one blocking never ran,it's kept in the dead letters
+	require.ErrorIs(t, pool.Stop(ctx), context.DeadlineExceeded)
+	letters := pool.DeadLetters()
+	require.Len(t, letters, 1)
+	require.Zero(t, letters[0].Attempts)
+	require.ErrorIs(t, pool.Requeue(letters[0].ID), ErrStopped)
+	require.Len(t, pool.DeadLetters(), 1)
+}
@@ -0,0 +1,132 @@
+// Package redistore is a gorilla sessions.Store that keeps session values in redis,
+// the cookie only carries the signed and encrypted session id so sessions are
+// shared by every instance of the server and survive restarts.
+package redistore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base32"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/redis/go-redis/v9"
+)
+
+// default lifetime of a session,same as sessions.CookieStore
+const defaultMaxAge = 86400 * 30
+
+type Store struct {
+	client  *redis.Client
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+	// prefix of the redis keys holding the session values
+	Prefix string
+}
+
+// New returns a store that signs and encrypts the session id cookie with keyPairs,
+// like sessions.NewCookieStore more than one pair can be passed to rotate keys.
+func New(client *redis.Client, keyPairs ...[]byte) *Store {
+	store := &Store{
+		client: client,
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: defaultMaxAge,
+		},
+		Prefix: "session:",
+	}
+	store.MaxAge(store.Options.MaxAge)
+	return store
+}
+
+// MaxAge sets the maximum age of the store's sessions and of their cookies
+func (s *Store) MaxAge(age int) {
+	s.Options.MaxAge = age
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the session stored for the request cookie or a new one when there is none
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		return session, err
+	}
+	found, err := s.load(r.Context(), session)
+	if err != nil {
+		return session, err
+	}
+	session.IsNew = !found
+	return session, nil
+}
+
+// Save writes the session values to redis and the session id to the response cookie,
+// a negative MaxAge deletes the session.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.client.Del(r.Context(), s.Prefix+session.ID).Err(); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+	if session.ID == "" {
+		session.ID = strings.TrimRight(base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
+	}
+	if err := s.save(r.Context(), session); err != nil {
+		return err
+	}
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+func (s *Store) save(ctx context.Context, session *sessions.Session) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		return err
+	}
+	age := session.Options.MaxAge
+	if age == 0 {
+		age = defaultMaxAge
+	}
+	return s.client.Set(ctx, s.Prefix+session.ID, buf.Bytes(), time.Duration(age)*time.Second).Err()
+}
+
+// load reads the session values from redis and reports whether they were found
+func (s *Store) load(ctx context.Context, session *sessions.Session) (bool, error) {
+	data, err := s.client.Get(ctx, s.Prefix+session.ID).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, gob.NewDecoder(bytes.NewReader(data)).Decode(&session.Values)
+}
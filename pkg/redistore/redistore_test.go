@@ -0,0 +1,78 @@
+package redistore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func testclient(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skip("redis is not available: ", err)
+	}
+	return client
+}
+
+func request(cookie *http.Cookie) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+func TestSaveAndLoadSession(t *testing.T) {
+	client := testclient(t)
+	authkey, enckey := securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32)
+	store := New(client, authkey, enckey)
+
+	session, err := store.Get(request(nil), "user-session")
+	require.NoError(t, err)
+	require.True(t, session.IsNew)
+	session.Values["user"] = "patient"
+	w := httptest.NewRecorder()
+	require.NoError(t, session.Save(request(nil), w))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	// a second store with the same keys,i.e another instance or a restart,sees the session
+	other := New(client, authkey, enckey)
+	loaded, err := other.New(request(cookies[0]), "user-session")
+	require.NoError(t, err)
+	require.False(t, loaded.IsNew)
+	require.Equal(t, "patient", loaded.Values["user"])
+
+	loaded.Options.MaxAge = -1
+	w = httptest.NewRecorder()
+	require.NoError(t, store.Save(request(nil), w, loaded))
+	deleted, err := store.New(request(cookies[0]), "user-session")
+	require.NoError(t, err)
+	require.True(t, deleted.IsNew)
+}
+
+func TestKeyRotation(t *testing.T) {
+	client := testclient(t)
+	oldauth, oldenc := securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32)
+	old := New(client, oldauth, oldenc)
+	session, err := old.New(request(nil), "admin")
+	require.NoError(t, err)
+	session.Values["admin"] = "user"
+	w := httptest.NewRecorder()
+	require.NoError(t, old.Save(request(nil), w, session))
+	cookie := w.Result().Cookies()[0]
+
+	rotated := New(client, securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32), oldauth, oldenc)
+	loaded, err := rotated.New(request(cookie), "admin")
+	require.NoError(t, err)
+	require.Equal(t, "user", loaded.Values["admin"])
+
+	unknown := New(client, securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32))
+	_, err = unknown.New(request(cookie), "admin")
+	require.Error(t, err)
+}
@@ -0,0 +1,13 @@
+/* Show the times of the page in the time zone of the viewer,
+   the server writes them as instants in the datetime attribute */
+
+for (const time of document.querySelectorAll('time[datetime]')) {
+  const date = new Date(time.getAttribute('datetime'))
+  if (!isNaN(date)) {
+    time.title = time.textContent
+    time.textContent = date.toLocaleString([], {
+      dateStyle: 'medium',
+      timeStyle: 'short',
+    })
+  }
+}